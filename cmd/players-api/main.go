@@ -2,9 +2,13 @@ package main
 
 import (
 	"context"
+	"crypto/tls"
 	"fmt"
 	"net/http"
 	"os"
+	"os/signal"
+	"strings"
+	"syscall"
 
 	"github.com/gorilla/handlers"
 	"github.com/gorilla/mux"
@@ -13,6 +17,8 @@ import (
 	"github.com/rsmaxwell/players-api/internal/config"
 	"github.com/rsmaxwell/players-api/internal/debug"
 	"github.com/rsmaxwell/players-api/internal/httphandler"
+	"github.com/rsmaxwell/players-api/internal/metrics"
+	"github.com/rsmaxwell/players-api/internal/migrations"
 	"github.com/rsmaxwell/players-api/internal/model"
 
 	_ "github.com/jackc/pgx/stdlib"
@@ -43,7 +49,37 @@ func main() {
 		f.Errorf("Error setting up")
 		os.Exit(1)
 	}
-	defer db.Close()
+
+	if len(c.SigningKeys) > 0 {
+		err = basic.SetSigningKeys(c.SigningKeys, c.SigningKey)
+		if err != nil {
+			message := "Could not set the signing keys"
+			f.Errorf(message)
+			f.DumpError(err, message)
+			os.Exit(1)
+		}
+	} else {
+		f.Infof("No signing keys are configured: tokens will not be accepted after a restart")
+	}
+
+	pending, err := migrations.Pending(ctx, db)
+	if err != nil {
+		f.Errorf("Could not check the database schema: %s", err.Error())
+		os.Exit(1)
+	}
+	if len(pending) > 0 {
+		if !c.AutoMigrate {
+			f.Errorf("The database schema is %d migrations behind: run 'players-migrate up', or set 'autoMigrate' in the configuration", len(pending))
+			os.Exit(1)
+		}
+
+		applied, err := migrations.Up(ctx, db)
+		if err != nil {
+			f.Errorf("Could not migrate the database schema")
+			os.Exit(1)
+		}
+		f.Infof("Applied %d migrations: the database schema is at version %d", len(applied), migrations.Latest())
+	}
 
 	count, err := model.CheckConistencyTx(db, true)
 	if err != nil {
@@ -55,25 +91,131 @@ func main() {
 		os.Exit(1)
 	}
 
+	model.RegisterMetrics(metrics.Default, db)
+
+	var metricsServer *http.Server
+	if c.Server.MetricsPort != 0 {
+		metricsRouter := http.NewServeMux()
+		metricsRouter.Handle("/metrics", metrics.Default)
+
+		metricsServer = &http.Server{
+			Addr:         fmt.Sprintf(":%d", c.Server.MetricsPort),
+			Handler:      metricsRouter,
+			ReadTimeout:  c.ReadTimeout,
+			WriteTimeout: c.WriteTimeout,
+			IdleTimeout:  c.IdleTimeout,
+		}
+
+		f.Infof("Serving metrics on port: %d", c.Server.MetricsPort)
+		go func() {
+			err := metricsServer.ListenAndServe()
+			if err != nil && err != http.ErrServerClosed {
+				f.Errorf("Could not serve the metrics: %s", err.Error())
+			}
+		}()
+	}
+
 	f.Verbosef("Registering Router and setting Handlers")
 	router := mux.NewRouter()
 	httphandler.SetupHandlers(router)
 
-	headers := handlers.AllowedHeaders([]string{"X-Requested-With", "Content-Type", "Authorization"})
-	methods := handlers.AllowedMethods([]string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS", "HEAD"})
-	origins := handlers.AllowedOrigins([]string{"http://localhost:4200"})
+	f.Verbosef("Allowing origins: %s", strings.Join(c.CORS.AllowedOrigins, ", "))
+	headers := handlers.AllowedHeaders(c.CORS.AllowedHeaders)
+	exposed := handlers.ExposedHeaders([]string{"ETag"})
+	methods := handlers.AllowedMethods(c.CORS.AllowedMethods)
+	origins := handlers.AllowedOrigins(c.CORS.AllowedOrigins)
 	credentials := handlers.AllowCredentials()
 
-	handler := handlers.CORS(headers, methods, origins, credentials)(router)
+	handler := handlers.CORS(headers, exposed, methods, origins, credentials)(router)
 	handler = httphandler.WithLogging(handler)
 	handler = httphandler.AddDatabaseContext(handler, db)
-	handler = httphandler.AddRequestContext(handler)
+	handler = httphandler.AddRequestContext(handler, c.RequestTimeout)
 	handler = httphandler.AddConfigContext(handler, c)
 
-	f.Infof("Listening on port: %d", c.Server.Port)
-	address := fmt.Sprintf(":%d", c.Server.Port)
-	err = http.ListenAndServe(address, handler)
+	server := &http.Server{
+		Addr:         fmt.Sprintf(":%d", c.Server.Port),
+		Handler:      handler,
+		ReadTimeout:  c.ReadTimeout,
+		WriteTimeout: c.WriteTimeout,
+		IdleTimeout:  c.IdleTimeout,
+	}
+
+	// The event streams only end with their request, so they are ended when
+	// shutting down, rather than holding it up
+	server.RegisterOnShutdown(model.Events.Close)
+
+	var certificate *basic.Certificate
+	if c.Server.CertFile != "" {
+		certificate, err = basic.NewCertificate(c.Server.CertFile, c.Server.KeyFile)
+		if err != nil {
+			message := "Could not read the certificate"
+			f.Errorf(message)
+			f.DumpError(err, message)
+			os.Exit(1)
+		}
+		server.TLSConfig = &tls.Config{
+			GetCertificate: certificate.GetCertificate,
+			MinVersion:     tls.VersionTLS12,
+		}
+	}
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGHUP, syscall.SIGTERM, os.Interrupt)
+
+	failed := make(chan error, 1)
+	go func() {
+		if certificate != nil {
+			f.Infof("Listening for HTTPS on port: %d", c.Server.Port)
+			failed <- server.ListenAndServeTLS("", "")
+		} else {
+			f.Infof("Listening on port: %d", c.Server.Port)
+			failed <- server.ListenAndServe()
+		}
+	}()
+
+	for running := true; running; {
+		select {
+		case err = <-failed:
+			f.Fatalf(ctx, err.Error())
+
+		case sig := <-signals:
+			if sig != syscall.SIGHUP {
+				f.Infof("Received %s: stopping", sig)
+				running = false
+				break
+			}
+
+			if certificate == nil {
+				f.Infof("Received %s: there is no certificate to reload", sig)
+				break
+			}
+
+			err = certificate.Reload()
+			if err != nil {
+				f.Errorf("Could not reload the certificate, so the current one is kept: %s", err.Error())
+				break
+			}
+			f.Infof("Reloaded the certificate")
+		}
+	}
+
+	// Stop accepting requests, and wait for those in progress to finish
+	shutdownCtx, cancel := context.WithTimeout(ctx, c.ShutdownTimeout)
+	defer cancel()
+
+	if metricsServer != nil {
+		metricsServer.Shutdown(shutdownCtx)
+	}
+
+	err = server.Shutdown(shutdownCtx)
 	if err != nil {
-		f.Fatalf(ctx, err.Error())
+		f.Errorf("Could not finish the requests in progress: %s", err.Error())
 	}
+
+	err = db.Close()
+	if err != nil {
+		f.Errorf("Could not close the database: %s", err.Error())
+	}
+
+	f.Infof("Stopped")
 }
@@ -21,12 +21,18 @@ import (
 )
 
 var (
-	pkg                = debug.NewPackage("main")
-	functionMain       = debug.NewFunction(pkg, "main")
-	functionGetPeople  = debug.NewFunction(pkg, "getPeople")
-	functionGetCourts  = debug.NewFunction(pkg, "getCourts")
-	functionGetPlays   = debug.NewFunction(pkg, "getPlays")
-	functionGetWaiters = debug.NewFunction(pkg, "GetWaiters")
+	pkg                  = debug.NewPackage("main")
+	functionMain         = debug.NewFunction(pkg, "main")
+	functionGetPeople    = debug.NewFunction(pkg, "getPeople")
+	functionGetCourts    = debug.NewFunction(pkg, "getCourts")
+	functionGetPlays     = debug.NewFunction(pkg, "getPlays")
+	functionGetWaiters   = debug.NewFunction(pkg, "GetWaiters")
+	functionGetGames     = debug.NewFunction(pkg, "getGames")
+	functionGetSessions  = debug.NewFunction(pkg, "getSessions")
+	functionGetRoles     = debug.NewFunction(pkg, "getRoles")
+	functionGetApprovals = debug.NewFunction(pkg, "getApprovals")
+	functionGetClubs     = debug.NewFunction(pkg, "getClubs")
+	functionGetMembers   = debug.NewFunction(pkg, "getClubMembers")
 )
 
 func init() {
@@ -50,6 +56,14 @@ func main() {
 
 	var myBackup backup.Backup
 
+	err = getClubs(ctx, db, &myBackup)
+	if err != nil {
+		message := "Could not get the clubs"
+		f.Errorf(message)
+		f.DumpError(err, message)
+		os.Exit(1)
+	}
+
 	err = getPeople(ctx, db, &myBackup)
 	if err != nil {
 		message := "Could not get the people"
@@ -82,6 +96,46 @@ func main() {
 		os.Exit(1)
 	}
 
+	err = getGames(ctx, db, &myBackup)
+	if err != nil {
+		message := "Could not get the games"
+		f.Errorf(message)
+		f.DumpError(err, message)
+		os.Exit(1)
+	}
+
+	err = getSessions(ctx, db, &myBackup)
+	if err != nil {
+		message := "Could not get the sessions"
+		f.Errorf(message)
+		f.DumpError(err, message)
+		os.Exit(1)
+	}
+
+	err = getRoles(ctx, db, &myBackup)
+	if err != nil {
+		message := "Could not get the roles"
+		f.Errorf(message)
+		f.DumpError(err, message)
+		os.Exit(1)
+	}
+
+	err = getApprovals(ctx, db, &myBackup)
+	if err != nil {
+		message := "Could not get the approvals"
+		f.Errorf(message)
+		f.DumpError(err, message)
+		os.Exit(1)
+	}
+
+	err = getClubMembers(ctx, db, &myBackup)
+	if err != nil {
+		message := "Could not get the club members"
+		f.Errorf(message)
+		f.DumpError(err, message)
+		os.Exit(1)
+	}
+
 	// Marshal and write the backup to file
 	bytearray, err := json.Marshal(&myBackup)
 	if err != nil {
@@ -107,7 +161,8 @@ func getPeople(ctx context.Context, db *sql.DB, myBackup *backup.Backup) error {
 	f := functionGetPeople
 
 	// Query all the people in the person table
-	sqlStatement := "SELECT * FROM " + model.PersonTable
+	fields := "id, firstname, lastname, knownas, email, phone, hash, status, skill, gender"
+	sqlStatement := "SELECT " + fields + " FROM " + model.PersonTable
 
 	rows, err := db.Query(sqlStatement)
 	if err != nil {
@@ -122,7 +177,7 @@ func getPeople(ctx context.Context, db *sql.DB, myBackup *backup.Backup) error {
 
 	for rows.Next() {
 		var p model.NullPerson
-		err := rows.Scan(&p.ID, &p.FirstName, &p.LastName, &p.Knownas, &p.Email, &p.Phone, &p.Hash, &p.Status)
+		err := rows.Scan(&p.ID, &p.FirstName, &p.LastName, &p.Knownas, &p.Email, &p.Phone, &p.Hash, &p.Status, &p.Skill, &p.Gender)
 		if err != nil {
 			f.Errorf("Error: %t %v\n", err, err)
 			return err
@@ -159,6 +214,14 @@ func getPeople(ctx context.Context, db *sql.DB, myBackup *backup.Backup) error {
 			fields["status"] = p.Status.String
 		}
 
+		if p.Skill.Valid {
+			fields["skill"] = p.Skill.Int32
+		}
+
+		if p.Gender.Valid {
+			fields["gender"] = p.Gender.String
+		}
+
 		myBackup.PersonFieldsArray = append(myBackup.PersonFieldsArray, fields)
 	}
 	err = rows.Err()
@@ -176,7 +239,7 @@ func getCourts(ctx context.Context, db *sql.DB, myBackup *backup.Backup) error {
 	f := functionGetCourts
 
 	// Query all the courts in the courts table
-	sqlStatement := "SELECT * FROM " + model.CourtTable
+	sqlStatement := "SELECT id, club, name, capacity, strategy FROM " + model.CourtTable
 
 	rows, err := db.Query(sqlStatement)
 	if err != nil {
@@ -191,7 +254,7 @@ func getCourts(ctx context.Context, db *sql.DB, myBackup *backup.Backup) error {
 
 	for rows.Next() {
 		var c model.NullCourt
-		err := rows.Scan(&c.ID, &c.Name)
+		err := rows.Scan(&c.ID, &c.Club, &c.Name, &c.Capacity, &c.Strategy)
 		if err != nil {
 			f.Errorf("Error: %t %v\n", err, err)
 			return err
@@ -199,11 +262,20 @@ func getCourts(ctx context.Context, db *sql.DB, myBackup *backup.Backup) error {
 
 		court := make(map[string]interface{})
 		court["id"] = c.ID
+		court["club"] = c.Club
 
 		if c.Name.Valid {
 			court["name"] = c.Name.String
 		}
 
+		if c.Capacity.Valid {
+			court["capacity"] = c.Capacity.Int32
+		}
+
+		if c.Strategy.Valid {
+			court["strategy"] = c.Strategy.String
+		}
+
 		myBackup.CourtFieldsArray = append(myBackup.CourtFieldsArray, court)
 	}
 	err = rows.Err()
@@ -221,7 +293,7 @@ func getPlays(ctx context.Context, db *sql.DB, myBackup *backup.Backup) error {
 	f := functionGetPlays
 
 	// Query all the plays in the playing table
-	sqlStatement := "SELECT * FROM playing"
+	sqlStatement := "SELECT person, court, position, start, wait FROM " + model.PlayingTable
 
 	rows, err := db.Query(sqlStatement)
 	if err != nil {
@@ -235,11 +307,14 @@ func getPlays(ctx context.Context, db *sql.DB, myBackup *backup.Backup) error {
 	myBackup.Playing = []backup.Play{}
 
 	var (
-		person int
-		court  int
+		person   int
+		court    int
+		position int
+		start    time.Time
+		wait     int
 	)
 	for rows.Next() {
-		err := rows.Scan(&person, &court)
+		err := rows.Scan(&person, &court, &position, &start, &wait)
 		if err != nil {
 			message := "Could not scan the play"
 			f.Errorf(message)
@@ -250,6 +325,9 @@ func getPlays(ctx context.Context, db *sql.DB, myBackup *backup.Backup) error {
 		var play backup.Play
 		play.Person = person
 		play.Court = court
+		play.Position = position
+		play.Start = start
+		play.Wait = wait
 
 		myBackup.Playing = append(myBackup.Playing, play)
 	}
@@ -268,7 +346,7 @@ func getWaiters(ctx context.Context, db *sql.DB, myBackup *backup.Backup) error
 	f := functionGetWaiters
 
 	// Query all the waiters in the waiting table
-	sqlStatement := "SELECT * FROM waiting"
+	sqlStatement := "SELECT club, person, start FROM " + model.WaitingTable
 
 	rows, err := db.Query(sqlStatement)
 	if err != nil {
@@ -283,7 +361,7 @@ func getWaiters(ctx context.Context, db *sql.DB, myBackup *backup.Backup) error
 
 	var nw backup.NullWaiter
 	for rows.Next() {
-		err := rows.Scan(&nw.Person, &nw.Start)
+		err := rows.Scan(&nw.Club, &nw.Person, &nw.Start)
 		if err != nil {
 			message := "Could not scan the waiter"
 			f.Errorf(message)
@@ -292,6 +370,7 @@ func getWaiters(ctx context.Context, db *sql.DB, myBackup *backup.Backup) error
 		}
 
 		var w backup.Waiter
+		w.Club = nw.Club
 		w.Person = nw.Person
 		w.Start = time.Now()
 
@@ -311,3 +390,207 @@ func getWaiters(ctx context.Context, db *sql.DB, myBackup *backup.Backup) error
 
 	return nil
 }
+
+func getGames(ctx context.Context, db *sql.DB, myBackup *backup.Backup) error {
+	f := functionGetGames
+
+	games, err := model.ListGames(ctx, db, model.GameFilter{})
+	if err != nil {
+		message := "Could not list the games"
+		f.Errorf(message)
+		f.DumpError(err, message)
+		return err
+	}
+
+	myBackup.Games = []backup.Game{}
+
+	for _, g := range games {
+
+		var game backup.Game
+		game.ID = g.ID
+		game.Club = g.Club
+		game.Court = g.Court
+		game.Session = g.Session
+		game.Start = g.Start
+		game.End = g.End
+		game.Players = []backup.GamePlayer{}
+
+		for _, p := range g.Players {
+			game.Players = append(game.Players, backup.GamePlayer{Person: p.Person, Position: p.Position, Team: p.Team, Wait: p.Wait})
+		}
+
+		myBackup.Games = append(myBackup.Games, game)
+	}
+
+	return nil
+}
+
+func getSessions(ctx context.Context, db *sql.DB, myBackup *backup.Backup) error {
+	f := functionGetSessions
+
+	sessions, err := model.ListSessions(ctx, db, 0)
+	if err != nil {
+		message := "Could not list the sessions"
+		f.Errorf(message)
+		f.DumpError(err, message)
+		return err
+	}
+
+	myBackup.Sessions = []backup.Session{}
+
+	for _, s := range sessions {
+
+		var session backup.Session
+		session.ID = s.ID
+		session.Club = s.Club
+		session.Opened = s.Opened
+		session.Closed = s.Closed
+		session.CheckIns = []backup.CheckIn{}
+
+		for _, c := range s.CheckIns {
+			session.CheckIns = append(session.CheckIns, backup.CheckIn{Person: c.Person, Time: c.Time})
+		}
+
+		myBackup.Sessions = append(myBackup.Sessions, session)
+	}
+
+	return nil
+}
+
+func getRoles(ctx context.Context, db *sql.DB, myBackup *backup.Backup) error {
+	f := functionGetRoles
+
+	sqlStatement := "SELECT person, role FROM " + model.PersonRoleTable + " ORDER BY person, role"
+	rows, err := db.QueryContext(ctx, sqlStatement)
+	if err != nil {
+		message := "Could not select all from " + model.PersonRoleTable
+		f.Errorf(message)
+		f.DumpSQLError(err, message, sqlStatement)
+		return err
+	}
+	defer rows.Close()
+
+	myBackup.Roles = []backup.PersonRole{}
+
+	for rows.Next() {
+
+		var role backup.PersonRole
+		err := rows.Scan(&role.Person, &role.Role)
+		if err != nil {
+			message := "Could not scan the role"
+			f.Errorf(message)
+			f.DumpError(err, message)
+			return err
+		}
+
+		myBackup.Roles = append(myBackup.Roles, role)
+	}
+	err = rows.Err()
+	if err != nil {
+		message := "Could not list the roles"
+		f.Errorf(message)
+		f.DumpError(err, message)
+		return err
+	}
+
+	return nil
+}
+
+func getApprovals(ctx context.Context, db *sql.DB, myBackup *backup.Backup) error {
+	f := functionGetApprovals
+
+	fields := "person, registered, verified, decision, status, decidedby, decided, reason"
+	sqlStatement := "SELECT " + fields + " FROM " + model.ApprovalTable + " ORDER BY person"
+	rows, err := db.QueryContext(ctx, sqlStatement)
+	if err != nil {
+		message := "Could not select all from " + model.ApprovalTable
+		f.Errorf(message)
+		f.DumpSQLError(err, message, sqlStatement)
+		return err
+	}
+	defer rows.Close()
+
+	myBackup.Approvals = []backup.Approval{}
+
+	for rows.Next() {
+
+		var a backup.Approval
+		err := rows.Scan(&a.Person, &a.Registered, &a.Verified, &a.Decision, &a.Status, &a.DecidedBy, &a.Decided, &a.Reason)
+		if err != nil {
+			message := "Could not scan the approval"
+			f.Errorf(message)
+			f.DumpError(err, message)
+			return err
+		}
+
+		myBackup.Approvals = append(myBackup.Approvals, a)
+	}
+	err = rows.Err()
+	if err != nil {
+		message := "Could not list the approvals"
+		f.Errorf(message)
+		f.DumpError(err, message)
+		return err
+	}
+
+	return nil
+}
+
+func getClubs(ctx context.Context, db *sql.DB, myBackup *backup.Backup) error {
+	f := functionGetClubs
+
+	clubs, err := model.ListClubs(ctx, db)
+	if err != nil {
+		message := "Could not list the clubs"
+		f.Errorf(message)
+		f.DumpError(err, message)
+		return err
+	}
+
+	myBackup.Clubs = []backup.Club{}
+
+	for _, c := range clubs {
+		myBackup.Clubs = append(myBackup.Clubs, backup.Club{ID: c.ID, Name: c.Name})
+	}
+
+	return nil
+}
+
+func getClubMembers(ctx context.Context, db *sql.DB, myBackup *backup.Backup) error {
+	f := functionGetMembers
+
+	sqlStatement := "SELECT club, person, role FROM " + model.ClubMemberTable + " ORDER BY club, person, role"
+	rows, err := db.QueryContext(ctx, sqlStatement)
+	if err != nil {
+		message := "Could not select all from " + model.ClubMemberTable
+		f.Errorf(message)
+		f.DumpSQLError(err, message, sqlStatement)
+		return err
+	}
+	defer rows.Close()
+
+	myBackup.ClubMembers = []backup.ClubMember{}
+
+	for rows.Next() {
+
+		var member backup.ClubMember
+		err := rows.Scan(&member.Club, &member.Person, &member.Role)
+		if err != nil {
+			message := "Could not scan the club member"
+			f.Errorf(message)
+			f.DumpError(err, message)
+			return err
+		}
+
+		myBackup.ClubMembers = append(myBackup.ClubMembers, member)
+	}
+	err = rows.Err()
+	if err != nil {
+		message := "Could not list the club members"
+		f.Errorf(message)
+		f.DumpError(err, message)
+		return err
+	}
+
+	return nil
+}
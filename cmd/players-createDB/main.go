@@ -8,6 +8,7 @@ import (
 	"github.com/rsmaxwell/players-api/internal/config"
 	"github.com/rsmaxwell/players-api/internal/debug"
 
+	"github.com/jackc/pgx"
 	_ "github.com/jackc/pgx/stdlib"
 )
 
@@ -34,8 +35,9 @@ func main() {
 	}
 	defer db.Close()
 
-	// Create the database
-	sqlStatement := fmt.Sprintf("CREATE DATABASE %s", c.Database.DatabaseName)
+	// Create the database. The name is an identifier, which cannot be passed
+	// as a parameter, so it is quoted
+	sqlStatement := "CREATE DATABASE " + pgx.Identifier{c.Database.DatabaseName}.Sanitize()
 	_, err = db.Exec(sqlStatement)
 	if err != nil {
 		message := fmt.Sprintf("Could not create database: %s", c.Database.DatabaseName)
@@ -13,6 +13,7 @@ import (
 	"github.com/rsmaxwell/players-api/internal/basic"
 	"github.com/rsmaxwell/players-api/internal/config"
 	"github.com/rsmaxwell/players-api/internal/debug"
+	"github.com/rsmaxwell/players-api/internal/migrations"
 
 	_ "github.com/jackc/pgx/stdlib"
 )
@@ -79,132 +80,82 @@ func main() {
 	defer db.Close()
 
 	// Drop the tables
-	err = dropTable(ctx, db, model.PlayingTable)
+	err = dropTable(ctx, db, model.ApprovalTable)
 	if err != nil {
 		return
 	}
 
-	err = dropTable(ctx, db, model.WaitingTable)
+	err = dropTable(ctx, db, model.PersonTokenTable)
 	if err != nil {
 		return
 	}
 
-	err = dropTable(ctx, db, model.PersonTable)
+	err = dropTable(ctx, db, model.RefreshTokenTable)
 	if err != nil {
 		return
 	}
 
-	err = dropTable(ctx, db, model.CourtTable)
+	err = dropTable(ctx, db, model.PersonRoleTable)
 	if err != nil {
 		return
 	}
 
-	// Create the people table
-	sqlStatement := `
-		CREATE TABLE ` + model.PersonTable + ` (
-			id SERIAL PRIMARY KEY,
-			firstname VARCHAR(255) NOT NULL,
-			lastname VARCHAR(255) NOT NULL,
-			knownas VARCHAR(32) NOT NULL,
-			email VARCHAR(255) NOT NULL UNIQUE,
-			phone VARCHAR(32) NOT NULL UNIQUE,
-			hash VARCHAR(255) NOT NULL,	
-			status VARCHAR(32) NOT NULL
-		 )`
-	_, err = db.Exec(sqlStatement)
+	err = dropTable(ctx, db, model.CheckInTable)
 	if err != nil {
-		message := "Could not create person table"
-		f.Errorf(message)
-		f.DumpSQLError(err, message, sqlStatement)
-		os.Exit(1)
+		return
 	}
 
-	// Create the person_email index
-	sqlStatement = "CREATE INDEX person_email ON " + model.PersonTable + " ( email )"
-	_, err = db.Exec(sqlStatement)
+	err = dropTable(ctx, db, model.SessionTable)
 	if err != nil {
-		message := "Could not create person_email index"
-		f.Errorf(message)
-		f.DumpSQLError(err, message, sqlStatement)
-		os.Exit(1)
+		return
 	}
 
-	// Create the court table
-	sqlStatement = `
-		CREATE TABLE ` + model.CourtTable + ` (
-			id SERIAL PRIMARY KEY,
-			name VARCHAR(255)
-		 )`
-	_, err = db.Exec(sqlStatement)
+	err = dropTable(ctx, db, model.GamePlayerTable)
 	if err != nil {
-		message := "Could not create court table"
-		f.Errorf(message)
-		f.DumpSQLError(err, message, sqlStatement)
-		os.Exit(1)
+		return
 	}
 
-	// Create the playing table
-	sqlStatement = `
-		CREATE TABLE ` + model.PlayingTable + ` (
-			court    INT NOT NULL,
-			person   INT NOT NULL,
-			position INT NOT NULL,		
+	err = dropTable(ctx, db, model.GameTable)
+	if err != nil {
+		return
+	}
 
-			PRIMARY KEY (court, person, position),
+	err = dropTable(ctx, db, model.PlayingTable)
+	if err != nil {
+		return
+	}
 
-			CONSTRAINT person FOREIGN KEY(person) REFERENCES person(id),
-			CONSTRAINT court FOREIGN KEY(court)  REFERENCES court(id)
-		 )`
-	_, err = db.Exec(sqlStatement)
+	err = dropTable(ctx, db, model.WaitingTable)
 	if err != nil {
-		message := "Could not create playing table"
-		f.Errorf(message)
-		f.DumpSQLError(err, message, sqlStatement)
-		os.Exit(1)
+		return
 	}
 
-	// Create the playing_court index
-	sqlStatement = "CREATE INDEX playing_court ON " + model.PlayingTable + " ( court )"
-	_, err = db.Exec(sqlStatement)
+	err = dropTable(ctx, db, model.PersonTable)
 	if err != nil {
-		message := "Could not create playing_court index"
-		f.Errorf(message)
-		f.DumpSQLError(err, message, sqlStatement)
-		os.Exit(1)
+		return
 	}
 
-	// Create the playing_person index
-	sqlStatement = "CREATE INDEX playing_person ON " + model.PlayingTable + " ( person )"
-	_, err = db.Exec(sqlStatement)
+	err = dropTable(ctx, db, model.CourtTable)
 	if err != nil {
-		message := "Could not create playing_person index"
-		f.Errorf(message)
-		f.DumpSQLError(err, message, sqlStatement)
-		os.Exit(1)
+		return
 	}
 
-	// Create the waiting table
-	sqlStatement = `
-		CREATE TABLE ` + model.WaitingTable + ` (
-			person INT PRIMARY KEY,
-			start  TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
-			CONSTRAINT person FOREIGN KEY(person) REFERENCES person(id)
-		 )`
-	_, err = db.Exec(sqlStatement)
+	err = dropTable(ctx, db, model.StateTable)
 	if err != nil {
-		message := "Could not create waiting table"
-		f.Errorf(message)
-		f.DumpSQLError(err, message, sqlStatement)
-		os.Exit(1)
+		return
 	}
 
-	// Create the waiting index
-	sqlStatement = "CREATE INDEX " + model.WaitingIndex + " ON " + model.WaitingTable + " ( start )"
-	_, err = db.Exec(sqlStatement)
+	err = dropTable(ctx, db, migrations.VersionTable)
+	if err != nil {
+		return
+	}
+
+	// Create the tables
+	_, err = migrations.Up(ctx, db)
 	if err != nil {
-		message := "Could not create playing_court index"
+		message := "Could not create the tables"
 		f.Errorf(message)
-		f.DumpSQLError(err, message, sqlStatement)
+		f.DumpError(err, message)
 		os.Exit(1)
 	}
 
@@ -226,6 +177,7 @@ func main() {
 		}
 
 		p.Status = model.StatusAdmin
+		p.Roles = []string{model.RoleAdmin, model.RoleMember}
 
 		err = p.SavePersonTx(db)
 		if err != nil {
@@ -245,14 +197,15 @@ func main() {
 		fmt.Printf("    Password:  %s\n", r.Password)
 		fmt.Printf("    Hash:      %s\n", p.Hash)
 		fmt.Printf("    Status:    %s\n", p.Status)
+		fmt.Printf("    Roles:     %v\n", p.Roles)
 	}
 }
 
 func tableExists(ctx context.Context, db *sql.DB, table string) (bool, error) {
 	f := functionTableExists
 
-	sqlStatement := fmt.Sprintf("SELECT EXISTS ( SELECT FROM %s WHERE schemaname = '%s' AND tablename = '%s' )", "pg_tables", "public", table)
-	row := db.QueryRow(sqlStatement)
+	sqlStatement := "SELECT EXISTS ( SELECT FROM pg_tables WHERE schemaname = $1 AND tablename = $2 )"
+	row := db.QueryRowContext(ctx, sqlStatement, "public", table)
 
 	var exists bool
 	err := row.Scan(&exists)
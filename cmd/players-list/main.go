@@ -80,7 +80,7 @@ func listPeople(ctx context.Context, db *sql.DB) error {
 
 	// Query all the records in the people table
 
-	fields := "id, firstname, lastname, knownas, email, phone, hash, status"
+	fields := "id, firstname, lastname, knownas, email, phone, hash, status, skill, gender"
 	sqlStatement := "SELECT " + fields + " FROM " + model.PersonTable
 	f.DebugVerbose("%s", sqlStatement)
 
@@ -97,7 +97,7 @@ func listPeople(ctx context.Context, db *sql.DB) error {
 	f.Infof("---[ people ]------------------------")
 	var np model.NullPerson
 	for rows.Next() {
-		err := rows.Scan(&np.ID, &np.FirstName, &np.LastName, &np.Knownas, &np.Email, &np.Phone, &np.Hash, &np.Status)
+		err := rows.Scan(&np.ID, &np.FirstName, &np.LastName, &np.Knownas, &np.Email, &np.Phone, &np.Hash, &np.Status, &np.Skill, &np.Gender)
 		if err != nil {
 			message := "could not scan the person record"
 			f.Errorf(message)
@@ -135,6 +135,14 @@ func listPeople(ctx context.Context, db *sql.DB) error {
 			f.Infof("status:%s", np.Status.String)
 		}
 
+		if np.Skill.Valid {
+			f.Infof("skill:%d", np.Skill.Int32)
+		}
+
+		if np.Gender.Valid {
+			f.Infof("gender:%s", np.Gender.String)
+		}
+
 		f.Infof("-------------------------------------")
 	}
 	err = rows.Err()
@@ -152,7 +160,7 @@ func listCourts(ctx context.Context, db *sql.DB) error {
 	f := functionListCourts
 
 	// Query all the records in the courts table
-	sqlStatement := "SELECT * FROM " + model.CourtTable
+	sqlStatement := "SELECT id, name, capacity, strategy FROM " + model.CourtTable
 	rows, err := db.Query(sqlStatement)
 	if err != nil {
 		message := "Could not select from " + model.CourtTable
@@ -166,7 +174,7 @@ func listCourts(ctx context.Context, db *sql.DB) error {
 	f.Infof("---[ courts ]------------------------")
 	var c model.NullCourt
 	for rows.Next() {
-		err := rows.Scan(&c.ID, &c.Name)
+		err := rows.Scan(&c.ID, &c.Name, &c.Capacity, &c.Strategy)
 		if err != nil {
 			message := "error scanning the results"
 			f.Errorf(message)
@@ -179,6 +187,14 @@ func listCourts(ctx context.Context, db *sql.DB) error {
 		if c.Name.Valid {
 			f.Infof("name:%s", c.Name.String)
 		}
+
+		if c.Capacity.Valid {
+			f.Infof("capacity:%d", c.Capacity.Int32)
+		}
+
+		if c.Strategy.Valid {
+			f.Infof("strategy:%s", c.Strategy.String)
+		}
 		f.Infof("-------------------------------------")
 	}
 	err = rows.Err()
@@ -196,7 +212,7 @@ func listPlaying(ctx context.Context, db *sql.DB) error {
 	f := functionListPlaying
 
 	// Query all the records in the playing table
-	sqlStatement := `SELECT * FROM playing`
+	sqlStatement := `SELECT person, court, position, start FROM playing`
 	rows, err := db.Query(sqlStatement)
 	if err != nil {
 		message := "Could not select all playing"
@@ -210,7 +226,7 @@ func listPlaying(ctx context.Context, db *sql.DB) error {
 	f.Infof("---[ playing ]-----------------------")
 	var p backup.Play
 	for rows.Next() {
-		err := rows.Scan(&p.Person, &p.Court)
+		err := rows.Scan(&p.Person, &p.Court, &p.Position, &p.Start)
 		if err != nil {
 			message := "error scanning the results"
 			f.Errorf(message)
@@ -220,6 +236,8 @@ func listPlaying(ctx context.Context, db *sql.DB) error {
 
 		f.Infof("person: %d", p.Person)
 		f.Infof("court:  %d", p.Court)
+		f.Infof("position: %d", p.Position)
+		f.Infof("start:  %s", p.Start)
 		f.Infof("-------------------------------------")
 	}
 	err = rows.Err()
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/rsmaxwell/players-api/internal/basic"
+	"github.com/rsmaxwell/players-api/internal/config"
+	"github.com/rsmaxwell/players-api/internal/debug"
+	"github.com/rsmaxwell/players-api/internal/migrations"
+
+	_ "github.com/jackc/pgx/stdlib"
+)
+
+var (
+	pkg          = debug.NewPackage("main")
+	functionMain = debug.NewFunction(pkg, "main")
+)
+
+func init() {
+	debug.InitDump("com.rsmaxwell.players", "players-migrate", "https://server.rsmaxwell.co.uk/archiva")
+}
+
+const usage = "usage: players-migrate up|down|status"
+
+func main() {
+	f := functionMain
+	ctx := context.Background()
+
+	f.Infof("Players Migrate: Version: %s", basic.Version())
+
+	if len(os.Args) != 2 {
+		fmt.Println(usage)
+		os.Exit(2)
+	}
+	command := os.Args[1]
+
+	// Read configuration and connect to the database
+	db, c, err := config.Setup()
+	if err != nil {
+		f.Errorf("Error setting up")
+		os.Exit(1)
+	}
+	defer db.Close()
+
+	switch command {
+	case "up":
+		applied, err := migrations.Up(ctx, db)
+		for _, m := range applied {
+			fmt.Printf("Applied migration %d: %s\n", m.Version, m.Name)
+		}
+		if err != nil {
+			f.Errorf("Could not migrate the database: %s", err.Error())
+			os.Exit(1)
+		}
+		fmt.Printf("The database %s is at version %d\n", c.Database.DatabaseName, migrations.Latest())
+
+	case "down":
+		reverted, err := migrations.Down(ctx, db)
+		if err != nil {
+			f.Errorf("Could not revert the database: %s", err.Error())
+			os.Exit(1)
+		}
+		if reverted == nil {
+			fmt.Printf("The database %s has no migrations to revert\n", c.Database.DatabaseName)
+			return
+		}
+		fmt.Printf("Reverted migration %d: %s\n", reverted.Version, reverted.Name)
+
+	case "status":
+		list, err := migrations.ListStatus(ctx, db)
+		if err != nil {
+			f.Errorf("Could not read the migrations: %s", err.Error())
+			os.Exit(1)
+		}
+		for _, status := range list {
+			applied := "pending"
+			if status.Applied != nil {
+				applied = status.Applied.Format("2006-01-02 15:04:05")
+			}
+			fmt.Printf("%4d  %-32s %s\n", status.Version, status.Name, applied)
+		}
+
+	default:
+		fmt.Println(usage)
+		os.Exit(2)
+	}
+}
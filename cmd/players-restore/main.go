@@ -8,6 +8,7 @@ import (
 	"os"
 	"path/filepath"
 	"strconv"
+	"time"
 
 	"github.com/rsmaxwell/players-api/internal/backup"
 	"github.com/rsmaxwell/players-api/internal/basic"
@@ -21,12 +22,18 @@ import (
 )
 
 var (
-	pkg                   = debug.NewPackage("main")
-	functionMain          = debug.NewFunction(pkg, "main")
-	functionInsertPeople  = debug.NewFunction(pkg, "insertPeople")
-	functionInsertCourts  = debug.NewFunction(pkg, "insertCourts")
-	functionInsertPlays   = debug.NewFunction(pkg, "insertPlays")
-	functionInsertWaiters = debug.NewFunction(pkg, "insertWaiters")
+	pkg                     = debug.NewPackage("main")
+	functionMain            = debug.NewFunction(pkg, "main")
+	functionInsertPeople    = debug.NewFunction(pkg, "insertPeople")
+	functionInsertCourts    = debug.NewFunction(pkg, "insertCourts")
+	functionInsertPlays     = debug.NewFunction(pkg, "insertPlays")
+	functionInsertWaiters   = debug.NewFunction(pkg, "insertWaiters")
+	functionInsertGames     = debug.NewFunction(pkg, "insertGames")
+	functionInsertSessions  = debug.NewFunction(pkg, "insertSessions")
+	functionInsertRoles     = debug.NewFunction(pkg, "insertRoles")
+	functionInsertApprovals = debug.NewFunction(pkg, "insertApprovals")
+	functionInsertClubs     = debug.NewFunction(pkg, "insertClubs")
+	functionInsertMembers   = debug.NewFunction(pkg, "insertClubMembers")
 )
 
 func init() {
@@ -78,6 +85,13 @@ func main() {
 
 	indexes := backup.NewIndexes()
 
+	err = insertClubs(ctx, db, &myBackup, indexes)
+	if err != nil {
+		message := "could not insert clubs"
+		f.Errorf(message)
+		os.Exit(1)
+	}
+
 	err = insertPeople(ctx, db, &myBackup, indexes)
 	if err != nil {
 		message := "could not insert people"
@@ -85,6 +99,27 @@ func main() {
 		os.Exit(1)
 	}
 
+	err = insertClubMembers(ctx, db, &myBackup, indexes)
+	if err != nil {
+		message := "could not insert club members"
+		f.Errorf(message)
+		os.Exit(1)
+	}
+
+	err = insertRoles(ctx, db, &myBackup, indexes)
+	if err != nil {
+		message := "could not insert roles"
+		f.Errorf(message)
+		os.Exit(1)
+	}
+
+	err = insertApprovals(ctx, db, &myBackup, indexes)
+	if err != nil {
+		message := "could not insert approvals"
+		f.Errorf(message)
+		os.Exit(1)
+	}
+
 	err = insertCourts(ctx, db, &myBackup, indexes)
 	if err != nil {
 		message := "could not insert courts"
@@ -106,6 +141,20 @@ func main() {
 		os.Exit(1)
 	}
 
+	err = insertSessions(ctx, db, &myBackup, indexes)
+	if err != nil {
+		message := "could not insert sessions"
+		f.Errorf(message)
+		os.Exit(1)
+	}
+
+	err = insertGames(ctx, db, &myBackup, indexes)
+	if err != nil {
+		message := "could not insert games"
+		f.Errorf(message)
+		os.Exit(1)
+	}
+
 	fmt.Printf("Successfully restored the database: %s\n", c.Database.DatabaseName)
 }
 
@@ -118,6 +167,7 @@ func insertPeople(ctx context.Context, db *sql.DB, myBackup *backup.Backup, inde
 		fields := ""
 		values := ""
 		separator := ""
+		args := []interface{}{}
 		id1 := 0
 
 		if value, ok := fieldsMap["id"]; ok {
@@ -129,7 +179,8 @@ func insertPeople(ctx context.Context, db *sql.DB, myBackup *backup.Backup, inde
 		if value, ok := fieldsMap["firstname"]; ok {
 			if str, ok := value.(string); ok {
 				fields = fields + separator + "firstname"
-				values = values + separator + basic.Quote(str)
+				args = append(args, str)
+				values = values + separator + placeholder(args)
 				separator = ", "
 			}
 		}
@@ -137,7 +188,8 @@ func insertPeople(ctx context.Context, db *sql.DB, myBackup *backup.Backup, inde
 		if value, ok := fieldsMap["lastname"]; ok {
 			if str, ok := value.(string); ok {
 				fields = fields + separator + "lastname"
-				values = values + separator + basic.Quote(str)
+				args = append(args, str)
+				values = values + separator + placeholder(args)
 				separator = ", "
 			}
 		}
@@ -145,7 +197,8 @@ func insertPeople(ctx context.Context, db *sql.DB, myBackup *backup.Backup, inde
 		if value, ok := fieldsMap["displayname"]; ok {
 			if str, ok := value.(string); ok {
 				fields = fields + separator + "displayname"
-				values = values + separator + basic.Quote(str)
+				args = append(args, str)
+				values = values + separator + placeholder(args)
 				separator = ", "
 			}
 		}
@@ -153,7 +206,8 @@ func insertPeople(ctx context.Context, db *sql.DB, myBackup *backup.Backup, inde
 		if value, ok := fieldsMap["username"]; ok {
 			if str, ok := value.(string); ok {
 				fields = fields + separator + "username"
-				values = values + separator + basic.Quote(str)
+				args = append(args, str)
+				values = values + separator + placeholder(args)
 				separator = ", "
 			}
 		}
@@ -161,7 +215,8 @@ func insertPeople(ctx context.Context, db *sql.DB, myBackup *backup.Backup, inde
 		if value, ok := fieldsMap["email"]; ok {
 			if str, ok := value.(string); ok {
 				fields = fields + separator + "email"
-				values = values + separator + basic.Quote(str)
+				args = append(args, str)
+				values = values + separator + placeholder(args)
 				separator = ", "
 			}
 		}
@@ -169,7 +224,8 @@ func insertPeople(ctx context.Context, db *sql.DB, myBackup *backup.Backup, inde
 		if value, ok := fieldsMap["phone"]; ok {
 			if str, ok := value.(string); ok {
 				fields = fields + separator + "phone"
-				values = values + separator + basic.Quote(str)
+				args = append(args, str)
+				values = values + separator + placeholder(args)
 				separator = ", "
 			}
 		}
@@ -177,7 +233,8 @@ func insertPeople(ctx context.Context, db *sql.DB, myBackup *backup.Backup, inde
 		if value, ok := fieldsMap["hash"]; ok {
 			if str, ok := value.(string); ok {
 				fields = fields + separator + "hash"
-				values = values + separator + basic.Quote(str)
+				args = append(args, str)
+				values = values + separator + placeholder(args)
 				separator = ", "
 			}
 		}
@@ -185,7 +242,26 @@ func insertPeople(ctx context.Context, db *sql.DB, myBackup *backup.Backup, inde
 		if value, ok := fieldsMap["status"]; ok {
 			if str, ok := value.(string); ok {
 				fields = fields + separator + "status"
-				values = values + separator + basic.Quote(str)
+				args = append(args, str)
+				values = values + separator + placeholder(args)
+				separator = ", "
+			}
+		}
+
+		if value, ok := fieldsMap["skill"]; ok {
+			if num, ok := value.(float64); ok {
+				fields = fields + separator + "skill"
+				args = append(args, int(num))
+				values = values + separator + placeholder(args)
+				separator = ", "
+			}
+		}
+
+		if value, ok := fieldsMap["gender"]; ok {
+			if str, ok := value.(string); ok {
+				fields = fields + separator + "gender"
+				args = append(args, str)
+				values = values + separator + placeholder(args)
 				separator = ", "
 			}
 		}
@@ -201,7 +277,7 @@ func insertPeople(ctx context.Context, db *sql.DB, myBackup *backup.Backup, inde
 		sqlStatement := "INSERT INTO " + model.PersonTable + " (" + fields + ") VALUES	(" + values + ") RETURNING id"
 
 		var id2 int
-		err := db.QueryRowContext(ctx, sqlStatement).Scan(&id2)
+		err := db.QueryRowContext(ctx, sqlStatement, args...).Scan(&id2)
 		if err != nil {
 			message := "Could not insert into people"
 			f.Errorf(message)
@@ -216,6 +292,67 @@ func insertPeople(ctx context.Context, db *sql.DB, myBackup *backup.Backup, inde
 	return nil
 }
 
+func insertRoles(ctx context.Context, db *sql.DB, myBackup *backup.Backup, indexes *backup.Indexes) error {
+	f := functionInsertRoles
+
+	// Backups taken before people had roles make everyone a member
+	roles := myBackup.Roles
+	if roles == nil {
+		for id := range indexes.People {
+			roles = append(roles, backup.PersonRole{Person: id, Role: model.RoleMember})
+		}
+	}
+
+	for _, role := range roles {
+		person, ok := indexes.People[role.Person]
+		if !ok {
+			continue
+		}
+
+		sqlStatement := "INSERT INTO " + model.PersonRoleTable + " (person, role) VALUES ($1, $2) ON CONFLICT DO NOTHING"
+		_, err := db.ExecContext(ctx, sqlStatement, person, role.Role)
+		if err != nil {
+			message := "Could not insert into personrole"
+			f.Errorf(message)
+			f.DumpSQLError(err, message, sqlStatement)
+			return err
+		}
+	}
+
+	return nil
+}
+
+func insertApprovals(ctx context.Context, db *sql.DB, myBackup *backup.Backup, indexes *backup.Indexes) error {
+	f := functionInsertApprovals
+
+	for _, a := range myBackup.Approvals {
+		person, ok := indexes.People[a.Person]
+		if !ok {
+			continue
+		}
+
+		// The admin who decided may since have been deleted
+		var decidedBy *int
+		if a.DecidedBy != nil {
+			if id, ok := indexes.People[*a.DecidedBy]; ok {
+				decidedBy = &id
+			}
+		}
+
+		fields := "person, registered, verified, decision, status, decidedby, decided, reason"
+		sqlStatement := "INSERT INTO " + model.ApprovalTable + " (" + fields + ") VALUES ($1, $2, $3, $4, $5, $6, $7, $8)"
+		_, err := db.ExecContext(ctx, sqlStatement, person, a.Registered, a.Verified, a.Decision, a.Status, decidedBy, a.Decided, a.Reason)
+		if err != nil {
+			message := "Could not insert into " + model.ApprovalTable
+			f.Errorf(message)
+			f.DumpSQLError(err, message, sqlStatement)
+			return err
+		}
+	}
+
+	return nil
+}
+
 func insertCourts(ctx context.Context, db *sql.DB, myBackup *backup.Backup, indexes *backup.Indexes) error {
 	f := functionInsertCourts
 
@@ -225,6 +362,7 @@ func insertCourts(ctx context.Context, db *sql.DB, myBackup *backup.Backup, inde
 		fields := ""
 		values := ""
 		separator := ""
+		args := []interface{}{}
 		id1 := 0
 
 		if value, ok := fieldsMap["id"]; ok {
@@ -233,18 +371,48 @@ func insertCourts(ctx context.Context, db *sql.DB, myBackup *backup.Backup, inde
 			}
 		}
 
+		club := model.DefaultClubID
+		if value, ok := fieldsMap["club"]; ok {
+			if num, ok := value.(float64); ok {
+				club = clubIndex(indexes, int(num))
+			}
+		}
+		fields = fields + separator + "club"
+		args = append(args, club)
+		values = values + separator + placeholder(args)
+		separator = ", "
+
 		if value, ok := fieldsMap["name"]; ok {
 			if str, ok := value.(string); ok {
 				fields = fields + separator + "name"
-				values = values + separator + basic.Quote(str)
+				args = append(args, str)
+				values = values + separator + placeholder(args)
+				separator = ", "
+			}
+		}
+
+		if value, ok := fieldsMap["capacity"]; ok {
+			if num, ok := value.(float64); ok {
+				fields = fields + separator + "capacity"
+				args = append(args, int(num))
+				values = values + separator + placeholder(args)
+				separator = ", "
+			}
+		}
+
+		if value, ok := fieldsMap["strategy"]; ok {
+			if str, ok := value.(string); ok {
+				fields = fields + separator + "strategy"
+				args = append(args, str)
+				values = values + separator + placeholder(args)
 				separator = ", "
 			}
 		}
 
-		sqlStatement := "INSERT INTO courts (" + fields + ") VALUES	(" + values + ") RETURNING id"
+		sqlStatement := "INSERT INTO " + model.CourtTable + " (" + fields + ") VALUES	(" + values + ") RETURNING id"
 
 		var id2 int
-		err := db.QueryRowContext(ctx, sqlStatement).Scan(&id2)
+		err := db.QueryRowContext(ctx, sqlStatement, args...).Scan(&id2)
 		if err != nil {
 			message := "Could not insert into courts"
 			f.Errorf(message)
@@ -265,23 +433,18 @@ func insertPlays(ctx context.Context, db *sql.DB, myBackup *backup.Backup, index
 	// Insert plays into the playing table
 
 	for _, play := range myBackup.Playing {
-		fields := ""
-		values := ""
-		separator := ""
-
-		id := indexes.People[play.Person]
-		fields = fields + separator + "person"
-		values = values + separator + strconv.Itoa(id)
-		separator = ", "
+		start := play.Start
+		if start.IsZero() {
+			start = time.Now()
+		}
 
-		id = indexes.Courts[play.Court]
-		fields = fields + separator + "court"
-		values = values + separator + strconv.Itoa(id)
-		separator = ", "
+		person := indexes.People[play.Person]
+		court := indexes.Courts[play.Court]
 
-		sqlStatement := "INSERT INTO playing (" + fields + ") VALUES	(" + values + ")"
+		fields := "person, court, position, start, wait"
+		sqlStatement := "INSERT INTO " + model.PlayingTable + " (" + fields + ") VALUES ($1, $2, $3, $4, $5)"
 
-		_, err := db.ExecContext(ctx, sqlStatement)
+		_, err := db.ExecContext(ctx, sqlStatement, person, court, play.Position, start, play.Wait)
 		if err != nil {
 			message := "Could not insert into plays"
 			f.Errorf(message)
@@ -300,17 +463,11 @@ func insertWaiters(ctx context.Context, db *sql.DB, myBackup *backup.Backup, ind
 
 	for _, waiter := range myBackup.Waiting {
 
-		fields := ""
-		separator := ""
-
-		fields = fields + separator + "person"
-		separator = ", "
-		fields = fields + separator + "start"
-
-		sqlStatement := "INSERT INTO waiting (" + fields + ") VALUES ($1, $2)"
+		fields := "club, person, start"
+		sqlStatement := "INSERT INTO " + model.WaitingTable + " (" + fields + ") VALUES ($1, $2, $3)"
 
 		person := indexes.People[waiter.Person]
-		_, err := db.ExecContext(ctx, sqlStatement, person, waiter.Start)
+		_, err := db.ExecContext(ctx, sqlStatement, clubIndex(indexes, waiter.Club), person, waiter.Start)
 		if err != nil {
 			message := "Could not insert into waiting"
 			f.Errorf(message)
@@ -321,3 +478,158 @@ func insertWaiters(ctx context.Context, db *sql.DB, myBackup *backup.Backup, ind
 
 	return nil
 }
+
+func insertGames(ctx context.Context, db *sql.DB, myBackup *backup.Backup, indexes *backup.Indexes) error {
+	f := functionInsertGames
+
+	// Insert the games into the game and gameplayer tables. A game may refer
+	// to a court or person which has since been deleted, in which case the
+	// original id is kept
+
+	for _, g := range myBackup.Games {
+
+		game := model.Game{Club: clubIndex(indexes, g.Club), Court: g.Court, Session: g.Session, Start: g.Start, End: g.End}
+		if id, ok := indexes.Courts[g.Court]; ok {
+			game.Court = id
+		}
+		if id, ok := indexes.Sessions[g.Session]; ok {
+			game.Session = id
+		}
+
+		for _, p := range g.Players {
+			player := model.GamePlayer{Person: p.Person, Position: p.Position, Team: p.Team, Wait: p.Wait}
+			if id, ok := indexes.People[p.Person]; ok {
+				player.Person = id
+			}
+			game.Players = append(game.Players, player)
+		}
+
+		err := game.SaveGame(ctx, db)
+		if err != nil {
+			message := "Could not insert the game"
+			f.Errorf(message)
+			f.DumpError(err, message)
+			return err
+		}
+	}
+
+	return nil
+}
+
+func insertSessions(ctx context.Context, db *sql.DB, myBackup *backup.Backup, indexes *backup.Indexes) error {
+	f := functionInsertSessions
+
+	// Insert the sessions into the session and checkin tables
+
+	for _, session := range myBackup.Sessions {
+
+		var closed sql.NullTime
+		if !session.Closed.IsZero() {
+			closed = sql.NullTime{Time: session.Closed, Valid: true}
+		}
+
+		var id int
+		sqlStatement := "INSERT INTO " + model.SessionTable + " (club, opened, closed) VALUES ($1, $2, $3) RETURNING id"
+		err := db.QueryRowContext(ctx, sqlStatement, clubIndex(indexes, session.Club), session.Opened, closed).Scan(&id)
+		if err != nil {
+			message := "Could not insert into sessions"
+			f.Errorf(message)
+			f.DumpSQLError(err, message, sqlStatement)
+			return err
+		}
+		f.Infof("Inserted session: %d", id)
+
+		indexes.Sessions[session.ID] = id
+
+		for _, checkIn := range session.CheckIns {
+			person, ok := indexes.People[checkIn.Person]
+			if !ok {
+				continue
+			}
+
+			sqlStatement = "INSERT INTO " + model.CheckInTable + " (session, person, time) VALUES ($1, $2, $3)"
+			_, err = db.ExecContext(ctx, sqlStatement, id, person, checkIn.Time)
+			if err != nil {
+				message := "Could not insert into checkin"
+				f.Errorf(message)
+				f.DumpSQLError(err, message, sqlStatement)
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func insertClubs(ctx context.Context, db *sql.DB, myBackup *backup.Backup, indexes *backup.Indexes) error {
+	f := functionInsertClubs
+
+	// The default club is kept when the records are deleted, so it is renamed
+	// rather than inserted
+
+	for _, club := range myBackup.Clubs {
+
+		if club.ID == model.DefaultClubID {
+			sqlStatement := "UPDATE " + model.ClubTable + " SET name=$1 WHERE id=$2"
+			_, err := db.ExecContext(ctx, sqlStatement, club.Name, model.DefaultClubID)
+			if err != nil {
+				message := "Could not update the default club"
+				f.Errorf(message)
+				f.DumpSQLError(err, message, sqlStatement)
+				return err
+			}
+			indexes.Clubs[club.ID] = model.DefaultClubID
+			continue
+		}
+
+		c := model.Club{Name: club.Name}
+		err := c.SaveClub(ctx, db)
+		if err != nil {
+			message := "Could not insert the club"
+			f.Errorf(message)
+			f.DumpError(err, message)
+			return err
+		}
+		f.Infof("Inserted club: %d", c.ID)
+
+		indexes.Clubs[club.ID] = c.ID
+	}
+
+	return nil
+}
+
+func insertClubMembers(ctx context.Context, db *sql.DB, myBackup *backup.Backup, indexes *backup.Indexes) error {
+	f := functionInsertMembers
+
+	for _, member := range myBackup.ClubMembers {
+		person, ok := indexes.People[member.Person]
+		if !ok {
+			continue
+		}
+
+		sqlStatement := "INSERT INTO " + model.ClubMemberTable + " (club, person, role) VALUES ($1, $2, $3) ON CONFLICT DO NOTHING"
+		_, err := db.ExecContext(ctx, sqlStatement, clubIndex(indexes, member.Club), person, member.Role)
+		if err != nil {
+			message := "Could not insert into " + model.ClubMemberTable
+			f.Errorf(message)
+			f.DumpSQLError(err, message, sqlStatement)
+			return err
+		}
+	}
+
+	return nil
+}
+
+// clubIndex returns the restored id of a club. Backups taken before there
+// were clubs put everything in the default club
+func clubIndex(indexes *backup.Indexes, id int) int {
+	if club, ok := indexes.Clubs[id]; ok {
+		return club
+	}
+	return model.DefaultClubID
+}
+
+// placeholder returns the parameter placeholder for the last of the args
+func placeholder(args []interface{}) string {
+	return "$" + strconv.Itoa(len(args))
+}
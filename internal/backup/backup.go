@@ -11,6 +11,25 @@ type Backup struct {
 	CourtFieldsArray  []CourtFields  `json:"courts"`
 	Playing           []Play         `json:"playing"`
 	Waiting           []Waiter       `json:"waiting"`
+	Games             []Game         `json:"games"`
+	Sessions          []Session      `json:"sessions"`
+	Roles             []PersonRole   `json:"roles"`
+	Approvals         []Approval     `json:"approvals"`
+	Clubs             []Club         `json:"clubs"`
+	ClubMembers       []ClubMember   `json:"clubmembers"`
+}
+
+// Club type
+type Club struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+// ClubMember type
+type ClubMember struct {
+	Club   int    `json:"club"`
+	Person int    `json:"person"`
+	Role   string `json:"role"`
 }
 
 // PersonFields type
@@ -21,32 +40,93 @@ type CourtFields map[string]interface{}
 
 // Play type
 type Play struct {
-	Person int `json:"person"`
-	Court  int `json:"court"`
+	Person   int       `json:"person"`
+	Court    int       `json:"court"`
+	Position int       `json:"position"`
+	Start    time.Time `json:"start"`
+	Wait     int       `json:"wait"`
+}
+
+// GamePlayer type
+type GamePlayer struct {
+	Person   int `json:"person"`
+	Position int `json:"position"`
+	Team     int `json:"team"`
+	Wait     int `json:"wait"`
+}
+
+// Game type
+type Game struct {
+	ID      int          `json:"id"`
+	Club    int          `json:"club"`
+	Court   int          `json:"court"`
+	Session int          `json:"session"`
+	Start   time.Time    `json:"start"`
+	End     time.Time    `json:"end"`
+	Players []GamePlayer `json:"players"`
+}
+
+// CheckIn type
+type CheckIn struct {
+	Person int       `json:"person"`
+	Time   time.Time `json:"time"`
+}
+
+// Session type
+type Session struct {
+	ID       int       `json:"id"`
+	Club     int       `json:"club"`
+	Opened   time.Time `json:"opened"`
+	Closed   time.Time `json:"closed"`
+	CheckIns []CheckIn `json:"checkins"`
+}
+
+// PersonRole type
+type PersonRole struct {
+	Person int    `json:"person"`
+	Role   string `json:"role"`
+}
+
+// Approval type
+type Approval struct {
+	Person     int        `json:"person"`
+	Registered time.Time  `json:"registered"`
+	Verified   *time.Time `json:"verified,omitempty"`
+	Decision   *string    `json:"decision,omitempty"`
+	Status     *string    `json:"status,omitempty"`
+	DecidedBy  *int       `json:"decidedby,omitempty"`
+	Decided    *time.Time `json:"decided,omitempty"`
+	Reason     *string    `json:"reason,omitempty"`
 }
 
 // NullWaiter type
 type NullWaiter struct {
+	Club   int
 	Person int
 	Start  sql.NullTime
 }
 
 // Waiter type
 type Waiter struct {
+	Club   int       `json:"club"`
 	Person int       `json:"person"`
 	Start  time.Time `json:"start"`
 }
 
 // Indexes type
 type Indexes struct {
-	People map[int]int
-	Courts map[int]int
+	Clubs    map[int]int
+	People   map[int]int
+	Courts   map[int]int
+	Sessions map[int]int
 }
 
 // NewIndexes is a constructor
 func NewIndexes() *Indexes {
 	i := new(Indexes)
+	i.Clubs = make(map[int]int)
 	i.People = make(map[int]int)
 	i.Courts = make(map[int]int)
+	i.Sessions = make(map[int]int)
 	return i
 }
@@ -2,45 +2,65 @@ package basic
 
 import (
 	"errors"
+	"fmt"
 	"time"
 
 	jwt "github.com/dgrijalva/jwt-go"
 )
 
-var (
-	mySigningKey = []byte("<SESSION_SECRET_KEY>")
+const (
+	// TokenTypeCheckIn is the type of the token shown at the door, which
+	// people scan to check in to a session. The ID is the session's
+	TokenTypeCheckIn = "checkin"
 )
 
 type MyJwtClaims struct {
-	ID      int `json:"id"`
-	Request int `json:"request"`
+	ID      int    `json:"id"`
+	Request int    `json:"request"`
+	Type    string `json:"type,omitempty"`
 	jwt.StandardClaims
 }
 
 // GenerateToken generates a jwt token
 func GenerateToken(id int, request int, expiresAfter time.Duration) (string, error) {
+	return generateToken(id, request, "", expiresAfter)
+}
+
+// GenerateTypedToken generates a jwt token which is only accepted where the
+// given type of token is expected
+func GenerateTypedToken(id int, tokenType string, expiresAfter time.Duration) (string, error) {
+	return generateToken(id, 0, tokenType, expiresAfter)
+}
+
+func generateToken(id int, request int, tokenType string, expiresAfter time.Duration) (string, error) {
 
 	claims := MyJwtClaims{
 		ID:      id,
 		Request: request,
+		Type:    tokenType,
 		StandardClaims: jwt.StandardClaims{
 			ExpiresAt: time.Now().Add(expiresAfter).Unix(),
 			Issuer:    "test",
 		},
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString(mySigningKey)
+	key := getCurrentKey()
+	token := jwt.NewWithClaims(key.signingMethod(), claims)
+	token.Header["kid"] = key.ID
+	return token.SignedString(key.signingKey())
 }
 
-// ValidateToken validates the jwt token
+// ValidateToken validates the jwt token. Typed tokens are refused
 func ValidateToken(signedToken string) (*MyJwtClaims, error) {
+	return ValidateTypedToken(signedToken, "")
+}
+
+// ValidateTypedToken validates a jwt token of the given type
+func ValidateTypedToken(signedToken string, tokenType string) (*MyJwtClaims, error) {
 	token, err := jwt.ParseWithClaims(
 		signedToken,
 		&MyJwtClaims{},
-		func(token *jwt.Token) (interface{}, error) {
-			return mySigningKey, nil
-		},
+		lookupKey,
 	)
 	if err != nil {
 		return nil, err
@@ -57,5 +77,10 @@ func ValidateToken(signedToken string) (*MyJwtClaims, error) {
 		return nil, err
 	}
 
+	if claims.Type != tokenType {
+		err = fmt.Errorf("unexpected jwt type: %q", claims.Type)
+		return nil, err
+	}
+
 	return claims, nil
 }
@@ -0,0 +1,61 @@
+package basic
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTokenTypes(t *testing.T) {
+
+	accessToken, err := GenerateToken(1, 2, time.Minute)
+	if err != nil {
+		t.Log("Could not generate an access token")
+		t.FailNow()
+	}
+
+	checkInToken, err := GenerateTypedToken(3, TokenTypeCheckIn, time.Minute)
+	if err != nil {
+		t.Log("Could not generate a check-in token")
+		t.FailNow()
+	}
+
+	claims, err := ValidateToken(accessToken)
+	if err != nil || claims.ID != 1 || claims.Request != 2 {
+		t.Logf("Could not validate the access token: %v", err)
+		t.FailNow()
+	}
+
+	claims, err = ValidateTypedToken(checkInToken, TokenTypeCheckIn)
+	if err != nil || claims.ID != 3 {
+		t.Logf("Could not validate the check-in token: %v", err)
+		t.FailNow()
+	}
+
+	// Neither kind of token is accepted in place of the other
+	_, err = ValidateToken(checkInToken)
+	if err == nil {
+		t.Log("A check-in token was accepted as an access token")
+		t.FailNow()
+	}
+
+	_, err = ValidateTypedToken(accessToken, TokenTypeCheckIn)
+	if err == nil {
+		t.Log("An access token was accepted as a check-in token")
+		t.FailNow()
+	}
+}
+
+func TestExpiredToken(t *testing.T) {
+
+	token, err := GenerateTypedToken(1, TokenTypeCheckIn, -time.Minute)
+	if err != nil {
+		t.Log("Could not generate a token")
+		t.FailNow()
+	}
+
+	_, err = ValidateTypedToken(token, TokenTypeCheckIn)
+	if err == nil {
+		t.Log("An expired token was accepted")
+		t.FailNow()
+	}
+}
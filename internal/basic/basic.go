@@ -142,25 +142,6 @@ func CheckStringArraysAreEqualInOrder(x, y []string) bool {
 	return true
 }
 
-// Quote makes the string safe for database input
-func Quote(s string) string {
-	if CheckSubstrings(s, "'", `"`, "`", "\\") {
-		tag := "$@@@$"
-		return "'" + tag + s + tag + "'"
-	}
-	return "'" + s + "'"
-}
-
-// CheckSubstrings checks a string for substrings
-func CheckSubstrings(str string, subs ...string) bool {
-	for _, sub := range subs {
-		if strings.Contains(str, sub) {
-			return true
-		}
-	}
-	return false
-}
-
 func GetEnvInteger(name string, def int) (int, error) {
 	value, ok := os.LookupEnv(name)
 	if !ok {
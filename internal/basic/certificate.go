@@ -0,0 +1,50 @@
+package basic
+
+import (
+	"crypto/tls"
+	"sync"
+)
+
+// Certificate is a TLS certificate and key read from files. It can be read
+// again while the server is running, so a renewed certificate is used for
+// the following connections
+type Certificate struct {
+	certFile string
+	keyFile  string
+	lock     sync.RWMutex
+	current  *tls.Certificate
+}
+
+// NewCertificate reads the certificate and key
+func NewCertificate(certFile string, keyFile string) (*Certificate, error) {
+	c := &Certificate{certFile: certFile, keyFile: keyFile}
+
+	err := c.Reload()
+	if err != nil {
+		return nil, err
+	}
+
+	return c, nil
+}
+
+// Reload reads the certificate and key again. The current certificate is
+// kept if they cannot be read
+func (c *Certificate) Reload() error {
+
+	certificate, err := tls.LoadX509KeyPair(c.certFile, c.keyFile)
+	if err != nil {
+		return err
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.current = &certificate
+	return nil
+}
+
+// GetCertificate returns the current certificate, for tls.Config
+func (c *Certificate) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	return c.current, nil
+}
@@ -0,0 +1,107 @@
+package basic
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// writeTestCertificate writes a self signed certificate and its key
+func writeTestCertificate(t *testing.T, certFile string, keyFile string, name string) {
+
+	public, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Logf("Could not generate a key: %v", err)
+		t.FailNow()
+	}
+
+	template := x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		DNSNames:     []string{name},
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, &template, &template, public, private)
+	if err != nil {
+		t.Logf("Could not create a certificate: %v", err)
+		t.FailNow()
+	}
+
+	keyDer, err := x509.MarshalPKCS8PrivateKey(private)
+	if err != nil {
+		t.Logf("Could not marshal the key: %v", err)
+		t.FailNow()
+	}
+
+	ioutil.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0644)
+	ioutil.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyDer}), 0600)
+}
+
+func commonName(t *testing.T, c *Certificate) string {
+	current, _ := c.GetCertificate(nil)
+	leaf, err := x509.ParseCertificate(current.Certificate[0])
+	if err != nil {
+		t.Logf("Could not parse the certificate: %v", err)
+		t.FailNow()
+	}
+	return leaf.Subject.CommonName
+}
+
+func TestCertificateReload(t *testing.T) {
+
+	dir := t.TempDir()
+	certFile := filepath.Join(dir, "cert.pem")
+	keyFile := filepath.Join(dir, "key.pem")
+
+	writeTestCertificate(t, certFile, keyFile, "old.example.com")
+
+	c, err := NewCertificate(certFile, keyFile)
+	if err != nil {
+		t.Logf("Could not read the certificate: %v", err)
+		t.FailNow()
+	}
+	if name := commonName(t, c); name != "old.example.com" {
+		t.Logf("Unexpected certificate: %s", name)
+		t.FailNow()
+	}
+
+	writeTestCertificate(t, certFile, keyFile, "new.example.com")
+
+	err = c.Reload()
+	if err != nil {
+		t.Logf("Could not reload the certificate: %v", err)
+		t.FailNow()
+	}
+	if name := commonName(t, c); name != "new.example.com" {
+		t.Logf("Unexpected certificate after reloading: %s", name)
+		t.FailNow()
+	}
+
+	// A bad certificate is refused, and the current one kept
+	ioutil.WriteFile(certFile, []byte("junk"), 0644)
+
+	err = c.Reload()
+	if err == nil {
+		t.Log("Unexpected success reloading a bad certificate")
+		t.FailNow()
+	}
+	if name := commonName(t, c); name != "new.example.com" {
+		t.Logf("Unexpected certificate after a failed reload: %s", name)
+		t.FailNow()
+	}
+
+	_, err = NewCertificate(filepath.Join(dir, "missing.pem"), keyFile)
+	if err == nil {
+		t.Log("Unexpected success reading a missing certificate")
+		t.FailNow()
+	}
+}
@@ -0,0 +1,272 @@
+package basic
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"sync"
+
+	jwt "github.com/dgrijalva/jwt-go"
+)
+
+const (
+	// AlgorithmHS256 signs tokens with a shared secret. Only players-api can
+	// verify them
+	AlgorithmHS256 = "HS256"
+
+	// AlgorithmRS256 signs tokens with an RSA private key
+	AlgorithmRS256 = "RS256"
+
+	// AlgorithmEdDSA signs tokens with an Ed25519 private key
+	AlgorithmEdDSA = "EdDSA"
+)
+
+// SigningKey type. A key is identified by the 'kid' header of the tokens it
+// signs
+type SigningKey struct {
+	ID        string
+	Algorithm string
+	secret    []byte
+	private   crypto.Signer
+}
+
+// JWK type. A public key, as served in a JWKS
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+}
+
+// JWKS type
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+var (
+	signingKeysLock sync.RWMutex
+	signingKeys     = map[string]*SigningKey{}
+	currentKey      *SigningKey
+)
+
+func init() {
+	jwt.RegisterSigningMethod(AlgorithmEdDSA, func() jwt.SigningMethod {
+		return signingMethodEdDSA
+	})
+
+	// Until keys are configured, tokens are signed with a random secret, so
+	// they only last as long as the process
+	secret := make([]byte, 32)
+	_, err := rand.Read(secret)
+	if err != nil {
+		panic(err)
+	}
+	key, _ := NewHMACKey("", secret)
+	SetSigningKeys([]*SigningKey{key}, "")
+}
+
+// NewHMACKey returns a key which signs with a shared secret
+func NewHMACKey(id string, secret []byte) (*SigningKey, error) {
+	if len(secret) < 32 {
+		return nil, fmt.Errorf("the secret for key [%s] is too short: at least 32 bytes are needed", id)
+	}
+	return &SigningKey{ID: id, Algorithm: AlgorithmHS256, secret: secret}, nil
+}
+
+// ParsePrivateKey returns a key which signs with the PEM encoded private
+// key. RSA keys may be in PKCS #1 or PKCS #8 form, Ed25519 keys in PKCS #8
+func ParsePrivateKey(id string, algorithm string, data []byte) (*SigningKey, error) {
+
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("no PEM data found for key [%s]", id)
+	}
+
+	var private interface{}
+	private, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		private, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("could not parse the private key [%s]: %s", id, err.Error())
+		}
+	}
+
+	switch algorithm {
+	case AlgorithmRS256:
+		if key, ok := private.(*rsa.PrivateKey); ok {
+			return &SigningKey{ID: id, Algorithm: algorithm, private: key}, nil
+		}
+	case AlgorithmEdDSA:
+		if key, ok := private.(ed25519.PrivateKey); ok {
+			return &SigningKey{ID: id, Algorithm: algorithm, private: key}, nil
+		}
+	default:
+		return nil, fmt.Errorf("unexpected algorithm for key [%s]: %s", id, algorithm)
+	}
+
+	return nil, fmt.Errorf("the private key [%s] is not a %s key", id, algorithm)
+}
+
+// SetSigningKeys replaces the keys. New tokens are signed with the current
+// key, and tokens signed with any of the keys are accepted, so an old key
+// should stay listed until the tokens it signed have expired
+func SetSigningKeys(keys []*SigningKey, current string) error {
+
+	byID := map[string]*SigningKey{}
+	for _, key := range keys {
+		if _, ok := byID[key.ID]; ok {
+			return fmt.Errorf("duplicate signing key: [%s]", key.ID)
+		}
+		byID[key.ID] = key
+	}
+
+	key, ok := byID[current]
+	if !ok {
+		return fmt.Errorf("the current signing key [%s] is not listed", current)
+	}
+
+	signingKeysLock.Lock()
+	defer signingKeysLock.Unlock()
+
+	signingKeys = byID
+	currentKey = key
+	return nil
+}
+
+// PublicJWKS returns the public keys, so other services can verify tokens.
+// Shared secrets are not included
+func PublicJWKS() JWKS {
+	signingKeysLock.RLock()
+	defer signingKeysLock.RUnlock()
+
+	jwks := JWKS{Keys: []JWK{}}
+	for _, key := range signingKeys {
+		switch public := key.public().(type) {
+		case *rsa.PublicKey:
+			jwks.Keys = append(jwks.Keys, JWK{
+				Kty: "RSA",
+				Kid: key.ID,
+				Use: "sig",
+				Alg: key.Algorithm,
+				N:   base64.RawURLEncoding.EncodeToString(public.N.Bytes()),
+				E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(public.E)).Bytes()),
+			})
+		case ed25519.PublicKey:
+			jwks.Keys = append(jwks.Keys, JWK{
+				Kty: "OKP",
+				Kid: key.ID,
+				Use: "sig",
+				Alg: key.Algorithm,
+				Crv: "Ed25519",
+				X:   base64.RawURLEncoding.EncodeToString(public),
+			})
+		}
+	}
+
+	return jwks
+}
+
+// public returns the key used to verify tokens
+func (key *SigningKey) public() interface{} {
+	if key.private != nil {
+		return key.private.Public()
+	}
+	return nil
+}
+
+// signingMethod returns the jwt signing method for the key
+func (key *SigningKey) signingMethod() jwt.SigningMethod {
+	return jwt.GetSigningMethod(key.Algorithm)
+}
+
+// signingKey returns the key in the form the jwt signing method expects
+func (key *SigningKey) signingKey() interface{} {
+	if key.secret != nil {
+		return key.secret
+	}
+	return key.private
+}
+
+// verifyKey returns the key in the form the jwt signing method expects
+func (key *SigningKey) verifyKey() interface{} {
+	if key.secret != nil {
+		return key.secret
+	}
+	return key.public()
+}
+
+// getCurrentKey returns the key which signs new tokens
+func getCurrentKey() *SigningKey {
+	signingKeysLock.RLock()
+	defer signingKeysLock.RUnlock()
+	return currentKey
+}
+
+// lookupKey finds the key which signed a token. The token must use the
+// key's algorithm, so a public key cannot be mistaken for a shared secret
+func lookupKey(token *jwt.Token) (interface{}, error) {
+
+	kid, _ := token.Header["kid"].(string)
+
+	signingKeysLock.RLock()
+	key, ok := signingKeys[kid]
+	signingKeysLock.RUnlock()
+
+	if !ok {
+		return nil, fmt.Errorf("unknown signing key: [%s]", kid)
+	}
+
+	if token.Method.Alg() != key.Algorithm {
+		return nil, fmt.Errorf("unexpected signing method for key [%s]: %s", kid, token.Method.Alg())
+	}
+
+	return key.verifyKey(), nil
+}
+
+// edDSASigningMethod implements EdDSA with Ed25519 keys, which this version
+// of jwt-go does not provide
+type edDSASigningMethod struct{}
+
+var signingMethodEdDSA = &edDSASigningMethod{}
+
+// Alg method
+func (m *edDSASigningMethod) Alg() string {
+	return AlgorithmEdDSA
+}
+
+// Sign method
+func (m *edDSASigningMethod) Sign(signingString string, key interface{}) (string, error) {
+	private, ok := key.(ed25519.PrivateKey)
+	if !ok {
+		return "", jwt.ErrInvalidKeyType
+	}
+	signature := ed25519.Sign(private, []byte(signingString))
+	return jwt.EncodeSegment(signature), nil
+}
+
+// Verify method
+func (m *edDSASigningMethod) Verify(signingString string, signature string, key interface{}) error {
+	public, ok := key.(ed25519.PublicKey)
+	if !ok {
+		return jwt.ErrInvalidKeyType
+	}
+	sig, err := jwt.DecodeSegment(signature)
+	if err != nil {
+		return err
+	}
+	if !ed25519.Verify(public, []byte(signingString), sig) {
+		return errors.New("EdDSA verification failed")
+	}
+	return nil
+}
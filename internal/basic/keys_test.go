@@ -0,0 +1,163 @@
+package basic
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"testing"
+	"time"
+
+	jwt "github.com/dgrijalva/jwt-go"
+)
+
+func newTestHMACKey(t *testing.T, id string) *SigningKey {
+	secret := make([]byte, 32)
+	rand.Read(secret)
+
+	key, err := NewHMACKey(id, secret)
+	if err != nil {
+		t.Logf("Could not create the key [%s]: %v", id, err)
+		t.FailNow()
+	}
+	return key
+}
+
+func newTestPrivateKey(t *testing.T, id string, algorithm string, private interface{}) *SigningKey {
+	der, err := x509.MarshalPKCS8PrivateKey(private)
+	if err != nil {
+		t.Logf("Could not marshal the key [%s]: %v", id, err)
+		t.FailNow()
+	}
+
+	data := pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})
+
+	key, err := ParsePrivateKey(id, algorithm, data)
+	if err != nil {
+		t.Logf("Could not parse the key [%s]: %v", id, err)
+		t.FailNow()
+	}
+	return key
+}
+
+func TestKeyRotation(t *testing.T) {
+
+	oldKey := newTestHMACKey(t, "old")
+	newKey := newTestHMACKey(t, "new")
+
+	err := SetSigningKeys([]*SigningKey{oldKey}, "old")
+	if err != nil {
+		t.Log("Could not set the signing keys")
+		t.FailNow()
+	}
+
+	oldToken, err := GenerateToken(1, 1, time.Minute)
+	if err != nil {
+		t.Log("Could not generate a token")
+		t.FailNow()
+	}
+
+	// Tokens signed with the old key are still accepted after the rotation ...
+	err = SetSigningKeys([]*SigningKey{newKey, oldKey}, "new")
+	if err != nil {
+		t.Log("Could not rotate the signing keys")
+		t.FailNow()
+	}
+
+	_, err = ValidateToken(oldToken)
+	if err != nil {
+		t.Logf("A token signed with the old key was refused: %v", err)
+		t.FailNow()
+	}
+
+	// ... until the old key is dropped
+	err = SetSigningKeys([]*SigningKey{newKey}, "new")
+	if err != nil {
+		t.Log("Could not drop the old signing key")
+		t.FailNow()
+	}
+
+	_, err = ValidateToken(oldToken)
+	if err == nil {
+		t.Log("A token signed with a dropped key was accepted")
+		t.FailNow()
+	}
+
+	err = SetSigningKeys([]*SigningKey{newKey}, "old")
+	if err == nil {
+		t.Log("Unexpected success making an unlisted key current")
+		t.FailNow()
+	}
+}
+
+func TestAsymmetricKeys(t *testing.T) {
+
+	rsaPrivate, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Log("Could not generate an RSA key")
+		t.FailNow()
+	}
+
+	_, edPrivate, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Log("Could not generate an Ed25519 key")
+		t.FailNow()
+	}
+
+	rsaKey := newTestPrivateKey(t, "rsa", AlgorithmRS256, rsaPrivate)
+	edKey := newTestPrivateKey(t, "ed", AlgorithmEdDSA, edPrivate)
+	hmacKey := newTestHMACKey(t, "hmac")
+
+	for _, current := range []string{"rsa", "ed"} {
+
+		err = SetSigningKeys([]*SigningKey{rsaKey, edKey, hmacKey}, current)
+		if err != nil {
+			t.Log("Could not set the signing keys")
+			t.FailNow()
+		}
+
+		token, err := GenerateToken(1, 2, time.Minute)
+		if err != nil {
+			t.Logf("Could not generate a token with the [%s] key: %v", current, err)
+			t.FailNow()
+		}
+
+		claims, err := ValidateToken(token)
+		if err != nil || claims.ID != 1 {
+			t.Logf("Could not validate a token signed with the [%s] key: %v", current, err)
+			t.FailNow()
+		}
+	}
+
+	// Only the public keys are published
+	jwks := PublicJWKS()
+	if len(jwks.Keys) != 2 {
+		t.Logf("Unexpected number of keys in the JWKS. expected: 2, actual: %d", len(jwks.Keys))
+		t.FailNow()
+	}
+
+	// A token which names the RSA key, but is signed as HS256 with the
+	// public key as the secret, is refused
+	der, err := x509.MarshalPKIXPublicKey(&rsaPrivate.PublicKey)
+	if err != nil {
+		t.Log("Could not marshal the public key")
+		t.FailNow()
+	}
+
+	forged := jwt.NewWithClaims(jwt.SigningMethodHS256, MyJwtClaims{ID: 1, StandardClaims: jwt.StandardClaims{ExpiresAt: time.Now().Add(time.Minute).Unix()}})
+	forged.Header["kid"] = "rsa"
+	forgedToken, err := forged.SignedString(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}))
+	if err != nil {
+		t.Log("Could not sign the forged token")
+		t.FailNow()
+	}
+
+	_, err = ValidateToken(forgedToken)
+	if err == nil {
+		t.Log("A token signed with the wrong algorithm was accepted")
+		t.FailNow()
+	}
+
+	SetSigningKeys([]*SigningKey{hmacKey}, "hmac")
+}
@@ -0,0 +1,191 @@
+package basic
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	// PasswordBcrypt hashes are kept in the modular crypt form, for example
+	// $2a$12$<salt and hash>
+	PasswordBcrypt = "bcrypt"
+
+	// PasswordArgon2id hashes are kept in the PHC string form, for example
+	// $argon2id$v=19$m=65536,t=3,p=2$<salt>$<hash>
+	PasswordArgon2id = "argon2id"
+
+	argon2SaltLength = 16
+	argon2KeyLength  = 32
+)
+
+// PasswordPolicy type. New passwords are hashed with the algorithm and its
+// parameters, and older hashes are replaced when they are weaker
+type PasswordPolicy struct {
+	Algorithm     string
+	BcryptCost    int
+	Argon2Memory  uint32 // KiB
+	Argon2Time    uint32
+	Argon2Threads uint8
+}
+
+var (
+	// DefaultPasswordPolicy is used until a policy is configured
+	DefaultPasswordPolicy = PasswordPolicy{
+		Algorithm:     PasswordBcrypt,
+		BcryptCost:    12,
+		Argon2Memory:  64 * 1024,
+		Argon2Time:    3,
+		Argon2Threads: 2,
+	}
+
+	// ErrPasswordMismatch is returned when the password does not match the hash
+	ErrPasswordMismatch = errors.New("the password does not match the hash")
+
+	passwordPolicyLock sync.RWMutex
+	passwordPolicy     = DefaultPasswordPolicy
+)
+
+// Validate checks the algorithm is known and its parameters are usable
+func (policy PasswordPolicy) Validate() error {
+
+	switch policy.Algorithm {
+	case PasswordBcrypt:
+		if policy.BcryptCost < bcrypt.MinCost || policy.BcryptCost > bcrypt.MaxCost {
+			return fmt.Errorf("the bcrypt cost must be between %d and %d: %d", bcrypt.MinCost, bcrypt.MaxCost, policy.BcryptCost)
+		}
+	case PasswordArgon2id:
+		if policy.Argon2Memory == 0 || policy.Argon2Time == 0 || policy.Argon2Threads == 0 {
+			return fmt.Errorf("the argon2id memory, time and threads must all be set: %+v", policy)
+		}
+	default:
+		return fmt.Errorf("unexpected password algorithm: [%s]", policy.Algorithm)
+	}
+
+	return nil
+}
+
+// SetPasswordPolicy replaces the policy for hashing passwords
+func SetPasswordPolicy(policy PasswordPolicy) error {
+
+	err := policy.Validate()
+	if err != nil {
+		return err
+	}
+
+	passwordPolicyLock.Lock()
+	defer passwordPolicyLock.Unlock()
+
+	passwordPolicy = policy
+	return nil
+}
+
+// getPasswordPolicy returns the current policy
+func getPasswordPolicy() PasswordPolicy {
+	passwordPolicyLock.RLock()
+	defer passwordPolicyLock.RUnlock()
+	return passwordPolicy
+}
+
+// HashPassword hashes a password with the current policy. The hash records
+// the algorithm and its parameters
+func HashPassword(password string) ([]byte, error) {
+	policy := getPasswordPolicy()
+
+	if policy.Algorithm == PasswordArgon2id {
+		salt := make([]byte, argon2SaltLength)
+		_, err := rand.Read(salt)
+		if err != nil {
+			return nil, err
+		}
+
+		key := argon2.IDKey([]byte(password), salt, policy.Argon2Time, policy.Argon2Memory, policy.Argon2Threads, argon2KeyLength)
+
+		encoded := fmt.Sprintf("$%s$v=%d$m=%d,t=%d,p=%d$%s$%s", PasswordArgon2id, argon2.Version,
+			policy.Argon2Memory, policy.Argon2Time, policy.Argon2Threads,
+			base64.RawStdEncoding.EncodeToString(salt), base64.RawStdEncoding.EncodeToString(key))
+		return []byte(encoded), nil
+	}
+
+	return bcrypt.GenerateFromPassword([]byte(password), policy.BcryptCost)
+}
+
+// CheckPassword checks the password matches the hash, and returns whether
+// the hash is weaker than the current policy, and so should be replaced
+func CheckPassword(hash []byte, password string) (bool, error) {
+	policy := getPasswordPolicy()
+
+	if strings.HasPrefix(string(hash), "$"+PasswordArgon2id+"$") {
+		params, salt, key, err := decodeArgon2id(string(hash))
+		if err != nil {
+			return false, err
+		}
+
+		other := argon2.IDKey([]byte(password), salt, params.Argon2Time, params.Argon2Memory, params.Argon2Threads, uint32(len(key)))
+		if subtle.ConstantTimeCompare(key, other) != 1 {
+			return false, ErrPasswordMismatch
+		}
+
+		weaker := policy.Algorithm != PasswordArgon2id ||
+			params.Argon2Memory < policy.Argon2Memory ||
+			params.Argon2Time < policy.Argon2Time ||
+			params.Argon2Threads < policy.Argon2Threads
+		return weaker, nil
+	}
+
+	err := bcrypt.CompareHashAndPassword(hash, []byte(password))
+	if err == bcrypt.ErrMismatchedHashAndPassword {
+		return false, ErrPasswordMismatch
+	} else if err != nil {
+		return false, err
+	}
+
+	cost, err := bcrypt.Cost(hash)
+	if err != nil {
+		return false, err
+	}
+
+	weaker := policy.Algorithm != PasswordBcrypt || cost < policy.BcryptCost
+	return weaker, nil
+}
+
+// decodeArgon2id returns the parameters, salt and key held in an argon2id
+// hash
+func decodeArgon2id(encoded string) (PasswordPolicy, []byte, []byte, error) {
+	params := PasswordPolicy{Algorithm: PasswordArgon2id}
+
+	parts := strings.Split(encoded, "$")
+	if len(parts) != 6 {
+		return params, nil, nil, fmt.Errorf("unexpected argon2id hash format")
+	}
+
+	var version int
+	_, err := fmt.Sscanf(parts[2], "v=%d", &version)
+	if err != nil || version != argon2.Version {
+		return params, nil, nil, fmt.Errorf("unexpected argon2id version: [%s]", parts[2])
+	}
+
+	_, err = fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &params.Argon2Memory, &params.Argon2Time, &params.Argon2Threads)
+	if err != nil {
+		return params, nil, nil, fmt.Errorf("unexpected argon2id parameters: [%s]", parts[3])
+	}
+
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return params, nil, nil, fmt.Errorf("unexpected argon2id salt: %s", err.Error())
+	}
+
+	key, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil {
+		return params, nil, nil, fmt.Errorf("unexpected argon2id key: %s", err.Error())
+	}
+
+	return params, salt, key, nil
+}
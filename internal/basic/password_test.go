@@ -0,0 +1,93 @@
+package basic
+
+import (
+	"testing"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+func setTestPasswordPolicy(t *testing.T, policy PasswordPolicy) {
+	err := SetPasswordPolicy(policy)
+	if err != nil {
+		t.Logf("Could not set the password policy: %v", err)
+		t.FailNow()
+	}
+}
+
+func TestPasswordHashing(t *testing.T) {
+
+	weakBcrypt := PasswordPolicy{Algorithm: PasswordBcrypt, BcryptCost: bcrypt.MinCost}
+	strongBcrypt := PasswordPolicy{Algorithm: PasswordBcrypt, BcryptCost: bcrypt.MinCost + 1}
+	weakArgon2id := PasswordPolicy{Algorithm: PasswordArgon2id, Argon2Memory: 1024, Argon2Time: 1, Argon2Threads: 1}
+	strongArgon2id := PasswordPolicy{Algorithm: PasswordArgon2id, Argon2Memory: 2048, Argon2Time: 1, Argon2Threads: 1}
+
+	defer SetPasswordPolicy(DefaultPasswordPolicy)
+
+	// Hashes written before the algorithm was recorded are plain bcrypt hashes
+	legacy, err := bcrypt.GenerateFromPassword([]byte("password"), bcrypt.MinCost)
+	if err != nil {
+		t.Log("Could not generate a legacy hash")
+		t.FailNow()
+	}
+
+	tests := []struct {
+		name        string
+		hashWith    PasswordPolicy
+		checkWith   PasswordPolicy
+		password    string
+		needsRehash bool
+		err         error
+	}{
+		{name: "bcrypt", hashWith: weakBcrypt, checkWith: weakBcrypt, password: "password", needsRehash: false},
+		{name: "bcrypt wrong password", hashWith: weakBcrypt, checkWith: weakBcrypt, password: "wrong", err: ErrPasswordMismatch},
+		{name: "bcrypt lower cost", hashWith: weakBcrypt, checkWith: strongBcrypt, password: "password", needsRehash: true},
+		{name: "bcrypt higher cost", hashWith: strongBcrypt, checkWith: weakBcrypt, password: "password", needsRehash: false},
+		{name: "argon2id", hashWith: weakArgon2id, checkWith: weakArgon2id, password: "password", needsRehash: false},
+		{name: "argon2id wrong password", hashWith: weakArgon2id, checkWith: weakArgon2id, password: "wrong", err: ErrPasswordMismatch},
+		{name: "argon2id less memory", hashWith: weakArgon2id, checkWith: strongArgon2id, password: "password", needsRehash: true},
+		{name: "bcrypt to argon2id", hashWith: strongBcrypt, checkWith: weakArgon2id, password: "password", needsRehash: true},
+		{name: "argon2id to bcrypt", hashWith: strongArgon2id, checkWith: weakBcrypt, password: "password", needsRehash: true},
+	}
+
+	for _, test := range tests {
+		setTestPasswordPolicy(t, test.hashWith)
+
+		hash, err := HashPassword("password")
+		if err != nil {
+			t.Logf("%s: Could not hash the password: %v", test.name, err)
+			t.FailNow()
+		}
+
+		if hash[0] != '$' {
+			t.Logf("%s: The hash does not record the algorithm: %s", test.name, string(hash))
+			t.FailNow()
+		}
+
+		setTestPasswordPolicy(t, test.checkWith)
+
+		needsRehash, err := CheckPassword(hash, test.password)
+		if err != test.err {
+			t.Logf("%s: Unexpected error. expected: %v, actual: %v", test.name, test.err, err)
+			t.FailNow()
+		}
+
+		if needsRehash != test.needsRehash {
+			t.Logf("%s: Unexpected rehash. expected: %t, actual: %t", test.name, test.needsRehash, needsRehash)
+			t.FailNow()
+		}
+	}
+
+	setTestPasswordPolicy(t, strongBcrypt)
+
+	needsRehash, err := CheckPassword(legacy, "password")
+	if err != nil || !needsRehash {
+		t.Logf("A legacy hash was not accepted for rehashing: %t, %v", needsRehash, err)
+		t.FailNow()
+	}
+
+	err = SetPasswordPolicy(PasswordPolicy{Algorithm: "md5"})
+	if err == nil {
+		t.Log("Unexpected success setting an unknown algorithm")
+		t.FailNow()
+	}
+}
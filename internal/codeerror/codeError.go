@@ -44,6 +44,16 @@ func NewForbidden(text string) *CodeError {
 	return &CodeError{http.StatusForbidden, text}
 }
 
+// NewPreconditionFailed function
+func NewPreconditionFailed(text string) *CodeError {
+	return &CodeError{http.StatusPreconditionFailed, text}
+}
+
+// NewTooManyRequests function
+func NewTooManyRequests(text string) *CodeError {
+	return &CodeError{http.StatusTooManyRequests, text}
+}
+
 // NewUnauthorized function
 func NewUnauthorized(text string) *CodeError {
 	return &CodeError{http.StatusUnauthorized, text}
@@ -7,41 +7,156 @@ import (
 	"path/filepath"
 	"time"
 
+	"github.com/rsmaxwell/players-api/internal/basic"
 	"github.com/rsmaxwell/players-api/internal/debug"
+	"github.com/rsmaxwell/players-api/internal/mailer"
+	"github.com/rsmaxwell/players-api/internal/metrics"
 )
 
-// Database type
+// Database type. The connection pool limits which are not given keep their
+// defaults. A maximum of zero open connections means no limit
 type Database struct {
-	DriverName   string `json:"driverName"`
-	UserName     string `json:"userName"`
-	Password     string `json:"password"`
-	Scheme       string `json:"scheme"`
-	Host         string `json:"host"`
-	Path         string `json:"path"`
-	DatabaseName string `json:"databaseName"`
+	DriverName      string `json:"driverName"`
+	UserName        string `json:"userName"`
+	Password        string `json:"password"`
+	Scheme          string `json:"scheme"`
+	Host            string `json:"host"`
+	Path            string `json:"path"`
+	DatabaseName    string `json:"databaseName"`
+	MaxOpenConns    *int   `json:"maxOpenConns"`
+	MaxIdleConns    *int   `json:"maxIdleConns"`
+	ConnMaxLifetime string `json:"connMaxLifetime"`
+	ConnMaxIdleTime string `json:"connMaxIdleTime"`
 }
 
-// Server type
+// Pool type. The limits of the database connection pool
+type Pool struct {
+	MaxOpenConns    int
+	MaxIdleConns    int
+	ConnMaxLifetime time.Duration
+	ConnMaxIdleTime time.Duration
+}
+
+// CORS type. The browser origins, such as "https://players.example.com",
+// which may call the API, and the request headers and methods they may use.
+// The origins default to the client URL
+type CORS struct {
+	AllowedOrigins []string `json:"allowedOrigins"`
+	AllowedHeaders []string `json:"allowedHeaders"`
+	AllowedMethods []string `json:"allowedMethods"`
+}
+
+// Server type. The metrics are served in the Prometheus text format on the
+// metrics port, unless it is zero. The requests are served over HTTPS when a
+// certificate and key are given, as PEM files relative to the config
+// directory. The timeouts are durations, such as "30s"
 type Server struct {
-	Port int `json:"port"`
+	Port            int    `json:"port"`
+	MetricsPort     int    `json:"metricsPort"`
+	CertFile        string `json:"certFile"`
+	KeyFile         string `json:"keyFile"`
+	ReadTimeout     string `json:"readTimeout"`
+	WriteTimeout    string `json:"writeTimeout"`
+	IdleTimeout     string `json:"idleTimeout"`
+	RequestTimeout  string `json:"requestTimeout"`
+	ShutdownTimeout string `json:"shutdownTimeout"`
+}
+
+// SigningKey type. The key material is given by exactly one of: the secret
+// itself, for HS256; a PEM file holding the private key, for RS256 and
+// EdDSA, relative to the config directory; or the name of an environment
+// variable holding either
+type SigningKey struct {
+	ID        string `json:"kid"`
+	Algorithm string `json:"alg"`
+	Secret    string `json:"secret"`
+	File      string `json:"file"`
+	Env       string `json:"env"`
+}
+
+// Mail type. The type is "smtp", to deliver mail, or "file", to write it to
+// files in the directory, relative to the root directory
+type Mail struct {
+	Type     string `json:"type"`
+	Host     string `json:"host"`
+	Port     int    `json:"port"`
+	UserName string `json:"userName"`
+	Password string `json:"password"`
+	From     string `json:"from"`
+	Dir      string `json:"dir"`
+}
+
+// Password type. The algorithm is "bcrypt" or "argon2id". Parameters which
+// are not given keep their defaults
+type Password struct {
+	Algorithm     string `json:"algorithm"`
+	BcryptCost    int    `json:"bcryptCost"`
+	Argon2Memory  uint32 `json:"argon2Memory"` // KiB
+	Argon2Time    uint32 `json:"argon2Time"`
+	Argon2Threads uint8  `json:"argon2Threads"`
+}
+
+// Dumps type. The ages and window are durations, such as "720h". A limit of
+// zero means no limit. Limits which are not given keep their defaults
+type Dumps struct {
+	MaxAge      string `json:"maxAge"`
+	MaxCount    *int   `json:"maxCount"`
+	MaxBytes    *int64 `json:"maxBytes"`
+	DedupWindow string `json:"dedupWindow"`
 }
 
 // Config type
 type ConfigFile struct {
-	Database           Database `json:"database"`
-	Server             Server   `json:"server"`
-	AccessTokenExpiry  string   `json:"accessToken_expiry"`
-	RefreshTokenExpiry string   `json:"refreshToken_expiry"`
-	ClientRefreshDelta string   `json:"clientRefreshDelta"`
+	Database           Database     `json:"database"`
+	AutoMigrate        bool         `json:"autoMigrate"`
+	Server             Server       `json:"server"`
+	CORS               CORS         `json:"cors"`
+	AccessTokenExpiry  string       `json:"accessToken_expiry"`
+	RefreshTokenExpiry string       `json:"refreshToken_expiry"`
+	ClientRefreshDelta string       `json:"clientRefreshDelta"`
+	CheckInTokenExpiry string       `json:"checkInToken_expiry"`
+	LoginMaxFailures   *int         `json:"loginMaxFailures"`
+	LoginLockout       string       `json:"loginLockout"`
+	LoginBackoff       string       `json:"loginBackoff"`
+	LoginMaxBackoff    string       `json:"loginMaxBackoff"`
+	Password           Password     `json:"password"`
+	VerifyTokenExpiry  string       `json:"verifyToken_expiry"`
+	ResetTokenExpiry   string       `json:"resetToken_expiry"`
+	Mail               Mail         `json:"mail"`
+	Dumps              Dumps        `json:"dumps"`
+	ClientURL          string       `json:"clientURL"`
+	SigningKeys        []SigningKey `json:"signingKeys"`
+	SigningKey         string       `json:"signingKey"`
 }
 
 // Config type
 type Config struct {
 	Database           Database
+	Pool               Pool
+	AutoMigrate        bool // players-api migrates an out of date schema, rather than refusing to start
 	Server             Server
+	CORS               CORS
+	ReadTimeout        time.Duration // to read a whole request
+	WriteTimeout       time.Duration // to write a response, from the end of reading the request
+	IdleTimeout        time.Duration // to wait for the next request on a connection
+	RequestTimeout     time.Duration // for the handlers to finish with a request
+	ShutdownTimeout    time.Duration // to finish the requests in progress when stopping
 	AccessTokenExpiry  time.Duration
 	RefreshTokenExpiry time.Duration
 	ClientRefreshDelta time.Duration
+	CheckInTokenExpiry time.Duration
+	LoginMaxFailures   int           // failed signins in a row before an account is locked. 0 never locks
+	LoginLockout       time.Duration // how long an account stays locked
+	LoginBackoff       time.Duration // the wait after the first failed signin, doubled after each further one
+	LoginMaxBackoff    time.Duration
+	PasswordPolicy     basic.PasswordPolicy // how new passwords are hashed
+	VerifyTokenExpiry  time.Duration
+	ResetTokenExpiry   time.Duration
+	Mailer             mailer.Mailer
+	DumpRetention      debug.Retention     // which dumps are kept
+	ClientURL          string              // the links sent by mail point here
+	SigningKeys        []*basic.SigningKey // none means tokens are signed with a random secret
+	SigningKey         string
 }
 
 var (
@@ -59,13 +174,33 @@ func Setup() (*sql.DB, *Config, error) {
 	c, err := Open(rootDir)
 	if err != nil {
 		message := "Could not open configuration"
+		f.Errorf("%s: %s", message, err.Error())
+		f.DumpError(err, message)
+		return nil, nil, err
+	}
+
+	// Every tool hashes passwords the same way
+	err = basic.SetPasswordPolicy(c.PasswordPolicy)
+	if err != nil {
+		message := "Could not set the password policy"
+		f.Errorf(message)
+		f.DumpError(err, message)
+		return nil, nil, err
+	}
+
+	// Every tool keeps the same dumps
+	debug.SetRetention(c.DumpRetention)
+
+	// Connect to the database, timing each query
+	driverName, err := metrics.InstrumentDriver(c.DriverName())
+	if err != nil {
+		message := "Could not instrument the database driver"
 		f.Errorf(message)
 		f.DumpError(err, message)
 		return nil, nil, err
 	}
 
-	// Connect to the database
-	db, err := sql.Open(c.DriverName(), c.ConnectionString())
+	db, err := sql.Open(driverName, c.ConnectionString())
 	if err != nil {
 		message := "Could not connect to the database"
 		f.Errorf(message)
@@ -73,6 +208,11 @@ func Setup() (*sql.DB, *Config, error) {
 		return nil, nil, err
 	}
 
+	db.SetMaxOpenConns(c.Pool.MaxOpenConns)
+	db.SetMaxIdleConns(c.Pool.MaxIdleConns)
+	db.SetConnMaxLifetime(c.Pool.ConnMaxLifetime)
+	db.SetConnMaxIdleTime(c.Pool.ConnMaxIdleTime)
+
 	return db, c, nil
 }
 
@@ -95,5 +235,5 @@ func Open(rootDir string) (*Config, error) {
 		return nil, err
 	}
 
-	return configFile.toConfig()
+	return configFile.toConfig(filepath.Join(rootDir, "config"))
 }
@@ -0,0 +1,171 @@
+package config
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+func validConfigFile() *ConfigFile {
+	return &ConfigFile{
+		Database: Database{DriverName: "pgx", Scheme: "postgres", Host: "localhost:5432", DatabaseName: "players"},
+		Server:   Server{Port: 4201},
+	}
+}
+
+func TestEnvName(t *testing.T) {
+
+	tests := []struct {
+		path     []string
+		expected string
+	}{
+		{path: []string{"server", "metricsPort"}, expected: "PLAYERS_SERVER_METRICS_PORT"},
+		{path: []string{"accessToken_expiry"}, expected: "PLAYERS_ACCESS_TOKEN_EXPIRY"},
+		{path: []string{"clientURL"}, expected: "PLAYERS_CLIENT_URL"},
+		{path: []string{"password", "argon2Memory"}, expected: "PLAYERS_PASSWORD_ARGON2_MEMORY"},
+		{path: []string{"cors", "allowedOrigins"}, expected: "PLAYERS_CORS_ALLOWED_ORIGINS"},
+		{path: []string{"database", "connMaxIdleTime"}, expected: "PLAYERS_DATABASE_CONN_MAX_IDLE_TIME"},
+	}
+
+	for _, test := range tests {
+		actual := EnvName(test.path...)
+		if actual != test.expected {
+			t.Logf("Unexpected name for %v. expected: %s, actual: %s", test.path, test.expected, actual)
+			t.FailNow()
+		}
+	}
+}
+
+func TestEnvironmentOverrides(t *testing.T) {
+
+	env := map[string]string{
+		"PLAYERS_SERVER_PORT":             "8443",
+		"PLAYERS_DATABASE_MAX_OPEN_CONNS": "5",
+		"PLAYERS_DATABASE_MAX_IDLE_CONNS": "2",
+		"PLAYERS_CORS_ALLOWED_ORIGINS":    "https://a.example.com, https://b.example.com",
+		"PLAYERS_ACCESS_TOKEN_EXPIRY":     "20m",
+		"PLAYERS_AUTO_MIGRATE":            "true",
+	}
+	for name, value := range env {
+		os.Setenv(name, value)
+		defer os.Unsetenv(name)
+	}
+
+	c, err := validConfigFile().toConfig(t.TempDir())
+	if err != nil {
+		t.Logf("Could not read the configuration: %v", err)
+		t.FailNow()
+	}
+
+	if c.Server.Port != 8443 || c.Pool.MaxOpenConns != 5 || c.Pool.MaxIdleConns != 2 || !c.AutoMigrate {
+		t.Logf("Unexpected settings: port: %d, pool: %+v, autoMigrate: %v", c.Server.Port, c.Pool, c.AutoMigrate)
+		t.FailNow()
+	}
+	if c.AccessTokenExpiry.String() != "20m0s" {
+		t.Logf("Unexpected access token expiry: %s", c.AccessTokenExpiry)
+		t.FailNow()
+	}
+	if strings.Join(c.CORS.AllowedOrigins, " ") != "https://a.example.com https://b.example.com" {
+		t.Logf("Unexpected origins: %v", c.CORS.AllowedOrigins)
+		t.FailNow()
+	}
+}
+
+func TestDeprecatedEnvironment(t *testing.T) {
+
+	env := map[string]string{
+		"AccessTokenExpiry":            "30m",
+		"RefreshTokenExpiry":           "3h",
+		"PLAYERS_REFRESH_TOKEN_EXPIRY": "4h",
+		"MetricsPort":                  "9100",
+	}
+	for name, value := range env {
+		os.Setenv(name, value)
+		defer os.Unsetenv(name)
+	}
+
+	c, err := validConfigFile().toConfig(t.TempDir())
+	if err != nil {
+		t.Logf("Could not read the configuration: %v", err)
+		t.FailNow()
+	}
+
+	if c.AccessTokenExpiry.String() != "30m0s" {
+		t.Logf("Unexpected access token expiry. expected: 30m0s, actual: %s", c.AccessTokenExpiry)
+		t.FailNow()
+	}
+	if c.RefreshTokenExpiry.String() != "4h0m0s" {
+		t.Logf("Unexpected refresh token expiry. expected: 4h0m0s, actual: %s", c.RefreshTokenExpiry)
+		t.FailNow()
+	}
+	if c.Server.MetricsPort != 0 {
+		t.Logf("Unexpected metrics port. expected: 0, actual: %d", c.Server.MetricsPort)
+		t.FailNow()
+	}
+}
+
+func TestDefaults(t *testing.T) {
+
+	c, err := validConfigFile().toConfig(t.TempDir())
+	if err != nil {
+		t.Logf("Could not read the configuration: %v", err)
+		t.FailNow()
+	}
+
+	if len(c.CORS.AllowedOrigins) != 1 || c.CORS.AllowedOrigins[0] != c.ClientURL {
+		t.Logf("Unexpected origins. expected: [%s], actual: %v", c.ClientURL, c.CORS.AllowedOrigins)
+		t.FailNow()
+	}
+	if c.Pool.MaxOpenConns == 0 || c.Pool.ConnMaxLifetime == 0 {
+		t.Logf("Unexpected pool: %+v", c.Pool)
+		t.FailNow()
+	}
+}
+
+func TestProblems(t *testing.T) {
+
+	maxIdle := 20
+	maxOpen := 10
+
+	file := validConfigFile()
+	file.Database.Host = ""
+	file.Database.MaxIdleConns = &maxIdle
+	file.Database.MaxOpenConns = &maxOpen
+	file.Server.Port = 0
+	file.Server.CertFile = "server.crt"
+	file.RefreshTokenExpiry = "junk"
+	file.CORS.AllowedOrigins = []string{"https://players.example.com", "players.example.com", "*"}
+	file.CORS.AllowedMethods = []string{"GET", "FETCH"}
+
+	os.Setenv("PLAYERS_SERVER_METRICS_PORT", "junk")
+	defer os.Unsetenv("PLAYERS_SERVER_METRICS_PORT")
+
+	_, err := file.toConfig(t.TempDir())
+	problems, ok := err.(Problems)
+	if !ok {
+		t.Logf("Unexpected error: %v", err)
+		t.FailNow()
+	}
+
+	expected := []string{
+		"PLAYERS_SERVER_METRICS_PORT",
+		"refreshToken_expiry",
+		"database.host",
+		"database.maxIdleConns",
+		"server.port",
+		"server: needs both a certFile and a keyFile",
+		"'players.example.com' is not an origin",
+		"'*' cannot be used",
+		"'FETCH'",
+	}
+	if len(problems) != len(expected) {
+		t.Logf("Unexpected number of problems. expected: %d, actual: %d\n%s", len(expected), len(problems), problems.Error())
+		t.FailNow()
+	}
+	for _, text := range expected {
+		if !strings.Contains(problems.Error(), text) {
+			t.Logf("Missing problem [%s] from:\n%s", text, problems.Error())
+			t.FailNow()
+		}
+	}
+}
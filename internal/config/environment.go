@@ -0,0 +1,150 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// EnvPrefix starts the name of the environment variable which overrides each
+// setting of the configuration file
+const EnvPrefix = "PLAYERS_"
+
+// EnvName returns the environment variable which overrides a setting, given
+// the JSON names leading to it. For example, "server" and "metricsPort" give
+// PLAYERS_SERVER_METRICS_PORT
+func EnvName(path ...string) string {
+
+	var words []string
+	for _, name := range path {
+		for _, part := range strings.Split(name, "_") {
+			words = append(words, splitCamelCase(part)...)
+		}
+	}
+
+	return EnvPrefix + strings.ToUpper(strings.Join(words, "_"))
+}
+
+// splitCamelCase splits a name into its words, keeping acronyms and numbers
+// with the word before. For example, "clientURL" gives "client" and "URL",
+// and "argon2Memory" gives "argon2" and "Memory"
+func splitCamelCase(name string) []string {
+
+	runes := []rune(name)
+	var words []string
+	start := 0
+	for i := 1; i < len(runes); i++ {
+		if !unicode.IsUpper(runes[i]) {
+			continue
+		}
+		previous := runes[i-1]
+		acronymEnds := unicode.IsUpper(previous) && i+1 < len(runes) && unicode.IsLower(runes[i+1])
+		if unicode.IsLower(previous) || unicode.IsDigit(previous) || acronymEnds {
+			words = append(words, string(runes[start:i]))
+			start = i
+		}
+	}
+	if start < len(runes) {
+		words = append(words, string(runes[start:]))
+	}
+
+	return words
+}
+
+// applyEnvironment overrides the settings of the configuration file with the
+// environment variables named after them, and returns the problems with
+// their values. A list is given as comma separated values. The signing keys
+// cannot be overridden, as there may be any number of them
+func applyEnvironment(c *ConfigFile) []string {
+	return applyEnvironmentTo(reflect.ValueOf(c).Elem(), nil)
+}
+
+func applyEnvironmentTo(v reflect.Value, path []string) []string {
+
+	var problems []string
+	for i := 0; i < v.NumField(); i++ {
+		field := v.Field(i)
+		name := strings.Split(v.Type().Field(i).Tag.Get("json"), ",")[0]
+		if name == "" || name == "-" {
+			continue
+		}
+		fieldPath := append(append([]string(nil), path...), name)
+
+		if field.Kind() == reflect.Struct {
+			problems = append(problems, applyEnvironmentTo(field, fieldPath)...)
+			continue
+		}
+
+		envvar := EnvName(fieldPath...)
+		value, ok := os.LookupEnv(envvar)
+		if !ok {
+			continue
+		}
+
+		err := setField(field, value)
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("%s: %s", envvar, err.Error()))
+		}
+	}
+
+	return problems
+}
+
+// setField parses the value into the field
+func setField(field reflect.Value, value string) error {
+
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(value)
+
+	case reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return err
+		}
+		field.SetBool(b)
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i, err := strconv.ParseInt(value, 10, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetInt(i)
+
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		u, err := strconv.ParseUint(value, 10, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetUint(u)
+
+	case reflect.Ptr:
+		pointer := reflect.New(field.Type().Elem())
+		err := setField(pointer.Elem(), value)
+		if err != nil {
+			return err
+		}
+		field.Set(pointer)
+
+	case reflect.Slice:
+		if field.Type().Elem().Kind() != reflect.String {
+			return fmt.Errorf("cannot be set from the environment")
+		}
+		list := []string{}
+		for _, item := range strings.Split(value, ",") {
+			item = strings.TrimSpace(item)
+			if item != "" {
+				list = append(list, item)
+			}
+		}
+		field.Set(reflect.ValueOf(list))
+
+	default:
+		return fmt.Errorf("cannot be set from the environment")
+	}
+
+	return nil
+}
@@ -2,33 +2,147 @@ package config
 
 import (
 	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
 	"time"
 
 	"github.com/rsmaxwell/players-api/internal/basic"
 	"github.com/rsmaxwell/players-api/internal/debug"
+	"github.com/rsmaxwell/players-api/internal/mailer"
 )
 
 var (
-	functionGetDuration = debug.NewFunction(pkg, "GetDuration")
+	functionGetDuration    = debug.NewFunction(pkg, "GetDuration")
+	functionGetSigningKeys = debug.NewFunction(pkg, "getSigningKeys")
+	functionGetMailer      = debug.NewFunction(pkg, "getMailer")
+	functionGetPassword    = debug.NewFunction(pkg, "getPasswordPolicy")
+	functionGetRetention   = debug.NewFunction(pkg, "getDumpRetention")
 )
 
-func (c *ConfigFile) toConfig() (*Config, error) {
-	config := Config{Database: c.Database, Server: c.Server}
+// deprecatedEnv gives the older environment variables which still override
+// a setting, unless the PLAYERS_ variable for the setting is set
+var deprecatedEnv = map[string]string{
+	"accessToken_expiry":  "AccessTokenExpiry",
+	"refreshToken_expiry": "RefreshTokenExpiry",
+	"clientRefreshDelta":  "ClientRefreshDelta",
+}
+
+// toConfig checks the settings, fills in the defaults, and returns the
+// configuration, or every problem found
+func (c *ConfigFile) toConfig(configDir string) (*Config, error) {
+	var problems Problems
+	problems.add(applyEnvironment(c)...)
+
+	config := Config{Database: c.Database, AutoMigrate: c.AutoMigrate, Server: c.Server}
+
+	duration := func(setting string, value string, def string) time.Duration {
+		envvar := ""
+		if old, ok := deprecatedEnv[setting]; ok {
+			if _, set := os.LookupEnv(EnvName(setting)); !set {
+				envvar = old
+			}
+		}
+
+		d, err := GetDuration(envvar, value, def)
+		if err != nil {
+			problems.add(fmt.Sprintf("%s: %s", setting, err.Error()))
+
+			// The default is checked instead, so the problem is only listed once
+			d, _ = time.ParseDuration(def)
+		}
+		return d
+	}
+
+	config.AccessTokenExpiry = duration("accessToken_expiry", c.AccessTokenExpiry, "10m")
+	config.RefreshTokenExpiry = duration("refreshToken_expiry", c.RefreshTokenExpiry, "2h")
+	config.ClientRefreshDelta = duration("clientRefreshDelta", c.ClientRefreshDelta, "30s")
+	config.CheckInTokenExpiry = duration("checkInToken_expiry", c.CheckInTokenExpiry, "5m")
+
+	maxFailures := 5
+	if c.LoginMaxFailures != nil {
+		maxFailures = *c.LoginMaxFailures
+	}
+	config.LoginMaxFailures = maxFailures
+
+	config.LoginLockout = duration("loginLockout", c.LoginLockout, "15m")
+	config.LoginBackoff = duration("loginBackoff", c.LoginBackoff, "1s")
+	config.LoginMaxBackoff = duration("loginMaxBackoff", c.LoginMaxBackoff, "1m")
 
 	var err error
-	config.AccessTokenExpiry, err = GetDuration("AccessTokenExpiry", c.AccessTokenExpiry, "10m")
+	config.PasswordPolicy, err = getPasswordPolicy(c.Password)
 	if err != nil {
-		return nil, err
+		problems.add(fmt.Sprintf("password: %s", err.Error()))
 	}
 
-	config.RefreshTokenExpiry, err = GetDuration("RefreshTokenExpiry", c.RefreshTokenExpiry, "2h")
+	config.VerifyTokenExpiry = duration("verifyToken_expiry", c.VerifyTokenExpiry, "24h")
+	config.ResetTokenExpiry = duration("resetToken_expiry", c.ResetTokenExpiry, "1h")
+
+	config.Mailer, err = getMailer(filepath.Dir(configDir), c.Mail)
 	if err != nil {
-		return nil, err
+		problems.add(fmt.Sprintf("mail: %s", err.Error()))
 	}
 
-	config.ClientRefreshDelta, err = GetDuration("ClientRefreshDelta", c.ClientRefreshDelta, "30s")
+	config.DumpRetention, err = getDumpRetention(c.Dumps)
 	if err != nil {
-		return nil, err
+		problems.add(fmt.Sprintf("dumps: %s", err.Error()))
+	}
+
+	if config.Server.CertFile != "" && !filepath.IsAbs(config.Server.CertFile) {
+		config.Server.CertFile = filepath.Join(configDir, config.Server.CertFile)
+	}
+	if config.Server.KeyFile != "" && !filepath.IsAbs(config.Server.KeyFile) {
+		config.Server.KeyFile = filepath.Join(configDir, config.Server.KeyFile)
+	}
+
+	config.ReadTimeout = duration("server.readTimeout", c.Server.ReadTimeout, "15s")
+	config.RequestTimeout = duration("server.requestTimeout", c.Server.RequestTimeout, "60s")
+
+	// The write timeout allows the handlers to reach their own timeout first,
+	// so they can still respond. The event streams set their own deadlines
+	config.WriteTimeout = duration("server.writeTimeout", c.Server.WriteTimeout, (config.RequestTimeout + 15*time.Second).String())
+
+	config.IdleTimeout = duration("server.idleTimeout", c.Server.IdleTimeout, "2m")
+	config.ShutdownTimeout = duration("server.shutdownTimeout", c.Server.ShutdownTimeout, "30s")
+
+	config.Pool = Pool{MaxOpenConns: 20, MaxIdleConns: 10}
+	if c.Database.MaxOpenConns != nil {
+		config.Pool.MaxOpenConns = *c.Database.MaxOpenConns
+	}
+	if c.Database.MaxIdleConns != nil {
+		config.Pool.MaxIdleConns = *c.Database.MaxIdleConns
+	}
+	config.Pool.ConnMaxLifetime = duration("database.connMaxLifetime", c.Database.ConnMaxLifetime, "30m")
+	config.Pool.ConnMaxIdleTime = duration("database.connMaxIdleTime", c.Database.ConnMaxIdleTime, "5m")
+
+	config.ClientURL = c.ClientURL
+	if config.ClientURL == "" {
+		config.ClientURL = "http://localhost:4200"
+	}
+	config.ClientURL = strings.TrimSuffix(config.ClientURL, "/")
+
+	config.CORS = c.CORS
+	if config.CORS.AllowedOrigins == nil {
+		config.CORS.AllowedOrigins = []string{config.ClientURL}
+	}
+	if config.CORS.AllowedHeaders == nil {
+		config.CORS.AllowedHeaders = []string{"X-Requested-With", "Content-Type", "Authorization", "If-Match"}
+	}
+	if config.CORS.AllowedMethods == nil {
+		config.CORS.AllowedMethods = []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS", "HEAD"}
+	}
+
+	config.SigningKeys, err = getSigningKeys(configDir, c.SigningKeys)
+	if err != nil {
+		problems.add(fmt.Sprintf("signingKeys: %s", err.Error()))
+	}
+
+	config.SigningKey = c.SigningKey
+
+	problems.add(config.validate()...)
+	if len(problems) > 0 {
+		return nil, problems
 	}
 
 	return &config, nil
@@ -51,13 +165,166 @@ func GetDuration(envvar string, def1 string, def2 string) (time.Duration, error)
 
 	duration, err := time.ParseDuration(str)
 	if err != nil {
-		f.DumpError(err, "could not parse the AccessTokenExpiry: [%s]", str)
+		f.DumpError(err, "could not parse the duration [%s]: [%s]", envvar, str)
 		return 0, err
 	}
 
 	return duration, nil
 }
 
+// getSigningKeys reads the key material for each signing key
+func getSigningKeys(configDir string, keys []SigningKey) ([]*basic.SigningKey, error) {
+	f := functionGetSigningKeys
+
+	list := []*basic.SigningKey{}
+	for _, k := range keys {
+
+		var data []byte
+		if k.Secret != "" {
+			data = []byte(k.Secret)
+		} else if k.File != "" {
+			filename := k.File
+			if !filepath.IsAbs(filename) {
+				filename = filepath.Join(configDir, filename)
+			}
+			var err error
+			data, err = ioutil.ReadFile(filename)
+			if err != nil {
+				f.DumpError(err, "could not read the signing key [%s]", k.ID)
+				return nil, err
+			}
+		} else if k.Env != "" {
+			str, err := basic.GetEnvString(k.Env, "")
+			if err != nil {
+				f.DumpError(err, "could get the environment variable [%s]", k.Env)
+				return nil, err
+			}
+			data = []byte(str)
+		}
+
+		if len(data) == 0 {
+			err := fmt.Errorf("no key material for signing key [%s]", k.ID)
+			f.DumpError(err, err.Error())
+			return nil, err
+		}
+
+		var key *basic.SigningKey
+		var err error
+		if k.Algorithm == basic.AlgorithmHS256 {
+			key, err = basic.NewHMACKey(k.ID, data)
+		} else {
+			key, err = basic.ParsePrivateKey(k.ID, k.Algorithm, data)
+		}
+		if err != nil {
+			f.DumpError(err, "could not load the signing key [%s]", k.ID)
+			return nil, err
+		}
+
+		list = append(list, key)
+	}
+
+	return list, nil
+}
+
+// getMailer returns the mailer for the configured type. Without one, mail
+// is written to files in the 'mail' directory
+func getMailer(rootDir string, mail Mail) (mailer.Mailer, error) {
+	f := functionGetMailer
+
+	switch mail.Type {
+	case "smtp":
+		port := mail.Port
+		if port == 0 {
+			port = 587
+		}
+		return &mailer.SMTPMailer{Host: mail.Host, Port: port, Username: mail.UserName, Password: mail.Password, From: mail.From}, nil
+
+	case "file", "":
+		dir := mail.Dir
+		if dir == "" {
+			dir = "mail"
+		}
+		if !filepath.IsAbs(dir) {
+			dir = filepath.Join(rootDir, dir)
+		}
+		return &mailer.FileMailer{Dir: dir, From: mail.From}, nil
+	}
+
+	err := fmt.Errorf("unexpected mail type: [%s]", mail.Type)
+	f.DumpError(err, err.Error())
+	return nil, err
+}
+
+// getPasswordPolicy returns the policy for hashing passwords, starting from
+// the default
+func getPasswordPolicy(password Password) (basic.PasswordPolicy, error) {
+	f := functionGetPassword
+
+	policy := basic.DefaultPasswordPolicy
+
+	if password.Algorithm != "" {
+		policy.Algorithm = password.Algorithm
+	}
+
+	if password.BcryptCost != 0 {
+		policy.BcryptCost = password.BcryptCost
+	}
+	if password.Argon2Memory != 0 {
+		policy.Argon2Memory = password.Argon2Memory
+	}
+	if password.Argon2Time != 0 {
+		policy.Argon2Time = password.Argon2Time
+	}
+	if password.Argon2Threads != 0 {
+		policy.Argon2Threads = password.Argon2Threads
+	}
+
+	err := policy.Validate()
+	if err != nil {
+		f.DumpError(err, err.Error())
+		return policy, err
+	}
+
+	return policy, nil
+}
+
+// getDumpRetention returns which dumps are kept, starting from the default
+func getDumpRetention(dumps Dumps) (debug.Retention, error) {
+	f := functionGetRetention
+
+	retention := debug.DefaultRetention
+
+	var err error
+	if dumps.MaxAge != "" {
+		retention.MaxAge, err = time.ParseDuration(dumps.MaxAge)
+		if err != nil {
+			f.DumpError(err, "could not parse the maximum age of the dumps: [%s]", dumps.MaxAge)
+			return retention, err
+		}
+	}
+	if dumps.DedupWindow != "" {
+		retention.DedupWindow, err = time.ParseDuration(dumps.DedupWindow)
+		if err != nil {
+			f.DumpError(err, "could not parse the window for identical dumps: [%s]", dumps.DedupWindow)
+			return retention, err
+		}
+	}
+	if dumps.MaxCount != nil {
+		retention.MaxCount = *dumps.MaxCount
+	}
+	if dumps.MaxBytes != nil {
+		retention.MaxBytes = *dumps.MaxBytes
+	}
+
+	if retention.MaxAge < 0 || retention.DedupWindow < 0 || retention.MaxCount < 0 || retention.MaxBytes < 0 {
+		err = fmt.Errorf("unexpected negative dump limit: %+v", retention)
+		f.DumpError(err, err.Error())
+		return retention, err
+	}
+
+	return retention, nil
+}
+
 // DriverName returns the driver name for the configured database
 func (c *Config) DriverName() string {
 	return c.Database.DriverName
@@ -0,0 +1,160 @@
+package config
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Problems lists everything wrong with the configuration, so it can all be
+// put right at once
+type Problems []string
+
+func (p Problems) Error() string {
+	return "invalid configuration:\n    " + strings.Join(p, "\n    ")
+}
+
+func (p *Problems) add(problems ...string) {
+	*p = append(*p, problems...)
+}
+
+var corsMethods = map[string]bool{
+	http.MethodGet:     true,
+	http.MethodHead:    true,
+	http.MethodPost:    true,
+	http.MethodPut:     true,
+	http.MethodPatch:   true,
+	http.MethodDelete:  true,
+	http.MethodOptions: true,
+}
+
+// validate returns the problems with the settings
+func (c *Config) validate() []string {
+
+	var problems []string
+	problem := func(format string, a ...interface{}) {
+		problems = append(problems, fmt.Sprintf(format, a...))
+	}
+
+	if c.Database.DriverName == "" {
+		problem("database.driverName: missing")
+	}
+	if c.Database.Scheme == "" {
+		problem("database.scheme: missing")
+	}
+	if c.Database.Host == "" {
+		problem("database.host: missing")
+	}
+	if c.Database.DatabaseName == "" {
+		problem("database.databaseName: missing")
+	}
+	if c.Pool.MaxOpenConns < 0 {
+		problem("database.maxOpenConns: %d is negative", c.Pool.MaxOpenConns)
+	}
+	if c.Pool.MaxIdleConns < 0 {
+		problem("database.maxIdleConns: %d is negative", c.Pool.MaxIdleConns)
+	}
+	if c.Pool.MaxOpenConns > 0 && c.Pool.MaxIdleConns > c.Pool.MaxOpenConns {
+		problem("database.maxIdleConns: %d is more than the %d maxOpenConns", c.Pool.MaxIdleConns, c.Pool.MaxOpenConns)
+	}
+
+	if c.Server.Port < 1 || c.Server.Port > 65535 {
+		problem("server.port: %d is not a port", c.Server.Port)
+	}
+	if c.Server.MetricsPort < 0 || c.Server.MetricsPort > 65535 {
+		problem("server.metricsPort: %d is not a port", c.Server.MetricsPort)
+	}
+	if c.Server.MetricsPort != 0 && c.Server.MetricsPort == c.Server.Port {
+		problem("server.metricsPort: %d is also the server port", c.Server.MetricsPort)
+	}
+	if (c.Server.CertFile == "") != (c.Server.KeyFile == "") {
+		problem("server: needs both a certFile and a keyFile, or neither")
+	}
+
+	notNegative := map[string]time.Duration{
+		"server.readTimeout":       c.ReadTimeout,
+		"server.writeTimeout":      c.WriteTimeout,
+		"server.idleTimeout":       c.IdleTimeout,
+		"server.requestTimeout":    c.RequestTimeout,
+		"server.shutdownTimeout":   c.ShutdownTimeout,
+		"database.connMaxLifetime": c.Pool.ConnMaxLifetime,
+		"database.connMaxIdleTime": c.Pool.ConnMaxIdleTime,
+		"clientRefreshDelta":       c.ClientRefreshDelta,
+		"loginLockout":             c.LoginLockout,
+		"loginBackoff":             c.LoginBackoff,
+		"loginMaxBackoff":          c.LoginMaxBackoff,
+	}
+	for _, setting := range sortedSettings(notNegative) {
+		if notNegative[setting] < 0 {
+			problem("%s: %s is negative", setting, notNegative[setting])
+		}
+	}
+
+	positive := map[string]time.Duration{
+		"accessToken_expiry":  c.AccessTokenExpiry,
+		"refreshToken_expiry": c.RefreshTokenExpiry,
+		"checkInToken_expiry": c.CheckInTokenExpiry,
+		"verifyToken_expiry":  c.VerifyTokenExpiry,
+		"resetToken_expiry":   c.ResetTokenExpiry,
+	}
+	for _, setting := range sortedSettings(positive) {
+		if positive[setting] <= 0 {
+			problem("%s: %s is not positive", setting, positive[setting])
+		}
+	}
+
+	if c.LoginMaxFailures < 0 {
+		problem("loginMaxFailures: %d is negative", c.LoginMaxFailures)
+	}
+
+	if !isOrigin(c.ClientURL, true) {
+		problem("clientURL: '%s' is not an http or https URL", c.ClientURL)
+	}
+
+	if len(c.CORS.AllowedOrigins) == 0 {
+		problem("cors.allowedOrigins: empty")
+	}
+	for _, origin := range c.CORS.AllowedOrigins {
+
+		// Credentials are always allowed, so every origin must be named
+		if origin == "*" {
+			problem("cors.allowedOrigins: '*' cannot be used, as credentials are allowed")
+		} else if !isOrigin(origin, false) {
+			problem("cors.allowedOrigins: '%s' is not an origin, such as 'https://players.example.com'", origin)
+		}
+	}
+	for _, header := range c.CORS.AllowedHeaders {
+		if header == "" || strings.ContainsAny(header, " \t:,") {
+			problem("cors.allowedHeaders: '%s' is not a header name", header)
+		}
+	}
+	for _, method := range c.CORS.AllowedMethods {
+		if !corsMethods[method] {
+			problem("cors.allowedMethods: unexpected method '%s'", method)
+		}
+	}
+
+	return problems
+}
+
+// isOrigin reports whether the text is an http or https URL. Only a URL may
+// have a path
+func isOrigin(text string, path bool) bool {
+	u, err := url.Parse(text)
+	if err != nil || u.Host == "" || (u.Scheme != "http" && u.Scheme != "https") {
+		return false
+	}
+	return path || u.Path == "" && u.RawQuery == "" && u.Fragment == ""
+}
+
+func sortedSettings(settings map[string]time.Duration) []string {
+	names := make([]string, 0, len(settings))
+	for name := range settings {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -11,6 +11,7 @@ import (
 	"runtime/debug"
 	"strconv"
 	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/jackc/pgconn"
@@ -20,15 +21,16 @@ import (
 
 // Package type
 type Package struct {
-	name  string
-	level int
+	name      string
+	level     int32
+	functions map[string][]*Function
 }
 
 // Function type
 type Function struct {
 	pkg   *Package
 	name  string
-	level int
+	level int32
 }
 
 const (
@@ -57,10 +59,12 @@ const (
 
 )
 
+// The levels are set as the variables are initialised, rather than in init,
+// so they are ready for packages and functions declared in this package
 var (
-	level                int
-	defaultPackageLevel  int
-	defaultFunctionLevel int
+	level                = int32(getEnvLevel("DEBUG_LEVEL"))
+	defaultPackageLevel  = getEnvLevel("DEBUG_DEFAULT_PACKAGE_LEVEL")
+	defaultFunctionLevel = getEnvLevel("DEBUG_DEFAULT_FUNCTION_LEVEL")
 	rootDir              string
 	rootDumpDir          string
 	dumpFields           map[string]string
@@ -69,11 +73,12 @@ var (
 	dumpRepositoryURL    string
 )
 
-func init() {
-	level, _ = basic.GetEnvInteger("DEBUG_LEVEL", InfoLevel)
-	defaultPackageLevel, _ = basic.GetEnvInteger("DEBUG_DEFAULT_PACKAGE_LEVEL", InfoLevel)
-	defaultFunctionLevel, _ = basic.GetEnvInteger("DEBUG_DEFAULT_FUNCTION_LEVEL", InfoLevel)
+func getEnvLevel(name string) int {
+	l, _ := basic.GetEnvInteger(name, InfoLevel)
+	return l
+}
 
+func init() {
 	path, ok := os.LookupEnv("DEBUG_DUMP_DIR")
 	if !ok {
 		callinfo, ok := basic.GetCallInfo(1)
@@ -95,34 +100,35 @@ func RootDir() string {
 	return rootDir
 }
 
-// NewPackage function
+// NewPackage function. There is one package for each name
 func NewPackage(name string) *Package {
-	m := &Package{name: name, level: defaultPackageLevel}
+	m := &Package{name: name, level: int32(defaultPackageLevel), functions: make(map[string][]*Function)}
 
 	value, ok := os.LookupEnv("DEBUG_PACKAGE_LEVEL_" + name)
 	if ok {
 		number, err := strconv.Atoi(value)
 		if err == nil {
-			m.level = number
+			m.level = int32(number)
 		}
 	}
 
-	return m
+	return register(m)
 }
 
 // NewFunction function
 func NewFunction(pkg *Package, name string) *Function {
 
-	d := &Function{pkg: pkg, name: name, level: defaultFunctionLevel}
+	d := &Function{pkg: pkg, name: name, level: int32(defaultFunctionLevel)}
 
 	value, ok := os.LookupEnv("DEBUG_FUNCTION_LEVEL_" + pkg.name + "_" + name)
 	if ok {
 		number, err := strconv.Atoi(value)
 		if err == nil {
-			d.level = number
+			d.level = int32(number)
 		}
 	}
 
+	registerFunction(d)
 	return d
 }
 
@@ -195,42 +201,24 @@ func (f *Function) Fatalf(ctx context.Context, format string, a ...interface{})
 	os.Exit(1)
 }
 
-func timestamp() string {
-	return time.Now().Format("20060102 150405.0000000")
-}
-
-// Debug prints the function name
+// Debug prints a trace message, with the time and the function name
 func (f *Function) Debug(l int, format string, a ...interface{}) {
-	if l <= level {
-		if l <= f.pkg.level {
-			if l <= f.level {
-				line1 := fmt.Sprintf(format, a...)
-				line2 := fmt.Sprintf("%s %s.%s %s", timestamp(), f.pkg.name, f.name, line1)
-				fmt.Fprintln(os.Stderr, line2)
-			}
-		}
+	if l <= f.Level() {
+		f.write(true, l, nil, fmt.Sprintf(format, a...))
 	}
 }
 
 // Printf prints a debug message
 func (f *Function) Printf(l int, format string, a ...interface{}) {
-	if l <= level {
-		if l <= f.pkg.level {
-			if l <= f.level {
-				fmt.Printf(format, a...)
-			}
-		}
+	if l <= f.Level() {
+		f.write(false, l, nil, strings.TrimSuffix(fmt.Sprintf(format, a...), "\n"))
 	}
 }
 
 // Println prints a debug message
 func (f *Function) Println(l int, format string, a ...interface{}) {
-	if l <= level {
-		if l <= f.pkg.level {
-			if l <= f.level {
-				fmt.Println(fmt.Sprintf(format, a...))
-			}
-		}
+	if l <= f.Level() {
+		f.write(false, l, nil, fmt.Sprintf(format, a...))
 	}
 }
 
@@ -239,16 +227,16 @@ func (f *Function) Level() int {
 
 	effectiveLevel := maxInt
 
-	if level < effectiveLevel {
-		effectiveLevel = level
+	if l := int(atomic.LoadInt32(&level)); l < effectiveLevel {
+		effectiveLevel = l
 	}
 
-	if f.pkg.level < effectiveLevel {
-		effectiveLevel = f.pkg.level
+	if l := int(atomic.LoadInt32(&f.pkg.level)); l < effectiveLevel {
+		effectiveLevel = l
 	}
 
-	if f.level < effectiveLevel {
-		effectiveLevel = f.level
+	if l := int(atomic.LoadInt32(&f.level)); l < effectiveLevel {
+		effectiveLevel = l
 	}
 
 	return effectiveLevel
@@ -258,8 +246,13 @@ func (f *Function) Level() int {
 type Dump struct {
 	Directory string
 	Err       error
+	Repeated  bool // counted in an identical dump, so nothing more is added
 }
 
+// dumpTimeFormat names the dump directories, so they sort in the order they
+// were written
+const dumpTimeFormat = "2006-01-02_15-04-05.999999999"
+
 // DumpInfo type
 type DumpInfo struct {
 	GroupID       string `json:"groupidid"`
@@ -279,6 +272,8 @@ type DumpInfo struct {
 	GitBranch     string `json:"gitbranch"`
 	GitURL        string `json:"giturl"`
 	Message       string `json:"message"`
+	Count         int    `json:"count"`                   // the number of identical dumps
+	LastTimestamp string `json:"lasttimestamp,omitempty"` // the time of the last identical dump
 }
 
 // Dump function
@@ -287,10 +282,18 @@ func (f *Function) Dump(format string, a ...interface{}) *Dump {
 	message := fmt.Sprintf(format, a...)
 	f.DebugError(message)
 
+	t := time.Now()
+	key := dumpKey(f, message)
+
+	repeated := repeatDump(key, t)
+	if repeated != nil {
+		f.DebugError("Repeated dump:[%s]", repeated.Directory)
+		return repeated
+	}
+
 	dump := new(Dump)
 
-	t := time.Now()
-	now := t.Format("2006-01-02_15-04-05.999999999")
+	now := t.Format(dumpTimeFormat)
 	dump.Directory = filepath.Join(rootDumpDir, now)
 
 	f.DebugError("Writing dump:[%s]", dump.Directory)
@@ -318,6 +321,7 @@ func (f *Function) Dump(format string, a ...interface{}) *Dump {
 	info.Message = message
 	info.Package = f.pkg.name
 	info.Function = f.name
+	info.Count = 1
 
 	pc, fn, line, ok := runtime.Caller(1)
 	if ok {
@@ -352,6 +356,8 @@ func (f *Function) Dump(format string, a ...interface{}) *Dump {
 		return dump
 	}
 
+	rememberDump(key, dump.Directory, t)
+
 	return dump
 }
 
@@ -385,7 +391,7 @@ func (d *Dump) AddString(filename string, data string) {
 // AddByteArray method
 func (d *Dump) AddByteArray(filename string, data []byte) {
 
-	if d.Err != nil {
+	if d.Err != nil || d.Repeated {
 		return
 	}
 
@@ -490,6 +496,7 @@ func (d *Dump) Remove() error {
 		return err
 	}
 
+	forgetDump(d.Directory)
 	return nil
 }
 
@@ -557,7 +564,7 @@ func (f *Function) DumpError(err error, format string, a ...interface{}) *Dump {
 
 	if err3 != nil {
 		fmt.Println("could not marshal error: " + err3.Error())
-	} else {
+	} else if d.Err == nil && !d.Repeated {
 		filename := filepath.Join(d.Directory, "error.json")
 		err = ioutil.WriteFile(filename, data, 0644)
 		if err != nil {
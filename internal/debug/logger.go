@@ -0,0 +1,281 @@
+package debug
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Formats of the log records
+const (
+	// FormatText writes each record as a line of free text
+	FormatText = "text"
+
+	// FormatJSON writes each record as a JSON object on a line
+	FormatJSON = "json"
+
+	// FormatLogfmt writes each record as a line of key=value pairs
+	FormatLogfmt = "logfmt"
+)
+
+// Fields are the named values added to a log record, such as the request ID
+type Fields map[string]interface{}
+
+// Levels are the trace levels. Only the packages and functions whose level
+// differs from the default are listed, unless they are being set
+type Levels struct {
+	Format    string         `json:"format,omitempty"`
+	Level     *int           `json:"level,omitempty"`
+	Packages  map[string]int `json:"packages,omitempty"`
+	Functions map[string]int `json:"functions,omitempty"` // keyed by "package.function"
+}
+
+var (
+	logLock   sync.Mutex
+	logFormat           = FormatText
+	errOutput io.Writer = os.Stderr
+	stdOutput io.Writer = os.Stdout
+
+	registryLock sync.Mutex
+	packages     = make(map[string]*Package)
+)
+
+func init() {
+	format, ok := os.LookupEnv("DEBUG_FORMAT")
+	if ok {
+		SetFormat(format)
+	}
+}
+
+// SetFormat sets the format of the log records
+func SetFormat(format string) error {
+	switch format {
+	case FormatText, FormatJSON, FormatLogfmt:
+	default:
+		return fmt.Errorf("unexpected log format: '%s'", format)
+	}
+
+	logLock.Lock()
+	defer logLock.Unlock()
+	logFormat = format
+	return nil
+}
+
+// SetOutput sends the log records to the writer, rather than to stderr and
+// stdout. A nil writer sends them back to stderr and stdout
+func SetOutput(w io.Writer) {
+	logLock.Lock()
+	defer logLock.Unlock()
+	if w == nil {
+		errOutput = os.Stderr
+		stdOutput = os.Stdout
+		return
+	}
+	errOutput = w
+	stdOutput = w
+}
+
+// levelName returns the name of the trace level at or above the level
+func levelName(l int) string {
+	switch {
+	case l <= ErrorLevel:
+		return "error"
+	case l <= WarningLevel:
+		return "warning"
+	case l <= APILevel:
+		return "api"
+	case l <= InfoLevel:
+		return "info"
+	}
+	return "verbose"
+}
+
+// register records a package, so its level can be changed by name, and
+// returns the package first recorded with the name
+func register(p *Package) *Package {
+	registryLock.Lock()
+	defer registryLock.Unlock()
+	if existing, ok := packages[p.name]; ok {
+		return existing
+	}
+	packages[p.name] = p
+	return p
+}
+
+// registerFunction records a function, so its level can be changed by name
+func registerFunction(f *Function) {
+	registryLock.Lock()
+	defer registryLock.Unlock()
+	f.pkg.functions[f.name] = append(f.pkg.functions[f.name], f)
+}
+
+// GetLevels returns the format, the overall level, and the levels of the
+// packages and functions which differ from the default
+func GetLevels() Levels {
+	registryLock.Lock()
+	defer registryLock.Unlock()
+
+	logLock.Lock()
+	format := logFormat
+	logLock.Unlock()
+
+	overall := int(atomic.LoadInt32(&level))
+	levels := Levels{Format: format, Level: &overall, Packages: make(map[string]int), Functions: make(map[string]int)}
+
+	for name, p := range packages {
+		if l := int(atomic.LoadInt32(&p.level)); l != defaultPackageLevel {
+			levels.Packages[name] = l
+		}
+		for _, list := range p.functions {
+			for _, f := range list {
+				if l := int(atomic.LoadInt32(&f.level)); l != defaultFunctionLevel {
+					levels.Functions[name+"."+f.name] = l
+				}
+			}
+		}
+	}
+
+	return levels
+}
+
+// SetLevels changes the format, the overall level, and the levels of the
+// named packages and functions. Nothing is changed if any name is unknown
+func SetLevels(levels Levels) error {
+	registryLock.Lock()
+	defer registryLock.Unlock()
+
+	if levels.Format != "" {
+		switch levels.Format {
+		case FormatText, FormatJSON, FormatLogfmt:
+		default:
+			return fmt.Errorf("unexpected log format: '%s'", levels.Format)
+		}
+	}
+
+	for name := range levels.Packages {
+		if _, ok := packages[name]; !ok {
+			return fmt.Errorf("unknown package: '%s'", name)
+		}
+	}
+
+	var functions []*Function
+	var values []int
+	for name, l := range levels.Functions {
+		i := strings.LastIndex(name, ".")
+		if i < 0 {
+			return fmt.Errorf("unknown function: '%s'", name)
+		}
+		p, ok := packages[name[:i]]
+		if !ok || len(p.functions[name[i+1:]]) == 0 {
+			return fmt.Errorf("unknown function: '%s'", name)
+		}
+		for _, f := range p.functions[name[i+1:]] {
+			functions = append(functions, f)
+			values = append(values, l)
+		}
+	}
+
+	if levels.Format != "" {
+		logLock.Lock()
+		logFormat = levels.Format
+		logLock.Unlock()
+	}
+	if levels.Level != nil {
+		atomic.StoreInt32(&level, int32(*levels.Level))
+	}
+	for name, l := range levels.Packages {
+		atomic.StoreInt32(&packages[name].level, int32(l))
+	}
+	for i, f := range functions {
+		atomic.StoreInt32(&f.level, int32(values[i]))
+	}
+
+	return nil
+}
+
+// Log writes a record with the fields, if the level is enabled for the
+// function
+func (f *Function) Log(l int, fields Fields, format string, a ...interface{}) {
+	if l <= f.Level() {
+		f.write(true, l, fields, fmt.Sprintf(format, a...))
+	}
+}
+
+// write writes a record, to stderr for tracing or stdout for messages
+func (f *Function) write(trace bool, l int, fields Fields, message string) {
+
+	logLock.Lock()
+	defer logLock.Unlock()
+
+	w := stdOutput
+	if trace {
+		w = errOutput
+	}
+
+	now := time.Now()
+
+	switch logFormat {
+	case FormatJSON:
+		record := make(map[string]interface{}, len(fields)+5)
+		for key, value := range fields {
+			record[key] = value
+		}
+		record["time"] = now.Format(time.RFC3339Nano)
+		record["level"] = levelName(l)
+		record["package"] = f.pkg.name
+		record["function"] = f.name
+		record["message"] = message
+
+		data, err := json.Marshal(record)
+		if err != nil {
+			data, _ = json.Marshal(map[string]string{"message": message, "error": err.Error()})
+		}
+		fmt.Fprintln(w, string(data))
+
+	case FormatLogfmt:
+		var b strings.Builder
+		b.WriteString("time=" + now.Format(time.RFC3339Nano))
+		b.WriteString(" level=" + levelName(l))
+		b.WriteString(" package=" + logfmtValue(f.pkg.name))
+		b.WriteString(" function=" + logfmtValue(f.name))
+		b.WriteString(" msg=" + logfmtValue(message))
+		for _, key := range sortedKeys(fields) {
+			b.WriteString(" " + key + "=" + logfmtValue(fmt.Sprint(fields[key])))
+		}
+		fmt.Fprintln(w, b.String())
+
+	default:
+		line := message
+		if trace {
+			line = fmt.Sprintf("%s %s.%s %s", now.Format("20060102 150405.0000000"), f.pkg.name, f.name, message)
+		}
+		for _, key := range sortedKeys(fields) {
+			line = line + fmt.Sprintf(" %s=%v", key, fields[key])
+		}
+		fmt.Fprintln(w, line)
+	}
+}
+
+func sortedKeys(fields Fields) []string {
+	keys := make([]string, 0, len(fields))
+	for key := range fields {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// logfmtValue quotes a value which is empty, or holds spaces, quotes or '='
+func logfmtValue(value string) string {
+	if value == "" || strings.ContainsAny(value, " \t\r\n\"=\\") {
+		return strconv.Quote(value)
+	}
+	return value
+}
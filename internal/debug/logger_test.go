@@ -0,0 +1,115 @@
+package debug
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+var (
+	functionTestLog = NewFunction(pkg, "TestLog")
+)
+
+func TestLogFormats(t *testing.T) {
+	f := functionTestLog
+
+	var buffer bytes.Buffer
+	SetOutput(&buffer)
+	defer SetOutput(nil)
+	defer SetFormat(logFormat)
+
+	err := SetFormat(FormatJSON)
+	if err != nil {
+		t.Logf("Could not set the format: %v", err)
+		t.FailNow()
+	}
+
+	f.Log(ErrorLevel, Fields{"requestID": 7, "userID": 42}, "court %d is full", 3)
+
+	var record map[string]interface{}
+	err = json.Unmarshal(buffer.Bytes(), &record)
+	if err != nil {
+		t.Logf("Could not parse the record: %s: %v", buffer.String(), err)
+		t.FailNow()
+	}
+
+	expected := map[string]interface{}{
+		"level":     "error",
+		"package":   "debug",
+		"function":  "TestLog",
+		"message":   "court 3 is full",
+		"requestID": float64(7),
+		"userID":    float64(42),
+	}
+	for key, value := range expected {
+		if record[key] != value {
+			t.Logf("Unexpected %s. expected: %v, actual: %v", key, value, record[key])
+			t.FailNow()
+		}
+	}
+
+	buffer.Reset()
+	SetFormat(FormatLogfmt)
+
+	f.Log(ErrorLevel, Fields{"route": "/courts/{id}"}, "said \"hi\"")
+
+	line := buffer.String()
+	if !strings.Contains(line, ` level=error package=debug function=TestLog msg="said \"hi\"" route=/courts/{id}`) {
+		t.Logf("Unexpected record: %s", line)
+		t.FailNow()
+	}
+
+	err = SetFormat("junk")
+	if err == nil {
+		t.Log("Unexpected success setting an unknown format")
+		t.FailNow()
+	}
+}
+
+func TestLogLevels(t *testing.T) {
+	f := functionTestLog
+
+	var buffer bytes.Buffer
+	SetOutput(&buffer)
+	defer SetOutput(nil)
+
+	original := GetLevels()
+	defer SetLevels(Levels{Level: original.Level, Functions: map[string]int{"debug.TestLog": defaultFunctionLevel}})
+
+	err := SetLevels(Levels{Functions: map[string]int{"debug.TestLog": WarningLevel}})
+	if err != nil {
+		t.Logf("Could not set the levels: %v", err)
+		t.FailNow()
+	}
+
+	levels := GetLevels()
+	if levels.Functions["debug.TestLog"] != WarningLevel {
+		t.Logf("Unexpected levels: %v", levels.Functions)
+		t.FailNow()
+	}
+
+	f.Log(InfoLevel, nil, "hidden")
+	f.Log(WarningLevel, nil, "shown")
+	if strings.Contains(buffer.String(), "hidden") || !strings.Contains(buffer.String(), "shown") {
+		t.Logf("Unexpected output: %s", buffer.String())
+		t.FailNow()
+	}
+
+	// Nothing is changed when any name is unknown
+	err = SetLevels(Levels{Packages: map[string]int{"debug": ErrorLevel, "junk": ErrorLevel}})
+	if err == nil {
+		t.Log("Unexpected success setting the level of an unknown package")
+		t.FailNow()
+	}
+	if _, ok := GetLevels().Packages["debug"]; ok && defaultPackageLevel != ErrorLevel {
+		t.Log("Unexpected change to the level of a package")
+		t.FailNow()
+	}
+
+	err = SetLevels(Levels{Functions: map[string]int{"debug.junk": ErrorLevel}})
+	if err == nil {
+		t.Log("Unexpected success setting the level of an unknown function")
+		t.FailNow()
+	}
+}
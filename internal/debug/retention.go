@@ -0,0 +1,289 @@
+package debug
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rsmaxwell/players-api/internal/codeerror"
+)
+
+// Retention says which dumps are kept. The dumps older than the maximum age
+// are removed, then the oldest while there are more than the maximum count
+// or bytes. A zero limit means no limit. Identical dumps, from the same
+// function with the same message, within the window of the first are
+// counted in the first rather than written again
+type Retention struct {
+	MaxAge      time.Duration `json:"maxAge"`
+	MaxCount    int           `json:"maxCount"`
+	MaxBytes    int64         `json:"maxBytes"`
+	DedupWindow time.Duration `json:"dedupWindow"`
+}
+
+// DefaultRetention is the retention until it is set from the configuration
+var DefaultRetention = Retention{
+	MaxAge:      30 * 24 * time.Hour,
+	MaxCount:    1000,
+	MaxBytes:    100 * 1024 * 1024,
+	DedupWindow: time.Hour,
+}
+
+// pruneInterval is the least time between the prunes made as dumps are
+// written, so a burst of dumps does not list the directory each time
+const pruneInterval = 10 * time.Second
+
+// recentDump is the first of a run of identical dumps
+type recentDump struct {
+	directory string
+	first     time.Time
+	count     int
+}
+
+var (
+	functionPruneDumps = NewFunction(NewPackage("debug"), "PruneDumps")
+
+	retentionLock sync.Mutex
+	retention     = DefaultRetention
+	lastPrune     time.Time
+	recentDumps   = make(map[string]*recentDump)
+)
+
+// SetRetention sets which dumps are kept, and prunes the dumps at the next
+// one written
+func SetRetention(r Retention) {
+	retentionLock.Lock()
+	defer retentionLock.Unlock()
+	retention = r
+	lastPrune = time.Time{}
+}
+
+// GetRetention returns which dumps are kept
+func GetRetention() Retention {
+	retentionLock.Lock()
+	defer retentionLock.Unlock()
+	return retention
+}
+
+// dumpKey identifies identical dumps
+func dumpKey(f *Function, message string) string {
+	return f.pkg.name + "." + f.name + "\n" + message
+}
+
+// repeatDump counts a dump in the first of a run of identical dumps, and
+// returns it, or returns nil if the dump should be written
+func repeatDump(key string, t time.Time) *Dump {
+	retentionLock.Lock()
+	defer retentionLock.Unlock()
+
+	r, ok := recentDumps[key]
+	if !ok {
+		return nil
+	}
+	if retention.DedupWindow <= 0 || t.Sub(r.first) >= retention.DedupWindow {
+		delete(recentDumps, key)
+		return nil
+	}
+
+	dump := &Dump{Directory: r.directory, Repeated: true}
+	info, err := dump.GetInfo()
+	if err != nil {
+		delete(recentDumps, key)
+		return nil
+	}
+
+	r.count++
+	info.Count = r.count
+	info.LastTimestamp = t.Format(dumpTimeFormat)
+
+	data, err := json.MarshalIndent(info, "", "    ")
+	if err == nil {
+		ioutil.WriteFile(filepath.Join(r.directory, "dump.json"), data, 0644)
+	}
+
+	return dump
+}
+
+// rememberDump records a dump, so identical ones can be counted in it, and
+// prunes the dumps if they have not been pruned recently
+func rememberDump(key string, directory string, t time.Time) {
+	retentionLock.Lock()
+	if retention.DedupWindow > 0 {
+		recentDumps[key] = &recentDump{directory: directory, first: t, count: 1}
+	}
+	prune := t.Sub(lastPrune) >= pruneInterval
+	if prune {
+		lastPrune = t
+	}
+	retentionLock.Unlock()
+
+	if prune {
+		_, err := PruneDumps()
+		if err != nil {
+			functionPruneDumps.DebugError("could not prune the dumps: %s", err.Error())
+		}
+	}
+}
+
+// forgetDump stops counting identical dumps in a dump which is removed
+func forgetDump(directory string) {
+	retentionLock.Lock()
+	defer retentionLock.Unlock()
+	for key, r := range recentDumps {
+		if r.directory == directory {
+			delete(recentDumps, key)
+		}
+	}
+}
+
+// Name returns the name of the dump, which is the time it was written
+func (d *Dump) Name() string {
+	return filepath.Base(d.Directory)
+}
+
+// Size returns the number of bytes in the files of the dump
+func (d *Dump) Size() (int64, error) {
+	var size int64
+	err := filepath.Walk(d.Directory, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.IsDir() {
+			size += info.Size()
+		}
+		return nil
+	})
+	return size, err
+}
+
+// FindDump returns the dump with the name
+func FindDump(name string) (*Dump, error) {
+
+	if name == "" || name != filepath.Base(name) || strings.HasPrefix(name, ".") {
+		return nil, codeerror.NewBadRequest("unexpected dump name: " + name)
+	}
+
+	directory := filepath.Join(rootDumpDir, name)
+	info, err := os.Stat(directory)
+	if err != nil || !info.IsDir() {
+		return nil, codeerror.NewNotFound("dump not found: " + name)
+	}
+
+	return &Dump{Directory: directory}, nil
+}
+
+// WriteZip writes the files of the dump to a zip archive, in a directory
+// named after the dump
+func (d *Dump) WriteZip(w io.Writer) error {
+
+	archive := zip.NewWriter(w)
+
+	err := filepath.Walk(d.Directory, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			return nil
+		}
+
+		relative, err := filepath.Rel(d.Directory, path)
+		if err != nil {
+			return err
+		}
+
+		header, err := zip.FileInfoHeader(info)
+		if err != nil {
+			return err
+		}
+		header.Name = d.Name() + "/" + filepath.ToSlash(relative)
+		header.Method = zip.Deflate
+
+		out, err := archive.CreateHeader(header)
+		if err != nil {
+			return err
+		}
+
+		in, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer in.Close()
+
+		_, err = io.Copy(out, in)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	return archive.Close()
+}
+
+// PruneDumps removes the dumps which are not kept, oldest first, and
+// returns the number removed. The newest dump is always kept
+func PruneDumps() (int, error) {
+
+	r := GetRetention()
+
+	dumps, err := ListDumps()
+	if err != nil {
+		return 0, err
+	}
+
+	// The names are the times the dumps were written, so they sort oldest
+	// first
+	sort.Slice(dumps, func(i, j int) bool {
+		return dumps[i].Name() < dumps[j].Name()
+	})
+
+	var sizes []int64
+	var total int64
+	for _, dump := range dumps {
+		size, err := dump.Size()
+		if err != nil {
+			return 0, err
+		}
+		sizes = append(sizes, size)
+		total += size
+	}
+
+	now := time.Now()
+	removed := 0
+	for i, dump := range dumps {
+		if i == len(dumps)-1 {
+			break
+		}
+
+		remove := false
+		if r.MaxAge > 0 {
+			info, err := os.Stat(dump.Directory)
+			if err == nil && now.Sub(info.ModTime()) > r.MaxAge {
+				remove = true
+			}
+		}
+		if r.MaxCount > 0 && len(dumps)-removed > r.MaxCount {
+			remove = true
+		}
+		if r.MaxBytes > 0 && total > r.MaxBytes {
+			remove = true
+		}
+		if !remove {
+			break
+		}
+
+		err = dump.Remove()
+		if err != nil {
+			return removed, err
+		}
+		removed++
+		total -= sizes[i]
+	}
+
+	return removed, nil
+}
@@ -0,0 +1,151 @@
+package debug
+
+import (
+	"archive/zip"
+	"bytes"
+	"testing"
+	"time"
+)
+
+var (
+	functionTestRetention = NewFunction(pkg, "TestRetention")
+)
+
+// useDumpDir writes the dumps of a test to a directory of its own
+func useDumpDir(t *testing.T, r Retention) {
+	original := rootDumpDir
+	rootDumpDir = t.TempDir()
+	SetRetention(r)
+
+	t.Cleanup(func() {
+		rootDumpDir = original
+		SetRetention(DefaultRetention)
+	})
+}
+
+func TestRepeatedDumps(t *testing.T) {
+	f := functionTestRetention
+
+	useDumpDir(t, Retention{DedupWindow: time.Hour})
+
+	for i := 0; i < 3; i++ {
+		d := f.Dump("the same message")
+		d.AddString("extra.txt", "extra")
+	}
+	f.Dump("another message")
+
+	dumps, err := ListDumps()
+	if err != nil {
+		t.Logf("Could not list the dumps: %v", err)
+		t.FailNow()
+	}
+	if len(dumps) != 2 {
+		t.Logf("Unexpected number of dumps. expected: 2, actual: %d", len(dumps))
+		t.FailNow()
+	}
+
+	info, err := dumps[0].GetInfo()
+	if err != nil {
+		t.Logf("Could not read the dump: %v", err)
+		t.FailNow()
+	}
+	if info.Count != 3 || info.LastTimestamp == "" {
+		t.Logf("Unexpected count. expected: 3, actual: %d, last: %s", info.Count, info.LastTimestamp)
+		t.FailNow()
+	}
+
+	// A removed dump is written again
+	dumps[0].Remove()
+	d := f.Dump("the same message")
+	if d.Repeated {
+		t.Log("Unexpected repeat of a removed dump")
+		t.FailNow()
+	}
+}
+
+func TestPruneDumps(t *testing.T) {
+	f := functionTestRetention
+
+	useDumpDir(t, Retention{})
+
+	var names []string
+	for i := 0; i < 4; i++ {
+		d := f.Dump("dump %d", i)
+		names = append(names, d.Name())
+		time.Sleep(time.Millisecond)
+	}
+
+	SetRetention(Retention{MaxCount: 2})
+	removed, err := PruneDumps()
+	if err != nil || removed != 2 {
+		t.Logf("Unexpected prune. expected: 2, actual: %d, err: %v", removed, err)
+		t.FailNow()
+	}
+
+	dumps, _ := ListDumps()
+	if len(dumps) != 2 || dumps[0].Name() != names[2] || dumps[1].Name() != names[3] {
+		t.Logf("Unexpected dumps kept: %v", dumps)
+		t.FailNow()
+	}
+
+	// The newest dump is kept, even if it is too big
+	SetRetention(Retention{MaxBytes: 1})
+	removed, err = PruneDumps()
+	if err != nil || removed != 1 {
+		t.Logf("Unexpected prune. expected: 1, actual: %d, err: %v", removed, err)
+		t.FailNow()
+	}
+
+	dumps, _ = ListDumps()
+	if len(dumps) != 1 || dumps[0].Name() != names[3] {
+		t.Logf("Unexpected dumps kept: %v", dumps)
+		t.FailNow()
+	}
+}
+
+func TestDumpArchive(t *testing.T) {
+	f := functionTestRetention
+
+	useDumpDir(t, Retention{})
+
+	d := f.Dump("archived")
+	d.AddString("extra.txt", "extra")
+
+	for _, name := range []string{"", ".", "..", "../dump", "junk"} {
+		_, err := FindDump(name)
+		if err == nil {
+			t.Logf("Unexpected success finding the dump [%s]", name)
+			t.FailNow()
+		}
+	}
+
+	found, err := FindDump(d.Name())
+	if err != nil {
+		t.Logf("Could not find the dump: %v", err)
+		t.FailNow()
+	}
+
+	var buffer bytes.Buffer
+	err = found.WriteZip(&buffer)
+	if err != nil {
+		t.Logf("Could not archive the dump: %v", err)
+		t.FailNow()
+	}
+
+	archive, err := zip.NewReader(bytes.NewReader(buffer.Bytes()), int64(buffer.Len()))
+	if err != nil {
+		t.Logf("Could not read the archive: %v", err)
+		t.FailNow()
+	}
+
+	files := map[string]bool{}
+	for _, file := range archive.File {
+		files[file.Name] = true
+	}
+	for _, name := range []string{"dump.json", "callstack.txt", "extra.txt"} {
+		if !files[d.Name()+"/"+name] {
+			t.Logf("Missing %s from the archive: %v", name, files)
+			t.FailNow()
+		}
+	}
+}
@@ -0,0 +1,128 @@
+package httphandler
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/rsmaxwell/players-api/internal/config"
+	"github.com/rsmaxwell/players-api/internal/debug"
+	"github.com/rsmaxwell/players-api/internal/model"
+)
+
+// ApproveRegistrationRequest structure. The status defaults to inactive
+type ApproveRegistrationRequest struct {
+	Status string `json:"status"`
+}
+
+// RejectRegistrationRequest structure
+type RejectRegistrationRequest struct {
+	Reason string `json:"reason"`
+}
+
+var (
+	functionApproveRegistration = debug.NewFunction(pkg, "ApproveRegistration")
+	functionRejectRegistration  = debug.NewFunction(pkg, "RejectRegistration")
+	functionDecideRegistration  = debug.NewFunction(pkg, "decideRegistration")
+)
+
+// ApproveRegistration method lets a newly registered person join. They are
+// given the status once they have verified their email address
+func ApproveRegistration(writer http.ResponseWriter, request *http.Request) {
+	f := functionApproveRegistration
+
+	var approveRequest ApproveRegistrationRequest
+	decideRegistration(f, writer, request, &approveRequest, func(ctx context.Context, db *sql.DB, personID int, adminID int) (*model.FullPerson, *model.Approval, error) {
+		return model.ApproveRegistrationTx(ctx, db, personID, adminID, approveRequest.Status)
+	})
+}
+
+// RejectRegistration method turns down a newly registered person, who stays
+// suspended
+func RejectRegistration(writer http.ResponseWriter, request *http.Request) {
+	f := functionRejectRegistration
+
+	var rejectRequest RejectRegistrationRequest
+	decideRegistration(f, writer, request, &rejectRequest, func(ctx context.Context, db *sql.DB, personID int, adminID int) (*model.FullPerson, *model.Approval, error) {
+		return model.RejectRegistrationTx(ctx, db, personID, adminID, rejectRequest.Reason)
+	})
+}
+
+// decideRegistration reads the optional request body, records the decision
+// made by the signed in admin, and tells the applicant the outcome
+func decideRegistration(f *debug.Function, writer http.ResponseWriter, request *http.Request, body interface{}, decide func(ctx context.Context, db *sql.DB, personID int, adminID int) (*model.FullPerson, *model.Approval, error)) {
+
+	adminID, err := checkAuthenticated(request)
+	if err != nil {
+		writeResponseError(writer, request, err)
+		return
+	}
+
+	ctx, err := contextWithIfMatch(request)
+	if err != nil {
+		writeResponseError(writer, request, err)
+		return
+	}
+
+	str := mux.Vars(request)["id"]
+	personID, err := strconv.Atoi(str)
+	if err != nil {
+		writeResponseMessage(writer, request, http.StatusBadRequest, fmt.Sprintf("the key [%s] is not an int", str))
+		return
+	}
+
+	limitedReader := &io.LimitedReader{R: request.Body, N: 20 * 1024}
+	b, err := ioutil.ReadAll(limitedReader)
+	if err != nil {
+		writeResponseMessage(writer, request, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	DebugRequestBody(f, request, b)
+
+	if len(b) > 0 {
+		err = json.Unmarshal(b, body)
+		if err != nil {
+			writeResponseMessage(writer, request, http.StatusBadRequest, err.Error())
+			return
+		}
+	}
+
+	object := request.Context().Value(ContextDatabaseKey)
+	db, ok := object.(*sql.DB)
+	if !ok {
+		message := "unexpected context type"
+		Dump(f, request, message)
+		writeResponseMessage(writer, request, http.StatusInternalServerError, message)
+		return
+	}
+
+	object = request.Context().Value(ContextConfigKey)
+	cfg, ok := object.(*config.Config)
+	if !ok {
+		message := fmt.Sprintf("unexpected context type: %#v", cfg)
+		Dump(f, request, message)
+		writeResponseMessage(writer, request, http.StatusInternalServerError, message)
+		return
+	}
+
+	person, approval, err := decide(ctx, db, personID, adminID)
+	if err != nil {
+		writeResponseError(writer, request, err)
+		return
+	}
+
+	// The decision stands even if the applicant cannot be told
+	err = NotifyApplicant(request.Context(), cfg, person, approval)
+	if err != nil {
+		DebugError(f, request, "could not notify person [%d] of the %s registration: %s", personID, approval.Decision, err.Error())
+	}
+
+	writeResponseMessage(writer, request, http.StatusOK, "ok")
+}
@@ -0,0 +1,192 @@
+package httphandler
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/require"
+
+	"github.com/rsmaxwell/players-api/internal/config"
+	"github.com/rsmaxwell/players-api/internal/model"
+
+	_ "github.com/jackc/pgx/stdlib"
+)
+
+func TestApproveRegistration(t *testing.T) {
+
+	teardown, db, _ := model.Setup(t)
+	defer teardown(t)
+
+	cfg, mailer := NewTestConfig()
+
+	// ***************************************************************
+	// * Login as an admin
+	// ***************************************************************
+	logonCookie, accessToken := GetSigninToken(t, db, model.GoodEmail, model.GoodPassword)
+
+	// ***************************************************************
+	// * Register two new people
+	// ***************************************************************
+	applicants := []*model.FullPerson{}
+	for _, email := range []string{"007@mi6.co.uk", "008@mi6.co.uk"} {
+		registration := model.NewRegistration("James", "Bond", "bond", email, "012345 123456", "topsecret")
+		person, err := registration.ToPerson()
+		require.Nil(t, err, "err should be nothing")
+
+		err = person.RegisterPersonTx(db)
+		require.Nil(t, err, "err should be nothing")
+
+		applicants = append(applicants, person)
+	}
+
+	// ***************************************************************
+	// * Testcases
+	// ***************************************************************
+	tests := []struct {
+		testName       string
+		method         string
+		command        string
+		body           string
+		expectedStatus int
+		expectedMail   string
+	}{
+		{
+			testName:       "List pending registrations",
+			method:         http.MethodGet,
+			command:        "/registrations/pending",
+			expectedStatus: http.StatusOK,
+		},
+		{
+			testName:       "Approve with a bad status",
+			method:         http.MethodPut,
+			command:        fmt.Sprintf("/registrations/%d/approve", applicants[0].ID),
+			body:           `{"status": "admin"}`,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			testName:       "Approve",
+			method:         http.MethodPut,
+			command:        fmt.Sprintf("/registrations/%d/approve", applicants[0].ID),
+			body:           `{"status": "player"}`,
+			expectedStatus: http.StatusOK,
+			expectedMail:   applicants[0].Email,
+		},
+		{
+			testName:       "Already approved",
+			method:         http.MethodPut,
+			command:        fmt.Sprintf("/registrations/%d/reject", applicants[0].ID),
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			testName:       "Reject",
+			method:         http.MethodPut,
+			command:        fmt.Sprintf("/registrations/%d/reject", applicants[1].ID),
+			body:           `{"reason": "Not a member of the club"}`,
+			expectedStatus: http.StatusOK,
+			expectedMail:   applicants[1].Email,
+		},
+		{
+			testName:       "No registration",
+			method:         http.MethodPut,
+			command:        "/registrations/999999999/approve",
+			expectedStatus: http.StatusNotFound,
+		},
+	}
+
+	// ***************************************************************
+	// * Run the tests
+	// ***************************************************************
+	for _, test := range tests {
+		t.Run(test.testName, func(t *testing.T) {
+
+			numberOfMessages := len(mailer.Sent())
+
+			// Set up the handlers on the router
+			router := mux.NewRouter()
+			SetupHandlers(router)
+			w := httptest.NewRecorder()
+
+			r, err := http.NewRequest(test.method, contextPath+test.command, strings.NewReader(test.body))
+			require.Nil(t, err, "err should be nothing")
+
+			r.AddCookie(logonCookie)
+			r.Header.Set("Authorization", "Bearer "+accessToken)
+
+			// ---------------------------------------
+
+			ctx, cancel := context.WithTimeout(r.Context(), time.Duration(60*time.Second))
+			defer cancel()
+			r2 := r.WithContext(ctx)
+
+			ctx = context.WithValue(r2.Context(), ContextDatabaseKey, db)
+			ctx = context.WithValue(ctx, ContextConfigKey, cfg)
+			r3 := r.WithContext(ctx)
+
+			// ---------------------------------------
+
+			// Serve the request
+			router.ServeHTTP(w, r3)
+			require.Equal(t, test.expectedStatus, w.Code, fmt.Sprintf("handler returned wrong status code: got %v want %v", w.Code, test.expectedStatus))
+
+			// Check the applicant was told the outcome
+			sent := mailer.Sent()[numberOfMessages:]
+			if test.expectedMail == "" {
+				require.Equal(t, 0, len(sent), "Unexpected mail")
+			} else {
+				require.Equal(t, 1, len(sent), "Unexpected number of mails")
+				require.Equal(t, test.expectedMail, sent[0].To, "Unexpected recipient")
+			}
+		})
+	}
+
+	// ***************************************************************
+	// * Neither applicant is pending any more, and both stay suspended
+	// * until they verify their email address
+	// ***************************************************************
+	list := listPendingRegistrations(t, db, cfg, logonCookie, accessToken)
+	for _, registration := range list {
+		require.NotEqual(t, applicants[0].ID, registration.Person.ID, "approved registration still pending")
+		require.NotEqual(t, applicants[1].ID, registration.Person.ID, "rejected registration still pending")
+	}
+
+	for _, applicant := range applicants {
+		p := model.FullPerson{ID: applicant.ID}
+		err := p.LoadPerson(context.Background(), db)
+		require.Nil(t, err, "err should be nothing")
+		require.Equal(t, model.StatusSuspended, p.Status, "Unexpected status")
+	}
+}
+
+// listPendingRegistrations returns the registrations waiting for a decision
+func listPendingRegistrations(t *testing.T, db *sql.DB, cfg *config.Config, logonCookie *http.Cookie, accessToken string) []PendingRegistration {
+
+	router := mux.NewRouter()
+	SetupHandlers(router)
+	w := httptest.NewRecorder()
+
+	r, err := http.NewRequest(http.MethodGet, contextPath+"/registrations/pending", nil)
+	require.Nil(t, err, "err should be nothing")
+
+	r.AddCookie(logonCookie)
+	r.Header.Set("Authorization", "Bearer "+accessToken)
+
+	ctx := context.WithValue(r.Context(), ContextDatabaseKey, db)
+	ctx = context.WithValue(ctx, ContextConfigKey, cfg)
+
+	router.ServeHTTP(w, r.WithContext(ctx))
+	require.Equal(t, http.StatusOK, w.Code, "handler returned wrong status code")
+
+	var response ListPendingRegistrationsResponse
+	err = json.Unmarshal(w.Body.Bytes(), &response)
+	require.Nil(t, err, "err should be nothing")
+
+	return response.Registrations
+}
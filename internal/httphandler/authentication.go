@@ -7,11 +7,12 @@ import (
 	"fmt"
 	"io"
 	"io/ioutil"
+	"net"
 	"net/http"
+	"strconv"
 	"strings"
 	"time"
 
-	"github.com/gorilla/sessions"
 	"github.com/rsmaxwell/players-api/internal/basic"
 	"github.com/rsmaxwell/players-api/internal/codeerror"
 	"github.com/rsmaxwell/players-api/internal/config"
@@ -22,7 +23,17 @@ import (
 
 var (
 	functionSignin             = debug.NewFunction(pkg, "Signin")
+	functionSignout            = debug.NewFunction(pkg, "Signout")
 	functionCheckAuthenticated = debug.NewFunction(pkg, "checkAuthenticated")
+	functionCheckAccessToken   = debug.NewFunction(pkg, "checkAccessToken")
+)
+
+const (
+	// refreshCookieName is the name of the cookie holding the refresh token
+	refreshCookieName = "players-api"
+
+	// maxDeviceLength is the longest device name kept with a refresh token
+	maxDeviceLength = 255
 )
 
 // SigninRequest structure
@@ -72,31 +83,54 @@ func Signin(writer http.ResponseWriter, request *http.Request) {
 		return
 	}
 
+	object = request.Context().Value(ContextConfigKey)
+	cfg, ok := object.(*config.Config)
+	if !ok {
+		message := fmt.Sprintf("unexpected context type: %#v", cfg)
+		Dump(f, request, message)
+		writeResponseMessage(writer, request, http.StatusInternalServerError, message)
+		return
+	}
+
 	email := signinRequest.Signin.Username
+	address := remoteAddress(request)
+	policy := loginPolicy(cfg)
+
+	wait, locked := model.LoginAttempts.Check(policy, email, address)
+	if wait > 0 {
+		DebugVerbose(f, request, "signin for [%s] from [%s] throttled for %s, locked: %t", email, address, wait, locked)
+		writer.Header().Set("Retry-After", strconv.Itoa(int((wait+time.Second-1)/time.Second)))
+		if locked {
+			writeResponseError(writer, request, codeerror.NewTooManyRequests("Account locked"))
+		} else {
+			writeResponseError(writer, request, codeerror.NewTooManyRequests("Too many failed signins"))
+		}
+		return
+	}
 
 	p, err := model.FindPersonByEmail(context.Background(), db, email)
 	if err != nil {
+		signinFailed(f, request, policy, email, address)
 		writeResponseError(writer, request, codeerror.NewUnauthorized("Not Authenticated"))
 		return
 	}
 
 	err = p.Authenticate(db, signinRequest.Signin.Password)
 	if err != nil {
+		if codeErr, ok := err.(*codeerror.CodeError); ok && codeErr.Code() == http.StatusUnauthorized {
+			signinFailed(f, request, policy, email, address)
+		} else {
+			model.LoginAttempts.Release(email, address)
+		}
 		writeResponseError(writer, request, err)
 		return
 	}
 
+	model.LoginAttempts.Succeeded(email, address)
+
 	// *********************************************************************
 	// * Create the token pair
 	// *********************************************************************
-	object = request.Context().Value(ContextConfigKey)
-	cfg, ok := object.(*config.Config)
-	if !ok {
-		message := fmt.Sprintf("unexpected context type: %#v", cfg)
-		Dump(f, request, message)
-		writeResponseMessage(writer, request, http.StatusInternalServerError, message)
-		return
-	}
 
 	requestID := getRequestID(request)
 
@@ -108,7 +142,7 @@ func Signin(writer http.ResponseWriter, request *http.Request) {
 	}
 
 	f.DebugVerbose("refreshTokenExpiry: %10s     expires at: %s", cfg.RefreshTokenExpiry, time.Now().Add(cfg.RefreshTokenExpiry).Round(time.Second))
-	newRefreshToken, err := basic.GenerateToken(p.ID, requestID, cfg.RefreshTokenExpiry)
+	newRefreshToken, err := model.CreateRefreshToken(request.Context(), db, p.ID, requestDevice(request), cfg.RefreshTokenExpiry)
 	if err != nil {
 		writeResponseError(writer, request, err)
 		return
@@ -116,31 +150,7 @@ func Signin(writer http.ResponseWriter, request *http.Request) {
 
 	f.DebugVerbose("clientRefreshDelta: %10s", cfg.ClientRefreshDelta)
 
-	// *********************************************************************
-	// * Create a new session to contain the refresh token
-	// *********************************************************************
-	sess, err := store.Get(request, "players-api")
-	if err != nil {
-		writeResponseError(writer, request, err)
-		return
-	}
-
-	sess.Options = &sessions.Options{
-		Path:     "/",
-		MaxAge:   int(cfg.RefreshTokenExpiry / time.Second),
-		HttpOnly: true,
-		SameSite: http.SameSiteStrictMode,
-	}
-
-	sess.Values["userID"] = p.ID
-	sess.Values["refreshToken"] = newRefreshToken
-	sess.Values["expiresAt"] = time.Now().UTC().Add(cfg.RefreshTokenExpiry).Unix()
-
-	err = sess.Save(request, writer)
-	if err != nil {
-		writeResponseError(writer, request, err)
-		return
-	}
+	setRefreshCookie(writer, newRefreshToken, cfg.RefreshTokenExpiry)
 
 	// *********************************************************************
 	// * Write the response
@@ -154,14 +164,43 @@ func Signin(writer http.ResponseWriter, request *http.Request) {
 	})
 }
 
+// loginPolicy returns the configured protection against guessed passwords
+func loginPolicy(cfg *config.Config) model.LoginPolicy {
+	return model.LoginPolicy{
+		MaxFailures: cfg.LoginMaxFailures,
+		Lockout:     cfg.LoginLockout,
+		Backoff:     cfg.LoginBackoff,
+		MaxBackoff:  cfg.LoginMaxBackoff,
+	}
+}
+
+// signinFailed counts a failed signin against the email and the address
+func signinFailed(f *debug.Function, request *http.Request, policy model.LoginPolicy, email string, address string) {
+	locked := model.LoginAttempts.Failed(policy, email, address)
+	if locked {
+		DebugInfo(f, request, "account [%s] locked for %s after %d failed signins", email, policy.Lockout, policy.MaxFailures)
+	}
+}
+
+// remoteAddress returns the address the request came from, without the port
+func remoteAddress(request *http.Request) string {
+	host, _, err := net.SplitHostPort(request.RemoteAddr)
+	if err != nil {
+		return request.RemoteAddr
+	}
+	return host
+}
+
 func NewDuration(hours int, minutes int, seconds int) (time.Duration, int) {
 	duration := time.Duration(hours)*time.Hour + time.Duration(minutes)*time.Minute + time.Duration(seconds)*time.Second
 	durationInSeconds := hours*3600 + minutes*60 + seconds
 	return duration, durationInSeconds
 }
 
-// Signout method
+// Signout method revokes the refresh token held in the cookie, so it
+// cannot be used again, and removes the cookie
 func Signout(w http.ResponseWriter, req *http.Request) {
+	f := functionSignout
 
 	_, err := checkAuthenticated(req)
 	if err != nil {
@@ -169,9 +208,75 @@ func Signout(w http.ResponseWriter, req *http.Request) {
 		return
 	}
 
+	if token := getRefreshCookie(req); token != "" {
+
+		object := req.Context().Value(ContextDatabaseKey)
+		db, ok := object.(*sql.DB)
+		if !ok {
+			message := "unexpected context type"
+			Dump(f, req, message)
+			writeResponseMessage(w, req, http.StatusInternalServerError, message)
+			return
+		}
+
+		err = model.RevokeRefreshToken(req.Context(), db, token)
+		if err != nil {
+			writeResponseError(w, req, err)
+			return
+		}
+	}
+
+	clearRefreshCookie(w)
 	writeResponseMessage(w, req, http.StatusOK, "ok")
 }
 
+// setRefreshCookie gives the client its refresh token, in a cookie which
+// scripts cannot read
+func setRefreshCookie(writer http.ResponseWriter, token string, expiry time.Duration) {
+	http.SetCookie(writer, &http.Cookie{
+		Name:     refreshCookieName,
+		Value:    token,
+		Path:     "/",
+		MaxAge:   int(expiry / time.Second),
+		HttpOnly: true,
+		SameSite: http.SameSiteStrictMode,
+	})
+}
+
+// clearRefreshCookie tells the client to forget its refresh token
+func clearRefreshCookie(writer http.ResponseWriter) {
+	http.SetCookie(writer, &http.Cookie{
+		Name:     refreshCookieName,
+		Value:    "",
+		Path:     "/",
+		MaxAge:   -1,
+		HttpOnly: true,
+		SameSite: http.SameSiteStrictMode,
+	})
+}
+
+// getRefreshCookie returns the refresh token from the request, if any
+func getRefreshCookie(request *http.Request) string {
+	cookie, err := request.Cookie(refreshCookieName)
+	if err != nil {
+		return ""
+	}
+	return cookie.Value
+}
+
+// requestDevice names the device making the request. A person has a
+// separate refresh token on each of their devices
+func requestDevice(request *http.Request) string {
+	device := request.Header.Get("User-Agent")
+	if device == "" {
+		device = "unknown"
+	}
+	if len(device) > maxDeviceLength {
+		device = device[:maxDeviceLength]
+	}
+	return device
+}
+
 // checkAuthenticated method
 func checkAuthenticated(request *http.Request) (int, error) {
 	f := functionCheckAuthenticated
@@ -179,21 +284,30 @@ func checkAuthenticated(request *http.Request) (int, error) {
 	authorizationHeader := request.Header.Get("Authorization")
 	if authorizationHeader == "" {
 		DebugError(f, request, "missing Authorization header")
-		return 0, fmt.Errorf("not authorized")
+		return 0, codeerror.NewUnauthorized("not authorized")
 	}
 
-	splitToken := strings.Split(authorizationHeader, "Bearer ")
-	if splitToken == nil {
-		return 0, fmt.Errorf("not authorized")
+	if !strings.HasPrefix(authorizationHeader, "Bearer ") {
+		DebugError(f, request, "unexpected Authorization scheme")
+		return 0, codeerror.NewUnauthorized("not authorized")
 	}
 
-	tokenString := splitToken[1]
+	return checkAccessToken(request, strings.TrimPrefix(authorizationHeader, "Bearer "))
+}
+
+// checkAccessToken validates an access token, and returns the ID of the person
+func checkAccessToken(request *http.Request, tokenString string) (int, error) {
+	f := functionCheckAccessToken
 
 	claims, err := basic.ValidateToken(tokenString)
 	if err != nil {
 		return 0, err
 	}
 
+	if info := getRequestInfo(request); info != nil {
+		info.UserID = claims.ID
+	}
+
 	DebugVerbose(f, request, fmt.Sprintf("jwtClaims: user:%d, request:%d", claims.ID, claims.Request))
 
 	return claims.ID, nil
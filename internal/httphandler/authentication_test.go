@@ -24,6 +24,8 @@ func TestAuthenticate(t *testing.T) {
 	teardown, db, _ := model.Setup(t)
 	defer teardown(t)
 
+	cfg, _ := NewTestConfig()
+
 	tests := []struct {
 		testName       string
 		email          string
@@ -71,6 +73,7 @@ func TestAuthenticate(t *testing.T) {
 			r2 := r.WithContext(ctx)
 
 			ctx = context.WithValue(r2.Context(), ContextDatabaseKey, db)
+			ctx = context.WithValue(ctx, ContextConfigKey, cfg)
 			r3 := r.WithContext(ctx)
 
 			// ---------------------------------------
@@ -98,3 +101,89 @@ func TestAuthenticate(t *testing.T) {
 		})
 	}
 }
+
+func TestSigninLockout(t *testing.T) {
+
+	teardown, db, _ := model.Setup(t)
+	defer teardown(t)
+
+	cfg, _ := NewTestConfig()
+	cfg.LoginMaxFailures = 2
+	cfg.LoginLockout = time.Hour
+
+	model.LoginAttempts = model.NewLoginThrottle()
+	defer func() { model.LoginAttempts = model.NewLoginThrottle() }()
+
+	tests := []struct {
+		testName       string
+		password       string
+		unlock         bool
+		expectedStatus int
+	}{
+		{
+			testName:       "First bad password",
+			password:       "junk",
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			testName:       "Second bad password",
+			password:       "junk",
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			testName:       "Locked",
+			password:       model.AnotherPassword,
+			expectedStatus: http.StatusTooManyRequests,
+		},
+		{
+			testName:       "Unlocked",
+			password:       model.AnotherPassword,
+			unlock:         true,
+			expectedStatus: http.StatusOK,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.testName, func(t *testing.T) {
+
+			if test.unlock {
+				model.LoginAttempts.Unlock(model.AnotherEmail)
+			}
+
+			requestBody, err := json.Marshal(SigninRequest{
+				Signin: model.Signin{
+					Username: model.AnotherEmail,
+					Password: test.password,
+				},
+			})
+			require.Nil(t, err, "err should be nothing")
+
+			r, err := http.NewRequest("POST", contextPath+"/signin", bytes.NewBuffer(requestBody))
+			require.Nil(t, err, "err should be nothing")
+
+			r.RemoteAddr = "192.0.2.1:1234"
+			w := httptest.NewRecorder()
+
+			// ---------------------------------------
+
+			ctx, cancel := context.WithTimeout(r.Context(), time.Duration(60*time.Second))
+			defer cancel()
+			r2 := r.WithContext(ctx)
+
+			ctx = context.WithValue(r2.Context(), ContextDatabaseKey, db)
+			ctx = context.WithValue(ctx, ContextConfigKey, cfg)
+			r3 := r.WithContext(ctx)
+
+			// ---------------------------------------
+
+			router := mux.NewRouter()
+			SetupHandlers(router)
+			router.ServeHTTP(w, r3)
+
+			require.Equal(t, test.expectedStatus, w.Code, "handler returned wrong status code: got %v want %v", w.Code, test.expectedStatus)
+			if w.Code == http.StatusTooManyRequests {
+				require.NotEqual(t, "", w.Header().Get("Retry-After"), "Retry-After missing")
+			}
+		})
+	}
+}
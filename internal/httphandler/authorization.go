@@ -0,0 +1,228 @@
+package httphandler
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gorilla/mux"
+
+	"github.com/rsmaxwell/players-api/internal/debug"
+	"github.com/rsmaxwell/players-api/internal/model"
+)
+
+var (
+	functionAuthorize = debug.NewFunction(pkg, "Authorize")
+)
+
+// routePermissions maps each route, as its method and path template, to the
+// permission needed to use it. Every route must be listed: routes which are
+// missing are refused
+var routePermissions = map[string]model.Permission{
+	"POST " + contextPath + "/register": model.PermissionNone,
+	"POST " + contextPath + "/signin":   model.PermissionNone,
+	"GET " + contextPath + "/signout":   model.PermissionNone,
+	"POST " + contextPath + "/refresh":  model.PermissionNone,
+	"GET " + contextPath + "/jwks":      model.PermissionNone,
+	"POST " + contextPath + "/people":   model.PermissionNone,
+
+	"POST " + contextPath + "/verifyemail":    model.PermissionNone,
+	"POST " + contextPath + "/forgotpassword": model.PermissionNone,
+	"POST " + contextPath + "/resetpassword":  model.PermissionNone,
+
+	// The handler lists the clubs of the signed in person
+	"GET " + contextPath + "/clubs": model.PermissionNone,
+
+	"GET " + contextPath + "/people":            model.PermissionView,
+	"GET " + contextPath + "/people/{id}":       model.PermissionView,
+	"GET " + contextPath + "/people/{id}/stats": model.PermissionView,
+
+	"PUT " + contextPath + "/people/{id}": model.PermissionEditSelf,
+
+	"GET " + contextPath + clubPath + "/members":      model.PermissionView,
+	"PUT " + contextPath + clubPath + "/members/{id}": model.PermissionManageRoles,
+
+	"DELETE " + contextPath + "/people/{id}":      model.PermissionEditPeople,
+	"PUT " + contextPath + "/people/{id}/signout": model.PermissionEditPeople,
+	"PUT " + contextPath + "/people/{id}/unlock":  model.PermissionEditPeople,
+	"PUT " + contextPath + "/people/{id}/roles":   model.PermissionManageRoles,
+
+	"GET " + contextPath + "/registrations/pending":      model.PermissionApproveRegistrations,
+	"PUT " + contextPath + "/registrations/{id}/approve": model.PermissionApproveRegistrations,
+	"PUT " + contextPath + "/registrations/{id}/reject":  model.PermissionApproveRegistrations,
+
+	"GET " + contextPath + "/metrics": model.PermissionViewMetrics,
+
+	"GET " + contextPath + "/loglevels": model.PermissionManageLogging,
+	"PUT " + contextPath + "/loglevels": model.PermissionManageLogging,
+
+	"GET " + contextPath + "/dumps":           model.PermissionManageDumps,
+	"DELETE " + contextPath + "/dumps":        model.PermissionManageDumps,
+	"GET " + contextPath + "/dumps/{name}":    model.PermissionManageDumps,
+	"DELETE " + contextPath + "/dumps/{name}": model.PermissionManageDumps,
+
+	"POST " + contextPath + "/clubs": model.PermissionManageClubs,
+}
+
+// clubRoutePermissions maps each route which works on a club's courts, queue
+// or sessions to the permission needed in that club. Each route is served
+// under the club's path, and also without it for the default club
+var clubRoutePermissions = map[string]model.Permission{
+	"GET /waiters":          model.PermissionView,
+	"GET /events":           model.PermissionView,
+	"GET /games":            model.PermissionView,
+	"GET /leaderboard":      model.PermissionView,
+	"GET /sessions/current": model.PermissionView,
+	"GET /courts":           model.PermissionView,
+	"GET /courts/{id}":      model.PermissionView,
+
+	"PUT /checkin":  model.PermissionCheckIn,
+	"PUT /checkout": model.PermissionCheckIn,
+
+	"PUT /people/toplayer/{id1}":              model.PermissionManageQueue,
+	"PUT /people/toinactive/{id}":             model.PermissionManageQueue,
+	"PUT /people/toplaying/{id1}/{id2}/{id3}": model.PermissionManageQueue,
+	"PUT /people/towaiting/{id}":              model.PermissionManageQueue,
+	"PUT /courts/fill/{id}":                   model.PermissionManageQueue,
+	"PUT /courts/clear/{id}":                  model.PermissionManageQueue,
+
+	"POST /newcourt":      model.PermissionEditCourts,
+	"PUT /courts/{id}":    model.PermissionEditCourts,
+	"DELETE /courts/{id}": model.PermissionEditCourts,
+
+	"POST /sessions":             model.PermissionManageSessions,
+	"PUT /sessions/close/{id}":   model.PermissionManageSessions,
+	"GET /sessions/checkintoken": model.PermissionManageSessions,
+}
+
+// defaultClubRoutes are the club routes served without the club's path,
+// which work on the default club
+var defaultClubRoutes = map[string]bool{}
+
+// queryTokenRoutes may give the access token as the 'access_token' query
+// parameter, because EventSource cannot set an Authorization header
+var queryTokenRoutes = map[string]bool{
+	"GET " + contextPath + "/events":            true,
+	"GET " + contextPath + clubPath + "/events": true,
+}
+
+func init() {
+	for route, permission := range clubRoutePermissions {
+		method, path := splitRoute(route)
+
+		routePermissions[routeKey(method, contextPath+clubPath+path)] = permission
+
+		key := routeKey(method, contextPath+path)
+		routePermissions[key] = permission
+		defaultClubRoutes[key] = true
+	}
+}
+
+// splitRoute returns the method and path of a route
+func splitRoute(route string) (string, string) {
+	parts := strings.SplitN(route, " ", 2)
+	return parts[0], parts[1]
+}
+
+// routeKey returns the key of a route in routePermissions
+func routeKey(method string, template string) string {
+	return method + " " + template
+}
+
+// Authorize is middleware which checks the signed in person holds a role
+// granting the permission needed for the route. The routes of a club need
+// the permission in that club, and the club is passed on in the context
+func Authorize(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		f := functionAuthorize
+
+		template := ""
+		if route := mux.CurrentRoute(request); route != nil {
+			template, _ = route.GetPathTemplate()
+		}
+		key := routeKey(request.Method, template)
+
+		permission, ok := routePermissions[key]
+		if !ok {
+			DebugError(f, request, "no permission is defined for route: %s", key)
+			writeResponseMessage(writer, request, http.StatusForbidden, "Forbidden")
+			return
+		}
+
+		if permission == model.PermissionNone {
+			next.ServeHTTP(writer, request)
+			return
+		}
+
+		var userID int
+		var err error
+		if token := request.URL.Query().Get("access_token"); token != "" && queryTokenRoutes[key] {
+			userID, err = checkAccessToken(request, token)
+		} else {
+			userID, err = checkAuthenticated(request)
+		}
+		if err != nil {
+			DebugVerbose(f, request, "not authenticated: %s", err.Error())
+			writeResponseMessage(writer, request, http.StatusUnauthorized, "Not Authorized")
+			return
+		}
+
+		object := request.Context().Value(ContextDatabaseKey)
+		db, ok := object.(*sql.DB)
+		if !ok {
+			message := "unexpected context type"
+			Dump(f, request, message)
+			writeResponseMessage(writer, request, http.StatusInternalServerError, message)
+			return
+		}
+
+		str, scoped := mux.Vars(request)["club"]
+		if !scoped && !defaultClubRoutes[key] {
+			allowed, err := model.HasPermission(request.Context(), db, userID, permission)
+			if err != nil {
+				writeResponseError(writer, request, err)
+				return
+			}
+			if !allowed {
+				DebugVerbose(f, request, "person [%d] does not have the [%s] permission for route: %s", userID, permission, key)
+				writeResponseMessage(writer, request, http.StatusForbidden, "Forbidden")
+				return
+			}
+
+			next.ServeHTTP(writer, request)
+			return
+		}
+
+		club := model.Club{ID: model.DefaultClubID}
+		if scoped {
+			club.ID, err = strconv.Atoi(str)
+			if err != nil {
+				DebugVerbose(f, request, "unexpected club: %s", str)
+				writeResponseMessage(writer, request, http.StatusNotFound, "Not Found")
+				return
+			}
+		}
+
+		err = club.LoadClub(request.Context(), db)
+		if err != nil {
+			writeResponseError(writer, request, err)
+			return
+		}
+
+		allowed, err := model.HasClubPermission(request.Context(), db, club.ID, userID, permission)
+		if err != nil {
+			writeResponseError(writer, request, err)
+			return
+		}
+		if !allowed {
+			DebugVerbose(f, request, "person [%d] does not have the [%s] permission in club [%d] for route: %s", userID, permission, club.ID, key)
+			writeResponseMessage(writer, request, http.StatusForbidden, "Forbidden")
+			return
+		}
+
+		ctx := context.WithValue(request.Context(), ContextClubKey, club.ID)
+		next.ServeHTTP(writer, request.WithContext(ctx))
+	})
+}
@@ -0,0 +1,285 @@
+package httphandler
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/require"
+
+	"github.com/rsmaxwell/players-api/internal/model"
+
+	_ "github.com/jackc/pgx/stdlib"
+)
+
+func TestRoutePermissions(t *testing.T) {
+
+	router := mux.NewRouter()
+	SetupHandlers(router)
+
+	// Every route has a permission ...
+	found := make(map[string]bool)
+	err := router.Walk(func(route *mux.Route, router *mux.Router, ancestors []*mux.Route) error {
+		template, err := route.GetPathTemplate()
+		if err != nil {
+			return err
+		}
+		methods, err := route.GetMethods()
+		if err != nil {
+			return nil
+		}
+		for _, method := range methods {
+			key := routeKey(method, template)
+			found[key] = true
+			_, ok := routePermissions[key]
+			require.True(t, ok, fmt.Sprintf("no permission for route: %s", key))
+		}
+		return nil
+	})
+	require.Nil(t, err, "err should be nothing")
+
+	// ... and every permission has a route
+	for key := range routePermissions {
+		require.True(t, found[key], fmt.Sprintf("no route for permission: %s", key))
+	}
+}
+
+func TestAuthorization(t *testing.T) {
+
+	teardown, db, _ := model.Setup(t)
+	defer teardown(t)
+
+	// ***************************************************************
+	// * Login as a member
+	// ***************************************************************
+	logonCookie, accessToken := GetSigninToken(t, db, model.AnotherEmail, model.AnotherPassword)
+	goodCourt := GetFirstCourt(t, db)
+
+	ctx := context.Background()
+	goodPerson, err := model.FindPersonByEmail(ctx, db, model.GoodEmail)
+	require.Nil(t, err, "err should be nothing")
+
+	member, err := model.FindPersonByEmail(ctx, db, model.AnotherEmail)
+	require.Nil(t, err, "err should be nothing")
+
+	// ***************************************************************
+	// * Testcases
+	// ***************************************************************
+	tests := []struct {
+		testName               string
+		setAuthorizationHeader bool
+		authorization          string
+		method                 string
+		command                string
+		body                   string
+		expectedStatus         int
+	}{
+		{
+			testName:               "List courts",
+			setAuthorizationHeader: true,
+			method:                 http.MethodGet,
+			command:                "/courts",
+			expectedStatus:         http.StatusOK,
+		},
+		{
+			testName:               "No access token",
+			setAuthorizationHeader: false,
+			method:                 http.MethodGet,
+			command:                "/courts",
+			expectedStatus:         http.StatusUnauthorized,
+		},
+		{
+			testName:               "Fill court",
+			setAuthorizationHeader: true,
+			method:                 http.MethodPut,
+			command:                fmt.Sprintf("/courts/fill/%d", goodCourt.ID),
+			expectedStatus:         http.StatusForbidden,
+		},
+		{
+			testName:               "Create court",
+			setAuthorizationHeader: true,
+			method:                 http.MethodPost,
+			command:                "/newcourt",
+			body:                   `{"court": {"name": "Z"}}`,
+			expectedStatus:         http.StatusForbidden,
+		},
+		{
+			testName:               "Delete person",
+			setAuthorizationHeader: true,
+			method:                 http.MethodDelete,
+			command:                fmt.Sprintf("/people/%d", goodPerson.ID),
+			expectedStatus:         http.StatusForbidden,
+		},
+		{
+			testName:               "Update another person",
+			setAuthorizationHeader: true,
+			method:                 http.MethodPut,
+			command:                fmt.Sprintf("/people/%d", goodPerson.ID),
+			body:                   `{"person": {"knownas": "Hacker"}}`,
+			expectedStatus:         http.StatusForbidden,
+		},
+		{
+			testName:               "Update own status",
+			setAuthorizationHeader: true,
+			method:                 http.MethodPut,
+			command:                fmt.Sprintf("/people/%d", member.ID),
+			body:                   `{"person": {"status": "player"}}`,
+			expectedStatus:         http.StatusForbidden,
+		},
+		{
+			testName:               "Update own skill",
+			setAuthorizationHeader: true,
+			method:                 http.MethodPut,
+			command:                fmt.Sprintf("/people/%d", member.ID),
+			body:                   `{"person": {"skill": 9}}`,
+			expectedStatus:         http.StatusForbidden,
+		},
+		{
+			testName:               "Update own name",
+			setAuthorizationHeader: true,
+			method:                 http.MethodPut,
+			command:                fmt.Sprintf("/people/%d", member.ID),
+			body:                   fmt.Sprintf(`{"person": {"knownas": "%s"}}`, member.Knownas),
+			expectedStatus:         http.StatusOK,
+		},
+		{
+			testName:               "Update a person with a bad id",
+			setAuthorizationHeader: true,
+			method:                 http.MethodPut,
+			command:                "/people/junk",
+			body:                   `{"person": {"knownas": "Hacker"}}`,
+			expectedStatus:         http.StatusBadRequest,
+		},
+		{
+			testName:               "Basic authorization",
+			setAuthorizationHeader: true,
+			authorization:          "Basic eDp5",
+			method:                 http.MethodGet,
+			command:                "/courts",
+			expectedStatus:         http.StatusUnauthorized,
+		},
+		{
+			testName:               "Update roles",
+			setAuthorizationHeader: true,
+			method:                 http.MethodPut,
+			command:                fmt.Sprintf("/people/%d/roles", goodPerson.ID),
+			body:                   `{"roles": ["member"]}`,
+			expectedStatus:         http.StatusForbidden,
+		},
+		{
+			testName:               "Sign out another person",
+			setAuthorizationHeader: true,
+			method:                 http.MethodPut,
+			command:                fmt.Sprintf("/people/%d/signout", goodPerson.ID),
+			expectedStatus:         http.StatusForbidden,
+		},
+		{
+			testName:               "Unlock another person",
+			setAuthorizationHeader: true,
+			method:                 http.MethodPut,
+			command:                fmt.Sprintf("/people/%d/unlock", goodPerson.ID),
+			expectedStatus:         http.StatusForbidden,
+		},
+		{
+			testName:               "List pending registrations",
+			setAuthorizationHeader: true,
+			method:                 http.MethodGet,
+			command:                "/registrations/pending",
+			expectedStatus:         http.StatusForbidden,
+		},
+		{
+			testName:               "Get metrics",
+			setAuthorizationHeader: true,
+			method:                 http.MethodGet,
+			command:                "/metrics",
+			expectedStatus:         http.StatusForbidden,
+		},
+		{
+			testName:               "Change the log levels",
+			setAuthorizationHeader: true,
+			method:                 http.MethodPut,
+			command:                "/loglevels",
+			expectedStatus:         http.StatusForbidden,
+		},
+		{
+			testName:               "List the dumps",
+			setAuthorizationHeader: true,
+			method:                 http.MethodGet,
+			command:                "/dumps",
+			expectedStatus:         http.StatusForbidden,
+		},
+		{
+			testName:               "List courts at the default club",
+			setAuthorizationHeader: true,
+			method:                 http.MethodGet,
+			command:                fmt.Sprintf("/clubs/%d/courts", model.DefaultClubID),
+			expectedStatus:         http.StatusOK,
+		},
+		{
+			testName:               "Fill court at the default club",
+			setAuthorizationHeader: true,
+			method:                 http.MethodPut,
+			command:                fmt.Sprintf("/clubs/%d/courts/fill/%d", model.DefaultClubID, goodCourt.ID),
+			expectedStatus:         http.StatusForbidden,
+		},
+		{
+			testName:               "List courts at an unknown club",
+			setAuthorizationHeader: true,
+			method:                 http.MethodGet,
+			command:                "/clubs/9999/courts",
+			expectedStatus:         http.StatusNotFound,
+		},
+		{
+			testName:               "Create club",
+			setAuthorizationHeader: true,
+			method:                 http.MethodPost,
+			command:                "/clubs",
+			body:                   `{"club": {"name": "Riverside"}}`,
+			expectedStatus:         http.StatusForbidden,
+		},
+	}
+
+	// ***************************************************************
+	// * Run the tests
+	// ***************************************************************
+	for _, test := range tests {
+		t.Run(test.testName, func(t *testing.T) {
+
+			// Set up the handlers on the router
+			router := mux.NewRouter()
+			SetupHandlers(router)
+			w := httptest.NewRecorder()
+
+			r, err := http.NewRequest(test.method, contextPath+test.command, strings.NewReader(test.body))
+			require.Nil(t, err, "err should be nothing")
+
+			r.AddCookie(logonCookie)
+
+			if test.authorization != "" {
+				r.Header.Set("Authorization", test.authorization)
+			} else if test.setAuthorizationHeader {
+				r.Header.Set("Authorization", "Bearer "+accessToken)
+			}
+
+			// ---------------------------------------
+
+			ctx, cancel := context.WithTimeout(r.Context(), time.Duration(60*time.Second))
+			defer cancel()
+			r2 := r.WithContext(ctx)
+
+			ctx = context.WithValue(r2.Context(), ContextDatabaseKey, db)
+			r3 := r.WithContext(ctx)
+
+			// ---------------------------------------
+
+			// Serve the request
+			router.ServeHTTP(w, r3)
+			require.Equal(t, test.expectedStatus, w.Code, fmt.Sprintf("handler returned wrong status code: got %v want %v", w.Code, test.expectedStatus))
+		})
+	}
+}
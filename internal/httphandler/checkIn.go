@@ -0,0 +1,89 @@
+package httphandler
+
+import (
+	"database/sql"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/rsmaxwell/players-api/internal/basic"
+	"github.com/rsmaxwell/players-api/internal/codeerror"
+	"github.com/rsmaxwell/players-api/internal/debug"
+	"github.com/rsmaxwell/players-api/internal/model"
+)
+
+var (
+	functionCheckIn = debug.NewFunction(pkg, "CheckIn")
+)
+
+// CheckInRequest structure
+type CheckInRequest struct {
+	Token string `json:"token"`
+}
+
+// CheckIn method checks the signed in person in to the open session, which
+// puts them in the queue. The request carries the check-in token shown at
+// the door, so people can only check themselves in when they are there
+func CheckIn(writer http.ResponseWriter, request *http.Request) {
+	f := functionCheckIn
+
+	userID, err := checkAuthenticated(request)
+	if err != nil {
+		writeResponseError(writer, request, err)
+		return
+	}
+
+	ctx, err := contextWithIfMatch(request)
+	if err != nil {
+		writeResponseError(writer, request, err)
+		return
+	}
+
+	limitedReader := &io.LimitedReader{R: request.Body, N: 20 * 1024}
+	b, err := ioutil.ReadAll(limitedReader)
+	if err != nil {
+		writeResponseMessage(writer, request, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	DebugRequestBody(f, request, b)
+
+	var checkInRequest CheckInRequest
+	err = json.Unmarshal(b, &checkInRequest)
+	if err != nil {
+		writeResponseMessage(writer, request, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if checkInRequest.Token == "" {
+		writeResponseMessage(writer, request, http.StatusBadRequest, "missing check-in token")
+		return
+	}
+
+	claims, err := basic.ValidateTypedToken(checkInRequest.Token, basic.TokenTypeCheckIn)
+	if err != nil {
+		DebugVerbose(f, request, "check-in token not valid: %s", err.Error())
+		writeResponseError(writer, request, codeerror.NewUnauthorized("Unauthorized"))
+		return
+	}
+
+	DebugVerbose(f, request, "PersonID: %d, SessionID: %d", userID, claims.ID)
+
+	object := request.Context().Value(ContextDatabaseKey)
+	db, ok := object.(*sql.DB)
+	if !ok {
+		message := "unexpected context type"
+		Dump(f, request, message)
+		writeResponseMessage(writer, request, http.StatusInternalServerError, message)
+		return
+	}
+
+	err = model.CheckInTx(ctx, db, getClubID(request), claims.ID, userID)
+	if err != nil {
+		writeResponseError(writer, request, err)
+		return
+	}
+
+	writeResponseMessage(writer, request, http.StatusOK, "ok")
+}
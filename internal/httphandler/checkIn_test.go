@@ -0,0 +1,136 @@
+package httphandler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/rsmaxwell/players-api/internal/basic"
+	"github.com/rsmaxwell/players-api/internal/model"
+	"github.com/stretchr/testify/require"
+
+	_ "github.com/jackc/pgx/stdlib"
+)
+
+func TestCheckIn(t *testing.T) {
+
+	teardown, db, _ := model.Setup(t)
+	defer teardown(t)
+
+	ctx := context.Background()
+
+	// ***************************************************************
+	// * Login
+	// ***************************************************************
+	logonCookie, accessToken := GetSigninToken(t, db, model.GoodEmail, model.GoodPassword)
+
+	session, err := model.FindOpenSession(ctx, db, model.DefaultClubID)
+	require.Nil(t, err, "err should be nothing")
+	require.NotNil(t, session, "a session should be open")
+
+	goodToken, err := basic.GenerateTypedToken(session.ID, basic.TokenTypeCheckIn, time.Minute)
+	require.Nil(t, err, "err should be nothing")
+
+	oldToken, err := basic.GenerateTypedToken(session.ID-1, basic.TokenTypeCheckIn, time.Minute)
+	require.Nil(t, err, "err should be nothing")
+
+	// ***************************************************************
+	// * Testcases
+	// ***************************************************************
+	tests := []struct {
+		testName               string
+		setLogonCookie         bool
+		logonCookie            *http.Cookie
+		setAuthorizationHeader bool
+		accessToken            string
+		checkInToken           string
+		expectedStatus         int
+	}{
+		{
+			testName:               "Good request",
+			setLogonCookie:         true,
+			logonCookie:            logonCookie,
+			setAuthorizationHeader: true,
+			accessToken:            accessToken,
+			checkInToken:           goodToken,
+			expectedStatus:         http.StatusOK,
+		},
+		{
+			testName:               "Missing token",
+			setLogonCookie:         true,
+			logonCookie:            logonCookie,
+			setAuthorizationHeader: true,
+			accessToken:            accessToken,
+			checkInToken:           "",
+			expectedStatus:         http.StatusBadRequest,
+		},
+		{
+			testName:               "Access token",
+			setLogonCookie:         true,
+			logonCookie:            logonCookie,
+			setAuthorizationHeader: true,
+			accessToken:            accessToken,
+			checkInToken:           accessToken,
+			expectedStatus:         http.StatusUnauthorized,
+		},
+		{
+			testName:               "Token for another session",
+			setLogonCookie:         true,
+			logonCookie:            logonCookie,
+			setAuthorizationHeader: true,
+			accessToken:            accessToken,
+			checkInToken:           oldToken,
+			expectedStatus:         http.StatusBadRequest,
+		},
+	}
+
+	// ***************************************************************
+	// * Run the tests
+	// ***************************************************************
+	for _, test := range tests {
+		t.Run(test.testName, func(t *testing.T) {
+
+			// Set up the handlers on the router
+			router := mux.NewRouter()
+			SetupHandlers(router)
+			w := httptest.NewRecorder()
+
+			requestBody, err := json.Marshal(CheckInRequest{Token: test.checkInToken})
+			require.Nil(t, err, "err should be nothing")
+
+			// Create a request
+			command := "/checkin"
+			r, err := http.NewRequest("PUT", contextPath+command, bytes.NewBuffer(requestBody))
+			require.Nil(t, err, "err should be nothing")
+
+			if test.setLogonCookie {
+				r.AddCookie(test.logonCookie)
+			}
+
+			if test.setAuthorizationHeader {
+				r.Header.Set("Authorization", "Bearer "+test.accessToken)
+			}
+
+			// ---------------------------------------
+
+			ctx, cancel := context.WithTimeout(r.Context(), time.Duration(60*time.Second))
+			defer cancel()
+			r2 := r.WithContext(ctx)
+
+			ctx = context.WithValue(r2.Context(), ContextDatabaseKey, db)
+			r3 := r.WithContext(ctx)
+
+			// ---------------------------------------
+
+			// Serve the request
+			router.ServeHTTP(w, r3)
+			require.Equal(t, test.expectedStatus, w.Code, fmt.Sprintf("handler returned wrong status code: got %v want %v", w.Code, test.expectedStatus))
+		})
+	}
+}
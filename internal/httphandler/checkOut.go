@@ -0,0 +1,50 @@
+package httphandler
+
+import (
+	"database/sql"
+	"net/http"
+
+	"github.com/rsmaxwell/players-api/internal/debug"
+	"github.com/rsmaxwell/players-api/internal/model"
+)
+
+var (
+	functionCheckOut = debug.NewFunction(pkg, "CheckOut")
+)
+
+// CheckOut method takes the signed in person off the courts and out of the
+// queue, as they leave
+func CheckOut(writer http.ResponseWriter, request *http.Request) {
+	f := functionCheckOut
+
+	userID, err := checkAuthenticated(request)
+	if err != nil {
+		writeResponseError(writer, request, err)
+		return
+	}
+
+	ctx, err := contextWithIfMatch(request)
+	if err != nil {
+		writeResponseError(writer, request, err)
+		return
+	}
+
+	DebugVerbose(f, request, "PersonID: %d", userID)
+
+	object := request.Context().Value(ContextDatabaseKey)
+	db, ok := object.(*sql.DB)
+	if !ok {
+		message := "unexpected context type"
+		Dump(f, request, message)
+		writeResponseMessage(writer, request, http.StatusInternalServerError, message)
+		return
+	}
+
+	err = model.MakePersonInactiveTx(ctx, db, getClubID(request), userID)
+	if err != nil {
+		writeResponseError(writer, request, err)
+		return
+	}
+
+	writeResponseMessage(writer, request, http.StatusOK, "ok")
+}
@@ -26,6 +26,12 @@ func ClearCourt(writer http.ResponseWriter, request *http.Request) {
 		return
 	}
 
+	ctx, err := contextWithIfMatch(request)
+	if err != nil {
+		writeResponseError(writer, request, err)
+		return
+	}
+
 	str := mux.Vars(request)["id"]
 	courtID, err := strconv.Atoi(str)
 	if err != nil {
@@ -43,7 +49,7 @@ func ClearCourt(writer http.ResponseWriter, request *http.Request) {
 		return
 	}
 
-	err = model.ClearCourtTx(db, courtID)
+	err = model.ClearCourtTx(ctx, db, getClubID(request), courtID)
 	if err != nil {
 		writeResponseError(writer, request, err)
 		return
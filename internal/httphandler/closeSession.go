@@ -0,0 +1,60 @@
+package httphandler
+
+import (
+	"database/sql"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/rsmaxwell/players-api/internal/debug"
+	"github.com/rsmaxwell/players-api/internal/model"
+)
+
+var (
+	functionCloseSession = debug.NewFunction(pkg, "CloseSession")
+)
+
+// CloseSession method closes a session, clearing all the courts and
+// emptying the queue
+func CloseSession(writer http.ResponseWriter, request *http.Request) {
+	f := functionCloseSession
+
+	_, err := checkAuthenticated(request)
+	if err != nil {
+		writeResponseError(writer, request, err)
+		return
+	}
+
+	ctx, err := contextWithIfMatch(request)
+	if err != nil {
+		writeResponseError(writer, request, err)
+		return
+	}
+
+	str := mux.Vars(request)["id"]
+	sessionID, err := strconv.Atoi(str)
+	if err != nil {
+		writeResponseMessage(writer, request, http.StatusBadRequest, fmt.Sprintf("the key [%s] is not an int", str))
+		return
+	}
+
+	DebugVerbose(f, request, "SessionID: %d", sessionID)
+
+	object := request.Context().Value(ContextDatabaseKey)
+	db, ok := object.(*sql.DB)
+	if !ok {
+		message := "unexpected context type"
+		Dump(f, request, message)
+		writeResponseMessage(writer, request, http.StatusInternalServerError, message)
+		return
+	}
+
+	err = model.CloseSessionTx(ctx, db, getClubID(request), sessionID)
+	if err != nil {
+		writeResponseError(writer, request, err)
+		return
+	}
+
+	writeResponseMessage(writer, request, http.StatusOK, "ok")
+}
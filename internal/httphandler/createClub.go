@@ -0,0 +1,66 @@
+package httphandler
+
+import (
+	"database/sql"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/rsmaxwell/players-api/internal/debug"
+	"github.com/rsmaxwell/players-api/internal/model"
+)
+
+// CreateClubRequest structure
+type CreateClubRequest struct {
+	Club model.Club `json:"club"`
+}
+
+var (
+	functionCreateClub = debug.NewFunction(pkg, "CreateClub")
+)
+
+// CreateClub method
+func CreateClub(writer http.ResponseWriter, request *http.Request) {
+	f := functionCreateClub
+
+	_, err := checkAuthenticated(request)
+	if err != nil {
+		writeResponseError(writer, request, err)
+		return
+	}
+
+	limitedReader := &io.LimitedReader{R: request.Body, N: 20 * 1024}
+	b, err := ioutil.ReadAll(limitedReader)
+	if err != nil {
+		writeResponseMessage(writer, request, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	DebugRequestBody(f, request, b)
+
+	var createClubRequest CreateClubRequest
+	err = json.Unmarshal(b, &createClubRequest)
+	if err != nil {
+		writeResponseMessage(writer, request, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	object := request.Context().Value(ContextDatabaseKey)
+	db, ok := object.(*sql.DB)
+	if !ok {
+		message := "unexpected context type"
+		Dump(f, request, message)
+		writeResponseMessage(writer, request, http.StatusInternalServerError, message)
+		return
+	}
+
+	c := model.Club{Name: createClubRequest.Club.Name}
+	err = c.SaveClubTx(request.Context(), db)
+	if err != nil {
+		writeResponseError(writer, request, err)
+		return
+	}
+
+	writeResponseObject(writer, request, http.StatusOK, c)
+}
@@ -1,7 +1,6 @@
 package httphandler
 
 import (
-	"context"
 	"database/sql"
 	"encoding/json"
 	"io"
@@ -31,6 +30,12 @@ func CreateCourt(writer http.ResponseWriter, request *http.Request) {
 		return
 	}
 
+	ctx, err := contextWithIfMatch(request)
+	if err != nil {
+		writeResponseError(writer, request, err)
+		return
+	}
+
 	limitedReader := &io.LimitedReader{R: request.Body, N: 20 * 1024}
 	b, err := ioutil.ReadAll(limitedReader)
 	if err != nil {
@@ -56,8 +61,13 @@ func CreateCourt(writer http.ResponseWriter, request *http.Request) {
 		return
 	}
 
-	c := model.Court{Name: createCourtRequest.Court.Name}
-	err = c.SaveCourt(context.Background(), db)
+	c := model.Court{
+		Club:     getClubID(request),
+		Name:     createCourtRequest.Court.Name,
+		Capacity: createCourtRequest.Court.Capacity,
+		Strategy: createCourtRequest.Court.Strategy,
+	}
+	err = c.SaveCourtTx(ctx, db)
 	if err != nil {
 		writeResponseError(writer, request, err)
 		return
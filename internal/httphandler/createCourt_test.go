@@ -38,6 +38,7 @@ func TestCreateCourt(t *testing.T) {
 		logonCookie            *http.Cookie
 		setAuthorizationHeader bool
 		accessToken            string
+		capacity               int
 		players                []string
 		expectedStatus         int
 	}{
@@ -51,6 +52,28 @@ func TestCreateCourt(t *testing.T) {
 			players:                []string{},
 			expectedStatus:         http.StatusOK,
 		},
+		{
+			testName:               "Singles court",
+			name:                   "Court 2",
+			setLogonCookie:         true,
+			logonCookie:            logonCookie,
+			setAuthorizationHeader: true,
+			accessToken:            accessToken,
+			capacity:               2,
+			players:                []string{},
+			expectedStatus:         http.StatusOK,
+		},
+		{
+			testName:               "Bad capacity",
+			name:                   "Court 3",
+			setLogonCookie:         true,
+			logonCookie:            logonCookie,
+			setAuthorizationHeader: true,
+			accessToken:            accessToken,
+			capacity:               -1,
+			players:                []string{},
+			expectedStatus:         http.StatusBadRequest,
+		},
 	}
 
 	// ***************************************************************
@@ -59,13 +82,14 @@ func TestCreateCourt(t *testing.T) {
 	for _, test := range tests {
 		t.Run(test.testName, func(t *testing.T) {
 
-			listOfCourts, err := model.ListCourtsTx(db)
+			listOfCourts, err := model.ListCourtsTx(db, model.DefaultClubID)
 			require.Nil(t, err, "err should be nothing")
 			initialNumberOfCourts := len(listOfCourts)
 
 			requestBody, err := json.Marshal(CreateCourtRequest{
 				Court: model.Court{
-					Name: test.name,
+					Name:     test.name,
+					Capacity: test.capacity,
 				},
 			})
 			require.Nil(t, err, "err should be nothing")
@@ -76,7 +100,7 @@ func TestCreateCourt(t *testing.T) {
 			w := httptest.NewRecorder()
 
 			// Create a request
-			r, err := http.NewRequest("POST", contextPath+"/courts", bytes.NewBuffer(requestBody))
+			r, err := http.NewRequest("POST", contextPath+"/newcourt", bytes.NewBuffer(requestBody))
 			require.Nil(t, err, "err should be nothing")
 
 			if test.setLogonCookie {
@@ -103,7 +127,7 @@ func TestCreateCourt(t *testing.T) {
 			require.Equal(t, test.expectedStatus, w.Code, fmt.Sprintf("handler returned wrong status code: got %v want %v", w.Code, test.expectedStatus))
 
 			// Check the response
-			listOfCourts, err = model.ListCourtsTx(db)
+			listOfCourts, err = model.ListCourtsTx(db, model.DefaultClubID)
 			require.Nil(t, err, "err should be nothing")
 			finalNumberOfCourts := len(listOfCourts)
 
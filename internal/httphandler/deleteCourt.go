@@ -25,6 +25,12 @@ func DeleteCourt(writer http.ResponseWriter, request *http.Request) {
 		return
 	}
 
+	ctx, err := contextWithIfMatch(request)
+	if err != nil {
+		writeResponseError(writer, request, err)
+		return
+	}
+
 	str := mux.Vars(request)["id"]
 	id, err := strconv.Atoi(str)
 	if err != nil {
@@ -43,8 +49,8 @@ func DeleteCourt(writer http.ResponseWriter, request *http.Request) {
 		return
 	}
 
-	c := model.Court{ID: id}
-	err = c.DeleteCourtTx(db)
+	c := model.Court{ID: id, Club: getClubID(request)}
+	err = c.DeleteCourtTx(ctx, db)
 	if err != nil {
 		writeResponseError(writer, request, err)
 		return
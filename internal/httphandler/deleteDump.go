@@ -0,0 +1,42 @@
+package httphandler
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/rsmaxwell/players-api/internal/debug"
+)
+
+var (
+	functionDeleteDump = debug.NewFunction(pkg, "DeleteDump")
+)
+
+// DeleteDump method
+func DeleteDump(writer http.ResponseWriter, request *http.Request) {
+	f := functionDeleteDump
+
+	_, err := checkAuthenticated(request)
+	if err != nil {
+		writeResponseError(writer, request, err)
+		return
+	}
+
+	name := mux.Vars(request)["name"]
+	DebugVerbose(f, request, "name: %s", name)
+
+	dump, err := debug.FindDump(name)
+	if err != nil {
+		writeResponseError(writer, request, err)
+		return
+	}
+
+	err = dump.Remove()
+	if err != nil {
+		message := "could not remove the dump"
+		DumpError(f, request, err, message)
+		writeResponseMessage(writer, request, http.StatusInternalServerError, message)
+		return
+	}
+
+	writeResponseMessage(writer, request, http.StatusOK, "ok")
+}
@@ -0,0 +1,32 @@
+package httphandler
+
+import (
+	"net/http"
+
+	"github.com/rsmaxwell/players-api/internal/debug"
+)
+
+var (
+	functionDeleteDumps = debug.NewFunction(pkg, "DeleteDumps")
+)
+
+// DeleteDumps method removes every dump
+func DeleteDumps(writer http.ResponseWriter, request *http.Request) {
+	f := functionDeleteDumps
+
+	_, err := checkAuthenticated(request)
+	if err != nil {
+		writeResponseError(writer, request, err)
+		return
+	}
+
+	err = debug.ClearDumps()
+	if err != nil {
+		message := "could not remove the dumps"
+		DumpError(f, request, err, message)
+		writeResponseMessage(writer, request, http.StatusInternalServerError, message)
+		return
+	}
+
+	writeResponseMessage(writer, request, http.StatusOK, "ok")
+}
@@ -25,6 +25,12 @@ func DeletePerson(writer http.ResponseWriter, request *http.Request) {
 		return
 	}
 
+	ctx, err := contextWithIfMatch(request)
+	if err != nil {
+		writeResponseError(writer, request, err)
+		return
+	}
+
 	str := mux.Vars(request)["id"]
 	id, err := strconv.Atoi(str)
 	if err != nil {
@@ -51,7 +57,7 @@ func DeletePerson(writer http.ResponseWriter, request *http.Request) {
 	}
 
 	p := model.FullPerson{ID: id}
-	err = p.DeletePersonTx(db)
+	err = p.DeletePersonTx(ctx, db)
 	if err != nil {
 		writeResponseError(writer, request, err)
 		return
@@ -0,0 +1,186 @@
+package httphandler
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/rsmaxwell/players-api/internal/model"
+
+	"github.com/rsmaxwell/players-api/internal/debug"
+)
+
+const (
+	// keepaliveInterval is how often a comment is sent on an idle stream, so
+	// proxies do not close it
+	keepaliveInterval = 15 * time.Second
+
+	// streamWriteTimeout is how long each write to a stream may take. The
+	// server's write timeout covers the whole response, so a stream is given
+	// this deadline for each write instead
+	streamWriteTimeout = 30 * time.Second
+)
+
+var (
+	functionStreamEvents = debug.NewFunction(pkg, "StreamEvents")
+)
+
+// StreamEvents method sends the courts and the queue of a club as Server-Sent
+// Events. A snapshot of every court is sent when the stream opens, followed
+// by an event for each change. The stream ends with the request context, or
+// when the client falls too far behind to be sent every change, and clients
+// are expected to reconnect, which sends a fresh snapshot.
+//
+// EventSource cannot set an Authorization header, so the access token may
+// be given as the 'access_token' query parameter instead
+func StreamEvents(writer http.ResponseWriter, request *http.Request) {
+	f := functionStreamEvents
+	ctx := request.Context()
+
+	var err error
+	if token := request.URL.Query().Get("access_token"); token != "" {
+		_, err = checkAccessToken(request, token)
+	} else {
+		_, err = checkAuthenticated(request)
+	}
+	if err != nil {
+		writeResponseError(writer, request, err)
+		return
+	}
+
+	object := ctx.Value(ContextDatabaseKey)
+	db, ok := object.(*sql.DB)
+	if !ok {
+		message := "unexpected context type"
+		Dump(f, request, message)
+		writeResponseMessage(writer, request, http.StatusInternalServerError, message)
+		return
+	}
+
+	flusher, ok := writer.(http.Flusher)
+	if !ok {
+		message := "streaming is not supported"
+		Dump(f, request, message)
+		writeResponseMessage(writer, request, http.StatusInternalServerError, message)
+		return
+	}
+
+	clubID := getClubID(request)
+
+	// Subscribe before taking the snapshot, so no change is missed
+	events, cancel := model.Events.Subscribe()
+	defer cancel()
+
+	snapshot, err := model.NewSnapshotEvent(ctx, db, clubID)
+	if err != nil {
+		writeResponseError(writer, request, err)
+		return
+	}
+
+	// Without its own write deadlines, the stream is ended at the server's
+	// write timeout
+	deadlines := setWriteDeadline(writer, time.Now().Add(streamWriteTimeout)) == nil
+	if !deadlines {
+		DebugVerbose(f, request, "the write deadline cannot be set, so the stream ends at the server's write timeout")
+	}
+	extendDeadline := func() {
+		if deadlines {
+			setWriteDeadline(writer, time.Now().Add(streamWriteTimeout))
+		}
+	}
+
+	writer.Header().Set("Content-Type", "text/event-stream")
+	writer.Header().Set("Cache-Control", "no-cache")
+	writer.Header().Set("Connection", "keep-alive")
+	writeResponse(writer, request, http.StatusOK)
+
+	err = writeEvent(writer, snapshot)
+	if err != nil {
+		DebugError(f, request, "could not write the snapshot: %s", err)
+		return
+	}
+	flusher.Flush()
+
+	// The events arrive in version order, but one made before the snapshot
+	// may arrive after it, so only newer events are sent
+	lastSent := snapshot.Version
+
+	ticker := time.NewTicker(keepaliveInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+
+		case event, ok := <-events:
+			if !ok {
+				return
+			}
+			if event.Club != clubID || event.Version <= lastSent {
+				continue
+			}
+
+			extendDeadline()
+			err = writeEvent(writer, event)
+			if err != nil {
+				DebugError(f, request, "could not write the event: %s", err)
+				return
+			}
+			flusher.Flush()
+			lastSent = event.Version
+
+		case <-ticker.C:
+			extendDeadline()
+			_, err = fmt.Fprint(writer, ": keepalive\n\n")
+			if err != nil {
+				return
+			}
+			flusher.Flush()
+		}
+	}
+}
+
+// isStreamRequest tells whether the request opens an event stream, which
+// lasts longer than any request should
+func isStreamRequest(request *http.Request) bool {
+	path := request.URL.Path
+	return request.Method == http.MethodGet && strings.HasPrefix(path, contextPath+"/") && strings.HasSuffix(path, "/events")
+}
+
+// writeDeadliner is implemented by the server's response writers
+type writeDeadliner interface {
+	SetWriteDeadline(deadline time.Time) error
+}
+
+// setWriteDeadline sets the deadline for writing the rest of the response,
+// looking through the writers which wrap the server's own. A zero deadline
+// means none
+func setWriteDeadline(writer http.ResponseWriter, deadline time.Time) error {
+	for {
+		switch w := writer.(type) {
+		case writeDeadliner:
+			return w.SetWriteDeadline(deadline)
+		case interface{ Unwrap() http.ResponseWriter }:
+			writer = w.Unwrap()
+		default:
+			return http.ErrNotSupported
+		}
+	}
+}
+
+// writeEvent writes an event in the Server-Sent Events format, using the
+// state version as the event ID
+func writeEvent(writer http.ResponseWriter, event *model.Event) error {
+
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(writer, "id: %d\ndata: %s\n\n", event.Version, data)
+	return err
+}
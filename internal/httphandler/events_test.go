@@ -0,0 +1,236 @@
+package httphandler
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/require"
+
+	"github.com/rsmaxwell/players-api/internal/model"
+
+	_ "github.com/jackc/pgx/stdlib"
+)
+
+func TestStreamEvents(t *testing.T) {
+
+	teardown, db, _ := model.Setup(t)
+	defer teardown(t)
+
+	// ***************************************************************
+	// * Login
+	// ***************************************************************
+	logonCookie, accessToken := GetSigninToken(t, db, model.GoodEmail, model.GoodPassword)
+	goodCourt := GetFirstCourt(t, db)
+
+	// ***************************************************************
+	// * Testcases
+	// ***************************************************************
+	tests := []struct {
+		testName               string
+		setLogonCookie         bool
+		logonCookie            *http.Cookie
+		setAuthorizationHeader bool
+		accessToken            string
+		tokenInQuery           bool
+		expectedStatus         int
+		expectedEvents         int
+	}{
+		{
+			testName:               "Good request",
+			setLogonCookie:         true,
+			logonCookie:            logonCookie,
+			setAuthorizationHeader: true,
+			accessToken:            accessToken,
+			expectedStatus:         http.StatusOK,
+			expectedEvents:         2,
+		},
+		{
+			testName:       "Token in query",
+			setLogonCookie: true,
+			logonCookie:    logonCookie,
+			accessToken:    accessToken,
+			tokenInQuery:   true,
+			expectedStatus: http.StatusOK,
+			expectedEvents: 2,
+		},
+	}
+
+	// ***************************************************************
+	// * Run the tests
+	// ***************************************************************
+	for _, test := range tests {
+		t.Run(test.testName, func(t *testing.T) {
+
+			// Set up the handlers on the router
+			router := mux.NewRouter()
+			SetupHandlers(router)
+			w := httptest.NewRecorder()
+
+			command := "/events"
+			if test.tokenInQuery {
+				command = command + "?access_token=" + test.accessToken
+			}
+			r, err := http.NewRequest("GET", contextPath+command, nil)
+			require.Nil(t, err, "err should be nothing")
+
+			if test.setLogonCookie {
+				r.AddCookie(test.logonCookie)
+			}
+
+			if test.setAuthorizationHeader {
+				r.Header.Set("Authorization", "Bearer "+test.accessToken)
+			}
+
+			// ---------------------------------------
+
+			// The stream ends when the context does
+			ctx, cancel := context.WithTimeout(r.Context(), time.Duration(2*time.Second))
+			defer cancel()
+			r2 := r.WithContext(ctx)
+
+			ctx = context.WithValue(r2.Context(), ContextDatabaseKey, db)
+			r3 := r.WithContext(ctx)
+
+			// ---------------------------------------
+
+			// Make a change while the stream is open
+			done := make(chan error)
+			go func() {
+				time.Sleep(500 * time.Millisecond)
+				_, err := model.FillCourtTx(context.Background(), db, model.DefaultClubID, goodCourt.ID, "")
+				done <- err
+			}()
+
+			// Serve the request
+			router.ServeHTTP(w, r3)
+			require.Nil(t, <-done, "err should be nothing")
+			require.Equal(t, test.expectedStatus, w.Code, fmt.Sprintf("handler returned wrong status code: got %v want %v", w.Code, test.expectedStatus))
+
+			events := strings.Count(w.Body.String(), "data: ")
+			require.Equal(t, test.expectedEvents, events, fmt.Sprintf("unexpected number of events: got %v want %v", events, test.expectedEvents))
+		})
+	}
+}
+
+func TestStreamOutlivesRequestTimeout(t *testing.T) {
+
+	teardown, db, _ := model.Setup(t)
+	defer teardown(t)
+
+	_, accessToken := GetSigninToken(t, db, model.GoodEmail, model.GoodPassword)
+	goodCourt := GetFirstCourt(t, db)
+
+	// The request and write timeouts are much shorter than the stream is open
+	timeout := 200 * time.Millisecond
+
+	router := mux.NewRouter()
+	SetupHandlers(router)
+
+	var handler http.Handler = WithLogging(router)
+	handler = AddDatabaseContext(handler, db)
+	handler = AddRequestContext(handler, timeout)
+
+	server := httptest.NewUnstartedServer(handler)
+	server.Config.WriteTimeout = timeout
+	server.Start()
+	defer server.Close()
+
+	client := server.Client()
+	client.Timeout = 5 * time.Second
+
+	r, err := http.NewRequest("GET", server.URL+contextPath+"/events", nil)
+	require.Nil(t, err, "err should be nothing")
+	r.Header.Set("Authorization", "Bearer "+accessToken)
+
+	response, err := client.Do(r)
+	require.Nil(t, err, "err should be nothing")
+	defer response.Body.Close()
+	require.Equal(t, http.StatusOK, response.StatusCode, "handler returned wrong status code")
+
+	// Make a change once both timeouts have passed
+	time.Sleep(5 * timeout)
+	_, err = model.FillCourtTx(context.Background(), db, model.DefaultClubID, goodCourt.ID, "")
+	require.Nil(t, err, "err should be nothing")
+
+	// The snapshot, then the change
+	reader := bufio.NewReader(response.Body)
+	for events := 0; events < 2; {
+		line, err := reader.ReadString('\n')
+		require.Nil(t, err, fmt.Sprintf("the stream ended after %d events", events))
+		if strings.HasPrefix(line, "data: ") {
+			events++
+		}
+	}
+}
+
+// deadlineWriter records the write deadline set on it
+type deadlineWriter struct {
+	*httptest.ResponseRecorder
+	deadline time.Time
+}
+
+func (w *deadlineWriter) SetWriteDeadline(deadline time.Time) error {
+	w.deadline = deadline
+	return nil
+}
+
+func TestStreamRequests(t *testing.T) {
+
+	tests := []struct {
+		method   string
+		path     string
+		expected bool
+	}{
+		{method: "GET", path: contextPath + "/events", expected: true},
+		{method: "GET", path: contextPath + "/clubs/2/events", expected: true},
+		{method: "POST", path: contextPath + "/events", expected: false},
+		{method: "GET", path: contextPath + "/courts", expected: false},
+		{method: "GET", path: "/events", expected: false},
+	}
+
+	for _, test := range tests {
+		r := httptest.NewRequest(test.method, test.path, nil)
+		if isStreamRequest(r) != test.expected {
+			t.Logf("Unexpected stream request for %s %s. expected: %v", test.method, test.path, test.expected)
+			t.FailNow()
+		}
+	}
+
+	// A stream is not given the request timeout
+	for path, expected := range map[string]bool{"/events": false, "/courts": true} {
+		var hasDeadline bool
+		handler := AddRequestContext(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, hasDeadline = r.Context().Deadline()
+		}), time.Minute)
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", contextPath+path, nil))
+
+		if hasDeadline != expected {
+			t.Logf("Unexpected deadline for %s. expected: %v, actual: %v", path, expected, hasDeadline)
+			t.FailNow()
+		}
+	}
+
+	// The write deadline is set through the writers wrapping the server's
+	writer := &deadlineWriter{ResponseRecorder: httptest.NewRecorder()}
+	recorder := &StatusRecorder{ResponseWriter: &StatusRecorder{ResponseWriter: writer}}
+
+	deadline := time.Now().Add(time.Minute)
+	err := setWriteDeadline(recorder, deadline)
+	if err != nil || !writer.deadline.Equal(deadline) {
+		t.Logf("Unexpected write deadline: %v, err: %v", writer.deadline, err)
+		t.FailNow()
+	}
+
+	err = setWriteDeadline(httptest.NewRecorder(), deadline)
+	if err != http.ErrNotSupported {
+		t.Logf("Unexpected error for a writer without deadlines: %v", err)
+		t.FailNow()
+	}
+}
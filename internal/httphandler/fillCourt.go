@@ -24,7 +24,6 @@ type FillCourtRequest struct {
 // FillCourt method
 func FillCourt(writer http.ResponseWriter, request *http.Request) {
 	f := functionFillCourt
-	ctx := request.Context()
 
 	_, err := checkAuthenticated(request)
 	if err != nil {
@@ -32,6 +31,12 @@ func FillCourt(writer http.ResponseWriter, request *http.Request) {
 		return
 	}
 
+	ctx, err := contextWithIfMatch(request)
+	if err != nil {
+		writeResponseError(writer, request, err)
+		return
+	}
+
 	str := mux.Vars(request)["id"]
 	courtID, err := strconv.Atoi(str)
 	if err != nil {
@@ -53,7 +58,10 @@ func FillCourt(writer http.ResponseWriter, request *http.Request) {
 		return
 	}
 
-	positions, err := model.FillCourtTx(ctx, db, courtID)
+	strategy := request.URL.Query().Get("strategy")
+	DebugVerbose(f, request, "strategy: %s", strategy)
+
+	fill, err := model.FillCourtTx(ctx, db, getClubID(request), courtID, strategy)
 	if err != nil {
 		message := "problem filling court"
 		d := Dump(f, request, message)
@@ -62,5 +70,5 @@ func FillCourt(writer http.ResponseWriter, request *http.Request) {
 		return
 	}
 
-	writeResponseObject(writer, request, http.StatusOK, positions)
+	writeResponseObject(writer, request, http.StatusOK, fill)
 }
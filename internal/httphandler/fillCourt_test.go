@@ -27,6 +27,12 @@ func TestFillCourt(t *testing.T) {
 	logonCookie, accessToken := GetSigninToken(t, db, model.GoodEmail, model.GoodPassword)
 	goodCourt := GetFirstCourt(t, db)
 
+	version, err := model.GetVersion(context.Background(), db)
+	require.Nil(t, err, "err should be nothing")
+
+	goodVersion := fmt.Sprintf("\"%d\"", version)
+	staleVersion := fmt.Sprintf("\"%d\"", version-1)
+
 	// ***************************************************************
 	// * Testcases
 	// ***************************************************************
@@ -37,8 +43,41 @@ func TestFillCourt(t *testing.T) {
 		setAuthorizationHeader bool
 		accessToken            string
 		courtID                int
+		strategy               string
+		ifMatch                string
 		expectedStatus         int
 	}{
+		{
+			testName:               "Stale version",
+			setLogonCookie:         true,
+			logonCookie:            logonCookie,
+			setAuthorizationHeader: true,
+			accessToken:            accessToken,
+			courtID:                goodCourt.ID,
+			ifMatch:                staleVersion,
+			expectedStatus:         http.StatusPreconditionFailed,
+		},
+		{
+			testName:               "Bad version",
+			setLogonCookie:         true,
+			logonCookie:            logonCookie,
+			setAuthorizationHeader: true,
+			accessToken:            accessToken,
+			courtID:                goodCourt.ID,
+			ifMatch:                "\"junk\"",
+			expectedStatus:         http.StatusBadRequest,
+		},
+		{
+			testName:               "Unknown strategy",
+			setLogonCookie:         true,
+			logonCookie:            logonCookie,
+			setAuthorizationHeader: true,
+			accessToken:            accessToken,
+			courtID:                goodCourt.ID,
+			strategy:               "junk",
+			ifMatch:                goodVersion,
+			expectedStatus:         http.StatusBadRequest,
+		},
 		{
 			testName:               "Good request",
 			setLogonCookie:         true,
@@ -46,6 +85,8 @@ func TestFillCourt(t *testing.T) {
 			setAuthorizationHeader: true,
 			accessToken:            accessToken,
 			courtID:                goodCourt.ID,
+			strategy:               model.StrategyBalanced,
+			ifMatch:                goodVersion,
 			expectedStatus:         http.StatusOK,
 		},
 	}
@@ -62,6 +103,9 @@ func TestFillCourt(t *testing.T) {
 			w := httptest.NewRecorder()
 
 			command := fmt.Sprintf("/courts/fill/%d", test.courtID)
+			if test.strategy != "" {
+				command = command + "?strategy=" + test.strategy
+			}
 			r, err := http.NewRequest("PUT", contextPath+command, nil)
 			require.Nil(t, err, "err should be nothing")
 
@@ -73,6 +117,10 @@ func TestFillCourt(t *testing.T) {
 				r.Header.Set("Authorization", "Bearer "+test.accessToken)
 			}
 
+			if test.ifMatch != "" {
+				r.Header.Set("If-Match", test.ifMatch)
+			}
+
 			// ---------------------------------------
 
 			ctx, cancel := context.WithTimeout(r.Context(), time.Duration(60*time.Second))
@@ -0,0 +1,81 @@
+package httphandler
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/rsmaxwell/players-api/internal/config"
+	"github.com/rsmaxwell/players-api/internal/debug"
+	"github.com/rsmaxwell/players-api/internal/model"
+)
+
+// ForgotPasswordRequest structure
+type ForgotPasswordRequest struct {
+	Email string `json:"email"`
+}
+
+var (
+	functionForgotPassword = debug.NewFunction(pkg, "ForgotPassword")
+)
+
+// ForgotPassword method mails a password reset link to the person with the
+// email address. The response is the same whether or not the address is
+// known, so it cannot be used to find out who has registered
+func ForgotPassword(writer http.ResponseWriter, request *http.Request) {
+	f := functionForgotPassword
+
+	limitedReader := &io.LimitedReader{R: request.Body, N: 20 * 1024}
+	b, err := ioutil.ReadAll(limitedReader)
+	if err != nil {
+		writeResponseMessage(writer, request, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	DebugRequestBody(f, request, b)
+
+	var forgotPasswordRequest ForgotPasswordRequest
+	err = json.Unmarshal(b, &forgotPasswordRequest)
+	if err != nil {
+		writeResponseMessage(writer, request, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if forgotPasswordRequest.Email == "" {
+		writeResponseMessage(writer, request, http.StatusBadRequest, "missing email")
+		return
+	}
+
+	object := request.Context().Value(ContextDatabaseKey)
+	db, ok := object.(*sql.DB)
+	if !ok {
+		message := "unexpected context type"
+		Dump(f, request, message)
+		writeResponseMessage(writer, request, http.StatusInternalServerError, message)
+		return
+	}
+
+	object = request.Context().Value(ContextConfigKey)
+	cfg, ok := object.(*config.Config)
+	if !ok {
+		message := fmt.Sprintf("unexpected context type: %#v", cfg)
+		Dump(f, request, message)
+		writeResponseMessage(writer, request, http.StatusInternalServerError, message)
+		return
+	}
+
+	person, err := model.FindPersonByEmail(request.Context(), db, forgotPasswordRequest.Email)
+	if err != nil {
+		DebugVerbose(f, request, "no person with email [%s]", forgotPasswordRequest.Email)
+	} else {
+		err = sendPasswordResetMail(request.Context(), db, cfg, person)
+		if err != nil {
+			DebugError(f, request, "could not send the password reset mail to person [%d]: %s", person.ID, err.Error())
+		}
+	}
+
+	writeResponseMessage(writer, request, http.StatusOK, "ok")
+}
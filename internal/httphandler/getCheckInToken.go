@@ -0,0 +1,86 @@
+package httphandler
+
+import (
+	"database/sql"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/rsmaxwell/players-api/internal/basic"
+	"github.com/rsmaxwell/players-api/internal/config"
+	"github.com/rsmaxwell/players-api/internal/debug"
+	"github.com/rsmaxwell/players-api/internal/model"
+)
+
+var (
+	functionGetCheckInToken = debug.NewFunction(pkg, "GetCheckInToken")
+)
+
+// CheckInTokenResponse structure
+type CheckInTokenResponse struct {
+	Token   string    `json:"token"`
+	Session int       `json:"session"`
+	Expires time.Time `json:"expires"`
+}
+
+// GetCheckInToken method returns a short-lived token for the open session,
+// for an organiser to show as a QR code at the door. The display should fetch a
+// new token before the old one expires
+func GetCheckInToken(writer http.ResponseWriter, request *http.Request) {
+	f := functionGetCheckInToken
+	ctx := request.Context()
+
+	_, err := checkAuthenticated(request)
+	if err != nil {
+		writeResponseError(writer, request, err)
+		return
+	}
+
+	object := request.Context().Value(ContextDatabaseKey)
+	db, ok := object.(*sql.DB)
+	if !ok {
+		message := "unexpected context type"
+		Dump(f, request, message)
+		writeResponseMessage(writer, request, http.StatusInternalServerError, message)
+		return
+	}
+
+	object = request.Context().Value(ContextConfigKey)
+	cfg, ok := object.(*config.Config)
+	if !ok {
+		message := fmt.Sprintf("unexpected context type: %#v", cfg)
+		Dump(f, request, message)
+		writeResponseMessage(writer, request, http.StatusInternalServerError, message)
+		return
+	}
+
+	version, err := model.GetVersion(ctx, db)
+	if err != nil {
+		writeResponseError(writer, request, err)
+		return
+	}
+
+	session, err := model.FindOpenSession(ctx, db, getClubID(request))
+	if err != nil {
+		writeResponseError(writer, request, err)
+		return
+	}
+	if session == nil {
+		writeResponseMessage(writer, request, http.StatusNotFound, "No session is open")
+		return
+	}
+
+	expires := time.Now().Add(cfg.CheckInTokenExpiry)
+	token, err := basic.GenerateTypedToken(session.ID, basic.TokenTypeCheckIn, cfg.CheckInTokenExpiry)
+	if err != nil {
+		writeResponseError(writer, request, err)
+		return
+	}
+
+	setETag(writer, version)
+	writeResponseObject(writer, request, http.StatusOK, CheckInTokenResponse{
+		Token:   token,
+		Session: session.ID,
+		Expires: expires,
+	})
+}
@@ -0,0 +1,100 @@
+package httphandler
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/rsmaxwell/players-api/internal/model"
+	"github.com/stretchr/testify/require"
+
+	_ "github.com/jackc/pgx/stdlib"
+)
+
+func TestGetCheckInToken(t *testing.T) {
+
+	teardown, db, _ := model.Setup(t)
+	defer teardown(t)
+
+	// ***************************************************************
+	// * Login
+	// ***************************************************************
+	logonCookie, accessToken := GetSigninToken(t, db, model.GoodEmail, model.GoodPassword)
+	memberCookie, memberToken := GetSigninToken(t, db, model.AnotherEmail, model.AnotherPassword)
+
+	// ***************************************************************
+	// * Testcases
+	// ***************************************************************
+	tests := []struct {
+		testName               string
+		setLogonCookie         bool
+		logonCookie            *http.Cookie
+		setAuthorizationHeader bool
+		accessToken            string
+		command                string
+		expectedStatus         int
+	}{
+		{
+			testName:               "Not an organiser",
+			setLogonCookie:         true,
+			logonCookie:            memberCookie,
+			setAuthorizationHeader: true,
+			accessToken:            memberToken,
+			command:                "/sessions/checkintoken",
+			expectedStatus:         http.StatusForbidden,
+		},
+		{
+			testName:               "Good request",
+			setLogonCookie:         true,
+			logonCookie:            logonCookie,
+			setAuthorizationHeader: true,
+			accessToken:            accessToken,
+			command:                "/sessions/checkintoken",
+			expectedStatus:         http.StatusOK,
+		},
+	}
+
+	// ***************************************************************
+	// * Run the tests
+	// ***************************************************************
+	for _, test := range tests {
+		t.Run(test.testName, func(t *testing.T) {
+
+			// Set up the handlers on the router
+			router := mux.NewRouter()
+			SetupHandlers(router)
+			w := httptest.NewRecorder()
+
+			// Create a request
+			r, err := http.NewRequest("GET", contextPath+test.command, nil)
+			require.Nil(t, err, "err should be nothing")
+
+			if test.setLogonCookie {
+				r.AddCookie(test.logonCookie)
+			}
+
+			if test.setAuthorizationHeader {
+				r.Header.Set("Authorization", "Bearer "+test.accessToken)
+			}
+
+			// ---------------------------------------
+
+			ctx, cancel := context.WithTimeout(r.Context(), time.Duration(60*time.Second))
+			defer cancel()
+			r2 := r.WithContext(ctx)
+
+			ctx = context.WithValue(r2.Context(), ContextDatabaseKey, db)
+			r3 := r.WithContext(ctx)
+
+			// ---------------------------------------
+
+			// Serve the request
+			router.ServeHTTP(w, r3)
+			require.Equal(t, test.expectedStatus, w.Code, fmt.Sprintf("handler returned wrong status code: got %v want %v", w.Code, test.expectedStatus))
+		})
+	}
+}
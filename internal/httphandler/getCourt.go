@@ -42,13 +42,20 @@ func GetCourt(writer http.ResponseWriter, request *http.Request) {
 		return
 	}
 
+	version, err := model.GetVersion(request.Context(), db)
+	if err != nil {
+		writeResponseError(writer, request, err)
+		return
+	}
+
 	var c model.Court
 	c.ID = id
-	err = c.LoadCourt(ctx, db)
+	err = c.LoadClubCourt(ctx, db, getClubID(request))
 	if err != nil {
 		writeResponseError(writer, request, err)
 		return
 	}
 
+	setETag(writer, version)
 	writeResponseObject(writer, request, http.StatusOK, c)
 }
@@ -0,0 +1,54 @@
+package httphandler
+
+import (
+	"database/sql"
+	"net/http"
+
+	"github.com/rsmaxwell/players-api/internal/debug"
+	"github.com/rsmaxwell/players-api/internal/model"
+)
+
+var (
+	functionGetCurrentSession = debug.NewFunction(pkg, "GetCurrentSession")
+)
+
+// GetCurrentSession method returns the open session and the people checked
+// in to it
+func GetCurrentSession(writer http.ResponseWriter, request *http.Request) {
+	f := functionGetCurrentSession
+	ctx := request.Context()
+
+	_, err := checkAuthenticated(request)
+	if err != nil {
+		writeResponseError(writer, request, err)
+		return
+	}
+
+	object := request.Context().Value(ContextDatabaseKey)
+	db, ok := object.(*sql.DB)
+	if !ok {
+		message := "unexpected context type"
+		Dump(f, request, message)
+		writeResponseMessage(writer, request, http.StatusInternalServerError, message)
+		return
+	}
+
+	version, err := model.GetVersion(ctx, db)
+	if err != nil {
+		writeResponseError(writer, request, err)
+		return
+	}
+
+	session, err := model.FindOpenSession(ctx, db, getClubID(request))
+	if err != nil {
+		writeResponseError(writer, request, err)
+		return
+	}
+	if session == nil {
+		writeResponseMessage(writer, request, http.StatusNotFound, "No session is open")
+		return
+	}
+
+	setETag(writer, version)
+	writeResponseObject(writer, request, http.StatusOK, session)
+}
@@ -0,0 +1,50 @@
+package httphandler
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/rsmaxwell/players-api/internal/debug"
+)
+
+var (
+	functionGetDump = debug.NewFunction(pkg, "GetDump")
+)
+
+// GetDump method returns the files of a dump as a zip archive
+func GetDump(writer http.ResponseWriter, request *http.Request) {
+	f := functionGetDump
+
+	_, err := checkAuthenticated(request)
+	if err != nil {
+		writeResponseError(writer, request, err)
+		return
+	}
+
+	name := mux.Vars(request)["name"]
+	DebugVerbose(f, request, "name: %s", name)
+
+	dump, err := debug.FindDump(name)
+	if err != nil {
+		writeResponseError(writer, request, err)
+		return
+	}
+
+	// The archive is built before anything is written, so a failure can
+	// still be reported
+	var buffer bytes.Buffer
+	err = dump.WriteZip(&buffer)
+	if err != nil {
+		message := "could not archive the dump"
+		DumpError(f, request, err, message)
+		writeResponseMessage(writer, request, http.StatusInternalServerError, message)
+		return
+	}
+
+	writer.Header().Set("Content-Type", "application/zip")
+	writer.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=\"%s.zip\"", name))
+	writer.WriteHeader(http.StatusOK)
+	writer.Write(buffer.Bytes())
+}
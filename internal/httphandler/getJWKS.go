@@ -0,0 +1,14 @@
+package httphandler
+
+import (
+	"net/http"
+
+	"github.com/rsmaxwell/players-api/internal/basic"
+)
+
+// GetJWKS method returns the public keys which verify the tokens signed by
+// players-api, as a JSON Web Key Set. Keys which are shared secrets are
+// never included
+func GetJWKS(writer http.ResponseWriter, request *http.Request) {
+	writeResponseObject(writer, request, http.StatusOK, basic.PublicJWKS())
+}
@@ -0,0 +1,58 @@
+package httphandler
+
+import (
+	"database/sql"
+	"net/http"
+
+	"github.com/rsmaxwell/players-api/internal/debug"
+	"github.com/rsmaxwell/players-api/internal/model"
+)
+
+var (
+	functionGetLeaderboard = debug.NewFunction(pkg, "GetLeaderboard")
+)
+
+// GetLeaderboard method returns the games played and the waits of every
+// player over the period given by the 'period' query parameter: 'today',
+// 'week' (the default) or 'all'
+func GetLeaderboard(writer http.ResponseWriter, request *http.Request) {
+	f := functionGetLeaderboard
+	ctx := request.Context()
+
+	_, err := checkAuthenticated(request)
+	if err != nil {
+		writeResponseError(writer, request, err)
+		return
+	}
+
+	period := request.URL.Query().Get("period")
+	if period == "" {
+		period = model.PeriodWeek
+	}
+
+	DebugVerbose(f, request, "period: %s", period)
+
+	object := request.Context().Value(ContextDatabaseKey)
+	db, ok := object.(*sql.DB)
+	if !ok {
+		message := "unexpected context type"
+		Dump(f, request, message)
+		writeResponseMessage(writer, request, http.StatusInternalServerError, message)
+		return
+	}
+
+	version, err := model.GetVersion(ctx, db)
+	if err != nil {
+		writeResponseError(writer, request, err)
+		return
+	}
+
+	list, err := model.Leaderboard(ctx, db, getClubID(request), period)
+	if err != nil {
+		writeResponseError(writer, request, err)
+		return
+	}
+
+	setETag(writer, version)
+	writeResponseObject(writer, request, http.StatusOK, list)
+}
@@ -1,51 +1,35 @@
 package httphandler
 
 import (
-	"database/sql"
 	"net/http"
+	"strconv"
 
-	"github.com/rsmaxwell/players-api/internal/debug"
+	"github.com/rsmaxwell/players-api/internal/metrics"
 	"github.com/rsmaxwell/players-api/internal/model"
 )
 
-var (
-	functionGetMetrics = debug.NewFunction(pkg, "GetMetrics")
-)
-
-// GetMetrics method
+// GetMetrics method returns the metrics as JSON. They are the same metrics
+// served in the Prometheus text format on the metrics port
 func GetMetrics(writer http.ResponseWriter, request *http.Request) {
-	f := functionGetMetrics
-	ctx := request.Context()
 
-	userID, err := checkAuthenticated(request)
+	_, err := checkAuthenticated(request)
 	if err != nil {
 		writeResponseError(writer, request, err)
 		return
 	}
 
-	object := request.Context().Value(ContextDatabaseKey)
-	db, ok := object.(*sql.DB)
-	if !ok {
-		message := "unexpected context type"
-		Dump(f, request, message)
-		writeResponseMessage(writer, request, http.StatusInternalServerError, message)
-		return
+	response := model.Metrics{
+		StatusCodes: make(map[int]int),
+		Signins:     model.LoginAttempts.Stats(),
+		Metrics:     metrics.Default.Gather(),
 	}
 
-	p := model.FullPerson{ID: userID}
-	err = p.LoadPerson(ctx, db)
-	if err != nil {
-		Dump(f, request, "Could not load the logged on user: %d", userID)
-		writeResponseMessage(writer, request, http.StatusInternalServerError, "Not Authorized")
-		return
-	}
-
-	err = p.CanGetMetrics()
-	if err != nil {
-		DebugVerbose(f, request, "unauthorized person[%d] attempted to get metrics", userID)
-		writeResponseMessage(writer, request, http.StatusForbidden, "Forbidden")
-		return
+	for status, count := range requestsTotal.Totals("status") {
+		code, err := strconv.Atoi(status)
+		if err == nil {
+			response.StatusCodes[code] = int(count)
+		}
 	}
 
-	writeResponseObject(writer, request, http.StatusOK, model.MetricsData)
+	writeResponseObject(writer, request, http.StatusOK, response)
 }
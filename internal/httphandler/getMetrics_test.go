@@ -2,14 +2,18 @@ package httphandler
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
+	"strconv"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/gorilla/mux"
+	"github.com/rsmaxwell/players-api/internal/metrics"
 	"github.com/rsmaxwell/players-api/internal/model"
 	"github.com/stretchr/testify/require"
 
@@ -89,10 +93,38 @@ func TestGetMetrics(t *testing.T) {
 			bytes, err := ioutil.ReadAll(w.Body)
 			require.Nil(t, err, "err should be nothing")
 
-			data := string(bytes)
-			if len(data) <= 0 {
+			var response model.Metrics
+			err = json.Unmarshal(bytes, &response)
+			require.Nil(t, err, "err should be nothing")
+
+			if len(response.Metrics) <= 0 {
 				require.Fail(t, "no metrics were returned")
 			}
 		})
 	}
 }
+
+func TestInstrument(t *testing.T) {
+
+	router := mux.NewRouter()
+	SetupHandlers(router)
+
+	before := requestsTotal.Totals("status")[strconv.Itoa(http.StatusNotFound)]
+
+	w := httptest.NewRecorder()
+	r, err := http.NewRequest("GET", contextPath+"/nothing/here", nil)
+	require.Nil(t, err, "err should be nothing")
+
+	router.ServeHTTP(w, r)
+	require.Equal(t, http.StatusNotFound, w.Code, "handler returned wrong status code")
+
+	after := requestsTotal.Totals("status")[strconv.Itoa(http.StatusNotFound)]
+	require.Equal(t, before+1, after, "the request was not counted")
+
+	// The metrics are written in the Prometheus text format
+	var buffer strings.Builder
+	err = metrics.Default.WriteText(&buffer)
+	require.Nil(t, err, "err should be nothing")
+	require.Contains(t, buffer.String(), `players_http_requests_total{method="GET",route="none",status="404"}`)
+	require.Contains(t, buffer.String(), "# TYPE players_http_request_duration_seconds histogram")
+}
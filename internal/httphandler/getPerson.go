@@ -44,6 +44,12 @@ func GetPerson(writer http.ResponseWriter, request *http.Request) {
 		return
 	}
 
+	version, err := model.GetVersion(request.Context(), db)
+	if err != nil {
+		writeResponseError(writer, request, err)
+		return
+	}
+
 	var p model.FullPerson
 	p.ID = id
 	err = p.LoadPerson(ctx, db)
@@ -53,5 +59,6 @@ func GetPerson(writer http.ResponseWriter, request *http.Request) {
 	}
 
 	limitedPerson := p.ToLimited()
+	setETag(writer, version)
 	writeResponseObject(writer, request, http.StatusOK, limitedPerson)
 }
@@ -0,0 +1,62 @@
+package httphandler
+
+import (
+	"database/sql"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/rsmaxwell/players-api/internal/debug"
+	"github.com/rsmaxwell/players-api/internal/model"
+)
+
+var (
+	functionGetPersonStats = debug.NewFunction(pkg, "GetPersonStats")
+)
+
+// GetPersonStats method returns the games a person has played, how long they
+// waited to be called, and who they played with and against
+func GetPersonStats(writer http.ResponseWriter, request *http.Request) {
+	f := functionGetPersonStats
+	ctx := request.Context()
+
+	_, err := checkAuthenticated(request)
+	if err != nil {
+		writeResponseError(writer, request, err)
+		return
+	}
+
+	str := mux.Vars(request)["id"]
+	id, err := strconv.Atoi(str)
+	if err != nil {
+		writeResponseMessage(writer, request, http.StatusBadRequest, fmt.Sprintf("the key [%s] is not an int", str))
+		return
+	}
+
+	DebugVerbose(f, request, "ID: %d", id)
+
+	object := request.Context().Value(ContextDatabaseKey)
+	db, ok := object.(*sql.DB)
+	if !ok {
+		message := "unexpected context type"
+		Dump(f, request, message)
+		writeResponseMessage(writer, request, http.StatusInternalServerError, message)
+		return
+	}
+
+	version, err := model.GetVersion(ctx, db)
+	if err != nil {
+		writeResponseError(writer, request, err)
+		return
+	}
+
+	stats, err := model.GetPersonStats(ctx, db, id)
+	if err != nil {
+		writeResponseError(writer, request, err)
+		return
+	}
+
+	setETag(writer, version)
+	writeResponseObject(writer, request, http.StatusOK, stats)
+}
@@ -0,0 +1,48 @@
+package httphandler
+
+import (
+	"database/sql"
+	"net/http"
+
+	"github.com/rsmaxwell/players-api/internal/debug"
+	"github.com/rsmaxwell/players-api/internal/model"
+)
+
+var (
+	functionListClubMembers = debug.NewFunction(pkg, "ListClubMembers")
+)
+
+// ListClubMembers method lists the people who hold a role in the club
+func ListClubMembers(writer http.ResponseWriter, request *http.Request) {
+	f := functionListClubMembers
+
+	_, err := checkAuthenticated(request)
+	if err != nil {
+		writeResponseError(writer, request, err)
+		return
+	}
+
+	object := request.Context().Value(ContextDatabaseKey)
+	db, ok := object.(*sql.DB)
+	if !ok {
+		message := "unexpected context type"
+		Dump(f, request, message)
+		writeResponseMessage(writer, request, http.StatusInternalServerError, message)
+		return
+	}
+
+	version, err := model.GetVersion(request.Context(), db)
+	if err != nil {
+		writeResponseError(writer, request, err)
+		return
+	}
+
+	members, err := model.ListClubMembers(request.Context(), db, getClubID(request))
+	if err != nil {
+		writeResponseError(writer, request, err)
+		return
+	}
+
+	setETag(writer, version)
+	writeResponseObject(writer, request, http.StatusOK, members)
+}
@@ -0,0 +1,60 @@
+package httphandler
+
+import (
+	"database/sql"
+	"net/http"
+
+	"github.com/rsmaxwell/players-api/internal/debug"
+	"github.com/rsmaxwell/players-api/internal/model"
+)
+
+var (
+	functionListClubs = debug.NewFunction(pkg, "ListClubs")
+)
+
+// ListClubs method lists the clubs of the signed in person. Those who may
+// manage clubs see every club
+func ListClubs(writer http.ResponseWriter, request *http.Request) {
+	f := functionListClubs
+
+	userID, err := checkAuthenticated(request)
+	if err != nil {
+		writeResponseError(writer, request, err)
+		return
+	}
+
+	object := request.Context().Value(ContextDatabaseKey)
+	db, ok := object.(*sql.DB)
+	if !ok {
+		message := "unexpected context type"
+		Dump(f, request, message)
+		writeResponseMessage(writer, request, http.StatusInternalServerError, message)
+		return
+	}
+
+	version, err := model.GetVersion(request.Context(), db)
+	if err != nil {
+		writeResponseError(writer, request, err)
+		return
+	}
+
+	all, err := model.HasPermission(request.Context(), db, userID, model.PermissionManageClubs)
+	if err != nil {
+		writeResponseError(writer, request, err)
+		return
+	}
+
+	var clubs []model.Club
+	if all {
+		clubs, err = model.ListClubs(request.Context(), db)
+	} else {
+		clubs, err = model.ListClubsForPerson(request.Context(), db, userID)
+	}
+	if err != nil {
+		writeResponseError(writer, request, err)
+		return
+	}
+
+	setETag(writer, version)
+	writeResponseObject(writer, request, http.StatusOK, clubs)
+}
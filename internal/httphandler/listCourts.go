@@ -8,11 +8,20 @@ import (
 	"github.com/rsmaxwell/players-api/internal/model"
 )
 
+// ListCourtsResponse structure
+type ListCourtsResponse struct {
+	Total  int           `json:"total"`
+	Next   string        `json:"next,omitempty"`
+	Courts []model.Court `json:"courts"`
+}
+
 var (
 	functionListCourts = debug.NewFunction(pkg, "ListCourts")
 )
 
-// ListCourts method
+// ListCourts method returns a page of the club's courts. The courts may be
+// filtered by the 'search' query parameter for text in their names, and
+// are sorted by 'name' or 'id'
 func ListCourts(writer http.ResponseWriter, request *http.Request) {
 	f := functionListCourts
 
@@ -24,6 +33,14 @@ func ListCourts(writer http.ResponseWriter, request *http.Request) {
 		return
 	}
 
+	page, err := getPage(request)
+	if err != nil {
+		writeResponseError(writer, request, err)
+		return
+	}
+
+	filter := model.CourtFilter{Club: getClubID(request), Search: request.URL.Query().Get("search")}
+
 	object := request.Context().Value(ContextDatabaseKey)
 	db, ok := object.(*sql.DB)
 	if !ok {
@@ -33,7 +50,13 @@ func ListCourts(writer http.ResponseWriter, request *http.Request) {
 		return
 	}
 
-	listOfCourts, err := model.ListCourtsTx(db)
+	version, err := model.GetVersion(request.Context(), db)
+	if err != nil {
+		writeResponseError(writer, request, err)
+		return
+	}
+
+	listOfCourts, total, err := model.ListCourtsPageTx(db, filter, page)
 	if err != nil {
 		message := "Problem listing courts"
 		Dump(f, request, message)
@@ -41,5 +64,12 @@ func ListCourts(writer http.ResponseWriter, request *http.Request) {
 		return
 	}
 
-	writeResponseObject(writer, request, http.StatusOK, listOfCourts)
+	if listOfCourts == nil {
+		listOfCourts = make([]model.Court, 0)
+	}
+
+	response := ListCourtsResponse{Total: total, Next: nextCursor(page, len(listOfCourts), total), Courts: listOfCourts}
+
+	setETag(writer, version)
+	writeResponseObject(writer, request, http.StatusOK, response)
 }
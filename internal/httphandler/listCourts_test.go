@@ -2,9 +2,12 @@ package httphandler
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"testing"
 	"time"
 
@@ -29,8 +32,8 @@ func TestListCourts(t *testing.T) {
 	// ***************************************************************
 	// * Get the list of all courts
 	// ***************************************************************
-	// allCourtIDs, err := model.ListCourts(db)
-	// require.Nil(t, err, "err should be nothing")
+	allCourts, err := model.ListCourtsTx(db, model.DefaultClubID)
+	require.Nil(t, err, "err should be nothing")
 
 	// ***************************************************************
 	// * Testcases
@@ -41,8 +44,9 @@ func TestListCourts(t *testing.T) {
 		logonCookie            *http.Cookie
 		setAuthorizationHeader bool
 		accessToken            string
+		query                  string
 		expectedStatus         int
-		expectedResult         []int
+		expectedTotal          int
 	}{
 		{
 			testName:               "Good request",
@@ -51,7 +55,26 @@ func TestListCourts(t *testing.T) {
 			setAuthorizationHeader: true,
 			accessToken:            accessToken,
 			expectedStatus:         http.StatusOK,
-			expectedResult:         nil,
+			expectedTotal:          len(allCourts),
+		},
+		{
+			testName:               "Search",
+			setLogonCookie:         true,
+			logonCookie:            logonCookie,
+			setAuthorizationHeader: true,
+			accessToken:            accessToken,
+			query:                  "search=" + url.QueryEscape(allCourts[0].Name),
+			expectedStatus:         http.StatusOK,
+			expectedTotal:          1,
+		},
+		{
+			testName:               "Bad sort key",
+			setLogonCookie:         true,
+			logonCookie:            logonCookie,
+			setAuthorizationHeader: true,
+			accessToken:            accessToken,
+			query:                  "sort=status",
+			expectedStatus:         http.StatusBadRequest,
 		},
 	}
 
@@ -67,7 +90,7 @@ func TestListCourts(t *testing.T) {
 			w := httptest.NewRecorder()
 
 			// Create a request
-			command := "/courts"
+			command := "/courts?" + test.query
 			r, err := http.NewRequest("GET", contextPath+command, nil)
 			require.Nil(t, err, "err should be nothing")
 
@@ -95,21 +118,18 @@ func TestListCourts(t *testing.T) {
 			require.Equal(t, test.expectedStatus, w.Code, fmt.Sprintf("handler returned wrong status code: got %v want %v", w.Code, test.expectedStatus))
 
 			// Check the response
-			// if w.Code == http.StatusOK {
-			// bytes, err := ioutil.ReadAll(w.Body)
-			// require.Nil(t, err, "err should be nothing")
-
-			// var response ListCourtsResponse
-			// err = json.Unmarshal(bytes, &response)
-			// require.Nil(t, err, "err should be nothing")
-
-			// Check the response body is what we expect.
-			// if !model.EqualIntArray(response.Courts, test.expectedResult) {
-			// 	t.Logf("actual:   %v", response.Courts)
-			// 	t.Logf("expected: %v", test.expectedResult)
-			// 	t.Errorf("Unexpected list of courts")
-			// }
-			// }
+			if w.Code == http.StatusOK {
+				bytes, err := ioutil.ReadAll(w.Body)
+				require.Nil(t, err, "err should be nothing")
+
+				var response ListCourtsResponse
+				err = json.Unmarshal(bytes, &response)
+				require.Nil(t, err, "err should be nothing")
+
+				// Check the response body is what we expect.
+				require.Equal(t, test.expectedTotal, response.Total, "unexpected total")
+				require.Equal(t, test.expectedTotal, len(response.Courts), "unexpected number of courts")
+			}
 		})
 	}
 }
@@ -0,0 +1,66 @@
+package httphandler
+
+import (
+	"net/http"
+
+	"github.com/rsmaxwell/players-api/internal/debug"
+)
+
+var (
+	functionListDumps = debug.NewFunction(pkg, "ListDumps")
+)
+
+// DumpSummary describes a dump. The info is missing if the dump could not be
+// read
+type DumpSummary struct {
+	Name string          `json:"name"`
+	Size int64           `json:"size"`
+	Info *debug.DumpInfo `json:"info,omitempty"`
+}
+
+// ListDumpsResponse structure
+type ListDumpsResponse struct {
+	Retention debug.Retention `json:"retention"`
+	Total     int64           `json:"total"` // the bytes held by all the dumps
+	Dumps     []DumpSummary   `json:"dumps"`
+}
+
+// ListDumps method returns the dumps, newest first
+func ListDumps(writer http.ResponseWriter, request *http.Request) {
+	f := functionListDumps
+
+	_, err := checkAuthenticated(request)
+	if err != nil {
+		writeResponseError(writer, request, err)
+		return
+	}
+
+	dumps, err := debug.ListDumps()
+	if err != nil {
+		message := "could not list the dumps"
+		DumpError(f, request, err, message)
+		writeResponseMessage(writer, request, http.StatusInternalServerError, message)
+		return
+	}
+
+	response := ListDumpsResponse{Retention: debug.GetRetention(), Dumps: []DumpSummary{}}
+
+	for i := len(dumps) - 1; i >= 0; i-- {
+		summary := DumpSummary{Name: dumps[i].Name()}
+
+		summary.Size, err = dumps[i].Size()
+		if err != nil {
+			DebugVerbose(f, request, "could not size the dump [%s]: %s", summary.Name, err.Error())
+		}
+		response.Total += summary.Size
+
+		summary.Info, err = dumps[i].GetInfo()
+		if err != nil {
+			DebugVerbose(f, request, "could not read the dump [%s]: %s", summary.Name, err.Error())
+		}
+
+		response.Dumps = append(response.Dumps, summary)
+	}
+
+	writeResponseObject(writer, request, http.StatusOK, response)
+}
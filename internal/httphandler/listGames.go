@@ -0,0 +1,85 @@
+package httphandler
+
+import (
+	"database/sql"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/rsmaxwell/players-api/internal/model"
+
+	"github.com/rsmaxwell/players-api/internal/debug"
+)
+
+var (
+	functionListGames = debug.NewFunction(pkg, "ListGames")
+)
+
+// ListGames method returns the completed games, most recent first. The
+// games may be filtered by the 'person', 'court' and 'session' query parameters, and by
+// the 'from' and 'to' times in RFC 3339 format. 'limit' caps the number of
+// games returned
+func ListGames(writer http.ResponseWriter, request *http.Request) {
+	f := functionListGames
+
+	_, err := checkAuthenticated(request)
+	if err != nil {
+		writeResponseError(writer, request, err)
+		return
+	}
+
+	query := request.URL.Query()
+
+	filter := model.GameFilter{Club: getClubID(request)}
+
+	for name, value := range map[string]*int{"person": &filter.Person, "court": &filter.Court, "session": &filter.Session, "limit": &filter.Limit} {
+		str := query.Get(name)
+		if str == "" {
+			continue
+		}
+		*value, err = strconv.Atoi(str)
+		if err != nil {
+			writeResponseMessage(writer, request, http.StatusBadRequest, fmt.Sprintf("the %s [%s] is not an int", name, str))
+			return
+		}
+	}
+
+	for name, value := range map[string]*time.Time{"from": &filter.From, "to": &filter.To} {
+		str := query.Get(name)
+		if str == "" {
+			continue
+		}
+		*value, err = time.Parse(time.RFC3339, str)
+		if err != nil {
+			writeResponseMessage(writer, request, http.StatusBadRequest, fmt.Sprintf("the %s [%s] is not an RFC 3339 time", name, str))
+			return
+		}
+	}
+
+	DebugVerbose(f, request, "filter: %+v", filter)
+
+	object := request.Context().Value(ContextDatabaseKey)
+	db, ok := object.(*sql.DB)
+	if !ok {
+		message := "unexpected context type"
+		Dump(f, request, message)
+		writeResponseMessage(writer, request, http.StatusInternalServerError, message)
+		return
+	}
+
+	version, err := model.GetVersion(request.Context(), db)
+	if err != nil {
+		writeResponseError(writer, request, err)
+		return
+	}
+
+	games, err := model.ListGames(request.Context(), db, filter)
+	if err != nil {
+		writeResponseError(writer, request, err)
+		return
+	}
+
+	setETag(writer, version)
+	writeResponseObject(writer, request, http.StatusOK, games)
+}
@@ -0,0 +1,131 @@
+package httphandler
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/require"
+
+	"github.com/rsmaxwell/players-api/internal/model"
+
+	_ "github.com/jackc/pgx/stdlib"
+)
+
+func TestListGames(t *testing.T) {
+
+	teardown, db, _ := model.Setup(t)
+	defer teardown(t)
+
+	// ***************************************************************
+	// * Login
+	// ***************************************************************
+	logonCookie, accessToken := GetSigninToken(t, db, model.GoodEmail, model.GoodPassword)
+	goodCourt := GetFirstCourt(t, db)
+
+	// ***************************************************************
+	// * Play a game
+	// ***************************************************************
+	ctx := context.Background()
+
+	_, err := model.FillCourtTx(ctx, db, model.DefaultClubID, goodCourt.ID, "")
+	require.Nil(t, err, "err should be nothing")
+
+	err = model.ClearCourtTx(ctx, db, model.DefaultClubID, goodCourt.ID)
+	require.Nil(t, err, "err should be nothing")
+
+	// ***************************************************************
+	// * Testcases
+	// ***************************************************************
+	tests := []struct {
+		testName               string
+		setLogonCookie         bool
+		logonCookie            *http.Cookie
+		setAuthorizationHeader bool
+		accessToken            string
+		query                  url.Values
+		expectedStatus         int
+	}{
+		{
+			testName:               "Good request",
+			setLogonCookie:         true,
+			logonCookie:            logonCookie,
+			setAuthorizationHeader: true,
+			accessToken:            accessToken,
+			query:                  url.Values{"court": {fmt.Sprintf("%d", goodCourt.ID)}},
+			expectedStatus:         http.StatusOK,
+		},
+		{
+			testName:               "Good date range",
+			setLogonCookie:         true,
+			logonCookie:            logonCookie,
+			setAuthorizationHeader: true,
+			accessToken:            accessToken,
+			query:                  url.Values{"from": {time.Now().Add(-time.Hour).Format(time.RFC3339)}, "to": {time.Now().Add(time.Hour).Format(time.RFC3339)}},
+			expectedStatus:         http.StatusOK,
+		},
+		{
+			testName:               "Bad person",
+			setLogonCookie:         true,
+			logonCookie:            logonCookie,
+			setAuthorizationHeader: true,
+			accessToken:            accessToken,
+			query:                  url.Values{"person": {"junk"}},
+			expectedStatus:         http.StatusBadRequest,
+		},
+		{
+			testName:               "Bad from",
+			setLogonCookie:         true,
+			logonCookie:            logonCookie,
+			setAuthorizationHeader: true,
+			accessToken:            accessToken,
+			query:                  url.Values{"from": {"yesterday"}},
+			expectedStatus:         http.StatusBadRequest,
+		},
+	}
+
+	// ***************************************************************
+	// * Run the tests
+	// ***************************************************************
+	for _, test := range tests {
+		t.Run(test.testName, func(t *testing.T) {
+
+			// Set up the handlers on the router
+			router := mux.NewRouter()
+			SetupHandlers(router)
+			w := httptest.NewRecorder()
+
+			command := "/games?" + test.query.Encode()
+			r, err := http.NewRequest("GET", contextPath+command, nil)
+			require.Nil(t, err, "err should be nothing")
+
+			if test.setLogonCookie {
+				r.AddCookie(test.logonCookie)
+			}
+
+			if test.setAuthorizationHeader {
+				r.Header.Set("Authorization", "Bearer "+test.accessToken)
+			}
+
+			// ---------------------------------------
+
+			ctx, cancel := context.WithTimeout(r.Context(), time.Duration(60*time.Second))
+			defer cancel()
+			r2 := r.WithContext(ctx)
+
+			ctx = context.WithValue(r2.Context(), ContextDatabaseKey, db)
+			r3 := r.WithContext(ctx)
+
+			// ---------------------------------------
+
+			// Serve the request
+			router.ServeHTTP(w, r3)
+			require.Equal(t, test.expectedStatus, w.Code, fmt.Sprintf("handler returned wrong status code: got %v want %v", w.Code, test.expectedStatus))
+		})
+	}
+}
@@ -0,0 +1,82 @@
+package httphandler
+
+import (
+	"database/sql"
+	"net/http"
+	"time"
+
+	"github.com/rsmaxwell/players-api/internal/debug"
+	"github.com/rsmaxwell/players-api/internal/model"
+)
+
+// PendingRegistration structure
+type PendingRegistration struct {
+	Person     model.Person `json:"person"`
+	Registered time.Time    `json:"registered"`
+	Verified   bool         `json:"verified"`
+}
+
+// ListPendingRegistrationsResponse structure
+type ListPendingRegistrationsResponse struct {
+	Message       string                `json:"message"`
+	Registrations []PendingRegistration `json:"registrations"`
+}
+
+var (
+	functionListPendingRegistrations = debug.NewFunction(pkg, "ListPendingRegistrations")
+)
+
+// ListPendingRegistrations method returns the registrations waiting for an
+// admin to approve or reject them, oldest first
+func ListPendingRegistrations(writer http.ResponseWriter, request *http.Request) {
+	f := functionListPendingRegistrations
+
+	_, err := checkAuthenticated(request)
+	if err != nil {
+		writeResponseError(writer, request, err)
+		return
+	}
+
+	object := request.Context().Value(ContextDatabaseKey)
+	db, ok := object.(*sql.DB)
+	if !ok {
+		message := "unexpected context type"
+		Dump(f, request, message)
+		writeResponseMessage(writer, request, http.StatusInternalServerError, message)
+		return
+	}
+
+	version, err := model.GetVersion(request.Context(), db)
+	if err != nil {
+		writeResponseError(writer, request, err)
+		return
+	}
+
+	approvals, err := model.ListPendingApprovals(request.Context(), db)
+	if err != nil {
+		writeResponseError(writer, request, err)
+		return
+	}
+
+	list := []PendingRegistration{}
+	for _, approval := range approvals {
+		person := model.FullPerson{ID: approval.Person}
+		err = person.LoadPerson(request.Context(), db)
+		if err != nil {
+			writeResponseError(writer, request, err)
+			return
+		}
+
+		list = append(list, PendingRegistration{
+			Person:     *person.ToLimited(),
+			Registered: approval.Registered,
+			Verified:   approval.Verified != nil,
+		})
+	}
+
+	setETag(writer, version)
+	writeResponseObject(writer, request, http.StatusOK, ListPendingRegistrationsResponse{
+		Message:       "ok",
+		Registrations: list,
+	})
+}
@@ -16,26 +16,27 @@ type ListPeopleRequest struct {
 }
 
 // ListPeopleResponse structure
-// type ListPeopleResponse struct {
-// 	Message string                `json:"message"`
-// 	People  []model.LimitedPerson `json:"people"`
-// }
+type ListPeopleResponse struct {
+	Total  int            `json:"total"`
+	Next   string         `json:"next,omitempty"`
+	People []model.Person `json:"people"`
+}
 
 var (
 	functionListPeople = debug.NewFunction(pkg, "ListPeople")
 
-	filters = make(map[string]string)
+	filters = map[string]model.PeopleFilter{
+		"":          {},
+		"all":       {},
+		"players":   {Status: model.StatusPlayer},
+		"inactive":  {Status: model.StatusInactive},
+		"suspended": {Status: model.StatusSuspended},
+	}
 )
 
-func init() {
-	filters[""] = ``
-	filters["all"] = ``
-	filters["players"] = `WHERE status = 'player'`
-	filters["inactive"] = `WHERE status = 'inactive'`
-	filters["suspended"] = `WHERE status = 'suspended'`
-}
-
-// ListPeople method
+// ListPeople method returns a page of people. The people may be filtered by
+// the 'filter' query parameter, and by 'search' for text in their names or
+// email. They are sorted by 'name', 'id' or 'status'
 func ListPeople(writer http.ResponseWriter, request *http.Request) {
 	f := functionListPeople
 
@@ -47,13 +48,19 @@ func ListPeople(writer http.ResponseWriter, request *http.Request) {
 
 	filter := request.URL.Query().Get("filter")
 
-	var whereClause string
-	var ok bool
-	if whereClause, ok = filters[filter]; !ok {
+	peopleFilter, ok := filters[filter]
+	if !ok {
 		message := fmt.Sprintf("unexpected filter name: '%s'", filter)
 		writeResponseMessage(writer, request, http.StatusBadRequest, message)
 		return
 	}
+	peopleFilter.Search = request.URL.Query().Get("search")
+
+	page, err := getPage(request)
+	if err != nil {
+		writeResponseError(writer, request, err)
+		return
+	}
 
 	object := request.Context().Value(ContextDatabaseKey)
 	db, ok := object.(*sql.DB)
@@ -64,7 +71,13 @@ func ListPeople(writer http.ResponseWriter, request *http.Request) {
 		return
 	}
 
-	list, err := model.ListPeople(context.Background(), db, whereClause)
+	version, err := model.GetVersion(request.Context(), db)
+	if err != nil {
+		writeResponseError(writer, request, err)
+		return
+	}
+
+	list, total, err := model.ListPeoplePage(context.Background(), db, peopleFilter, page)
 	if err != nil {
 		message := "problem listing people"
 		DumpError(f, request, err, message)
@@ -72,10 +85,13 @@ func ListPeople(writer http.ResponseWriter, request *http.Request) {
 		return
 	}
 
-	var listOfPeople []model.Person
+	listOfPeople := make([]model.Person, 0, len(list))
 	for _, person := range list {
 		listOfPeople = append(listOfPeople, *person.ToLimited())
 	}
 
-	writeResponseObject(writer, request, http.StatusOK, listOfPeople)
+	response := ListPeopleResponse{Total: total, Next: nextCursor(page, len(list), total), People: listOfPeople}
+
+	setETag(writer, version)
+	writeResponseObject(writer, request, http.StatusOK, response)
 }
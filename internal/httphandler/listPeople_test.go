@@ -1,13 +1,13 @@
 package httphandler
 
 import (
-	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"testing"
 	"time"
 
@@ -31,8 +31,8 @@ func TestListPeople(t *testing.T) {
 	// ***************************************************************
 	// * Get a list of all the people
 	// ***************************************************************
-	//allPeople, err := model.FindPeople(db, "all")
-	//require.Nil(t, err, "err should be nothing")
+	everyone, err := model.ListPeople(context.Background(), db, model.PeopleFilter{})
+	require.Nil(t, err, "err should be nothing")
 
 	// ***************************************************************
 	// * Testcases
@@ -44,9 +44,10 @@ func TestListPeople(t *testing.T) {
 		signonCookie           *http.Cookie
 		setAuthorizationHeader bool
 		accessToken            string
-		filter                 string
+		query                  string
 		expectedStatus         int
-		expectedResult         []model.Person
+		expectedTotal          int
+		expectedNext           string
 	}{
 		// {
 		// 	testName:               "Good request",
@@ -64,9 +65,68 @@ func TestListPeople(t *testing.T) {
 			signonCookie:           signonCookie,
 			setAuthorizationHeader: true,
 			accessToken:            accessToken,
-			filter:                 "junk",
+			query:                  "filter=junk",
+			expectedStatus:         http.StatusBadRequest,
+		},
+		{
+			testName:               "First page",
+			setSignonCookie:        true,
+			signonCookie:           signonCookie,
+			setAuthorizationHeader: true,
+			accessToken:            accessToken,
+			query:                  "sort=id&limit=2",
+			expectedStatus:         http.StatusOK,
+			expectedTotal:          len(everyone),
+			expectedNext:           "2",
+		},
+		{
+			testName:               "Last page",
+			setSignonCookie:        true,
+			signonCookie:           signonCookie,
+			setAuthorizationHeader: true,
+			accessToken:            accessToken,
+			query:                  fmt.Sprintf("sort=id&order=desc&limit=2&cursor=%d", len(everyone)-1),
+			expectedStatus:         http.StatusOK,
+			expectedTotal:          len(everyone),
+			expectedNext:           "",
+		},
+		{
+			testName:               "Search",
+			setSignonCookie:        true,
+			signonCookie:           signonCookie,
+			setAuthorizationHeader: true,
+			accessToken:            accessToken,
+			query:                  "search=" + url.QueryEscape(model.GoodEmail),
+			expectedStatus:         http.StatusOK,
+			expectedTotal:          1,
+			expectedNext:           "",
+		},
+		{
+			testName:               "Bad sort key",
+			setSignonCookie:        true,
+			signonCookie:           signonCookie,
+			setAuthorizationHeader: true,
+			accessToken:            accessToken,
+			query:                  "sort=hash",
+			expectedStatus:         http.StatusBadRequest,
+		},
+		{
+			testName:               "Bad cursor",
+			setSignonCookie:        true,
+			signonCookie:           signonCookie,
+			setAuthorizationHeader: true,
+			accessToken:            accessToken,
+			query:                  "cursor=junk",
+			expectedStatus:         http.StatusBadRequest,
+		},
+		{
+			testName:               "Bad limit",
+			setSignonCookie:        true,
+			signonCookie:           signonCookie,
+			setAuthorizationHeader: true,
+			accessToken:            accessToken,
+			query:                  fmt.Sprintf("limit=%d", MaxPageLimit+1),
 			expectedStatus:         http.StatusBadRequest,
-			expectedResult:         nil,
 		},
 	}
 
@@ -82,11 +142,8 @@ func TestListPeople(t *testing.T) {
 			w := httptest.NewRecorder()
 
 			// Create a request
-			requestBody, err := json.Marshal(ListPeopleRequest{Filter: test.filter})
-			require.Nil(t, err, "err should be nothing")
-
-			command := "/people"
-			r, err := http.NewRequest("POST", contextPath+command, bytes.NewBuffer(requestBody))
+			command := "/people?" + test.query
+			r, err := http.NewRequest("GET", contextPath+command, nil)
 			require.Nil(t, err, "err should be nothing")
 
 			if test.setSignonCookie {
@@ -117,16 +174,13 @@ func TestListPeople(t *testing.T) {
 				bytes, err := ioutil.ReadAll(w.Body)
 				require.Nil(t, err, "err should be nothing")
 
-				var response []model.Person
+				var response ListPeopleResponse
 				err = json.Unmarshal(bytes, &response)
 				require.Nil(t, err, "err should be nothing")
 
 				// Check the response body is what we expect.
-				// if !model.EqualIntArray(response.People, test.expectedResult) {
-				// 	t.Logf("actual:   %v", response.People)
-				// 	t.Logf("expected: %v", test.expectedResult)
-				// 	t.Errorf("Unexpected list of people")
-				// }
+				require.Equal(t, test.expectedTotal, response.Total, "unexpected total")
+				require.Equal(t, test.expectedNext, response.Next, "unexpected next cursor")
 			} else if w.Code == http.StatusBadRequest {
 				bytes, err := ioutil.ReadAll(w.Body)
 				require.Nil(t, err, "err should be nothing")
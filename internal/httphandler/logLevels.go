@@ -0,0 +1,66 @@
+package httphandler
+
+import (
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/rsmaxwell/players-api/internal/debug"
+)
+
+var (
+	functionUpdateLogLevels = debug.NewFunction(pkg, "UpdateLogLevels")
+)
+
+// GetLogLevels method returns the log format, the overall trace level, and
+// the levels of the packages and functions which differ from the default
+func GetLogLevels(writer http.ResponseWriter, request *http.Request) {
+
+	_, err := checkAuthenticated(request)
+	if err != nil {
+		writeResponseError(writer, request, err)
+		return
+	}
+
+	writeResponseObject(writer, request, http.StatusOK, debug.GetLevels())
+}
+
+// UpdateLogLevels method changes the log format, the overall trace level, or
+// the levels of packages, such as "model", or functions, such as
+// "model.ListCourts". Nothing is changed if any of them is unknown
+func UpdateLogLevels(writer http.ResponseWriter, request *http.Request) {
+	f := functionUpdateLogLevels
+
+	_, err := checkAuthenticated(request)
+	if err != nil {
+		writeResponseError(writer, request, err)
+		return
+	}
+
+	limitedReader := &io.LimitedReader{R: request.Body, N: 20 * 1024}
+	b, err := ioutil.ReadAll(limitedReader)
+	if err != nil {
+		writeResponseMessage(writer, request, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	DebugRequestBody(f, request, b)
+
+	var levels debug.Levels
+	err = json.Unmarshal(b, &levels)
+	if err != nil {
+		writeResponseMessage(writer, request, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	err = debug.SetLevels(levels)
+	if err != nil {
+		writeResponseMessage(writer, request, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	f.Log(debug.InfoLevel, requestFields(request), "log levels changed: %s", string(b))
+
+	writeResponseObject(writer, request, http.StatusOK, debug.GetLevels())
+}
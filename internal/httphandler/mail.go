@@ -0,0 +1,85 @@
+package httphandler
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+
+	"github.com/rsmaxwell/players-api/internal/config"
+	"github.com/rsmaxwell/players-api/internal/mailer"
+	"github.com/rsmaxwell/players-api/internal/model"
+)
+
+// NotifyApplicant is the hook which tells a newly registered person whether
+// their registration was approved. By default they are sent a mail
+var NotifyApplicant = mailApplicant
+
+// mailApplicant mails a newly registered person the outcome of their
+// registration
+func mailApplicant(ctx context.Context, cfg *config.Config, person *model.FullPerson, approval *model.Approval) error {
+
+	var subject, body string
+	if approval.Decision == model.DecisionApproved {
+		subject = "Your registration has been approved"
+		body = "Your registration has been approved."
+		if approval.Verified == nil {
+			body += " You can sign in once you have followed the link in the mail which asked you to verify your email address."
+		} else {
+			body += " You can now sign in at " + cfg.ClientURL
+		}
+	} else {
+		subject = "Your registration has not been approved"
+		body = "Sorry, your registration has not been approved."
+		if approval.Reason != "" {
+			body += "\n\nReason: " + approval.Reason
+		}
+	}
+
+	return cfg.Mailer.Send(mailer.Message{
+		To:      person.Email,
+		Subject: subject,
+		Body:    fmt.Sprintf("Hello %s,\n\n%s\n", person.FirstName, body),
+	})
+}
+
+// sendVerificationMail sends a person a link which verifies their email
+// address
+func sendVerificationMail(ctx context.Context, db model.Queryer, cfg *config.Config, person *model.FullPerson) error {
+
+	token, err := model.CreatePersonToken(ctx, db, person.ID, model.PurposeVerifyEmail, cfg.VerifyTokenExpiry)
+	if err != nil {
+		return err
+	}
+
+	link := cfg.ClientURL + "/verifyemail?token=" + url.QueryEscape(token)
+
+	return cfg.Mailer.Send(mailer.Message{
+		To:      person.Email,
+		Subject: "Please verify your email address",
+		Body: fmt.Sprintf("Hello %s,\n\n"+
+			"Please follow the link below to verify your email address. The link can be used once, and expires in %s.\n\n"+
+			"%s\n\n"+
+			"If you did not register, you can ignore this message.\n", person.FirstName, cfg.VerifyTokenExpiry, link),
+	})
+}
+
+// sendPasswordResetMail sends a person a link which lets them choose a new
+// password
+func sendPasswordResetMail(ctx context.Context, db model.Queryer, cfg *config.Config, person *model.FullPerson) error {
+
+	token, err := model.CreatePersonToken(ctx, db, person.ID, model.PurposeResetPassword, cfg.ResetTokenExpiry)
+	if err != nil {
+		return err
+	}
+
+	link := cfg.ClientURL + "/resetpassword?token=" + url.QueryEscape(token)
+
+	return cfg.Mailer.Send(mailer.Message{
+		To:      person.Email,
+		Subject: "Reset your password",
+		Body: fmt.Sprintf("Hello %s,\n\n"+
+			"Please follow the link below to choose a new password. The link can be used once, and expires in %s.\n\n"+
+			"%s\n\n"+
+			"If you did not ask to reset your password, you can ignore this message.\n", person.FirstName, cfg.ResetTokenExpiry, link),
+	})
+}
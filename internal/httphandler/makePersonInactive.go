@@ -1,7 +1,6 @@
 package httphandler
 
 import (
-	"context"
 	"database/sql"
 	"fmt"
 	"net/http"
@@ -32,6 +31,12 @@ func MakePersonInactive(writer http.ResponseWriter, request *http.Request) {
 		return
 	}
 
+	ctx, err := contextWithIfMatch(request)
+	if err != nil {
+		writeResponseError(writer, request, err)
+		return
+	}
+
 	str := mux.Vars(request)["id"]
 	personID, err := strconv.Atoi(str)
 	if err != nil {
@@ -50,7 +55,7 @@ func MakePersonInactive(writer http.ResponseWriter, request *http.Request) {
 		return
 	}
 
-	err = model.MakePersonInactive(context.Background(), db, personID)
+	err = model.MakePersonInactiveTx(ctx, db, getClubID(request), personID)
 	if err != nil {
 		writeResponseError(writer, request, err)
 		return
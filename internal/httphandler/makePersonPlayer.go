@@ -31,6 +31,12 @@ func MakePersonPlayer(writer http.ResponseWriter, request *http.Request) {
 		return
 	}
 
+	ctx, err := contextWithIfMatch(request)
+	if err != nil {
+		writeResponseError(writer, request, err)
+		return
+	}
+
 	str := mux.Vars(request)["id1"]
 	personID, err := strconv.Atoi(str)
 	if err != nil {
@@ -49,7 +55,7 @@ func MakePersonPlayer(writer http.ResponseWriter, request *http.Request) {
 		return
 	}
 
-	err = model.MakePersonPlayerTx(db, personID)
+	err = model.MakePersonPlayerTx(ctx, db, getClubID(request), personID)
 	if err != nil {
 		writeResponseError(writer, request, err)
 		return
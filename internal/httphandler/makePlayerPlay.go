@@ -31,6 +31,12 @@ func MakePlayerPlay(writer http.ResponseWriter, request *http.Request) {
 		return
 	}
 
+	ctx, err := contextWithIfMatch(request)
+	if err != nil {
+		writeResponseError(writer, request, err)
+		return
+	}
+
 	str := mux.Vars(request)["id1"]
 	personID, err := strconv.Atoi(str)
 	if err != nil {
@@ -69,12 +75,7 @@ func MakePlayerPlay(writer http.ResponseWriter, request *http.Request) {
 		return
 	}
 
-	if position >= model.NumberOfCourtPositions {
-		writeResponseMessage(writer, request, http.StatusBadRequest, fmt.Sprintf("unexpected position: [%d]", position))
-		return
-	}
-
-	err = model.MakePlayerPlayTx(db, personID, courtID, position)
+	err = model.MakePlayerPlayTx(ctx, db, getClubID(request), personID, courtID, position)
 	if err != nil {
 		writeResponseError(writer, request, err)
 		return
@@ -31,6 +31,12 @@ func MakePlayerWait(writer http.ResponseWriter, request *http.Request) {
 		return
 	}
 
+	ctx, err := contextWithIfMatch(request)
+	if err != nil {
+		writeResponseError(writer, request, err)
+		return
+	}
+
 	str := mux.Vars(request)["id"]
 	id, err := strconv.Atoi(str)
 	if err != nil {
@@ -49,7 +55,7 @@ func MakePlayerWait(writer http.ResponseWriter, request *http.Request) {
 		return
 	}
 
-	err = model.MakePlayerWaitTx(db, id)
+	err = model.MakePlayerWaitTx(ctx, db, getClubID(request), id)
 	if err != nil {
 		writeResponseError(writer, request, err)
 		return
@@ -0,0 +1,44 @@
+package httphandler
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"github.com/rsmaxwell/players-api/internal/metrics"
+)
+
+var (
+	requestsTotal   = metrics.Default.NewCounterVec("players_http_requests_total", "Number of HTTP requests, by route and status", "method", "route", "status")
+	requestDuration = metrics.Default.NewHistogramVec("players_http_request_duration_seconds", "Time taken to serve HTTP requests, by route and status", metrics.DefaultBuckets, "method", "route", "status")
+)
+
+// Instrument counts and times the requests. The route is the template of the
+// matched route, so there is one series per route rather than per url
+func Instrument(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		start := time.Now()
+
+		recorder := &StatusRecorder{
+			ResponseWriter: writer,
+			Status:         http.StatusOK,
+		}
+		next.ServeHTTP(recorder, request)
+
+		route := "none"
+		if current := mux.CurrentRoute(request); current != nil {
+			if template, err := current.GetPathTemplate(); err == nil {
+				route = template
+			}
+		}
+		if info := getRequestInfo(request); info != nil {
+			info.Route = route
+		}
+
+		status := strconv.Itoa(recorder.Status)
+		requestsTotal.Inc(request.Method, route, status)
+		requestDuration.Observe(time.Since(start).Seconds(), request.Method, route, status)
+	})
+}
@@ -3,33 +3,65 @@ package httphandler
 import (
 	"context"
 	"net/http"
+	"sync/atomic"
 	"time"
 )
 
 var (
-	requestCounter = 0
+	requestCounter int64 = 0
 )
 
 func nextRequestID() int {
-	requestCounter++
-	return requestCounter
+	return int(atomic.AddInt64(&requestCounter, 1))
+}
+
+// requestInfo is what is known about a request, for its log records. The
+// user and route are filled in as the request is handled
+type requestInfo struct {
+	ID     int
+	UserID int
+	Route  string
 }
 
 type MyContext struct {
 	handler http.Handler
+	timeout time.Duration
 }
 
-func AddRequestContext(handlerToWrap http.Handler) *MyContext {
-	return &MyContext{handler: handlerToWrap}
+// AddRequestContext gives each request an ID, and a context which ends after
+// the timeout, unless it is zero. The event streams are not timed out, as
+// they are meant to stay open
+func AddRequestContext(handlerToWrap http.Handler, timeout time.Duration) *MyContext {
+	return &MyContext{handler: handlerToWrap, timeout: timeout}
 }
 
 func (h *MyContext) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 
-	ctx1, cancel := context.WithTimeout(r.Context(), time.Duration(60*time.Second))
-	defer cancel()
+	ctx1 := r.Context()
+	if h.timeout > 0 && !isStreamRequest(r) {
+		var cancel context.CancelFunc
+		ctx1, cancel = context.WithTimeout(ctx1, h.timeout)
+		defer cancel()
+	}
 
-	ctx2 := context.WithValue(ctx1, ContextRequestIdKey, nextRequestID())
+	ctx2 := context.WithValue(ctx1, ContextRequestKey, &requestInfo{ID: nextRequestID()})
 	r3 := r.WithContext(ctx2)
 
 	h.handler.ServeHTTP(w, r3)
 }
+
+// getRequestInfo returns what is known about the request, or nothing when
+// the request did not pass through AddRequestContext
+func getRequestInfo(request *http.Request) *requestInfo {
+	info, _ := request.Context().Value(ContextRequestKey).(*requestInfo)
+	return info
+}
+
+// getRequestID returns the ID of the request, or zero when it has none
+func getRequestID(request *http.Request) int {
+	info := getRequestInfo(request)
+	if info == nil {
+		return 0
+	}
+	return info.ID
+}
@@ -2,17 +2,19 @@ package httphandler
 
 import (
 	"net/http"
+	"time"
 
 	"github.com/rsmaxwell/players-api/internal/debug"
 )
 
 var (
-	functionHandlerFunc = debug.NewFunction(pkg, "HandlerFunc")
+	functionAccessLog = debug.NewFunction(pkg, "AccessLog")
 )
 
 type StatusRecorder struct {
 	http.ResponseWriter
 	Status int
+	Bytes  int
 }
 
 func (r *StatusRecorder) WriteHeader(status int) {
@@ -20,11 +22,32 @@ func (r *StatusRecorder) WriteHeader(status int) {
 	r.ResponseWriter.WriteHeader(status)
 }
 
+// Write passes the body on to the wrapped writer, counting the bytes
+func (r *StatusRecorder) Write(data []byte) (int, error) {
+	n, err := r.ResponseWriter.Write(data)
+	r.Bytes += n
+	return n, err
+}
+
+// Flush passes on a flush to the wrapped writer, so responses can be streamed
+func (r *StatusRecorder) Flush() {
+	if flusher, ok := r.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// Unwrap returns the wrapped writer, so its other methods can be reached
+func (r *StatusRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
+
+// WithLogging writes an access log record for each request, once it has been
+// served
 func WithLogging(h http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, request *http.Request) {
-		f := functionHandlerFunc
+		f := functionAccessLog
 
-		DebugRequest(f, request)
+		start := time.Now()
 
 		recorder := &StatusRecorder{
 			ResponseWriter: w,
@@ -32,6 +55,19 @@ func WithLogging(h http.Handler) http.Handler {
 		}
 		h.ServeHTTP(recorder, request)
 
-		DebugResponse(f, request, recorder.Status)
+		fields := requestFields(request)
+		if fields == nil {
+			fields = debug.Fields{}
+		}
+		fields["method"] = request.Method
+		fields["route"] = "none"
+		if info := getRequestInfo(request); info != nil && info.Route != "" {
+			fields["route"] = info.Route
+		}
+		fields["status"] = recorder.Status
+		fields["bytes"] = recorder.Bytes
+		fields["duration"] = time.Since(start).Seconds()
+
+		f.Log(debug.InfoLevel, fields, "%s %s", request.Method, request.URL.Path)
 	})
 }
@@ -0,0 +1,47 @@
+package httphandler
+
+import (
+	"database/sql"
+	"net/http"
+
+	"github.com/rsmaxwell/players-api/internal/debug"
+	"github.com/rsmaxwell/players-api/internal/model"
+)
+
+var (
+	functionOpenSession = debug.NewFunction(pkg, "OpenSession")
+)
+
+// OpenSession method opens a new session, so people can check in
+func OpenSession(writer http.ResponseWriter, request *http.Request) {
+	f := functionOpenSession
+
+	_, err := checkAuthenticated(request)
+	if err != nil {
+		writeResponseError(writer, request, err)
+		return
+	}
+
+	ctx, err := contextWithIfMatch(request)
+	if err != nil {
+		writeResponseError(writer, request, err)
+		return
+	}
+
+	object := request.Context().Value(ContextDatabaseKey)
+	db, ok := object.(*sql.DB)
+	if !ok {
+		message := "unexpected context type"
+		Dump(f, request, message)
+		writeResponseMessage(writer, request, http.StatusInternalServerError, message)
+		return
+	}
+
+	session, err := model.OpenSessionTx(ctx, db, getClubID(request))
+	if err != nil {
+		writeResponseError(writer, request, err)
+		return
+	}
+
+	writeResponseObject(writer, request, http.StatusOK, session)
+}
@@ -0,0 +1,65 @@
+package httphandler
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/rsmaxwell/players-api/internal/codeerror"
+	"github.com/rsmaxwell/players-api/internal/model"
+)
+
+const (
+	// DefaultPageLimit is the number of items in a page, unless the request
+	// says otherwise
+	DefaultPageLimit = 50
+
+	// MaxPageLimit is the most items in a page
+	MaxPageLimit = 500
+)
+
+// getPage returns the page selected by the 'sort', 'order', 'cursor' and
+// 'limit' query parameters. The cursor is the 'next' value from the
+// previous page, and is opaque to the client
+func getPage(request *http.Request) (model.Page, error) {
+
+	query := request.URL.Query()
+
+	page := model.Page{Sort: query.Get("sort"), Limit: DefaultPageLimit}
+
+	switch order := query.Get("order"); order {
+	case "", "asc":
+	case "desc":
+		page.Descending = true
+	default:
+		return page, codeerror.NewBadRequest(fmt.Sprintf("unexpected order: '%s'", order))
+	}
+
+	if str := query.Get("cursor"); str != "" {
+		offset, err := strconv.Atoi(str)
+		if err != nil || offset < 0 {
+			return page, codeerror.NewBadRequest(fmt.Sprintf("unexpected cursor: '%s'", str))
+		}
+		page.Offset = offset
+	}
+
+	if str := query.Get("limit"); str != "" {
+		limit, err := strconv.Atoi(str)
+		if err != nil || limit < 1 || limit > MaxPageLimit {
+			return page, codeerror.NewBadRequest(fmt.Sprintf("the limit [%s] is not between 1 and %d", str, MaxPageLimit))
+		}
+		page.Limit = limit
+	}
+
+	return page, nil
+}
+
+// nextCursor returns the cursor for the page after the one holding count
+// items, or nothing when there are no more items
+func nextCursor(page model.Page, count int, total int) string {
+	next := page.Offset + count
+	if count == 0 || next >= total {
+		return ""
+	}
+	return strconv.Itoa(next)
+}
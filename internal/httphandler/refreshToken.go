@@ -1,6 +1,7 @@
 package httphandler
 
 import (
+	"database/sql"
 	"fmt"
 	"net/http"
 	"time"
@@ -9,6 +10,7 @@ import (
 	"github.com/rsmaxwell/players-api/internal/codeerror"
 	"github.com/rsmaxwell/players-api/internal/config"
 	"github.com/rsmaxwell/players-api/internal/debug"
+	"github.com/rsmaxwell/players-api/internal/model"
 )
 
 var (
@@ -21,43 +23,36 @@ type GetRefreshTokensResponse struct {
 	AccessToken string `json:"accessToken"`
 }
 
-// RefreshToken method
+// RefreshToken method replaces the refresh token held in the cookie with a
+// new one, and returns a new access token. Each refresh token can only be
+// used once: presenting one again revokes all the tokens which followed it
 func RefreshToken(writer http.ResponseWriter, request *http.Request) {
 	f := functionRefreshToken
 
-	_, err := checkAuthenticated(request)
+	userID, err := checkAuthenticated(request)
 	if err != nil {
 		writeResponseError(writer, request, err)
 		return
 	}
 
-	session, err := store.Get(request, "players-api")
-	if err != nil {
-		writeResponseError(writer, request, err)
-		return
-	}
-
-	tokenObject := session.Values["refreshToken"]
-	tokenString, ok := tokenObject.(string)
-	if !ok {
+	tokenString := getRefreshCookie(request)
+	if tokenString == "" {
 		DebugVerbose(f, request, "refreshToken not found")
 		err = codeerror.NewUnauthorized("Unauthorized")
 		writeResponseError(writer, request, err)
 		return
 	}
 
-	claims, err := basic.ValidateToken(tokenString)
-	if err != nil {
-		DebugVerbose(f, request, "refreshToken not valid: %s", err.Error())
-		err = codeerror.NewUnauthorized("Unauthorized")
-		writeResponseError(writer, request, err)
+	object := request.Context().Value(ContextDatabaseKey)
+	db, ok := object.(*sql.DB)
+	if !ok {
+		message := "unexpected context type"
+		Dump(f, request, message)
+		writeResponseMessage(writer, request, http.StatusInternalServerError, message)
 		return
 	}
 
-	// *********************************************************************
-	// * Create a new access token
-	// *********************************************************************
-	object := request.Context().Value(ContextConfigKey)
+	object = request.Context().Value(ContextConfigKey)
 	cfg, ok := object.(*config.Config)
 	if !ok {
 		message := fmt.Sprintf("unexpected context type: %#v", cfg)
@@ -66,8 +61,28 @@ func RefreshToken(writer http.ResponseWriter, request *http.Request) {
 		return
 	}
 
+	personID, newRefreshToken, err := model.RotateRefreshTokenTx(request.Context(), db, tokenString, cfg.RefreshTokenExpiry)
+	if err != nil {
+		DebugVerbose(f, request, "refreshToken not valid: %s", err.Error())
+		clearRefreshCookie(writer)
+		writeResponseError(writer, request, err)
+		return
+	}
+
+	if personID != userID {
+		DebugVerbose(f, request, "refreshToken for person [%d] used by person [%d]", personID, userID)
+		clearRefreshCookie(writer)
+		writeResponseError(writer, request, codeerror.NewUnauthorized("Unauthorized"))
+		return
+	}
+
+	setRefreshCookie(writer, newRefreshToken, cfg.RefreshTokenExpiry)
+
+	// *********************************************************************
+	// * Create a new access token
+	// *********************************************************************
 	f.DebugVerbose("accessTokenExpiry:  %10s     expires at: %s", cfg.AccessTokenExpiry, time.Now().Add(cfg.AccessTokenExpiry))
-	newAccessToken, err := basic.GenerateToken(claims.ID, claims.Request, cfg.AccessTokenExpiry)
+	newAccessToken, err := basic.GenerateToken(personID, getRequestID(request), cfg.AccessTokenExpiry)
 	if err != nil {
 		writeResponseError(writer, request, err)
 		return
@@ -3,12 +3,14 @@ package httphandler
 import (
 	"database/sql"
 	"encoding/json"
+	"fmt"
 	"io"
 	"io/ioutil"
 	"net/http"
 
 	"github.com/jackc/pgx"
 	"github.com/rsmaxwell/players-api/internal/codeerror"
+	"github.com/rsmaxwell/players-api/internal/config"
 	"github.com/rsmaxwell/players-api/internal/model"
 
 	"github.com/rsmaxwell/players-api/internal/debug"
@@ -23,7 +25,9 @@ var (
 	functionRegister = debug.NewFunction(pkg, "Register")
 )
 
-// Register method
+// Register method. A newly registered person is suspended until an admin
+// approves their registration and they follow the link in the verification
+// mail
 func Register(writer http.ResponseWriter, request *http.Request) {
 	f := functionRegister
 
@@ -58,7 +62,16 @@ func Register(writer http.ResponseWriter, request *http.Request) {
 		return
 	}
 
-	err = p.SavePersonTx(db)
+	object = request.Context().Value(ContextConfigKey)
+	cfg, ok := object.(*config.Config)
+	if !ok {
+		message := fmt.Sprintf("unexpected context type: %#v", cfg)
+		Dump(f, request, message)
+		writeResponseMessage(writer, request, http.StatusInternalServerError, message)
+		return
+	}
+
+	err = p.RegisterPersonTx(db)
 	if err != nil {
 		pgx, ok := err.(pgx.PgError)
 		if ok {
@@ -69,5 +82,14 @@ func Register(writer http.ResponseWriter, request *http.Request) {
 		return
 	}
 
+	// The person is registered even if the mail cannot be sent: an admin
+	// can still update their status
+	if p.Status == model.StatusSuspended {
+		err = sendVerificationMail(request.Context(), db, cfg, p)
+		if err != nil {
+			DebugError(f, request, "could not send the verification mail to person [%d]: %s", p.ID, err.Error())
+		}
+	}
+
 	writeResponseMessage(writer, request, http.StatusOK, "ok")
 }
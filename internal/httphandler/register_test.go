@@ -24,6 +24,7 @@ func TestRegister(t *testing.T) {
 	defer teardown(t)
 
 	ctx := context.Background()
+	cfg, mailer := NewTestConfig()
 
 	tests := []struct {
 		testName       string
@@ -71,7 +72,7 @@ func TestRegister(t *testing.T) {
 	for _, test := range tests {
 		t.Run(test.testName, func(t *testing.T) {
 
-			listOfPeople, err := model.ListPeople(ctx, db, "")
+			listOfPeople, err := model.ListPeople(ctx, db, model.PeopleFilter{})
 			require.Nil(t, err)
 			initialNumberOfPeople := len(listOfPeople)
 
@@ -96,6 +97,7 @@ func TestRegister(t *testing.T) {
 			r2 := r.WithContext(ctx)
 
 			ctx = context.WithValue(r2.Context(), ContextDatabaseKey, db)
+			ctx = context.WithValue(ctx, ContextConfigKey, cfg)
 			r3 := r.WithContext(ctx)
 
 			// ---------------------------------------
@@ -106,7 +108,7 @@ func TestRegister(t *testing.T) {
 			// Check the response
 			if w.Code == http.StatusOK {
 
-				listOfPeople, err = model.ListPeople(ctx, db, "")
+				listOfPeople, err = model.ListPeople(ctx, db, model.PeopleFilter{})
 				require.Nil(t, err)
 				finalNumberOfPeople := len(listOfPeople)
 
@@ -119,6 +121,9 @@ func TestRegister(t *testing.T) {
 					require.Equal(t, model.StatusAdmin, p.Status, "Unexpected role")
 				} else {
 					require.Equal(t, model.StatusSuspended, p.Status, "Unexpected role")
+
+					// A link to verify the email address was sent
+					GetMailToken(t, mailer, test.registration.Email)
 				}
 			}
 
@@ -0,0 +1,64 @@
+package httphandler
+
+import (
+	"database/sql"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/rsmaxwell/players-api/internal/debug"
+	"github.com/rsmaxwell/players-api/internal/model"
+)
+
+// ResetPasswordRequest structure
+type ResetPasswordRequest struct {
+	Token    string `json:"token"`
+	Password string `json:"password"`
+}
+
+var (
+	functionResetPassword = debug.NewFunction(pkg, "ResetPassword")
+)
+
+// ResetPassword method sets a new password, using the token from a password
+// reset mail
+func ResetPassword(writer http.ResponseWriter, request *http.Request) {
+	f := functionResetPassword
+
+	limitedReader := &io.LimitedReader{R: request.Body, N: 20 * 1024}
+	b, err := ioutil.ReadAll(limitedReader)
+	if err != nil {
+		writeResponseMessage(writer, request, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	var resetPasswordRequest ResetPasswordRequest
+	err = json.Unmarshal(b, &resetPasswordRequest)
+	if err != nil {
+		writeResponseMessage(writer, request, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if resetPasswordRequest.Token == "" {
+		writeResponseMessage(writer, request, http.StatusBadRequest, "missing token")
+		return
+	}
+
+	object := request.Context().Value(ContextDatabaseKey)
+	db, ok := object.(*sql.DB)
+	if !ok {
+		message := "unexpected context type"
+		Dump(f, request, message)
+		writeResponseMessage(writer, request, http.StatusInternalServerError, message)
+		return
+	}
+
+	err = model.ResetPasswordTx(request.Context(), db, resetPasswordRequest.Token, resetPasswordRequest.Password)
+	if err != nil {
+		writeResponseError(writer, request, err)
+		return
+	}
+
+	writeResponseMessage(writer, request, http.StatusOK, "ok")
+}
@@ -0,0 +1,189 @@
+package httphandler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/require"
+
+	"github.com/rsmaxwell/players-api/internal/model"
+
+	_ "github.com/jackc/pgx/stdlib"
+)
+
+func TestForgotPassword(t *testing.T) {
+
+	teardown, db, _ := model.Setup(t)
+	defer teardown(t)
+
+	cfg, mailer := NewTestConfig()
+
+	tests := []struct {
+		testName       string
+		email          string
+		expectedStatus int
+		expectedMail   bool
+	}{
+		{
+			testName:       "Good request",
+			email:          model.GoodEmail,
+			expectedStatus: http.StatusOK,
+			expectedMail:   true,
+		},
+		{
+			testName:       "Unknown email",
+			email:          "nobody@example.com",
+			expectedStatus: http.StatusOK,
+			expectedMail:   false,
+		},
+		{
+			testName:       "Missing email",
+			email:          "",
+			expectedStatus: http.StatusBadRequest,
+			expectedMail:   false,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.testName, func(t *testing.T) {
+
+			numberOfMessages := len(mailer.Sent())
+
+			// Set up the handlers on the router
+			router := mux.NewRouter()
+			SetupHandlers(router)
+			w := httptest.NewRecorder()
+
+			requestBody, err := json.Marshal(ForgotPasswordRequest{Email: test.email})
+			require.Nil(t, err, "err should be nothing")
+
+			r, err := http.NewRequest(http.MethodPost, contextPath+"/forgotpassword", bytes.NewBuffer(requestBody))
+			require.Nil(t, err, "err should be nothing")
+
+			// ---------------------------------------
+
+			ctx, cancel := context.WithTimeout(r.Context(), time.Duration(60*time.Second))
+			defer cancel()
+			r2 := r.WithContext(ctx)
+
+			ctx = context.WithValue(r2.Context(), ContextDatabaseKey, db)
+			ctx = context.WithValue(ctx, ContextConfigKey, cfg)
+			r3 := r.WithContext(ctx)
+
+			// ---------------------------------------
+
+			// Serve the request
+			router.ServeHTTP(w, r3)
+			require.Equal(t, test.expectedStatus, w.Code, "handler returned wrong status code: got %v want %v", w.Code, test.expectedStatus)
+
+			sent := len(mailer.Sent()) > numberOfMessages
+			require.Equal(t, test.expectedMail, sent, "Unexpected mail")
+		})
+	}
+}
+
+func TestResetPassword(t *testing.T) {
+
+	teardown, db, _ := model.Setup(t)
+	defer teardown(t)
+
+	cfg, mailer := NewTestConfig()
+
+	// ***************************************************************
+	// * Send a password reset mail
+	// ***************************************************************
+	ctx := context.Background()
+	person, err := model.FindPersonByEmail(ctx, db, model.GoodEmail)
+	require.Nil(t, err, "err should be nothing")
+
+	err = sendPasswordResetMail(ctx, db, cfg, person)
+	require.Nil(t, err, "err should be nothing")
+
+	goodToken := GetMailToken(t, mailer, model.GoodEmail)
+	newPassword := "bananas-and-custard"
+
+	// ***************************************************************
+	// * Testcases
+	// ***************************************************************
+	tests := []struct {
+		testName       string
+		token          string
+		password       string
+		expectedStatus int
+	}{
+		{
+			testName:       "Password too short",
+			token:          goodToken,
+			password:       "short",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			testName:       "Good request",
+			token:          goodToken,
+			password:       newPassword,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			testName:       "Token already used",
+			token:          goodToken,
+			password:       newPassword,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			testName:       "Unknown token",
+			token:          "junk",
+			password:       newPassword,
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	// ***************************************************************
+	// * Run the tests
+	// ***************************************************************
+	for _, test := range tests {
+		t.Run(test.testName, func(t *testing.T) {
+
+			// Set up the handlers on the router
+			router := mux.NewRouter()
+			SetupHandlers(router)
+			w := httptest.NewRecorder()
+
+			requestBody, err := json.Marshal(ResetPasswordRequest{Token: test.token, Password: test.password})
+			require.Nil(t, err, "err should be nothing")
+
+			r, err := http.NewRequest(http.MethodPost, contextPath+"/resetpassword", bytes.NewBuffer(requestBody))
+			require.Nil(t, err, "err should be nothing")
+
+			// ---------------------------------------
+
+			ctx, cancel := context.WithTimeout(r.Context(), time.Duration(60*time.Second))
+			defer cancel()
+			r2 := r.WithContext(ctx)
+
+			ctx = context.WithValue(r2.Context(), ContextDatabaseKey, db)
+			r3 := r.WithContext(ctx)
+
+			// ---------------------------------------
+
+			// Serve the request
+			router.ServeHTTP(w, r3)
+			require.Equal(t, test.expectedStatus, w.Code, "handler returned wrong status code: got %v want %v", w.Code, test.expectedStatus)
+		})
+	}
+
+	// The new password works, and the old one does not
+	err = person.LoadPerson(ctx, db)
+	require.Nil(t, err, "err should be nothing")
+
+	err = person.Authenticate(db, newPassword)
+	require.Nil(t, err, "err should be nothing")
+
+	err = person.Authenticate(db, model.GoodPassword)
+	require.NotNil(t, err, "the old password should no longer work")
+}
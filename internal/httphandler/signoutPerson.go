@@ -0,0 +1,59 @@
+package httphandler
+
+import (
+	"database/sql"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/rsmaxwell/players-api/internal/debug"
+	"github.com/rsmaxwell/players-api/internal/model"
+)
+
+var (
+	functionSignoutPerson = debug.NewFunction(pkg, "SignoutPerson")
+)
+
+// SignoutPerson method revokes every refresh token held by a person, so
+// they are signed out on all their devices when their access tokens expire
+func SignoutPerson(writer http.ResponseWriter, request *http.Request) {
+	f := functionSignoutPerson
+
+	_, err := checkAuthenticated(request)
+	if err != nil {
+		writeResponseError(writer, request, err)
+		return
+	}
+
+	str := mux.Vars(request)["id"]
+	personID, err := strconv.Atoi(str)
+	if err != nil {
+		writeResponseMessage(writer, request, http.StatusBadRequest, fmt.Sprintf("the key [%s] is not an int", str))
+		return
+	}
+
+	object := request.Context().Value(ContextDatabaseKey)
+	db, ok := object.(*sql.DB)
+	if !ok {
+		message := "unexpected context type"
+		Dump(f, request, message)
+		writeResponseMessage(writer, request, http.StatusInternalServerError, message)
+		return
+	}
+
+	person := model.FullPerson{ID: personID}
+	err = person.LoadPerson(request.Context(), db)
+	if err != nil {
+		writeResponseError(writer, request, err)
+		return
+	}
+
+	err = model.RevokeRefreshTokens(request.Context(), db, personID)
+	if err != nil {
+		writeResponseError(writer, request, err)
+		return
+	}
+
+	writeResponseMessage(writer, request, http.StatusOK, "ok")
+}
@@ -9,11 +9,15 @@ import (
 	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
+	"regexp"
 	"testing"
 	"time"
 
 	"github.com/gorilla/mux"
+	"github.com/rsmaxwell/players-api/internal/config"
 	"github.com/rsmaxwell/players-api/internal/debug"
+	"github.com/rsmaxwell/players-api/internal/mailer"
 	"github.com/rsmaxwell/players-api/internal/model"
 	"github.com/stretchr/testify/require"
 )
@@ -25,8 +29,48 @@ type Credentials struct {
 
 var (
 	functionBasicAuth = debug.NewFunction(pkg, "BasicAuth")
+
+	mailTokenPattern = regexp.MustCompile(`token=(\S+)`)
 )
 
+// NewTestConfig returns a configuration for the handler tests. Mail is kept
+// by the mailer, rather than sent, and signins are not throttled
+func NewTestConfig() (*config.Config, *mailer.FileMailer) {
+	m := &mailer.FileMailer{From: "players-api@example.com"}
+	cfg := &config.Config{
+		AccessTokenExpiry:  10 * time.Minute,
+		RefreshTokenExpiry: 2 * time.Hour,
+		ClientRefreshDelta: 30 * time.Second,
+		CheckInTokenExpiry: 5 * time.Minute,
+		VerifyTokenExpiry:  24 * time.Hour,
+		ResetTokenExpiry:   time.Hour,
+		Mailer:             m,
+		ClientURL:          "http://localhost:4200",
+	}
+	return cfg, m
+}
+
+// GetMailToken returns the token from the link in the last mail sent to the
+// email address
+func GetMailToken(t *testing.T, m *mailer.FileMailer, email string) string {
+
+	var body string
+	for _, message := range m.Sent() {
+		if message.To == email {
+			body = message.Body
+		}
+	}
+	require.NotEqual(t, "", body, "no mail sent to "+email)
+
+	match := mailTokenPattern.FindStringSubmatch(body)
+	require.NotNil(t, match, "no token in the mail")
+
+	token, err := url.QueryUnescape(match[1])
+	require.Nil(t, err, "err should be nothing")
+
+	return token
+}
+
 // BasicAuth function
 func BasicAuth(username, password string) string {
 	auth := username + ":" + password
@@ -61,7 +105,10 @@ func GetSigninToken(t *testing.T, db *sql.DB, userName string, password string)
 	defer cancel()
 	request2 := request.WithContext(ctx)
 
+	cfg, _ := NewTestConfig()
+
 	ctx = context.WithValue(request2.Context(), ContextDatabaseKey, db)
+	ctx = context.WithValue(ctx, ContextConfigKey, cfg)
 	request3 := request.WithContext(ctx)
 
 	// ---------------------------------------
@@ -0,0 +1,55 @@
+package httphandler
+
+import (
+	"database/sql"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/rsmaxwell/players-api/internal/debug"
+	"github.com/rsmaxwell/players-api/internal/model"
+)
+
+var (
+	functionUnlockPerson = debug.NewFunction(pkg, "UnlockPerson")
+)
+
+// UnlockPerson method lifts the lock on a person's account, which follows
+// too many failed signins, and forgets those failures
+func UnlockPerson(writer http.ResponseWriter, request *http.Request) {
+	f := functionUnlockPerson
+
+	_, err := checkAuthenticated(request)
+	if err != nil {
+		writeResponseError(writer, request, err)
+		return
+	}
+
+	str := mux.Vars(request)["id"]
+	personID, err := strconv.Atoi(str)
+	if err != nil {
+		writeResponseMessage(writer, request, http.StatusBadRequest, fmt.Sprintf("the key [%s] is not an int", str))
+		return
+	}
+
+	object := request.Context().Value(ContextDatabaseKey)
+	db, ok := object.(*sql.DB)
+	if !ok {
+		message := "unexpected context type"
+		Dump(f, request, message)
+		writeResponseMessage(writer, request, http.StatusInternalServerError, message)
+		return
+	}
+
+	person := model.FullPerson{ID: personID}
+	err = person.LoadPerson(request.Context(), db)
+	if err != nil {
+		writeResponseError(writer, request, err)
+		return
+	}
+
+	model.LoginAttempts.Unlock(person.Email)
+
+	writeResponseMessage(writer, request, http.StatusOK, "ok")
+}
@@ -1,7 +1,6 @@
 package httphandler
 
 import (
-	"context"
 	"database/sql"
 	"encoding/json"
 	"fmt"
@@ -29,7 +28,13 @@ var (
 func UpdateCourt(writer http.ResponseWriter, request *http.Request) {
 	f := functionUpdateCourt
 
-	userID, err := checkAuthenticated(request)
+	_, err := checkAuthenticated(request)
+	if err != nil {
+		writeResponseError(writer, request, err)
+		return
+	}
+
+	ctx, err := contextWithIfMatch(request)
 	if err != nil {
 		writeResponseError(writer, request, err)
 		return
@@ -44,20 +49,6 @@ func UpdateCourt(writer http.ResponseWriter, request *http.Request) {
 		return
 	}
 
-	user := model.FullPerson{ID: userID}
-	err = user.LoadPerson(context.Background(), db)
-	if err != nil {
-		message := fmt.Sprintf("Could not load person [%d]", userID)
-		DumpError(f, request, err, message)
-		writeResponseMessage(writer, request, http.StatusInternalServerError, message)
-		return
-	}
-	err = user.CanEditCourt()
-	if err != nil {
-		DebugVerbose(f, request, fmt.Sprintf("Person [%d] is not allowed to edit court", userID))
-		writeResponseMessage(writer, request, http.StatusForbidden, "Forbidden")
-	}
-
 	limitedReader := &io.LimitedReader{R: request.Body, N: 20 * 1024}
 	b, err := ioutil.ReadAll(limitedReader)
 	if err != nil {
@@ -81,7 +72,7 @@ func UpdateCourt(writer http.ResponseWriter, request *http.Request) {
 		return
 	}
 
-	err = model.UpdateCourtFieldsTx(db, courtID, updateCourtRequest.Court)
+	err = model.UpdateCourtFieldsTx(ctx, db, getClubID(request), courtID, updateCourtRequest.Court)
 	if err != nil {
 		writeResponseError(writer, request, err)
 		return
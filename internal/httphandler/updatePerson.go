@@ -26,7 +26,6 @@ var (
 // UpdatePerson method
 func UpdatePerson(writer http.ResponseWriter, request *http.Request) {
 	f := functionUpdatePerson
-	ctx := request.Context()
 
 	userID, err := checkAuthenticated(request)
 	if err != nil {
@@ -36,6 +35,12 @@ func UpdatePerson(writer http.ResponseWriter, request *http.Request) {
 		return
 	}
 
+	ctx, err := contextWithIfMatch(request)
+	if err != nil {
+		writeResponseError(writer, request, err)
+		return
+	}
+
 	object := request.Context().Value(ContextDatabaseKey)
 	db, ok := object.(*sql.DB)
 	if !ok {
@@ -72,6 +77,7 @@ func UpdatePerson(writer http.ResponseWriter, request *http.Request) {
 		DebugInfo(f, request, message)
 		Dump(f, request, message)
 		writeResponseMessage(writer, request, http.StatusBadRequest, message)
+		return
 	}
 
 	user := model.FullPerson{ID: userID}
@@ -81,32 +87,42 @@ func UpdatePerson(writer http.ResponseWriter, request *http.Request) {
 		DebugVerbose(f, request, message)
 		DumpError(f, request, err, message)
 		writeResponseMessage(writer, request, http.StatusInternalServerError, message)
+		return
 	}
 
-	if userID == personID {
-		err = user.CanEditSelf()
-		if err != nil {
-			message := "Forbidden: Not allowed to edit self"
-			DebugVerbose(f, request, message)
-			DumpError(f, request, err, message)
-			writeResponseMessage(writer, request, http.StatusForbidden, "Forbidden")
-		}
-	} else {
-		err = user.CanEditOtherPeople()
-		if err != nil {
-			message := "Forbidden: Not allowed to edit other people"
-			DebugVerbose(f, request, message)
-			DumpError(f, request, err, message)
+	// The route only needs permission to edit yourself
+	if userID != personID && !user.Can(model.PermissionEditPeople) {
+		DebugVerbose(f, request, "Forbidden: Not allowed to edit other people")
+		writeResponseMessage(writer, request, http.StatusForbidden, "Forbidden")
+		return
+	}
+
+	// Nobody may change their own status or skill: a member would otherwise
+	// join the queue without checking in, rate themselves, or lift their own
+	// suspension
+	for _, field := range model.RestrictedPersonFields {
+		if _, ok := updatePersonRequest.Person[field]; ok && !user.Can(model.PermissionEditPeople) {
+			DebugVerbose(f, request, "Forbidden: Not allowed to change the [%s] of a person", field)
 			writeResponseMessage(writer, request, http.StatusForbidden, "Forbidden")
+			return
 		}
 	}
 
-	err = model.UpdatePersonFieldsTx(db, personID, updatePersonRequest.Person)
+	// Nor may they change their own email, which was verified when they
+	// registered: the new address would be used without being verified
+	if val, ok := updatePersonRequest.Person["email"]; ok && val != user.Email && !user.Can(model.PermissionEditPeople) {
+		DebugVerbose(f, request, "Forbidden: Not allowed to change the email of a person")
+		writeResponseMessage(writer, request, http.StatusForbidden, "Forbidden")
+		return
+	}
+
+	err = model.UpdatePersonFieldsTx(ctx, db, personID, updatePersonRequest.Person)
 	if err != nil {
 		message := fmt.Sprintf("problem updating person fields: userID: %d", userID)
 		d := DumpError(f, request, err, message)
 		d.AddObject("request.Person", updatePersonRequest.Person)
 		writeResponseError(writer, request, err)
+		return
 	}
 
 	writeResponseMessage(writer, request, http.StatusOK, "ok")
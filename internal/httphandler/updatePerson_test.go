@@ -31,6 +31,9 @@ func TestUpdatePerson(t *testing.T) {
 	logonCookie, accessToken := GetSigninToken(t, db, model.GoodEmail, model.GoodPassword)
 	goodPerson, _ := model.FindPersonByEmail(ctx, db, model.GoodEmail)
 
+	memberCookie, memberToken := GetSigninToken(t, db, model.AnotherEmail, model.AnotherPassword)
+	member, _ := model.FindPersonByEmail(ctx, db, model.AnotherEmail)
+
 	// ***************************************************************
 	// * Testcases
 	// ***************************************************************
@@ -59,6 +62,49 @@ func TestUpdatePerson(t *testing.T) {
 			},
 			expectedStatus: http.StatusOK,
 		},
+		{
+			testName:               "Unknown status",
+			setLogonCookie:         true,
+			logonCookie:            logonCookie,
+			setAuthorizationHeader: true,
+			accessToken:            accessToken,
+			id:                     goodPerson.ID,
+			person:                 map[string]interface{}{"status": "junk"},
+			expectedStatus:         http.StatusBadRequest,
+		},
+		{
+			testName:               "Status of the wrong type",
+			setLogonCookie:         true,
+			logonCookie:            logonCookie,
+			setAuthorizationHeader: true,
+			accessToken:            accessToken,
+			id:                     goodPerson.ID,
+			person:                 map[string]interface{}{"status": 7},
+			expectedStatus:         http.StatusBadRequest,
+		},
+		{
+			testName:               "Member keeps their email",
+			setLogonCookie:         true,
+			logonCookie:            memberCookie,
+			setAuthorizationHeader: true,
+			accessToken:            memberToken,
+			id:                     member.ID,
+			person: map[string]interface{}{
+				"firstname": member.FirstName,
+				"email":     member.Email,
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			testName:               "Member changes their email",
+			setLogonCookie:         true,
+			logonCookie:            memberCookie,
+			setAuthorizationHeader: true,
+			accessToken:            memberToken,
+			id:                     member.ID,
+			person:                 map[string]interface{}{"email": "unverified@example.com"},
+			expectedStatus:         http.StatusForbidden,
+		},
 		{
 			testName:               "Bad userID",
 			setLogonCookie:         true,
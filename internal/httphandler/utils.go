@@ -1,13 +1,14 @@
 package httphandler
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"strconv"
 	"strings"
 
 	"github.com/gorilla/mux"
-	"github.com/gorilla/sessions"
 
 	"github.com/rsmaxwell/players-api/internal/codeerror"
 	"github.com/rsmaxwell/players-api/internal/debug"
@@ -25,21 +26,19 @@ type ContextKey string
 const (
 	contextPath = "/players-api"
 
-	// Context Keys
-	ContextDatabaseKey  ContextKey = "database"
-	ContextRequestIdKey ContextKey = "requestID"
-	ContextConfigKey    ContextKey = "config"
-)
+	// clubPath is the prefix of the routes of a club, below the context path
+	clubPath = "/clubs/{club}"
 
-var (
-	key   = []byte("<SESSION_SECRET_KEY>")
-	store = sessions.NewCookieStore(key)
+	// Context Keys
+	ContextDatabaseKey ContextKey = "database"
+	ContextRequestKey  ContextKey = "request"
+	ContextConfigKey   ContextKey = "config"
+	ContextClubKey     ContextKey = "club"
 )
 
 var (
 	pkg = debug.NewPackage("httphandler")
 
-	functionGetRequestID       = debug.NewFunction(pkg, "getRequestID")
 	functionHidePasswords      = debug.NewFunction(pkg, "hidePasswords")
 	functionWriteResponseError = debug.NewFunction(pkg, "writeResponseError")
 )
@@ -60,10 +59,48 @@ func writeResponseObject(w http.ResponseWriter, r *http.Request, statusCode int,
 
 // writeResponse method
 func writeResponse(w http.ResponseWriter, r *http.Request, statusCode int) {
-	model.MetricsData.StatusCodes[statusCode]++
 	w.WriteHeader(statusCode)
 }
 
+// setETag writes the state version as the ETag of the response
+func setETag(writer http.ResponseWriter, version int64) {
+	writer.Header().Set("ETag", strconv.Quote(strconv.FormatInt(version, 10)))
+}
+
+// contextWithIfMatch returns the context of the request, carrying the state
+// version given in the If-Match header, if any. Updates made with this
+// context fail with 412 'precondition failed' when the client is out of date
+func contextWithIfMatch(request *http.Request) (context.Context, error) {
+	ctx := request.Context()
+
+	header := strings.TrimSpace(request.Header.Get("If-Match"))
+	if header == "" || header == "*" {
+		return ctx, nil
+	}
+
+	tag := strings.TrimPrefix(header, "W/")
+	str, err := strconv.Unquote(tag)
+	if err != nil {
+		return nil, codeerror.NewBadRequest(fmt.Sprintf("invalid If-Match header: %s", header))
+	}
+
+	version, err := strconv.ParseInt(str, 10, 64)
+	if err != nil {
+		return nil, codeerror.NewBadRequest(fmt.Sprintf("invalid If-Match header: %s", header))
+	}
+
+	return model.WithExpectedVersion(ctx, version), nil
+}
+
+// getClubID returns the club of the request, which Authorize has checked
+func getClubID(request *http.Request) int {
+	clubID, ok := request.Context().Value(ContextClubKey).(int)
+	if !ok {
+		return model.DefaultClubID
+	}
+	return clubID
+}
+
 // writeResponseError function
 func writeResponseError(writer http.ResponseWriter, request *http.Request, err error) {
 	f := functionWriteResponseError
@@ -81,116 +118,120 @@ func writeResponseError(writer http.ResponseWriter, request *http.Request, err e
 func SetupHandlers(w *mux.Router) {
 
 	s := w.PathPrefix("/players-api").Subrouter()
+	s.Use(Instrument, Authorize)
 
 	s.HandleFunc("/register", Register).Methods(http.MethodPost)
 	s.HandleFunc("/signin", Signin).Methods(http.MethodPost)
 	s.HandleFunc("/signout", Signout).Methods(http.MethodGet)
 	s.HandleFunc("/refresh", RefreshToken).Methods(http.MethodPost)
-
-	s.HandleFunc("/waiters", ListWaiters).Methods(http.MethodGet)
+	s.HandleFunc("/jwks", GetJWKS).Methods(http.MethodGet)
+	s.HandleFunc("/verifyemail", VerifyEmail).Methods(http.MethodPost)
+	s.HandleFunc("/forgotpassword", ForgotPassword).Methods(http.MethodPost)
+	s.HandleFunc("/resetpassword", ResetPassword).Methods(http.MethodPost)
+
+	s.HandleFunc("/clubs", ListClubs).Methods(http.MethodGet)
+	s.HandleFunc("/clubs", CreateClub).Methods(http.MethodPost)
+
+	c := s.PathPrefix(clubPath).Subrouter()
+
+	c.HandleFunc("/members", ListClubMembers).Methods(http.MethodGet)
+	c.HandleFunc("/members/{id}", UpdateClubMember).Methods(http.MethodPut)
+
+	// The routes of a club are also served without the club's path, for the
+	// default club
+	for _, r := range []*mux.Router{s, c} {
+		r.HandleFunc("/waiters", ListWaiters).Methods(http.MethodGet)
+		r.HandleFunc("/events", StreamEvents).Methods(http.MethodGet)
+		r.HandleFunc("/games", ListGames).Methods(http.MethodGet)
+		r.HandleFunc("/leaderboard", GetLeaderboard).Methods(http.MethodGet)
+
+		r.HandleFunc("/sessions", OpenSession).Methods(http.MethodPost)
+		r.HandleFunc("/sessions/current", GetCurrentSession).Methods(http.MethodGet)
+		r.HandleFunc("/sessions/close/{id}", CloseSession).Methods(http.MethodPut)
+		r.HandleFunc("/sessions/checkintoken", GetCheckInToken).Methods(http.MethodGet)
+		r.HandleFunc("/checkin", CheckIn).Methods(http.MethodPut)
+		r.HandleFunc("/checkout", CheckOut).Methods(http.MethodPut)
+
+		r.HandleFunc("/people/toplayer/{id1}", MakePersonPlayer).Methods(http.MethodPut)
+		r.HandleFunc("/people/toinactive/{id}", MakePersonInactive).Methods(http.MethodPut)
+		r.HandleFunc("/people/toplaying/{id1}/{id2}/{id3}", MakePlayerPlay).Methods(http.MethodPut)
+		r.HandleFunc("/people/towaiting/{id}", MakePlayerWait).Methods(http.MethodPut)
+
+		r.HandleFunc("/courts", ListCourts).Methods(http.MethodGet)
+		r.HandleFunc("/courts/{id}", GetCourt).Methods(http.MethodGet)
+		r.HandleFunc("/newcourt", CreateCourt).Methods(http.MethodPost)
+		r.HandleFunc("/courts/{id}", UpdateCourt).Methods(http.MethodPut)
+		r.HandleFunc("/courts/{id}", DeleteCourt).Methods(http.MethodDelete)
+		r.HandleFunc("/courts/fill/{id}", FillCourt).Methods(http.MethodPut)
+		r.HandleFunc("/courts/clear/{id}", ClearCourt).Methods(http.MethodPut)
+	}
 
 	s.HandleFunc("/people", Register).Methods(http.MethodPost)
 	s.HandleFunc("/people", ListPeople).Methods(http.MethodGet)
 	s.HandleFunc("/people/{id}", DeletePerson).Methods(http.MethodDelete)
 	s.HandleFunc("/people/{id}", GetPerson).Methods(http.MethodGet)
 	s.HandleFunc("/people/{id}", UpdatePerson).Methods(http.MethodPut)
+	s.HandleFunc("/people/{id}/stats", GetPersonStats).Methods(http.MethodGet)
+	s.HandleFunc("/people/{id}/roles", UpdatePersonRoles).Methods(http.MethodPut)
+	s.HandleFunc("/people/{id}/signout", SignoutPerson).Methods(http.MethodPut)
+	s.HandleFunc("/people/{id}/unlock", UnlockPerson).Methods(http.MethodPut)
 
-	s.HandleFunc("/people/toplayer/{id1}", MakePersonPlayer).Methods(http.MethodPut)
-	s.HandleFunc("/people/toinactive/{id}", MakePersonInactive).Methods(http.MethodPut)
-
-	s.HandleFunc("/people/toplaying/{id1}/{id2}/{id3}", MakePlayerPlay).Methods(http.MethodPut)
-	s.HandleFunc("/people/towaiting/{id}", MakePlayerWait).Methods(http.MethodPut)
-
-	s.HandleFunc("/courts", ListCourts).Methods(http.MethodGet)
-	s.HandleFunc("/courts/{id}", GetCourt).Methods(http.MethodGet)
-	s.HandleFunc("/newcourt", CreateCourt).Methods(http.MethodPost)
-	s.HandleFunc("/courts/{id}", UpdateCourt).Methods(http.MethodPut)
-	s.HandleFunc("/courts/{id}", DeleteCourt).Methods(http.MethodDelete)
-	s.HandleFunc("/courts/fill/{id}", FillCourt).Methods(http.MethodPut)
-	s.HandleFunc("/courts/clear/{id}", ClearCourt).Methods(http.MethodPut)
+	s.HandleFunc("/registrations/pending", ListPendingRegistrations).Methods(http.MethodGet)
+	s.HandleFunc("/registrations/{id}/approve", ApproveRegistration).Methods(http.MethodPut)
+	s.HandleFunc("/registrations/{id}/reject", RejectRegistration).Methods(http.MethodPut)
 
 	s.HandleFunc("/metrics", GetMetrics).Methods(http.MethodGet)
 
-	w.NotFoundHandler = http.HandlerFunc(NotFound)
-}
-
-func DebugRequest(f *debug.Function, request *http.Request) error {
-
-	if f.Level() >= debug.APILevel {
-
-		requestID := getFormattedRequestID(request)
-		f.DebugAPI("%s %s %s %s ------------------------------------------------------", requestID, request.Method, request.Host, request.URL)
-
-		for name, headers := range request.Header {
-			name = strings.ToLower(name)
-			for _, h := range headers {
-				f.DebugVerbose("          %v: %v", name, h)
-			}
-		}
-	}
+	s.HandleFunc("/loglevels", GetLogLevels).Methods(http.MethodGet)
+	s.HandleFunc("/loglevels", UpdateLogLevels).Methods(http.MethodPut)
 
-	return nil
-}
+	s.HandleFunc("/dumps", ListDumps).Methods(http.MethodGet)
+	s.HandleFunc("/dumps", DeleteDumps).Methods(http.MethodDelete)
+	s.HandleFunc("/dumps/{name}", GetDump).Methods(http.MethodGet)
+	s.HandleFunc("/dumps/{name}", DeleteDump).Methods(http.MethodDelete)
 
-func DebugResponse(f *debug.Function, request *http.Request, status int) {
-	if f.Level() >= debug.APILevel {
-		requestID := getFormattedRequestID(request)
-		f.DebugAPI("%s %s %s %s --> statusCode: %d", requestID, request.Method, request.Host, request.URL, status)
-	}
+	w.NotFoundHandler = Instrument(http.HandlerFunc(NotFound))
 }
 
 // DebugRequestBody traces the http request body
 func DebugRequestBody(f *debug.Function, req *http.Request, data []byte) {
 	if f.Level() >= debug.APILevel {
-		requestID := getFormattedRequestID(req)
 		data2, _ := hidePasswords(data)
-		f.DebugVerbose("%s %s", requestID, string(data2))
+		f.Log(debug.VerboseLevel, requestFields(req), "%s", string(data2))
 	}
 }
 
 func DebugInfo(f *debug.Function, req *http.Request, format string, a ...interface{}) {
 	if f.Level() >= debug.VerboseLevel {
-		requestID := getFormattedRequestID(req)
-		message := fmt.Sprintf(format, a...)
-		f.DebugInfo("%s %s", requestID, message)
+		f.Log(debug.InfoLevel, requestFields(req), format, a...)
 	}
 }
 
 func DebugError(f *debug.Function, req *http.Request, format string, a ...interface{}) {
 	if f.Level() >= debug.VerboseLevel {
-		requestID := getFormattedRequestID(req)
-		message := fmt.Sprintf(format, a...)
-		f.DebugError("%s %s", requestID, message)
+		f.Log(debug.ErrorLevel, requestFields(req), format, a...)
 	}
 }
 
 func DebugVerbose(f *debug.Function, req *http.Request, format string, a ...interface{}) {
 	if f.Level() >= debug.VerboseLevel {
-		requestID := getFormattedRequestID(req)
-		message := fmt.Sprintf(format, a...)
-		f.DebugVerbose("%s %s", requestID, message)
+		f.Log(debug.VerboseLevel, requestFields(req), format, a...)
 	}
 }
 
-// DebugRequestBody traces the http request body
-func getRequestID(request *http.Request) int {
-	f := functionGetRequestID
-
-	ctx := request.Context()
-	object := ctx.Value(ContextRequestIdKey)
-	id, ok := object.(int)
-	if !ok {
-		message := fmt.Sprintf("unexpected context type: %#v", id)
-		f.Dump(message)
-		return 0
+// requestFields returns the request ID and user ID of the request, to add
+// to its log records
+func requestFields(request *http.Request) debug.Fields {
+	info := getRequestInfo(request)
+	if info == nil {
+		return nil
 	}
 
-	return id
-}
-
-func getFormattedRequestID(request *http.Request) string {
-	id := getRequestID(request)
-	return fmt.Sprintf("[request:%d]", id)
+	fields := debug.Fields{"requestID": info.ID}
+	if info.UserID != 0 {
+		fields["userID"] = info.UserID
+	}
+	return fields
 }
 
 func hidePasswords(data []byte) ([]byte, error) {
@@ -233,12 +274,12 @@ func walk(input map[string]interface{}) map[string]interface{} {
 
 func Dump(f *debug.Function, request *http.Request, format string, a ...interface{}) *debug.Dump {
 	d := f.Dump(format, a...)
-	d.AddString("RequestID", getFormattedRequestID(request))
+	d.AddObject("request.json", requestFields(request))
 	return d
 }
 
 func DumpError(f *debug.Function, request *http.Request, err error, format string, a ...interface{}) *debug.Dump {
 	d := f.DumpError(err, format, a...)
-	d.AddString("RequestID", getFormattedRequestID(request))
+	d.AddObject("request.json", requestFields(request))
 	return d
 }
@@ -23,7 +23,7 @@ func TestGetLoginToken(t *testing.T) {
 
 func GetFirstCourt(t *testing.T, db *sql.DB) *model.Court {
 
-	listOfCourts, err := model.ListCourtsTx(db)
+	listOfCourts, err := model.ListCourtsTx(db, model.DefaultClubID)
 	require.Nil(t, err, "err should be nothing")
 
 	numberOfCourts := len(listOfCourts)
@@ -0,0 +1,63 @@
+package httphandler
+
+import (
+	"database/sql"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/rsmaxwell/players-api/internal/debug"
+	"github.com/rsmaxwell/players-api/internal/model"
+)
+
+// VerifyEmailRequest structure
+type VerifyEmailRequest struct {
+	Token string `json:"token"`
+}
+
+var (
+	functionVerifyEmail = debug.NewFunction(pkg, "VerifyEmail")
+)
+
+// VerifyEmail method confirms a person's email address, using the token
+// from a verification mail, which lets a newly registered person sign in
+func VerifyEmail(writer http.ResponseWriter, request *http.Request) {
+	f := functionVerifyEmail
+
+	limitedReader := &io.LimitedReader{R: request.Body, N: 20 * 1024}
+	b, err := ioutil.ReadAll(limitedReader)
+	if err != nil {
+		writeResponseMessage(writer, request, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	var verifyEmailRequest VerifyEmailRequest
+	err = json.Unmarshal(b, &verifyEmailRequest)
+	if err != nil {
+		writeResponseMessage(writer, request, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if verifyEmailRequest.Token == "" {
+		writeResponseMessage(writer, request, http.StatusBadRequest, "missing token")
+		return
+	}
+
+	object := request.Context().Value(ContextDatabaseKey)
+	db, ok := object.(*sql.DB)
+	if !ok {
+		message := "unexpected context type"
+		Dump(f, request, message)
+		writeResponseMessage(writer, request, http.StatusInternalServerError, message)
+		return
+	}
+
+	err = model.VerifyEmailTx(request.Context(), db, verifyEmailRequest.Token)
+	if err != nil {
+		writeResponseError(writer, request, err)
+		return
+	}
+
+	writeResponseMessage(writer, request, http.StatusOK, "ok")
+}
@@ -0,0 +1,118 @@
+package httphandler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/require"
+
+	"github.com/rsmaxwell/players-api/internal/model"
+
+	_ "github.com/jackc/pgx/stdlib"
+)
+
+func TestVerifyEmail(t *testing.T) {
+
+	teardown, db, _ := model.Setup(t)
+	defer teardown(t)
+
+	cfg, mailer := NewTestConfig()
+
+	// ***************************************************************
+	// * Register a new person, whose registration is approved, and who
+	// * is sent a verification mail
+	// ***************************************************************
+	ctx := context.Background()
+	registration := model.NewRegistration("James", "Bond", "007", "007@mi6.co.uk", "012345 123456", "topsecret")
+	person, err := registration.ToPerson()
+	require.Nil(t, err, "err should be nothing")
+
+	err = person.RegisterPersonTx(db)
+	require.Nil(t, err, "err should be nothing")
+
+	admin, err := model.FindPersonByEmail(ctx, db, model.GoodEmail)
+	require.Nil(t, err, "err should be nothing")
+
+	_, _, err = model.ApproveRegistrationTx(ctx, db, person.ID, admin.ID, model.StatusInactive)
+	require.Nil(t, err, "err should be nothing")
+
+	err = sendVerificationMail(ctx, db, cfg, person)
+	require.Nil(t, err, "err should be nothing")
+
+	goodToken := GetMailToken(t, mailer, registration.Email)
+
+	// ***************************************************************
+	// * Testcases
+	// ***************************************************************
+	tests := []struct {
+		testName       string
+		token          string
+		expectedStatus int
+		expectedState  string
+	}{
+		{
+			testName:       "Good request",
+			token:          goodToken,
+			expectedStatus: http.StatusOK,
+			expectedState:  model.StatusInactive,
+		},
+		{
+			testName:       "Token already used",
+			token:          goodToken,
+			expectedStatus: http.StatusBadRequest,
+			expectedState:  model.StatusInactive,
+		},
+		{
+			testName:       "Unknown token",
+			token:          "junk",
+			expectedStatus: http.StatusBadRequest,
+			expectedState:  model.StatusInactive,
+		},
+	}
+
+	// ***************************************************************
+	// * Run the tests
+	// ***************************************************************
+	for _, test := range tests {
+		t.Run(test.testName, func(t *testing.T) {
+
+			// Set up the handlers on the router
+			router := mux.NewRouter()
+			SetupHandlers(router)
+			w := httptest.NewRecorder()
+
+			requestBody, err := json.Marshal(VerifyEmailRequest{Token: test.token})
+			require.Nil(t, err, "err should be nothing")
+
+			r, err := http.NewRequest(http.MethodPost, contextPath+"/verifyemail", bytes.NewBuffer(requestBody))
+			require.Nil(t, err, "err should be nothing")
+
+			// ---------------------------------------
+
+			ctx, cancel := context.WithTimeout(r.Context(), time.Duration(60*time.Second))
+			defer cancel()
+			r2 := r.WithContext(ctx)
+
+			ctx = context.WithValue(r2.Context(), ContextDatabaseKey, db)
+			r3 := r.WithContext(ctx)
+
+			// ---------------------------------------
+
+			// Serve the request
+			router.ServeHTTP(w, r3)
+			require.Equal(t, test.expectedStatus, w.Code, "handler returned wrong status code: got %v want %v", w.Code, test.expectedStatus)
+
+			// Check the status of the person
+			p := model.FullPerson{ID: person.ID}
+			err = p.LoadPerson(ctx, db)
+			require.Nil(t, err, "err should be nothing")
+			require.Equal(t, test.expectedState, p.Status, "Unexpected status")
+		})
+	}
+}
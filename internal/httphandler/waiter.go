@@ -40,7 +40,13 @@ func ListWaiters(writer http.ResponseWriter, request *http.Request) {
 		return
 	}
 
-	waiters, err := model.ListWaiters(context.Background(), db)
+	version, err := model.GetVersion(request.Context(), db)
+	if err != nil {
+		writeResponseError(writer, request, err)
+		return
+	}
+
+	waiters, err := model.ListWaiters(context.Background(), db, getClubID(request))
 	if err != nil {
 		writeResponseError(writer, request, err)
 		return
@@ -64,5 +70,6 @@ func ListWaiters(writer http.ResponseWriter, request *http.Request) {
 		list = append(list, w)
 	}
 
+	setETag(writer, version)
 	writeResponseObject(writer, request, http.StatusOK, list)
 }
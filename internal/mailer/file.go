@@ -0,0 +1,71 @@
+package mailer
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/rsmaxwell/players-api/internal/debug"
+)
+
+// FileMailer does not deliver mail. Each message is logged, written to a
+// file in Dir, when set, and kept, so tests and developers can follow the
+// links it holds
+type FileMailer struct {
+	Dir  string
+	From string
+
+	lock     sync.Mutex
+	messages []Message
+}
+
+var (
+	functionFileSend = debug.NewFunction(pkg, "FileMailer.Send")
+)
+
+// Send method
+func (m *FileMailer) Send(message Message) error {
+	f := functionFileSend
+
+	data, err := message.format(m.From)
+	if err != nil {
+		f.DumpError(err, "could not format the message")
+		return err
+	}
+
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	m.messages = append(m.messages, message)
+	f.Infof("mail to [%s]: %s", message.To, message.Subject)
+
+	if m.Dir == "" {
+		return nil
+	}
+
+	err = os.MkdirAll(m.Dir, 0755)
+	if err != nil {
+		f.DumpError(err, "could not create the mail directory [%s]", m.Dir)
+		return err
+	}
+
+	filename := filepath.Join(m.Dir, fmt.Sprintf("%s-%d.eml", time.Now().Format("20060102-150405.000000"), len(m.messages)))
+	err = ioutil.WriteFile(filename, data, 0600)
+	if err != nil {
+		f.DumpError(err, "could not write the message to [%s]", filename)
+		return err
+	}
+
+	return nil
+}
+
+// Sent returns the messages sent so far
+func (m *FileMailer) Sent() []Message {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	return append([]Message{}, m.messages...)
+}
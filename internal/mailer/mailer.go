@@ -0,0 +1,50 @@
+package mailer
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/rsmaxwell/players-api/internal/debug"
+)
+
+// Message type
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Mailer interface. Mail is sent through a Mailer, so the way it is
+// delivered can be chosen in the configuration
+type Mailer interface {
+	Send(message Message) error
+}
+
+var (
+	pkg = debug.NewPackage("mailer")
+)
+
+// format returns the message in RFC 5322 form, refusing headers which
+// could smuggle in other headers
+func (message Message) format(from string) ([]byte, error) {
+
+	for _, header := range []string{from, message.To, message.Subject} {
+		if strings.ContainsAny(header, "\r\n") {
+			return nil, fmt.Errorf("unexpected line break in mail header: %q", header)
+		}
+	}
+
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "From: %s\r\n", from)
+	fmt.Fprintf(&b, "To: %s\r\n", message.To)
+	fmt.Fprintf(&b, "Subject: %s\r\n", message.Subject)
+	fmt.Fprintf(&b, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	fmt.Fprintf(&b, "MIME-Version: 1.0\r\n")
+	fmt.Fprintf(&b, "Content-Type: text/plain; charset=UTF-8\r\n")
+	fmt.Fprintf(&b, "\r\n")
+	b.WriteString(strings.ReplaceAll(message.Body, "\n", "\r\n"))
+
+	return b.Bytes(), nil
+}
@@ -0,0 +1,56 @@
+package mailer
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestFileMailer(t *testing.T) {
+
+	dir, err := ioutil.TempDir("", "mailer")
+	if err != nil {
+		t.Log("Could not create a temporary directory")
+		t.FailNow()
+	}
+	defer os.RemoveAll(dir)
+
+	m := &FileMailer{Dir: dir, From: "players-api@example.com"}
+
+	err = m.Send(Message{To: "someone@example.com", Subject: "Hello", Body: "line 1\nline 2\n"})
+	if err != nil {
+		t.Logf("Could not send the message: %v", err)
+		t.FailNow()
+	}
+
+	files, err := ioutil.ReadDir(dir)
+	if err != nil || len(files) != 1 {
+		t.Log("The message was not written to a file")
+		t.FailNow()
+	}
+
+	data, err := ioutil.ReadFile(filepath.Join(dir, files[0].Name()))
+	if err != nil {
+		t.Log("Could not read the message")
+		t.FailNow()
+	}
+
+	if !strings.Contains(string(data), "To: someone@example.com\r\n") || !strings.HasSuffix(string(data), "line 1\r\nline 2\r\n") {
+		t.Logf("Unexpected message: %q", string(data))
+		t.FailNow()
+	}
+
+	// Line breaks in a header could add headers of their own
+	err = m.Send(Message{To: "someone@example.com\r\nBcc: everyone@example.com", Subject: "Hello"})
+	if err == nil {
+		t.Log("Unexpected success sending a message with a line break in a header")
+		t.FailNow()
+	}
+
+	if len(m.Sent()) != 1 {
+		t.Logf("Unexpected number of messages sent. expected: 1, actual: %d", len(m.Sent()))
+		t.FailNow()
+	}
+}
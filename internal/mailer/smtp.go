@@ -0,0 +1,49 @@
+package mailer
+
+import (
+	"net"
+	"net/smtp"
+	"strconv"
+
+	"github.com/rsmaxwell/players-api/internal/debug"
+)
+
+// SMTPMailer sends mail through an SMTP server. The connection is upgraded
+// with STARTTLS when the server offers it
+type SMTPMailer struct {
+	Host     string
+	Port     int
+	Username string
+	Password string
+	From     string
+}
+
+var (
+	functionSMTPSend = debug.NewFunction(pkg, "SMTPMailer.Send")
+)
+
+// Send method
+func (m *SMTPMailer) Send(message Message) error {
+	f := functionSMTPSend
+
+	data, err := message.format(m.From)
+	if err != nil {
+		f.DumpError(err, "could not format the message")
+		return err
+	}
+
+	var auth smtp.Auth
+	if m.Username != "" {
+		auth = smtp.PlainAuth("", m.Username, m.Password, m.Host)
+	}
+
+	address := net.JoinHostPort(m.Host, strconv.Itoa(m.Port))
+	err = smtp.SendMail(address, auth, m.From, []string{message.To}, data)
+	if err != nil {
+		f.DumpError(err, "could not send mail to [%s] via [%s]", message.To, address)
+		return err
+	}
+
+	f.DebugVerbose("sent mail to [%s]: %s", message.To, message.Subject)
+	return nil
+}
@@ -0,0 +1,159 @@
+package metrics
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+)
+
+// QueryDuration is the time taken by each database query, labelled with the
+// function which made it
+var QueryDuration = Default.NewHistogramVec("players_db_query_duration_seconds", "Time taken by database queries, by the calling function", DefaultBuckets, "function")
+
+var (
+	instrumentLock sync.Mutex
+	instrumented   = make(map[string]string)
+)
+
+// InstrumentDriver registers a driver which wraps the named driver, and
+// times each query it runs. It returns the name of the new driver, to pass
+// to sql.Open
+func InstrumentDriver(name string) (string, error) {
+	instrumentLock.Lock()
+	defer instrumentLock.Unlock()
+
+	if wrapped, ok := instrumented[name]; ok {
+		return wrapped, nil
+	}
+
+	// The driver is found by opening, but not connecting to, a database
+	db, err := sql.Open(name, "")
+	if err != nil {
+		return "", err
+	}
+	d := db.Driver()
+	db.Close()
+
+	wrapped := name + "-instrumented"
+	sql.Register(wrapped, &instrumentedDriver{Driver: d})
+	instrumented[name] = wrapped
+	return wrapped, nil
+}
+
+type instrumentedDriver struct {
+	driver.Driver
+}
+
+func (d *instrumentedDriver) Open(name string) (driver.Conn, error) {
+	conn, err := d.Driver.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	return &instrumentedConn{Conn: conn}, nil
+}
+
+// instrumentedConn passes each call on to the wrapped connection, timing the
+// queries
+type instrumentedConn struct {
+	driver.Conn
+}
+
+func (c *instrumentedConn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	if preparer, ok := c.Conn.(driver.ConnPrepareContext); ok {
+		return preparer.PrepareContext(ctx, query)
+	}
+	return c.Conn.Prepare(query)
+}
+
+func (c *instrumentedConn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	if beginner, ok := c.Conn.(driver.ConnBeginTx); ok {
+		return beginner.BeginTx(ctx, opts)
+	}
+	return c.Conn.Begin()
+}
+
+func (c *instrumentedConn) Ping(ctx context.Context) error {
+	if pinger, ok := c.Conn.(driver.Pinger); ok {
+		return pinger.Ping(ctx)
+	}
+	return nil
+}
+
+func (c *instrumentedConn) CheckNamedValue(value *driver.NamedValue) error {
+	if checker, ok := c.Conn.(driver.NamedValueChecker); ok {
+		return checker.CheckNamedValue(value)
+	}
+	return driver.ErrSkip
+}
+
+func (c *instrumentedConn) ResetSession(ctx context.Context) error {
+	if resetter, ok := c.Conn.(driver.SessionResetter); ok {
+		return resetter.ResetSession(ctx)
+	}
+	return nil
+}
+
+func (c *instrumentedConn) IsValid() bool {
+	if validator, ok := c.Conn.(driver.Validator); ok {
+		return validator.IsValid()
+	}
+	return true
+}
+
+func (c *instrumentedConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	execer, ok := c.Conn.(driver.ExecerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+	defer observeQuery(time.Now())
+	return execer.ExecContext(ctx, query, args)
+}
+
+func (c *instrumentedConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	queryer, ok := c.Conn.(driver.QueryerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+	defer observeQuery(time.Now())
+	return queryer.QueryContext(ctx, query, args)
+}
+
+// observeQuery records the time since the query started
+func observeQuery(start time.Time) {
+	QueryDuration.Observe(time.Since(start).Seconds(), callingFunction())
+}
+
+// callingFunction returns the name of the function which made the query:
+// the first caller outside database/sql and this package, such as
+// "model.ListCourts"
+func callingFunction() string {
+	pcs := make([]uintptr, 32)
+	n := runtime.Callers(3, pcs)
+	frames := runtime.CallersFrames(pcs[:n])
+	for {
+		frame, more := frames.Next()
+		name := frame.Function
+		if !strings.HasPrefix(name, "database/sql.") && !strings.Contains(name, "/internal/metrics.") && name != "" {
+			return shortFunctionName(name)
+		}
+		if !more {
+			return "unknown"
+		}
+	}
+}
+
+// shortFunctionName removes the package path, and the pointer from the
+// receiver, of a function name: "github.com/x/model.(*Court).LoadCourt"
+// becomes "model.Court.LoadCourt"
+func shortFunctionName(name string) string {
+	if i := strings.LastIndex(name, "/"); i >= 0 {
+		name = name[i+1:]
+	}
+	name = strings.Replace(name, "(*", "", 1)
+	name = strings.Replace(name, ")", "", 1)
+	return name
+}
@@ -0,0 +1,347 @@
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Metric types, as named in the Prometheus text format
+const (
+	TypeCounter   = "counter"
+	TypeGauge     = "gauge"
+	TypeHistogram = "histogram"
+)
+
+// Label type
+type Label struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// Sample is one value of a metric. A histogram has a sample for each
+// bucket, and for its sum and count
+type Sample struct {
+	Name   string  `json:"name"`
+	Labels []Label `json:"labels,omitempty"`
+	Value  float64 `json:"value"`
+}
+
+// Family is a metric with all its samples
+type Family struct {
+	Name    string   `json:"name"`
+	Help    string   `json:"help"`
+	Type    string   `json:"type"`
+	Samples []Sample `json:"samples"`
+}
+
+// collector is a metric held by a registry
+type collector interface {
+	describe() (name string, help string, kind string)
+	collect() []Sample
+}
+
+// Registry holds a set of metrics, which may be updated from any goroutine
+type Registry struct {
+	lock       sync.Mutex
+	collectors []collector
+	names      map[string]bool
+}
+
+// Default is the registry which the application's metrics are added to
+var Default = NewRegistry()
+
+// DefaultBuckets are the upper bounds, in seconds, of the histogram buckets
+// used for durations
+var DefaultBuckets = []float64{0.001, 0.0025, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// NewRegistry returns an empty registry
+func NewRegistry() *Registry {
+	return &Registry{names: make(map[string]bool)}
+}
+
+// register adds a metric. Two metrics cannot share a name
+func (r *Registry) register(c collector) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	name, _, _ := c.describe()
+	if r.names[name] {
+		panic(fmt.Sprintf("metric [%s] is already registered", name))
+	}
+	r.names[name] = true
+	r.collectors = append(r.collectors, c)
+}
+
+// Gather returns the current value of every metric, in the order they were
+// registered
+func (r *Registry) Gather() []Family {
+	r.lock.Lock()
+	collectors := append([]collector(nil), r.collectors...)
+	r.lock.Unlock()
+
+	families := make([]Family, 0, len(collectors))
+	for _, c := range collectors {
+		name, help, kind := c.describe()
+		families = append(families, Family{Name: name, Help: help, Type: kind, Samples: c.collect()})
+	}
+	return families
+}
+
+// WriteText writes every metric in the Prometheus text exposition format
+func (r *Registry) WriteText(w io.Writer) error {
+	for _, family := range r.Gather() {
+		_, err := fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", family.Name, escapeHelp(family.Help), family.Name, family.Type)
+		if err != nil {
+			return err
+		}
+		for _, sample := range family.Samples {
+			_, err = fmt.Fprintf(w, "%s%s %s\n", sample.Name, formatLabels(sample.Labels), formatValue(sample.Value))
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// ServeHTTP writes the metrics in the Prometheus text format, so a registry
+// can be scraped
+func (r *Registry) ServeHTTP(writer http.ResponseWriter, request *http.Request) {
+	writer.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	r.WriteText(writer)
+}
+
+func escapeHelp(help string) string {
+	return strings.NewReplacer(`\`, `\\`, "\n", `\n`).Replace(help)
+}
+
+func formatLabels(labels []Label) string {
+	if len(labels) == 0 {
+		return ""
+	}
+	escaper := strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+	parts := make([]string, len(labels))
+	for i, label := range labels {
+		parts[i] = label.Name + `="` + escaper.Replace(label.Value) + `"`
+	}
+	return "{" + strings.Join(parts, ",") + "}"
+}
+
+func formatValue(value float64) string {
+	switch {
+	case math.IsInf(value, +1):
+		return "+Inf"
+	case math.IsInf(value, -1):
+		return "-Inf"
+	case math.IsNaN(value):
+		return "NaN"
+	}
+	return strconv.FormatFloat(value, 'g', -1, 64)
+}
+
+// vec holds the label names of a metric, and finds the child for each set
+// of label values
+type vec struct {
+	name       string
+	help       string
+	labelNames []string
+	lock       sync.Mutex
+	children   map[string][]string
+}
+
+func newVec(name string, help string, labelNames []string) vec {
+	return vec{name: name, help: help, labelNames: labelNames, children: make(map[string][]string)}
+}
+
+// key returns the key of the child for the label values
+func (v *vec) key(values []string) string {
+	if len(values) != len(v.labelNames) {
+		panic(fmt.Sprintf("metric [%s] has %d labels, not %d", v.name, len(v.labelNames), len(values)))
+	}
+	key := strings.Join(values, "\xff")
+	if _, ok := v.children[key]; !ok {
+		v.children[key] = append([]string(nil), values...)
+	}
+	return key
+}
+
+// keys returns the keys of the children, in a stable order
+func (v *vec) keys() []string {
+	keys := make([]string, 0, len(v.children))
+	for key := range v.children {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func (v *vec) labels(key string, extra ...Label) []Label {
+	values := v.children[key]
+	labels := make([]Label, 0, len(values)+len(extra))
+	for i, value := range values {
+		labels = append(labels, Label{Name: v.labelNames[i], Value: value})
+	}
+	return append(labels, extra...)
+}
+
+// CounterVec is a counter for each set of label values
+type CounterVec struct {
+	vec
+	values map[string]float64
+}
+
+// NewCounterVec adds a counter to the registry
+func (r *Registry) NewCounterVec(name string, help string, labelNames ...string) *CounterVec {
+	c := &CounterVec{vec: newVec(name, help, labelNames), values: make(map[string]float64)}
+	r.register(c)
+	return c
+}
+
+// Inc adds one to the counter for the label values
+func (c *CounterVec) Inc(labelValues ...string) {
+	c.Add(1, labelValues...)
+}
+
+// Add adds to the counter for the label values
+func (c *CounterVec) Add(value float64, labelValues ...string) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.values[c.key(labelValues)] += value
+}
+
+// Totals returns the sum of the counters for each value of the label
+func (c *CounterVec) Totals(labelName string) map[string]float64 {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	totals := make(map[string]float64)
+	for i, name := range c.labelNames {
+		if name != labelName {
+			continue
+		}
+		for key, value := range c.values {
+			totals[c.children[key][i]] += value
+		}
+	}
+	return totals
+}
+
+func (c *CounterVec) describe() (string, string, string) {
+	return c.name, c.help, TypeCounter
+}
+
+func (c *CounterVec) collect() []Sample {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	samples := make([]Sample, 0, len(c.values))
+	for _, key := range c.keys() {
+		samples = append(samples, Sample{Name: c.name, Labels: c.labels(key), Value: c.values[key]})
+	}
+	return samples
+}
+
+// HistogramVec is a histogram for each set of label values
+type HistogramVec struct {
+	vec
+	buckets    []float64
+	histograms map[string]*histogram
+}
+
+type histogram struct {
+	counts []uint64 // the count in each bucket, not including the ones below
+	count  uint64
+	sum    float64
+}
+
+// NewHistogramVec adds a histogram to the registry. The buckets are the
+// upper bounds, in increasing order
+func (r *Registry) NewHistogramVec(name string, help string, buckets []float64, labelNames ...string) *HistogramVec {
+	h := &HistogramVec{vec: newVec(name, help, labelNames), buckets: buckets, histograms: make(map[string]*histogram)}
+	r.register(h)
+	return h
+}
+
+// Observe adds a value to the histogram for the label values
+func (h *HistogramVec) Observe(value float64, labelValues ...string) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	key := h.key(labelValues)
+	x, ok := h.histograms[key]
+	if !ok {
+		x = &histogram{counts: make([]uint64, len(h.buckets))}
+		h.histograms[key] = x
+	}
+
+	i := sort.SearchFloat64s(h.buckets, value)
+	if i < len(h.buckets) {
+		x.counts[i]++
+	}
+	x.count++
+	x.sum += value
+}
+
+func (h *HistogramVec) describe() (string, string, string) {
+	return h.name, h.help, TypeHistogram
+}
+
+func (h *HistogramVec) collect() []Sample {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	var samples []Sample
+	for _, key := range h.keys() {
+		x := h.histograms[key]
+
+		var cumulative uint64
+		for i, bound := range h.buckets {
+			cumulative += x.counts[i]
+			samples = append(samples, Sample{Name: h.name + "_bucket", Labels: h.labels(key, Label{Name: "le", Value: formatValue(bound)}), Value: float64(cumulative)})
+		}
+		samples = append(samples, Sample{Name: h.name + "_bucket", Labels: h.labels(key, Label{Name: "le", Value: "+Inf"}), Value: float64(x.count)})
+		samples = append(samples, Sample{Name: h.name + "_sum", Labels: h.labels(key), Value: x.sum})
+		samples = append(samples, Sample{Name: h.name + "_count", Labels: h.labels(key), Value: float64(x.count)})
+	}
+	return samples
+}
+
+// funcCollector reads the samples of a metric when the metrics are gathered
+type funcCollector struct {
+	name string
+	help string
+	kind string
+	read func() []Sample
+}
+
+// NewGaugeFunc adds a gauge to the registry, whose samples are read when
+// the metrics are gathered
+func (r *Registry) NewGaugeFunc(name string, help string, read func() []Sample) {
+	r.register(&funcCollector{name: name, help: help, kind: TypeGauge, read: read})
+}
+
+// NewCounterFunc adds a counter to the registry, whose samples are read
+// when the metrics are gathered
+func (r *Registry) NewCounterFunc(name string, help string, read func() []Sample) {
+	r.register(&funcCollector{name: name, help: help, kind: TypeCounter, read: read})
+}
+
+func (c *funcCollector) describe() (string, string, string) {
+	return c.name, c.help, c.kind
+}
+
+func (c *funcCollector) collect() []Sample {
+	samples := c.read()
+	for i := range samples {
+		if samples[i].Name == "" {
+			samples[i].Name = c.name
+		}
+	}
+	return samples
+}
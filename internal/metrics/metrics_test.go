@@ -0,0 +1,152 @@
+package metrics
+
+import (
+	"bytes"
+	"database/sql"
+	"strings"
+	"sync"
+	"testing"
+
+	_ "github.com/jackc/pgx/stdlib"
+)
+
+func TestWriteText(t *testing.T) {
+
+	registry := NewRegistry()
+	requests := registry.NewCounterVec("test_requests_total", "Number of requests", "route", "status")
+	durations := registry.NewHistogramVec("test_duration_seconds", "Time taken", []float64{0.1, 1}, "route")
+	registry.NewGaugeFunc("test_waiting", "Number waiting", func() []Sample {
+		return []Sample{{Labels: []Label{{Name: "club", Value: "1"}}, Value: 3}}
+	})
+
+	requests.Inc("/courts", "200")
+	requests.Inc("/courts", "200")
+	requests.Inc(`/say "hi"`, "404")
+	durations.Observe(0.05, "/courts")
+	durations.Observe(0.5, "/courts")
+	durations.Observe(5, "/courts")
+
+	var buffer bytes.Buffer
+	err := registry.WriteText(&buffer)
+	if err != nil {
+		t.Logf("Could not write the metrics: %v", err)
+		t.FailNow()
+	}
+
+	expected := `# HELP test_requests_total Number of requests
+# TYPE test_requests_total counter
+test_requests_total{route="/courts",status="200"} 2
+test_requests_total{route="/say \"hi\"",status="404"} 1
+# HELP test_duration_seconds Time taken
+# TYPE test_duration_seconds histogram
+test_duration_seconds_bucket{route="/courts",le="0.1"} 1
+test_duration_seconds_bucket{route="/courts",le="1"} 2
+test_duration_seconds_bucket{route="/courts",le="+Inf"} 3
+test_duration_seconds_sum{route="/courts"} 5.55
+test_duration_seconds_count{route="/courts"} 3
+# HELP test_waiting Number waiting
+# TYPE test_waiting gauge
+test_waiting{club="1"} 3
+`
+	if buffer.String() != expected {
+		t.Logf("Unexpected metrics.\nexpected:\n%s\nactual:\n%s", expected, buffer.String())
+		t.FailNow()
+	}
+
+	totals := requests.Totals("status")
+	if totals["200"] != 2 || totals["404"] != 1 {
+		t.Logf("Unexpected totals: %v", totals)
+		t.FailNow()
+	}
+}
+
+func TestConcurrentUpdates(t *testing.T) {
+
+	registry := NewRegistry()
+	requests := registry.NewCounterVec("test_requests_total", "Number of requests", "status")
+	durations := registry.NewHistogramVec("test_duration_seconds", "Time taken", DefaultBuckets, "status")
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				requests.Inc("200")
+				durations.Observe(0.01, "200")
+				registry.Gather()
+			}
+		}()
+	}
+	wg.Wait()
+
+	if total := requests.Totals("status")["200"]; total != 1000 {
+		t.Logf("Unexpected count. expected: 1000, actual: %v", total)
+		t.FailNow()
+	}
+}
+
+func TestDuplicateMetric(t *testing.T) {
+
+	registry := NewRegistry()
+	registry.NewCounterVec("test_total", "A counter")
+
+	defer func() {
+		if recover() == nil {
+			t.Log("Unexpected success registering a metric twice")
+			t.FailNow()
+		}
+	}()
+	registry.NewCounterVec("test_total", "The same counter")
+}
+
+func TestShortFunctionName(t *testing.T) {
+
+	tests := map[string]string{
+		"github.com/rsmaxwell/players-api/internal/model.ListCourts":            "model.ListCourts",
+		"github.com/rsmaxwell/players-api/internal/model.(*Court).LoadCourt":    "model.Court.LoadCourt",
+		"github.com/rsmaxwell/players-api/internal/model.RegisterMetrics.func1": "model.RegisterMetrics.func1",
+	}
+
+	for name, expected := range tests {
+		actual := shortFunctionName(name)
+		if actual != expected {
+			t.Logf("Unexpected name for %s. expected: %s, actual: %s", name, expected, actual)
+			t.FailNow()
+		}
+	}
+
+	if !strings.HasPrefix(callingFunction(), "testing.") {
+		t.Logf("Unexpected calling function: %s", callingFunction())
+		t.FailNow()
+	}
+}
+
+func TestInstrumentDriver(t *testing.T) {
+
+	name, err := InstrumentDriver("pgx")
+	if err != nil {
+		t.Logf("Could not instrument the driver: %v", err)
+		t.FailNow()
+	}
+
+	// The driver is registered once
+	again, err := InstrumentDriver("pgx")
+	if err != nil || again != name {
+		t.Logf("Unexpected driver. expected: %s, actual: %s, err: %v", name, again, err)
+		t.FailNow()
+	}
+
+	db, err := sql.Open(name, "postgres://localhost/players")
+	if err != nil {
+		t.Logf("Could not open the instrumented driver: %v", err)
+		t.FailNow()
+	}
+	db.Close()
+
+	_, err = InstrumentDriver("junk")
+	if err == nil {
+		t.Log("Unexpected success instrumenting an unknown driver")
+		t.FailNow()
+	}
+}
@@ -0,0 +1,440 @@
+package migrations
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"fmt"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/rsmaxwell/players-api/internal/debug"
+)
+
+// Migration type. Each migration moves the schema from the previous version
+// to its own, and back again
+type Migration struct {
+	Version int
+	Name    string
+	Up      string
+	Down    string
+}
+
+// Status type
+type Status struct {
+	Version int        `json:"version"`
+	Name    string     `json:"name"`
+	Applied *time.Time `json:"applied,omitempty"` // nil while the migration is pending
+}
+
+const (
+	// VersionTable is the name of the table which records the migrations
+	// applied to the database
+	VersionTable = "schema_version"
+
+	// lockKey identifies the advisory lock held while migrating, so two
+	// processes cannot migrate the same database at once
+	lockKey = 71352021
+)
+
+var (
+	pkg                    = debug.NewPackage("migrations")
+	functionLoad           = debug.NewFunction(pkg, "load")
+	functionCurrentVersion = debug.NewFunction(pkg, "CurrentVersion")
+	functionUp             = debug.NewFunction(pkg, "Up")
+	functionDown           = debug.NewFunction(pkg, "Down")
+	functionListStatus     = debug.NewFunction(pkg, "ListStatus")
+	functionBeginMigration = debug.NewFunction(pkg, "beginMigration")
+	functionTableExists    = debug.NewFunction(pkg, "tableExists")
+
+	//go:embed sql/*.sql
+	files embed.FS
+
+	fileName = regexp.MustCompile(`^(\d+)_(\w+)\.(up|down)\.sql$`)
+
+	// initialTables are the tables created by the first migration
+	initialTables = []string{"person", "court", "playing", "waiting"}
+
+	migrations []Migration
+)
+
+func init() {
+	var err error
+	migrations, err = load()
+	if err != nil {
+		panic(err)
+	}
+}
+
+// load reads the migrations embedded in the binary. The versions must run
+// from 1 without gaps, and each must have an up and a down step
+func load() ([]Migration, error) {
+	f := functionLoad
+
+	entries, err := files.ReadDir("sql")
+	if err != nil {
+		f.DumpError(err, "could not read the migrations")
+		return nil, err
+	}
+
+	byVersion := map[int]*Migration{}
+	for _, entry := range entries {
+		match := fileName.FindStringSubmatch(entry.Name())
+		if match == nil {
+			return nil, fmt.Errorf("unexpected migration file name: [%s]", entry.Name())
+		}
+
+		version, err := strconv.Atoi(match[1])
+		if err != nil {
+			return nil, fmt.Errorf("unexpected migration version: [%s]", entry.Name())
+		}
+
+		data, err := files.ReadFile(path.Join("sql", entry.Name()))
+		if err != nil {
+			f.DumpError(err, "could not read the migration [%s]", entry.Name())
+			return nil, err
+		}
+
+		m, ok := byVersion[version]
+		if !ok {
+			m = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = m
+		} else if m.Name != match[2] {
+			return nil, fmt.Errorf("migration %d has two names: [%s] and [%s]", version, m.Name, match[2])
+		}
+
+		if match[3] == "up" {
+			m.Up = string(data)
+		} else {
+			m.Down = string(data)
+		}
+	}
+
+	list := []Migration{}
+	for _, m := range byVersion {
+		list = append(list, *m)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Version < list[j].Version })
+
+	for i, m := range list {
+		if m.Version != i+1 {
+			return nil, fmt.Errorf("missing migration %d", i+1)
+		}
+		if m.Up == "" || m.Down == "" {
+			return nil, fmt.Errorf("migration %d needs both an up and a down step", m.Version)
+		}
+	}
+
+	return list, nil
+}
+
+// All returns the migrations, in order
+func All() []Migration {
+	return append([]Migration{}, migrations...)
+}
+
+// Latest returns the version of the schema this binary expects
+func Latest() int {
+	return len(migrations)
+}
+
+// CurrentVersion returns the version of the database's schema. A database
+// which has never been migrated is at version 0
+func CurrentVersion(ctx context.Context, db *sql.DB) (int, error) {
+	return currentVersion(ctx, db)
+}
+
+// Pending returns the migrations not yet applied to the database. A
+// database newer than this binary is an error
+func Pending(ctx context.Context, db *sql.DB) ([]Migration, error) {
+
+	current, err := CurrentVersion(ctx, db)
+	if err != nil {
+		return nil, err
+	}
+
+	if current > Latest() {
+		return nil, fmt.Errorf("the database schema is at version %d, which is newer than this program's version %d", current, Latest())
+	}
+
+	return All()[current:], nil
+}
+
+// Up applies the pending migrations, each in its own transaction, and
+// returns them
+func Up(ctx context.Context, db *sql.DB) ([]Migration, error) {
+	f := functionUp
+
+	applied := []Migration{}
+	for {
+		tx, current, err := beginMigration(ctx, db)
+		if err != nil {
+			return applied, err
+		}
+
+		if current >= Latest() {
+			tx.Rollback()
+			return applied, nil
+		}
+
+		m := migrations[current]
+
+		// Databases created before there were migrations already have the
+		// tables of the first one. The later migrations only add what is
+		// missing, as those databases may have been changed by hand since
+		adopt := false
+		if m.Version == 1 {
+			adopt, err = adoptable(ctx, tx)
+			if err != nil {
+				tx.Rollback()
+				return applied, err
+			}
+		}
+
+		if adopt {
+			f.Infof("Adopting the existing schema as migration %d: %s", m.Version, m.Name)
+		} else {
+			f.Infof("Applying migration %d: %s", m.Version, m.Name)
+
+			_, err = tx.ExecContext(ctx, m.Up)
+			if err != nil {
+				tx.Rollback()
+				message := fmt.Sprintf("Could not apply migration %d: %s", m.Version, m.Name)
+				f.DumpSQLError(err, message, m.Up)
+				return applied, err
+			}
+		}
+
+		sqlStatement := "INSERT INTO " + VersionTable + " (version, name, applied) VALUES ($1, $2, $3)"
+		_, err = tx.ExecContext(ctx, sqlStatement, m.Version, m.Name, time.Now())
+		if err != nil {
+			tx.Rollback()
+			message := "Could not insert into " + VersionTable
+			f.DumpSQLError(err, message, sqlStatement)
+			return applied, err
+		}
+
+		err = tx.Commit()
+		if err != nil {
+			message := "Could not commit the transaction"
+			f.DumpError(err, message)
+			return applied, err
+		}
+
+		applied = append(applied, m)
+	}
+}
+
+// Down reverts the latest applied migration, within a transaction, and
+// returns it. Nothing is reverted, and nil is returned, at version 0
+func Down(ctx context.Context, db *sql.DB) (*Migration, error) {
+	f := functionDown
+
+	tx, current, err := beginMigration(ctx, db)
+	if err != nil {
+		return nil, err
+	}
+
+	if current == 0 {
+		tx.Rollback()
+		return nil, nil
+	}
+
+	if current > Latest() {
+		tx.Rollback()
+		return nil, fmt.Errorf("the database schema is at version %d, which is newer than this program's version %d", current, Latest())
+	}
+
+	m := migrations[current-1]
+	f.Infof("Reverting migration %d: %s", m.Version, m.Name)
+
+	_, err = tx.ExecContext(ctx, m.Down)
+	if err != nil {
+		tx.Rollback()
+		message := fmt.Sprintf("Could not revert migration %d: %s", m.Version, m.Name)
+		f.DumpSQLError(err, message, m.Down)
+		return nil, err
+	}
+
+	sqlStatement := "DELETE FROM " + VersionTable + " WHERE version=$1"
+	_, err = tx.ExecContext(ctx, sqlStatement, m.Version)
+	if err != nil {
+		tx.Rollback()
+		message := "Could not delete from " + VersionTable
+		f.DumpSQLError(err, message, sqlStatement)
+		return nil, err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		message := "Could not commit the transaction"
+		f.DumpError(err, message)
+		return nil, err
+	}
+
+	return &m, nil
+}
+
+// ListStatus returns each migration, and when it was applied
+func ListStatus(ctx context.Context, db *sql.DB) ([]Status, error) {
+	f := functionListStatus
+
+	list := []Status{}
+	for _, m := range migrations {
+		list = append(list, Status{Version: m.Version, Name: m.Name})
+	}
+
+	exists, err := tableExists(ctx, db, VersionTable)
+	if err != nil || !exists {
+		return list, err
+	}
+
+	sqlStatement := "SELECT version, applied FROM " + VersionTable + " ORDER BY version"
+	rows, err := db.QueryContext(ctx, sqlStatement)
+	if err != nil {
+		message := "Could not select all from " + VersionTable
+		f.DumpSQLError(err, message, sqlStatement)
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var version int
+		var applied time.Time
+		err := rows.Scan(&version, &applied)
+		if err != nil {
+			message := "Could not scan the schema version"
+			f.DumpError(err, message)
+			return nil, err
+		}
+
+		if version >= 1 && version <= len(list) {
+			list[version-1].Applied = &applied
+		} else {
+			list = append(list, Status{Version: version, Name: "unknown", Applied: &applied})
+		}
+	}
+	err = rows.Err()
+	if err != nil {
+		message := "Could not list the schema versions"
+		f.DumpError(err, message)
+		return nil, err
+	}
+
+	return list, nil
+}
+
+// beginMigration begins a transaction, which holds the migration lock, and
+// returns the current version
+func beginMigration(ctx context.Context, db *sql.DB) (*sql.Tx, int, error) {
+	f := functionBeginMigration
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		message := "Could not begin a new transaction"
+		f.DumpError(err, message)
+		return nil, 0, err
+	}
+
+	sqlStatement := "SELECT pg_advisory_xact_lock($1)"
+	_, err = tx.ExecContext(ctx, sqlStatement, lockKey)
+	if err != nil {
+		tx.Rollback()
+		message := "Could not lock the schema"
+		f.DumpSQLError(err, message, sqlStatement)
+		return nil, 0, err
+	}
+
+	sqlStatement = "CREATE TABLE IF NOT EXISTS " + VersionTable + ` (
+		version INT PRIMARY KEY,
+		name    VARCHAR(255) NOT NULL,
+		applied TIMESTAMP WITH TIME ZONE NOT NULL
+	)`
+	_, err = tx.ExecContext(ctx, sqlStatement)
+	if err != nil {
+		tx.Rollback()
+		message := "Could not create the " + VersionTable + " table"
+		f.DumpSQLError(err, message, sqlStatement)
+		return nil, 0, err
+	}
+
+	current, err := currentVersion(ctx, tx)
+	if err != nil {
+		tx.Rollback()
+		return nil, 0, err
+	}
+
+	return tx, current, nil
+}
+
+// queryer is satisfied by both *sql.DB and *sql.Tx
+type queryer interface {
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+// currentVersion returns the latest migration applied to the database
+func currentVersion(ctx context.Context, db queryer) (int, error) {
+	f := functionCurrentVersion
+
+	exists, err := tableExists(ctx, db, VersionTable)
+	if err != nil || !exists {
+		return 0, err
+	}
+
+	var version int
+	sqlStatement := "SELECT COALESCE(MAX(version), 0) FROM " + VersionTable
+	err = db.QueryRowContext(ctx, sqlStatement).Scan(&version)
+	if err != nil {
+		message := "Could not select the schema version"
+		f.DumpSQLError(err, message, sqlStatement)
+		return 0, err
+	}
+
+	return version, nil
+}
+
+// adoptable tells whether the database already has the tables of the first
+// migration, which players-createTables created before there were
+// migrations. Having only some of them is an error, as the first migration
+// can then be neither adopted nor applied
+func adoptable(ctx context.Context, db queryer) (bool, error) {
+
+	found := []string{}
+	for _, table := range initialTables {
+		exists, err := tableExists(ctx, db, table)
+		if err != nil {
+			return false, err
+		}
+		if exists {
+			found = append(found, table)
+		}
+	}
+
+	if len(found) == 0 {
+		return false, nil
+	}
+	if len(found) != len(initialTables) {
+		return false, fmt.Errorf("the database has only some of the tables of migration 1: %v of %v", found, initialTables)
+	}
+
+	return true, nil
+}
+
+// tableExists checks the table is in the current schema
+func tableExists(ctx context.Context, db queryer, table string) (bool, error) {
+	f := functionTableExists
+
+	var exists bool
+	sqlStatement := "SELECT EXISTS ( SELECT FROM pg_tables WHERE schemaname = current_schema() AND tablename = $1 )"
+	err := db.QueryRowContext(ctx, sqlStatement, table).Scan(&exists)
+	if err != nil {
+		message := fmt.Sprintf("Could not check the table exists: %s", table)
+		f.DumpSQLError(err, message, sqlStatement)
+		return false, err
+	}
+
+	return exists, nil
+}
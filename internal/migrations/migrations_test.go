@@ -0,0 +1,58 @@
+package migrations
+
+import (
+	"regexp"
+	"testing"
+)
+
+func TestMigrations(t *testing.T) {
+
+	list, err := load()
+	if err != nil {
+		t.Logf("Could not load the migrations: %v", err)
+		t.FailNow()
+	}
+
+	if len(list) == 0 || Latest() != len(list) {
+		t.Logf("Unexpected number of migrations: %d, latest: %d", len(list), Latest())
+		t.FailNow()
+	}
+
+	// The first migration holds only the tables from before there were
+	// migrations, so that databases which have them can adopt it
+	initial := map[string]bool{}
+	for _, match := range regexp.MustCompile(`(?i)CREATE TABLE (\w+)`).FindAllStringSubmatch(list[0].Up, -1) {
+		initial[match[1]] = true
+	}
+	if len(initial) != len(initialTables) {
+		t.Logf("Unexpected tables in migration 1. expected: %v, actual: %v", initialTables, initial)
+		t.FailNow()
+	}
+	for _, table := range initialTables {
+		if !initial[table] {
+			t.Logf("Missing table [%s] from migration 1", table)
+			t.FailNow()
+		}
+	}
+
+	created := regexp.MustCompile(`(?i)CREATE TABLE (?:IF NOT EXISTS )?(\w+)`)
+	dropped := regexp.MustCompile(`(?i)DROP TABLE (?:IF EXISTS )?(\w+)`)
+
+	// Each down step drops exactly the tables its up step creates
+	for _, m := range list {
+		tables := map[string]int{}
+		for _, match := range created.FindAllStringSubmatch(m.Up, -1) {
+			tables[match[1]]++
+		}
+		for _, match := range dropped.FindAllStringSubmatch(m.Down, -1) {
+			tables[match[1]]--
+		}
+
+		for table, count := range tables {
+			if count != 0 {
+				t.Logf("Migration %d: the table [%s] is created %d more times than it is dropped", m.Version, table, count)
+				t.FailNow()
+			}
+		}
+	}
+}
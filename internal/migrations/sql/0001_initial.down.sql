@@ -0,0 +1,4 @@
+DROP TABLE playing;
+DROP TABLE waiting;
+DROP TABLE person;
+DROP TABLE court;
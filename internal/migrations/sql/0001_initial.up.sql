@@ -0,0 +1,44 @@
+-- The tables which players-createTables created before there were
+-- migrations. Each later change to the schema is a migration of its own
+
+CREATE TABLE person (
+	id        SERIAL PRIMARY KEY,
+	firstname VARCHAR(255) NOT NULL,
+	lastname  VARCHAR(255) NOT NULL,
+	knownas   VARCHAR(32) NOT NULL,
+	email     VARCHAR(255) NOT NULL UNIQUE,
+	phone     VARCHAR(32) NOT NULL UNIQUE,
+	hash      VARCHAR(255) NOT NULL,
+	status    VARCHAR(32) NOT NULL
+);
+
+CREATE INDEX person_email ON person ( email );
+
+CREATE TABLE court (
+	id   SERIAL PRIMARY KEY,
+	name VARCHAR(255)
+);
+
+CREATE TABLE playing (
+	court    INT NOT NULL,
+	person   INT NOT NULL,
+	position INT NOT NULL,
+
+	PRIMARY KEY (court, person, position),
+
+	CONSTRAINT person FOREIGN KEY(person) REFERENCES person(id),
+	CONSTRAINT court FOREIGN KEY(court)  REFERENCES court(id)
+);
+
+CREATE INDEX playing_court ON playing ( court );
+
+CREATE INDEX playing_person ON playing ( person );
+
+CREATE TABLE waiting (
+	person INT PRIMARY KEY,
+	start  TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+
+	CONSTRAINT person FOREIGN KEY(person) REFERENCES person(id)
+);
+
+CREATE INDEX first_waiting ON waiting ( start );
@@ -0,0 +1,6 @@
+-- Holds the version of the courts and the queue
+
+CREATE TABLE IF NOT EXISTS state (
+	id      INT PRIMARY KEY,
+	version BIGINT NOT NULL
+);
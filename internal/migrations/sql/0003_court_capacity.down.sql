@@ -0,0 +1 @@
+ALTER TABLE court DROP COLUMN capacity;
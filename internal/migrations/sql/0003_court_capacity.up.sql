@@ -0,0 +1 @@
+ALTER TABLE court ADD COLUMN IF NOT EXISTS capacity INT NOT NULL DEFAULT 4;
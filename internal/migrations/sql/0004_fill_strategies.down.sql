@@ -0,0 +1,5 @@
+ALTER TABLE court DROP COLUMN strategy;
+
+ALTER TABLE person DROP COLUMN gender;
+
+ALTER TABLE person DROP COLUMN skill;
@@ -0,0 +1,7 @@
+-- The fill strategies use the skill and gender of the waiters
+
+ALTER TABLE person ADD COLUMN IF NOT EXISTS skill INT NOT NULL DEFAULT 0;
+
+ALTER TABLE person ADD COLUMN IF NOT EXISTS gender VARCHAR(16) NOT NULL DEFAULT '';
+
+ALTER TABLE court ADD COLUMN IF NOT EXISTS strategy VARCHAR(32) NOT NULL DEFAULT 'fifo';
@@ -0,0 +1,4 @@
+DROP TABLE gameplayer;
+DROP TABLE game;
+
+ALTER TABLE playing DROP COLUMN start;
@@ -0,0 +1,25 @@
+ALTER TABLE playing ADD COLUMN IF NOT EXISTS start TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP;
+
+-- Games outlive their courts and players, so there are no foreign keys to
+-- them
+CREATE TABLE IF NOT EXISTS game (
+	id     SERIAL PRIMARY KEY,
+	court  INT NOT NULL,
+	start  TIMESTAMP WITH TIME ZONE NOT NULL,
+	finish TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS game_start ON game ( start );
+
+CREATE TABLE IF NOT EXISTS gameplayer (
+	game     INT NOT NULL,
+	person   INT NOT NULL,
+	position INT NOT NULL,
+	team     INT NOT NULL,
+
+	PRIMARY KEY (game, position),
+
+	CONSTRAINT game FOREIGN KEY(game) REFERENCES game(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS gameplayer_person ON gameplayer ( person );
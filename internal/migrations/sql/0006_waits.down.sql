@@ -0,0 +1,3 @@
+ALTER TABLE gameplayer DROP COLUMN wait;
+
+ALTER TABLE playing DROP COLUMN wait;
@@ -0,0 +1,6 @@
+-- The wait of each player before they were called onto the court, in
+-- seconds
+
+ALTER TABLE playing ADD COLUMN IF NOT EXISTS wait INT NOT NULL DEFAULT 0;
+
+ALTER TABLE gameplayer ADD COLUMN IF NOT EXISTS wait INT NOT NULL DEFAULT 0;
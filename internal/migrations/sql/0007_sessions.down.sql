@@ -0,0 +1,4 @@
+ALTER TABLE game DROP COLUMN session;
+
+DROP TABLE checkin;
+DROP TABLE session;
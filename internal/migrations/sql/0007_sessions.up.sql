@@ -0,0 +1,21 @@
+CREATE TABLE IF NOT EXISTS session (
+	id     SERIAL PRIMARY KEY,
+	opened TIMESTAMP WITH TIME ZONE NOT NULL,
+	closed TIMESTAMP WITH TIME ZONE
+);
+
+-- Allows only one open session
+CREATE UNIQUE INDEX IF NOT EXISTS session_open ON session ( (closed IS NULL) ) WHERE closed IS NULL;
+
+CREATE TABLE IF NOT EXISTS checkin (
+	session INT NOT NULL,
+	person  INT NOT NULL,
+	time    TIMESTAMP WITH TIME ZONE NOT NULL,
+
+	PRIMARY KEY (session, person),
+
+	CONSTRAINT session FOREIGN KEY(session) REFERENCES session(id) ON DELETE CASCADE,
+	CONSTRAINT person FOREIGN KEY(person) REFERENCES person(id) ON DELETE CASCADE
+);
+
+ALTER TABLE game ADD COLUMN IF NOT EXISTS session INT NOT NULL DEFAULT 0;
@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS personrole (
+	person INT NOT NULL,
+	role   VARCHAR(32) NOT NULL,
+
+	PRIMARY KEY (person, role),
+
+	CONSTRAINT person FOREIGN KEY(person) REFERENCES person(id) ON DELETE CASCADE
+);
+
+-- Everybody is a member, and the people whose status made them an admin
+-- keep that role
+INSERT INTO personrole (person, role) SELECT id, 'member' FROM person ON CONFLICT DO NOTHING;
+
+INSERT INTO personrole (person, role) SELECT id, 'admin' FROM person WHERE status = 'admin' ON CONFLICT DO NOTHING;
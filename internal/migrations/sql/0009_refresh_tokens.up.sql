@@ -0,0 +1,16 @@
+-- Holds the hash of each refresh token
+CREATE TABLE IF NOT EXISTS refreshtoken (
+	id      SERIAL PRIMARY KEY,
+	person  INT NOT NULL,
+	device  VARCHAR(255) NOT NULL,
+	family  VARCHAR(32) NOT NULL,
+	hash    VARCHAR(64) NOT NULL UNIQUE,
+	created TIMESTAMP WITH TIME ZONE NOT NULL,
+	expires TIMESTAMP WITH TIME ZONE NOT NULL,
+	used    TIMESTAMP WITH TIME ZONE,
+	revoked TIMESTAMP WITH TIME ZONE,
+
+	CONSTRAINT person FOREIGN KEY(person) REFERENCES person(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS refreshtoken_family ON refreshtoken ( family );
@@ -0,0 +1,12 @@
+-- Holds the hash of each single use token sent by mail
+CREATE TABLE IF NOT EXISTS persontoken (
+	id      SERIAL PRIMARY KEY,
+	person  INT NOT NULL,
+	purpose VARCHAR(16) NOT NULL,
+	hash    VARCHAR(64) NOT NULL UNIQUE,
+	created TIMESTAMP WITH TIME ZONE NOT NULL,
+	expires TIMESTAMP WITH TIME ZONE NOT NULL,
+	used    TIMESTAMP WITH TIME ZONE,
+
+	CONSTRAINT person FOREIGN KEY(person) REFERENCES person(id) ON DELETE CASCADE
+);
@@ -0,0 +1,14 @@
+-- Records the decision on each registration, and who made it
+CREATE TABLE IF NOT EXISTS approval (
+	person     INT PRIMARY KEY,
+	registered TIMESTAMP WITH TIME ZONE NOT NULL,
+	verified   TIMESTAMP WITH TIME ZONE,
+	decision   VARCHAR(16),
+	status     VARCHAR(32),
+	decidedby  INT,
+	decided    TIMESTAMP WITH TIME ZONE,
+	reason     VARCHAR(255),
+
+	CONSTRAINT person FOREIGN KEY(person) REFERENCES person(id) ON DELETE CASCADE,
+	CONSTRAINT decidedby FOREIGN KEY(decidedby) REFERENCES person(id) ON DELETE SET NULL
+);
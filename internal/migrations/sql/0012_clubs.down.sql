@@ -0,0 +1,18 @@
+DROP INDEX session_open;
+
+ALTER TABLE session DROP COLUMN club;
+
+CREATE UNIQUE INDEX session_open ON session ( (closed IS NULL) ) WHERE closed IS NULL;
+
+ALTER TABLE game DROP COLUMN club;
+
+DROP INDEX first_waiting;
+
+ALTER TABLE waiting DROP COLUMN club;
+
+CREATE INDEX first_waiting ON waiting ( start );
+
+ALTER TABLE court DROP COLUMN club;
+
+DROP TABLE clubmember;
+DROP TABLE club;
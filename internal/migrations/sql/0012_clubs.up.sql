@@ -0,0 +1,45 @@
+-- Clubs, each with its own courts, queue, sessions and members. Everything
+-- which existed before belongs to the first club
+
+CREATE TABLE club (
+	id   SERIAL PRIMARY KEY,
+	name VARCHAR(255) NOT NULL UNIQUE
+);
+
+INSERT INTO club (id, name) VALUES (1, 'Default');
+
+SELECT setval(pg_get_serial_sequence('club', 'id'), 1);
+
+-- Holds the roles each person holds in each club
+CREATE TABLE clubmember (
+	club   INT NOT NULL,
+	person INT NOT NULL,
+	role   VARCHAR(32) NOT NULL,
+
+	PRIMARY KEY (club, person, role),
+
+	CONSTRAINT club FOREIGN KEY(club) REFERENCES club(id) ON DELETE CASCADE,
+	CONSTRAINT person FOREIGN KEY(person) REFERENCES person(id) ON DELETE CASCADE
+);
+
+CREATE INDEX clubmember_person ON clubmember ( person );
+
+ALTER TABLE court ADD COLUMN club INT NOT NULL DEFAULT 1 REFERENCES club(id);
+
+CREATE INDEX court_club ON court ( club );
+
+ALTER TABLE waiting ADD COLUMN club INT NOT NULL DEFAULT 1 REFERENCES club(id);
+
+DROP INDEX first_waiting;
+
+CREATE INDEX first_waiting ON waiting ( club, start );
+
+-- Games outlive their clubs, so there is no foreign key
+ALTER TABLE game ADD COLUMN club INT NOT NULL DEFAULT 1;
+
+ALTER TABLE session ADD COLUMN club INT NOT NULL DEFAULT 1 REFERENCES club(id);
+
+-- Allows only one open session in each club
+DROP INDEX session_open;
+
+CREATE UNIQUE INDEX session_open ON session ( club ) WHERE closed IS NULL;
@@ -0,0 +1,283 @@
+package model
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/rsmaxwell/players-api/internal/codeerror"
+	"github.com/rsmaxwell/players-api/internal/debug"
+)
+
+// Approval type. A person who registers is suspended until an admin
+// approves their registration and they have verified their email address
+type Approval struct {
+	Person     int        `json:"person"`
+	Registered time.Time  `json:"registered"`
+	Verified   *time.Time `json:"verified,omitempty"`
+	Decision   string     `json:"decision,omitempty"` // empty while the registration is pending
+	Status     string     `json:"status,omitempty"`   // the status the person is given when approved
+	DecidedBy  *int       `json:"decidedby,omitempty"`
+	Decided    *time.Time `json:"decided,omitempty"`
+	Reason     string     `json:"reason,omitempty"`
+}
+
+const (
+	// ApprovalTable is the name of the approval table
+	ApprovalTable = "approval"
+
+	// DecisionApproved means the person may join the club
+	DecisionApproved = "approved"
+
+	// DecisionRejected means the person stays suspended
+	DecisionRejected = "rejected"
+)
+
+var (
+	functionRegisterPersonTx       = debug.NewFunction(pkg, "RegisterPersonTx")
+	functionListPendingApprovals   = debug.NewFunction(pkg, "ListPendingApprovals")
+	functionDecideApprovalTx       = debug.NewFunction(pkg, "decideApprovalTx")
+	functionLoadApproval           = debug.NewFunction(pkg, "loadApproval")
+	functionMarkApprovalVerified   = debug.NewFunction(pkg, "markApprovalVerified")
+	functionActivateApprovedPerson = debug.NewFunction(pkg, "activateApprovedPerson")
+)
+
+// RegisterPersonTx saves a newly registered person, within a transaction.
+// A suspended person's registration waits for an admin's approval
+func (p *FullPerson) RegisterPersonTx(db *sql.DB) error {
+	f := functionRegisterPersonTx
+	ctx := context.Background()
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		message := "Could not begin a new transaction"
+		f.DumpError(err, message)
+		return err
+	}
+
+	_, err = IncrementVersion(ctx, tx)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	err = p.xxxSavePerson(ctx, tx)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	err = SetRoles(ctx, tx, p.ID, p.Roles)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if p.Status == StatusSuspended {
+		sqlStatement := "INSERT INTO " + ApprovalTable + " (person, registered) VALUES ($1, $2)"
+		_, err = tx.ExecContext(ctx, sqlStatement, p.ID, time.Now())
+		if err != nil {
+			tx.Rollback()
+			message := fmt.Sprintf("Could not insert into %s for person [%d]", ApprovalTable, p.ID)
+			f.DumpSQLError(err, message, sqlStatement)
+			return err
+		}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		message := "Could not commit the transaction"
+		f.DumpError(err, message)
+		return err
+	}
+
+	return nil
+}
+
+// ListPendingApprovals returns the registrations waiting for a decision,
+// oldest first
+func ListPendingApprovals(ctx context.Context, db Queryer) ([]Approval, error) {
+	f := functionListPendingApprovals
+
+	fields := "person, registered, verified"
+	sqlStatement := "SELECT " + fields + " FROM " + ApprovalTable + " WHERE decision IS NULL ORDER BY registered, person"
+	rows, err := db.QueryContext(ctx, sqlStatement)
+	if err != nil {
+		message := "Could not select all from " + ApprovalTable
+		f.DumpSQLError(err, message, sqlStatement)
+		return nil, err
+	}
+	defer rows.Close()
+
+	list := []Approval{}
+	for rows.Next() {
+		var approval Approval
+		err := rows.Scan(&approval.Person, &approval.Registered, &approval.Verified)
+		if err != nil {
+			message := "Could not scan the approval"
+			f.DumpError(err, message)
+			return nil, err
+		}
+		list = append(list, approval)
+	}
+	err = rows.Err()
+	if err != nil {
+		message := "Could not list the approvals"
+		f.DumpError(err, message)
+		return nil, err
+	}
+
+	return list, nil
+}
+
+// ApproveRegistrationTx approves a person's registration, within a
+// transaction. They are given the status once their email address is
+// verified
+func ApproveRegistrationTx(ctx context.Context, db *sql.DB, personID int, adminID int, status string) (*FullPerson, *Approval, error) {
+
+	if status == "" {
+		status = StatusInactive
+	}
+	if status == StatusAdmin || status == StatusSuspended || !validStatus(status) {
+		return nil, nil, codeerror.NewBadRequest(fmt.Sprintf("unexpected status for an approved person: [%s]", status))
+	}
+
+	return decideApprovalTx(ctx, db, personID, adminID, DecisionApproved, status, "")
+}
+
+// RejectRegistrationTx rejects a person's registration, within a
+// transaction. They stay suspended
+func RejectRegistrationTx(ctx context.Context, db *sql.DB, personID int, adminID int, reason string) (*FullPerson, *Approval, error) {
+
+	if len(reason) > 255 {
+		return nil, nil, codeerror.NewBadRequest("the reason is too long")
+	}
+
+	return decideApprovalTx(ctx, db, personID, adminID, DecisionRejected, "", reason)
+}
+
+// decideApprovalTx records the decision on a pending registration, and who
+// made it
+func decideApprovalTx(ctx context.Context, db *sql.DB, personID int, adminID int, decision string, status string, reason string) (*FullPerson, *Approval, error) {
+	f := functionDecideApprovalTx
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		message := "Could not begin a new transaction"
+		f.DumpError(err, message)
+		return nil, nil, err
+	}
+
+	_, err = IncrementVersion(ctx, tx)
+	if err != nil {
+		tx.Rollback()
+		return nil, nil, err
+	}
+
+	approval, err := loadApproval(ctx, tx, personID)
+	if err != nil {
+		tx.Rollback()
+		return nil, nil, err
+	}
+	if approval == nil {
+		tx.Rollback()
+		return nil, nil, codeerror.NewNotFound(fmt.Sprintf("No registration for person [%d]", personID))
+	}
+	if approval.Decision != "" {
+		tx.Rollback()
+		return nil, nil, codeerror.NewBadRequest(fmt.Sprintf("The registration has already been %s", approval.Decision))
+	}
+
+	now := time.Now()
+	approval.Decision = decision
+	approval.Status = status
+	approval.DecidedBy = &adminID
+	approval.Decided = &now
+	approval.Reason = reason
+
+	sqlStatement := "UPDATE " + ApprovalTable + " SET decision=$1, status=NULLIF($2, ''), decidedby=$3, decided=$4, reason=NULLIF($5, '') WHERE person=$6"
+	_, err = tx.ExecContext(ctx, sqlStatement, decision, status, adminID, now, reason, personID)
+	if err != nil {
+		tx.Rollback()
+		message := "Could not update " + ApprovalTable
+		f.DumpSQLError(err, message, sqlStatement)
+		return nil, nil, err
+	}
+
+	person, err := activateApprovedPerson(ctx, tx, approval)
+	if err != nil {
+		tx.Rollback()
+		return nil, nil, err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		message := "Could not commit the transaction"
+		f.DumpError(err, message)
+		return nil, nil, err
+	}
+
+	return person, approval, nil
+}
+
+// loadApproval returns a person's registration, or nil if they did not
+// register
+func loadApproval(ctx context.Context, db Queryer, personID int) (*Approval, error) {
+	f := functionLoadApproval
+
+	var approval Approval
+	fields := "person, registered, verified, COALESCE(decision, ''), COALESCE(status, ''), decidedby, decided, COALESCE(reason, '')"
+	sqlStatement := "SELECT " + fields + " FROM " + ApprovalTable + " WHERE person=$1 FOR UPDATE"
+	err := db.QueryRowContext(ctx, sqlStatement, personID).Scan(&approval.Person, &approval.Registered, &approval.Verified, &approval.Decision, &approval.Status, &approval.DecidedBy, &approval.Decided, &approval.Reason)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	} else if err != nil {
+		message := "Could not select the approval"
+		f.DumpSQLError(err, message, sqlStatement)
+		return nil, err
+	}
+
+	return &approval, nil
+}
+
+// markApprovalVerified records that a person has verified their email
+// address, and returns their registration, or nil if they did not register
+func markApprovalVerified(ctx context.Context, db Queryer, personID int) (*Approval, error) {
+	f := functionMarkApprovalVerified
+
+	sqlStatement := "UPDATE " + ApprovalTable + " SET verified=$1 WHERE person=$2 AND verified IS NULL"
+	_, err := db.ExecContext(ctx, sqlStatement, time.Now(), personID)
+	if err != nil {
+		message := "Could not update " + ApprovalTable
+		f.DumpSQLError(err, message, sqlStatement)
+		return nil, err
+	}
+
+	return loadApproval(ctx, db, personID)
+}
+
+// activateApprovedPerson gives a suspended person the status they were
+// approved for, once their email address is verified, and returns them
+func activateApprovedPerson(ctx context.Context, db Queryer, approval *Approval) (*FullPerson, error) {
+	f := functionActivateApprovedPerson
+
+	person := FullPerson{ID: approval.Person}
+	err := person.LoadPerson(ctx, db)
+	if err != nil {
+		return nil, err
+	}
+
+	if approval.Decision != DecisionApproved || approval.Verified == nil || person.Status != StatusSuspended {
+		return &person, nil
+	}
+
+	f.DebugVerbose("person [%d] approved and verified: %s", person.ID, approval.Status)
+
+	err = person.UpdatePersonFields(ctx, db, map[string]interface{}{"status": approval.Status})
+	if err != nil {
+		return nil, err
+	}
+
+	return &person, nil
+}
@@ -0,0 +1,185 @@
+package model
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	_ "github.com/jackc/pgx/stdlib"
+)
+
+// registerTestPerson registers a new person, whose registration is pending
+func registerTestPerson(t *testing.T, db *sql.DB, email string) *FullPerson {
+
+	registration := NewRegistration("James", "Bond", "bond", email, "012345 123456", "topsecret")
+	person, err := registration.ToPerson()
+	if err != nil {
+		t.Log("Could not create the person")
+		t.FailNow()
+	}
+
+	err = person.RegisterPersonTx(db)
+	if err != nil {
+		t.Log("Could not register the person")
+		t.FailNow()
+	}
+
+	return person
+}
+
+// isPending checks the person's registration is waiting for a decision
+func isPending(t *testing.T, ctx context.Context, db *sql.DB, personID int) bool {
+
+	list, err := ListPendingApprovals(ctx, db)
+	if err != nil {
+		t.Log("Could not list the pending approvals")
+		t.FailNow()
+	}
+
+	for _, approval := range list {
+		if approval.Person == personID {
+			return true
+		}
+	}
+	return false
+}
+
+func TestApproveRegistration(t *testing.T) {
+	teardown, db, _ := Setup(t)
+	defer teardown(t)
+
+	ctx := context.Background()
+
+	admin, err := FindPersonByEmail(ctx, db, GoodEmail)
+	if err != nil {
+		t.Log("Could not find the admin")
+		t.FailNow()
+	}
+
+	person := registerTestPerson(t, db, "007@mi6.co.uk")
+	if !isPending(t, ctx, db, person.ID) {
+		t.Log("The registration is not pending")
+		t.FailNow()
+	}
+
+	_, _, err = ApproveRegistrationTx(ctx, db, person.ID, admin.ID, StatusAdmin)
+	if err == nil {
+		t.Log("Unexpected success approving a registration as an admin")
+		t.FailNow()
+	}
+
+	_, approval, err := ApproveRegistrationTx(ctx, db, person.ID, admin.ID, StatusPlayer)
+	if err != nil {
+		t.Log("Could not approve the registration")
+		t.FailNow()
+	}
+	if approval.DecidedBy == nil || *approval.DecidedBy != admin.ID || approval.Decided == nil {
+		t.Log("The approval does not record who approved it, and when")
+		t.FailNow()
+	}
+
+	if isPending(t, ctx, db, person.ID) {
+		t.Log("The approved registration is still pending")
+		t.FailNow()
+	}
+
+	_, _, err = RejectRegistrationTx(ctx, db, person.ID, admin.ID, "")
+	if err == nil {
+		t.Log("Unexpected success rejecting an approved registration")
+		t.FailNow()
+	}
+
+	// The person stays suspended until they verify their email address
+	err = person.LoadPerson(ctx, db)
+	if err != nil || person.Status != StatusSuspended {
+		t.Log("The person should still be suspended")
+		t.FailNow()
+	}
+
+	token, err := CreatePersonToken(ctx, db, person.ID, PurposeVerifyEmail, time.Hour)
+	if err != nil {
+		t.Log("Could not create a person token")
+		t.FailNow()
+	}
+
+	err = VerifyEmailTx(ctx, db, token)
+	if err != nil {
+		t.Log("Could not verify the email address")
+		t.FailNow()
+	}
+
+	err = person.LoadPerson(ctx, db)
+	if err != nil || person.Status != StatusPlayer {
+		t.Logf("Unexpected status. expected: %s, actual: %s", StatusPlayer, person.Status)
+		t.FailNow()
+	}
+}
+
+func TestRejectRegistration(t *testing.T) {
+	teardown, db, _ := Setup(t)
+	defer teardown(t)
+
+	ctx := context.Background()
+
+	admin, err := FindPersonByEmail(ctx, db, GoodEmail)
+	if err != nil {
+		t.Log("Could not find the admin")
+		t.FailNow()
+	}
+
+	person := registerTestPerson(t, db, "007@mi6.co.uk")
+
+	// Verifying the email address is not enough to join
+	token, err := CreatePersonToken(ctx, db, person.ID, PurposeVerifyEmail, time.Hour)
+	if err != nil {
+		t.Log("Could not create a person token")
+		t.FailNow()
+	}
+
+	err = VerifyEmailTx(ctx, db, token)
+	if err != nil {
+		t.Log("Could not verify the email address")
+		t.FailNow()
+	}
+
+	_, approval, err := RejectRegistrationTx(ctx, db, person.ID, admin.ID, "Not a member of the club")
+	if err != nil || approval.Decision != DecisionRejected {
+		t.Log("Could not reject the registration")
+		t.FailNow()
+	}
+
+	err = person.LoadPerson(ctx, db)
+	if err != nil || person.Status != StatusSuspended {
+		t.Log("The rejected person should still be suspended")
+		t.FailNow()
+	}
+}
+
+func TestPendingRegistrationStaysSuspended(t *testing.T) {
+	teardown, db, _ := Setup(t)
+	defer teardown(t)
+
+	ctx := context.Background()
+
+	person := registerTestPerson(t, db, "007@mi6.co.uk")
+
+	// Neither the queue nor editing the person lets them in
+	err := MakePersonInactiveTx(ctx, db, DefaultClubID, person.ID)
+	if err == nil {
+		t.Log("Unexpected success making a suspended person inactive")
+		t.FailNow()
+	}
+
+	err = UpdatePersonFieldsTx(ctx, db, person.ID, map[string]interface{}{"status": StatusInactive})
+	if err == nil {
+		t.Log("Unexpected success lifting the suspension of a pending registration")
+		t.FailNow()
+	}
+
+	err = person.LoadPerson(ctx, db)
+	if err != nil || person.Status != StatusSuspended {
+		t.Log("The pending person should still be suspended")
+		t.FailNow()
+	}
+}
@@ -25,25 +25,34 @@ func CheckConistencyTx(db *sql.DB, fix bool) (int, error) {
 		return 0, err
 	}
 
-	count, err := CheckConistency(ctx, db, fix)
+	count, err := CheckConistency(ctx, tx, fix)
 	if err != nil {
 		tx.Rollback()
 		return 0, err
 	}
 
+	if fix && count > 0 {
+		_, err = IncrementVersion(ctx, tx)
+		if err != nil {
+			tx.Rollback()
+			return 0, err
+		}
+	}
+
 	err = tx.Commit()
 	if err != nil {
 		message := "Could not commit the transaction"
 		f.DumpError(err, message)
+		return 0, err
 	}
 
 	return count, nil
 }
 
-func CheckConistency(ctx context.Context, db *sql.DB, fix bool) (int, error) {
+func CheckConistency(ctx context.Context, db Queryer, fix bool) (int, error) {
 	f := functionCheckConistency
 
-	list, err := ListPeople(ctx, db, "")
+	list, err := ListPeople(ctx, db, PeopleFilter{})
 	if err != nil {
 		message := "Could not list people"
 		f.Errorf(message)
@@ -69,7 +78,7 @@ func CheckConistency(ctx context.Context, db *sql.DB, fix bool) (int, error) {
 	return total, nil
 }
 
-func (person *FullPerson) CheckConistencyPerson(ctx context.Context, db *sql.DB, fix bool) (int, error) {
+func (person *FullPerson) CheckConistencyPerson(ctx context.Context, db Queryer, fix bool) (int, error) {
 	f := functionCheckConistencyPerson
 
 	count := 0
@@ -90,6 +99,19 @@ func (person *FullPerson) CheckConistencyPerson(ctx context.Context, db *sql.DB,
 		return 0, err
 	}
 
+	// A player who is in no queue and on no court is put back in the queue
+	// of the default club
+	clubID, found, err := personClub(ctx, db, person.ID)
+	if err != nil {
+		message := fmt.Sprintf("Could not find the club of person: [%d: %s]", person.ID, person.Knownas)
+		f.Errorf(message)
+		f.DumpError(err, message)
+		return 0, err
+	}
+	if !found {
+		clubID = DefaultClubID
+	}
+
 	if person.Status == StatusPlayer {
 		if len(waiters) < 1 {
 			if len(players) < 1 {
@@ -98,7 +120,7 @@ func (person *FullPerson) CheckConistencyPerson(ctx context.Context, db *sql.DB,
 
 				if fix {
 					f.DebugError(fmt.Sprintf("Adding waiter record for person [%d: %s]", person.ID, person.Knownas))
-					err := AddWaiter(ctx, db, person.ID)
+					err := AddWaiter(ctx, db, clubID, person.ID)
 					if err != nil {
 						message := fmt.Sprintf("Could not add waiter: [%d: %s]", person.ID, person.Knownas)
 						f.Errorf(message)
@@ -123,7 +145,7 @@ func (person *FullPerson) CheckConistencyPerson(ctx context.Context, db *sql.DB,
 						return 0, err
 					}
 
-					err := AddWaiter(ctx, db, person.ID)
+					err := AddWaiter(ctx, db, clubID, person.ID)
 					if err != nil {
 						message := fmt.Sprintf("Could not add waiter: id: [%d: %s]", person.ID, person.Knownas)
 						f.Errorf(message)
@@ -159,7 +181,7 @@ func (person *FullPerson) CheckConistencyPerson(ctx context.Context, db *sql.DB,
 					return 0, err
 				}
 
-				err := AddWaiter(ctx, db, person.ID)
+				err := AddWaiter(ctx, db, clubID, person.ID)
 				if err != nil {
 					message := fmt.Sprintf("Could not add waiter: [%d: %s]", person.ID, person.Knownas)
 					f.Errorf(message)
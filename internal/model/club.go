@@ -0,0 +1,473 @@
+package model
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/rsmaxwell/players-api/internal/codeerror"
+	"github.com/rsmaxwell/players-api/internal/debug"
+)
+
+// Club type. Each club has its own courts, queue and sessions, and the
+// people who are members of it hold roles there
+type Club struct {
+	ID   int    `json:"id"`
+	Name string `json:"name" validate:"required,min=3,max=64"`
+}
+
+// ClubMember type
+type ClubMember struct {
+	Person  int      `json:"person"`
+	Knownas string   `json:"knownas"`
+	Roles   []string `json:"roles"`
+}
+
+const (
+	// ClubTable is the name of the club table
+	ClubTable = "club"
+
+	// ClubMemberTable is the name of the table of the roles each person
+	// holds in each club
+	ClubMemberTable = "clubmember"
+
+	// DefaultClubID is the club which everything belonged to before there
+	// were clubs. The roles people hold across every club also apply in it
+	DefaultClubID = 1
+)
+
+var (
+	functionSaveClubTx         = debug.NewFunction(pkg, "SaveClubTx")
+	functionSaveClub           = debug.NewFunction(pkg, "SaveClub")
+	functionLoadClub           = debug.NewFunction(pkg, "LoadClub")
+	functionListClubs          = debug.NewFunction(pkg, "ListClubs")
+	functionListClubsForPerson = debug.NewFunction(pkg, "ListClubsForPerson")
+	functionListClubRoles      = debug.NewFunction(pkg, "ListClubRoles")
+	functionListClubMembers    = debug.NewFunction(pkg, "ListClubMembers")
+	functionSetClubRolesTx     = debug.NewFunction(pkg, "SetClubRolesTx")
+	functionSetClubRoles       = debug.NewFunction(pkg, "SetClubRoles")
+	functionHasClubPermission  = debug.NewFunction(pkg, "HasClubPermission")
+	functionPersonClub         = debug.NewFunction(pkg, "personClub")
+	functionListClubPlayerIDs  = debug.NewFunction(pkg, "listClubPlayerIDs")
+)
+
+// SaveClubTx writes a new Club to disk, within a transaction
+func (c *Club) SaveClubTx(ctx context.Context, db *sql.DB) error {
+	f := functionSaveClubTx
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		message := "Could not begin a new transaction"
+		f.DumpError(err, message)
+		return err
+	}
+
+	_, err = IncrementVersion(ctx, tx)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	err = c.SaveClub(ctx, tx)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		message := "Could not commit the transaction"
+		f.DumpError(err, message)
+		return err
+	}
+
+	return nil
+}
+
+// SaveClub writes a new Club to disk and sets the generated id. The name
+// must not be in use by another club
+func (c *Club) SaveClub(ctx context.Context, db Queryer) error {
+	f := functionSaveClub
+
+	err := validate.Struct(c)
+	if err != nil {
+		errs := translateError(err, trans)
+		return codeerror.NewBadRequest(errs[0].Error())
+	}
+
+	var count int
+	sqlStatement := "SELECT COUNT(*) FROM " + ClubTable + " WHERE name=$1"
+	err = db.QueryRowContext(ctx, sqlStatement, c.Name).Scan(&count)
+	if err != nil {
+		message := "Could not count the clubs"
+		f.DumpSQLError(err, message, sqlStatement)
+		return err
+	}
+	if count > 0 {
+		return codeerror.NewBadRequest(fmt.Sprintf("The club [%s] already exists", c.Name))
+	}
+
+	sqlStatement = "INSERT INTO " + ClubTable + " (name) VALUES ($1) RETURNING id"
+	err = db.QueryRowContext(ctx, sqlStatement, c.Name).Scan(&c.ID)
+	if err != nil {
+		message := "Could not insert into " + ClubTable
+		d := f.DumpSQLError(err, message, sqlStatement)
+		d.AddObject("club.json", c)
+		return err
+	}
+
+	return nil
+}
+
+// LoadClub reads the Club with the given ID
+func (c *Club) LoadClub(ctx context.Context, db Queryer) error {
+	f := functionLoadClub
+
+	sqlStatement := "SELECT name FROM " + ClubTable + " WHERE id=$1"
+	err := db.QueryRowContext(ctx, sqlStatement, c.ID).Scan(&c.Name)
+	if err == sql.ErrNoRows {
+		return codeerror.NewNotFound(fmt.Sprintf("Club id %d not found", c.ID))
+	} else if err != nil {
+		message := "Could not select the club"
+		f.DumpSQLError(err, message, sqlStatement)
+		return err
+	}
+
+	return nil
+}
+
+// ListClubs returns every club, by name
+func ListClubs(ctx context.Context, db Queryer) ([]Club, error) {
+	f := functionListClubs
+
+	sqlStatement := "SELECT id, name FROM " + ClubTable + " ORDER BY name"
+	return queryClubs(ctx, db, f, sqlStatement)
+}
+
+// ListClubsForPerson returns the clubs in which the person holds a role, by
+// name. The roles held across every club make them a member of the default
+// club
+func ListClubsForPerson(ctx context.Context, db Queryer, personID int) ([]Club, error) {
+	f := functionListClubsForPerson
+
+	sqlStatement := "SELECT id, name FROM " + ClubTable + " WHERE " +
+		"id IN (SELECT club FROM " + ClubMemberTable + " WHERE person=$1) OR " +
+		"(id=$2 AND EXISTS (SELECT FROM " + PersonRoleTable + " WHERE person=$1)) " +
+		"ORDER BY name"
+	return queryClubs(ctx, db, f, sqlStatement, personID, DefaultClubID)
+}
+
+// queryClubs returns the clubs selected by the statement
+func queryClubs(ctx context.Context, db Queryer, f *debug.Function, sqlStatement string, args ...interface{}) ([]Club, error) {
+
+	rows, err := db.QueryContext(ctx, sqlStatement, args...)
+	if err != nil {
+		message := "Could not select from " + ClubTable
+		f.DumpSQLError(err, message, sqlStatement)
+		return nil, err
+	}
+	defer rows.Close()
+
+	list := make([]Club, 0)
+	for rows.Next() {
+
+		var club Club
+		err := rows.Scan(&club.ID, &club.Name)
+		if err != nil {
+			message := "Could not scan the club"
+			f.DumpError(err, message)
+			return nil, err
+		}
+
+		list = append(list, club)
+	}
+	err = rows.Err()
+	if err != nil {
+		message := "Could not list the clubs"
+		f.DumpError(err, message)
+		return nil, err
+	}
+
+	return list, nil
+}
+
+// ListClubRoles returns the roles a person holds in a club. It does not
+// include the roles they hold across every club
+func ListClubRoles(ctx context.Context, db Queryer, clubID int, personID int) ([]string, error) {
+	f := functionListClubRoles
+
+	sqlStatement := "SELECT role FROM " + ClubMemberTable + " WHERE club=$1 AND person=$2 ORDER BY role"
+	rows, err := db.QueryContext(ctx, sqlStatement, clubID, personID)
+	if err != nil {
+		message := "Could not select from " + ClubMemberTable
+		f.DumpSQLError(err, message, sqlStatement)
+		return nil, err
+	}
+	defer rows.Close()
+
+	list := make([]string, 0)
+	for rows.Next() {
+
+		var role string
+		err := rows.Scan(&role)
+		if err != nil {
+			message := "Could not scan the role"
+			f.DumpError(err, message)
+			return nil, err
+		}
+
+		list = append(list, role)
+	}
+	err = rows.Err()
+	if err != nil {
+		message := "Could not list the club roles"
+		f.DumpError(err, message)
+		return nil, err
+	}
+
+	return list, nil
+}
+
+// ListClubMembers returns the people who hold a role in a club, and their
+// roles there
+func ListClubMembers(ctx context.Context, db Queryer, clubID int) ([]ClubMember, error) {
+	f := functionListClubMembers
+
+	sqlStatement := "SELECT m.person, p.knownas, m.role FROM " + ClubMemberTable + " m " +
+		"JOIN " + PersonTable + " p ON p.id = m.person " +
+		"WHERE m.club=$1 ORDER BY p.knownas, m.person, m.role"
+	rows, err := db.QueryContext(ctx, sqlStatement, clubID)
+	if err != nil {
+		message := "Could not select from " + ClubMemberTable
+		f.DumpSQLError(err, message, sqlStatement)
+		return nil, err
+	}
+	defer rows.Close()
+
+	list := make([]ClubMember, 0)
+	for rows.Next() {
+
+		var personID int
+		var knownas string
+		var role string
+		err := rows.Scan(&personID, &knownas, &role)
+		if err != nil {
+			message := "Could not scan the club member"
+			f.DumpError(err, message)
+			return nil, err
+		}
+
+		if len(list) == 0 || list[len(list)-1].Person != personID {
+			list = append(list, ClubMember{Person: personID, Knownas: knownas, Roles: make([]string, 0)})
+		}
+		member := &list[len(list)-1]
+		member.Roles = append(member.Roles, role)
+	}
+	err = rows.Err()
+	if err != nil {
+		message := "Could not list the club members"
+		f.DumpError(err, message)
+		return nil, err
+	}
+
+	return list, nil
+}
+
+// SetClubRolesTx replaces the roles a person holds in a club, within a
+// transaction. Giving them no roles removes them from the club
+func SetClubRolesTx(ctx context.Context, db *sql.DB, clubID int, personID int, roles []string) error {
+	f := functionSetClubRolesTx
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		message := "Could not begin a new transaction"
+		f.DumpError(err, message)
+		return err
+	}
+
+	_, err = IncrementVersion(ctx, tx)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	club := Club{ID: clubID}
+	err = club.LoadClub(ctx, tx)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	person := FullPerson{ID: personID}
+	err = person.LoadPerson(ctx, tx)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	err = SetClubRoles(ctx, tx, clubID, personID, roles)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		message := "Could not commit the transaction"
+		f.DumpError(err, message)
+		return err
+	}
+
+	return nil
+}
+
+// SetClubRoles replaces the roles a person holds in a club
+func SetClubRoles(ctx context.Context, db Queryer, clubID int, personID int, roles []string) error {
+	f := functionSetClubRoles
+
+	for _, role := range roles {
+		if _, ok := rolePermissions[role]; !ok {
+			return codeerror.NewBadRequest(fmt.Sprintf("Unknown role: %s", role))
+		}
+	}
+
+	sqlStatement := "DELETE FROM " + ClubMemberTable + " WHERE club=$1 AND person=$2"
+	_, err := db.ExecContext(ctx, sqlStatement, clubID, personID)
+	if err != nil {
+		message := "Could not delete from " + ClubMemberTable
+		f.DumpSQLError(err, message, sqlStatement)
+		return err
+	}
+
+	sqlStatement = "INSERT INTO " + ClubMemberTable + " (club, person, role) VALUES ($1, $2, $3) ON CONFLICT DO NOTHING"
+	for _, role := range roles {
+		_, err = db.ExecContext(ctx, sqlStatement, clubID, personID, role)
+		if err != nil {
+			message := "Could not insert into " + ClubMemberTable
+			f.DumpSQLError(err, message, sqlStatement)
+			return err
+		}
+	}
+
+	return nil
+}
+
+// HasClubPermission tells whether the person's roles in a club grant the
+// permission. An admin across every club may do anything in any club, and
+// the roles held across every club also apply in the default club
+func HasClubPermission(ctx context.Context, db Queryer, clubID int, personID int, permission Permission) (bool, error) {
+	f := functionHasClubPermission
+
+	global, err := ListRoles(ctx, db, personID)
+	if err != nil {
+		message := fmt.Sprintf("Could not list the roles of person [%d]", personID)
+		f.Errorf(message)
+		f.DumpError(err, message)
+		return false, err
+	}
+
+	for _, role := range global {
+		if role == RoleAdmin {
+			return true, nil
+		}
+	}
+
+	roles, err := ListClubRoles(ctx, db, clubID, personID)
+	if err != nil {
+		message := fmt.Sprintf("Could not list the roles of person [%d] in club [%d]", personID, clubID)
+		f.Errorf(message)
+		f.DumpError(err, message)
+		return false, err
+	}
+
+	if clubID == DefaultClubID {
+		roles = append(roles, global...)
+	}
+
+	return RolesAllow(roles, permission), nil
+}
+
+// personClub returns the club whose queue or courts the person is in, if any
+func personClub(ctx context.Context, db Queryer, personID int) (int, bool, error) {
+	f := functionPersonClub
+
+	sqlStatement := "SELECT club FROM " + WaitingTable + " WHERE person=$1 " +
+		"UNION SELECT c.club FROM " + PlayingTable + " p JOIN " + CourtTable + " c ON c.id = p.court WHERE p.person=$1 " +
+		"LIMIT 1"
+
+	var clubID int
+	err := db.QueryRowContext(ctx, sqlStatement, personID).Scan(&clubID)
+	if err == sql.ErrNoRows {
+		return 0, false, nil
+	} else if err != nil {
+		message := "Could not select the club of the person"
+		f.DumpSQLError(err, message, sqlStatement)
+		return 0, false, err
+	}
+
+	return clubID, true, nil
+}
+
+// listClubPlayerIDs returns the people in the club's queue or on its courts
+func listClubPlayerIDs(ctx context.Context, db Queryer, clubID int) (map[int]bool, error) {
+	f := functionListClubPlayerIDs
+
+	sqlStatement := "SELECT person FROM " + WaitingTable + " WHERE club=$1 " +
+		"UNION SELECT p.person FROM " + PlayingTable + " p JOIN " + CourtTable + " c ON c.id = p.court WHERE c.club=$1"
+	rows, err := db.QueryContext(ctx, sqlStatement, clubID)
+	if err != nil {
+		message := "Could not select the players of the club"
+		f.DumpSQLError(err, message, sqlStatement)
+		return nil, err
+	}
+	defer rows.Close()
+
+	ids := make(map[int]bool)
+	for rows.Next() {
+		var id int
+		err := rows.Scan(&id)
+		if err != nil {
+			message := "Could not scan the player"
+			f.DumpError(err, message)
+			return nil, err
+		}
+		ids[id] = true
+	}
+	err = rows.Err()
+	if err != nil {
+		message := "Could not list the players of the club"
+		f.DumpError(err, message)
+		return nil, err
+	}
+
+	return ids, nil
+}
+
+// checkPersonClub makes sure the person is not in the queue or on the
+// courts of another club and, unless they are already at the club, that
+// they are a member of it. Everybody is a member of the default club
+func checkPersonClub(ctx context.Context, db Queryer, clubID int, personID int) error {
+
+	current, found, err := personClub(ctx, db, personID)
+	if err != nil {
+		return err
+	}
+
+	if found && current != clubID {
+		return codeerror.NewBadRequest(fmt.Sprintf("Person [%d] is at club [%d]", personID, current))
+	}
+
+	if found || clubID == DefaultClubID {
+		return nil
+	}
+
+	roles, err := ListClubRoles(ctx, db, clubID, personID)
+	if err != nil {
+		return err
+	}
+	if len(roles) == 0 {
+		return codeerror.NewBadRequest(fmt.Sprintf("Person [%d] is not a member of club [%d]", personID, clubID))
+	}
+
+	return nil
+}
@@ -0,0 +1,140 @@
+package model
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/rsmaxwell/players-api/internal/codeerror"
+
+	_ "github.com/jackc/pgx/stdlib"
+)
+
+func TestClubs(t *testing.T) {
+	teardown, db, _ := Setup(t)
+	defer teardown(t)
+
+	ctx := context.Background()
+
+	club := Club{Name: "Riverside"}
+	err := club.SaveClubTx(ctx, db)
+	if err != nil {
+		t.Log("Could not create the club")
+		t.FailNow()
+	}
+
+	duplicate := Club{Name: "Riverside"}
+	err = duplicate.SaveClubTx(ctx, db)
+	if codeErr, ok := err.(*codeerror.CodeError); !ok || codeErr.Code() != http.StatusBadRequest {
+		t.Logf("Expected a 'bad request' error creating a duplicate club, got: %v", err)
+		t.FailNow()
+	}
+
+	member, err := FindPersonByEmail(ctx, db, AnotherEmail)
+	if err != nil {
+		t.Log("Could not find the member")
+		t.FailNow()
+	}
+
+	// Roles in one club do not carry over to another
+	err = SetClubRolesTx(ctx, db, club.ID, member.ID, []string{RoleMember, RoleOrganiser})
+	if err != nil {
+		t.Log("Could not set the club roles")
+		t.FailNow()
+	}
+
+	allowed, err := HasClubPermission(ctx, db, club.ID, member.ID, PermissionManageSessions)
+	if err != nil || !allowed {
+		t.Log("An organiser should be able to manage sessions at their club")
+		t.FailNow()
+	}
+
+	allowed, err = HasClubPermission(ctx, db, DefaultClubID, member.ID, PermissionManageSessions)
+	if err != nil || allowed {
+		t.Log("An organiser at one club should not be able to manage sessions at another")
+		t.FailNow()
+	}
+
+	clubs, err := ListClubsForPerson(ctx, db, member.ID)
+	if err != nil || len(clubs) != 2 {
+		t.Logf("Unexpected clubs for the member: %v", clubs)
+		t.FailNow()
+	}
+
+	// Each club has its own courts and queue
+	court := Court{Club: club.ID, Name: "Riverside 1", Capacity: 4}
+	err = court.SaveCourt(ctx, db)
+	if err != nil {
+		t.Log("Could not create the court")
+		t.FailNow()
+	}
+
+	courts, err := ListCourtsTx(db, club.ID)
+	if err != nil || len(courts) != 1 || courts[0].ID != court.ID {
+		t.Logf("Unexpected courts at the club: %v", courts)
+		t.FailNow()
+	}
+
+	err = court.LoadClubCourt(ctx, db, DefaultClubID)
+	if codeErr, ok := err.(*codeerror.CodeError); !ok || codeErr.Code() != http.StatusNotFound {
+		t.Logf("Expected a 'not found' error loading the court from another club, got: %v", err)
+		t.FailNow()
+	}
+
+	_, err = OpenSessionTx(ctx, db, club.ID)
+	if err != nil {
+		t.Log("Could not open a session at the club")
+		t.FailNow()
+	}
+
+	// Populate puts the member in the queue at the default club
+	err = MakePersonPlayerTx(ctx, db, club.ID, member.ID)
+	if codeErr, ok := err.(*codeerror.CodeError); !ok || codeErr.Code() != http.StatusBadRequest {
+		t.Logf("Expected a 'bad request' error joining a second club's queue, got: %v", err)
+		t.FailNow()
+	}
+
+	err = MakePersonInactiveTx(ctx, db, DefaultClubID, member.ID)
+	if err != nil {
+		t.Log("Could not make the member inactive")
+		t.FailNow()
+	}
+
+	err = MakePersonPlayerTx(ctx, db, club.ID, member.ID)
+	if err != nil {
+		t.Log("Could not add the member to the club's queue")
+		t.FailNow()
+	}
+
+	waiters, err := ListWaiters(ctx, db, club.ID)
+	if err != nil || len(waiters) != 1 || waiters[0].Person != member.ID {
+		t.Logf("Unexpected waiters at the club: %v", waiters)
+		t.FailNow()
+	}
+
+	// Somebody who is not a member cannot be put in the club's queue, or
+	// made inactive by it
+	outsider, err := FindPersonByEmail(ctx, db, GoodEmail)
+	if err != nil {
+		t.Log("Could not find the outsider")
+		t.FailNow()
+	}
+
+	err = MakePersonInactiveTx(ctx, db, DefaultClubID, outsider.ID)
+	if err != nil {
+		t.Log("Could not make the outsider inactive")
+		t.FailNow()
+	}
+
+	err = MakePersonPlayerTx(ctx, db, club.ID, outsider.ID)
+	if codeErr, ok := err.(*codeerror.CodeError); !ok || codeErr.Code() != http.StatusBadRequest {
+		t.Logf("Expected a 'bad request' error putting a non-member in the club's queue, got: %v", err)
+		t.FailNow()
+	}
+
+	err = MakePersonInactiveTx(ctx, db, club.ID, outsider.ID)
+	if codeErr, ok := err.(*codeerror.CodeError); !ok || codeErr.Code() != http.StatusBadRequest {
+		t.Logf("Expected a 'bad request' error making a non-member inactive at the club, got: %v", err)
+		t.FailNow()
+	}
+}
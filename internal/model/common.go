@@ -5,9 +5,12 @@ import (
 	"database/sql"
 	"fmt"
 	"testing"
+	"time"
 
+	"github.com/rsmaxwell/players-api/internal/codeerror"
 	"github.com/rsmaxwell/players-api/internal/config"
 	"github.com/rsmaxwell/players-api/internal/debug"
+	"github.com/rsmaxwell/players-api/internal/metrics"
 )
 
 var (
@@ -19,19 +22,17 @@ var (
 	functionClearCourt       = debug.NewFunction(pkg, "ClearCourt")
 )
 
-var (
-	// MetricsData containing metrics
-	MetricsData Metrics
-)
-
-// Metrics structure
-type Metrics struct {
-	StatusCodes map[int]int `json:"statusCodes"`
+// CourtFill type
+type CourtFill struct {
+	Strategy  string     `json:"strategy"`
+	Positions []Position `json:"positions"`
 }
 
-func init() {
-	MetricsData = Metrics{}
-	MetricsData.StatusCodes = make(map[int]int)
+// Metrics structure. The status codes and signins summarise the metrics
+type Metrics struct {
+	StatusCodes map[int]int      `json:"statusCodes"`
+	Signins     LoginStats       `json:"signins"`
+	Metrics     []metrics.Family `json:"metrics"`
 }
 
 // Setup function
@@ -66,11 +67,47 @@ func Setup(t *testing.T) (func(t *testing.T), *sql.DB, *config.Config) {
 }
 
 // DeleteAllRecords removes all the records in the database
-func DeleteAllRecords(ctx context.Context, db *sql.DB) error {
+func DeleteAllRecords(ctx context.Context, db Queryer) error {
 	f := functionDeleteAllRecords
 
-	sqlStatement := "DELETE FROM " + PlayingTable
-	_, err := db.Exec(sqlStatement)
+	sqlStatement := "DELETE FROM " + CheckInTable
+	_, err := db.ExecContext(ctx, sqlStatement)
+	if err != nil {
+		message := "Could not delete all from checkin"
+		f.Errorf(message)
+		f.DumpSQLError(err, message, sqlStatement)
+		return err
+	}
+
+	sqlStatement = "DELETE FROM " + SessionTable
+	_, err = db.ExecContext(ctx, sqlStatement)
+	if err != nil {
+		message := "Could not delete all from session"
+		f.Errorf(message)
+		f.DumpSQLError(err, message, sqlStatement)
+		return err
+	}
+
+	sqlStatement = "DELETE FROM " + GamePlayerTable
+	_, err = db.ExecContext(ctx, sqlStatement)
+	if err != nil {
+		message := "Could not delete all from gameplayer"
+		f.Errorf(message)
+		f.DumpSQLError(err, message, sqlStatement)
+		return err
+	}
+
+	sqlStatement = "DELETE FROM " + GameTable
+	_, err = db.ExecContext(ctx, sqlStatement)
+	if err != nil {
+		message := "Could not delete all from game"
+		f.Errorf(message)
+		f.DumpSQLError(err, message, sqlStatement)
+		return err
+	}
+
+	sqlStatement = "DELETE FROM " + PlayingTable
+	_, err = db.ExecContext(ctx, sqlStatement)
 	if err != nil {
 		message := "Could not delete all from playing"
 		f.Errorf(message)
@@ -79,7 +116,7 @@ func DeleteAllRecords(ctx context.Context, db *sql.DB) error {
 	}
 
 	sqlStatement = "DELETE FROM " + WaitingTable
-	_, err = db.Exec(sqlStatement)
+	_, err = db.ExecContext(ctx, sqlStatement)
 	if err != nil {
 		message := "Could not delete all from waiting"
 		f.Errorf(message)
@@ -88,7 +125,7 @@ func DeleteAllRecords(ctx context.Context, db *sql.DB) error {
 	}
 
 	sqlStatement = "DELETE FROM " + CourtTable
-	_, err = db.Exec(sqlStatement)
+	_, err = db.ExecContext(ctx, sqlStatement)
 	if err != nil {
 		message := "Could not delete all from courts"
 		f.Errorf(message)
@@ -96,8 +133,26 @@ func DeleteAllRecords(ctx context.Context, db *sql.DB) error {
 		return err
 	}
 
-	sqlStatement = "DELETE FROM " + PersonTable + " WHERE status != '" + StatusAdmin + "'"
-	_, err = db.Exec(sqlStatement)
+	sqlStatement = "DELETE FROM " + ClubMemberTable
+	_, err = db.ExecContext(ctx, sqlStatement)
+	if err != nil {
+		message := "Could not delete all from clubmember"
+		f.Errorf(message)
+		f.DumpSQLError(err, message, sqlStatement)
+		return err
+	}
+
+	sqlStatement = "DELETE FROM " + ClubTable + " WHERE id != $1"
+	_, err = db.ExecContext(ctx, sqlStatement, DefaultClubID)
+	if err != nil {
+		message := "Could not delete all from club"
+		f.Errorf(message)
+		f.DumpSQLError(err, message, sqlStatement)
+		return err
+	}
+
+	sqlStatement = "DELETE FROM " + PersonTable + " WHERE status != $1"
+	_, err = db.ExecContext(ctx, sqlStatement, StatusAdmin)
 	if err != nil {
 		message := "Could not delete all from people"
 		f.Errorf(message)
@@ -108,8 +163,8 @@ func DeleteAllRecords(ctx context.Context, db *sql.DB) error {
 	return nil
 }
 
-// FillCourt
-func FillCourtTx(ctx context.Context, db *sql.DB, courtID int) ([]Position, error) {
+// FillCourtTx fills a club's court, within a transaction
+func FillCourtTx(ctx context.Context, db *sql.DB, clubID int, courtID int, strategyName string) (*CourtFill, error) {
 	f := functionFillCourtTx
 
 	tx, err := db.BeginTx(ctx, nil)
@@ -119,16 +174,36 @@ func FillCourtTx(ctx context.Context, db *sql.DB, courtID int) ([]Position, erro
 		return nil, err
 	}
 
-	positions, err := FillCourt(ctx, db, courtID)
+	version, err := IncrementVersion(ctx, tx)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	court := Court{ID: courtID}
+	err = court.LoadClubCourt(ctx, tx, clubID)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	fill, err := FillCourt(ctx, tx, courtID, strategyName)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	event, err := NewEvent(ctx, tx, version, court.Club, courtID)
 	if err != nil {
 		tx.Rollback()
 		return nil, err
 	}
 
-	err = tx.Commit()
+	err = Events.CommitAndPublish(tx, event)
 	if err != nil {
 		message := "Could not commit the transaction"
 		f.DumpError(err, message)
+		return nil, err
 	}
 
 	count, err := CheckConistencyTx(db, false)
@@ -143,13 +218,30 @@ func FillCourtTx(ctx context.Context, db *sql.DB, courtID int) ([]Position, erro
 		return nil, err
 	}
 
-	return positions, nil
+	return fill, nil
 }
 
-// FillCourt
-func FillCourt(ctx context.Context, db *sql.DB, courtID int) ([]Position, error) {
+// FillCourt puts waiters from the court's club into the empty positions on a
+// court, using the named strategy to choose them. If no strategy is named,
+// the court's own is used
+func FillCourt(ctx context.Context, db Queryer, courtID int, strategyName string) (*CourtFill, error) {
 	f := functionFillCourt
 
+	court := Court{ID: courtID}
+	err := court.LoadCourt(ctx, db)
+	if err != nil {
+		return nil, err
+	}
+
+	if strategyName == "" {
+		strategyName = court.Strategy
+	}
+
+	strategy, err := FindFillStrategy(strategyName)
+	if err != nil {
+		return nil, err
+	}
+
 	players, err := ListPlayersForCourt(ctx, db, courtID)
 	if err != nil {
 		message := "Could not list players"
@@ -158,30 +250,85 @@ func FillCourt(ctx context.Context, db *sql.DB, courtID int) ([]Position, error)
 		return nil, err
 	}
 
-	mapOfPlayers := make(map[int]*Player)
+	state := FillState{Court: &court, Players: make(map[int]*FullPerson)}
 	for _, player := range players {
-		p := player                        // take a copy of each object ...
-		mapOfPlayers[player.Position] = &p // ... so their references are actually different!
+		person := FullPerson{ID: player.Person}
+		err = person.LoadPerson(ctx, db)
+		if err != nil {
+			message := "Could not load player"
+			f.Errorf(message)
+			f.DumpError(err, message)
+			return nil, err
+		}
+		state.Players[player.Position] = &person
 	}
 
-	changes := 0
-	positions := make([]Position, 0)
-	for index := 0; index < NumberOfCourtPositions; index++ {
+	for index := 0; index < court.Capacity; index++ {
+		if _, ok := state.Players[index]; !ok {
+			state.Empty = append(state.Empty, index)
+		}
+	}
 
-		var ok bool
-		var player *Player
-		var personID int
+	if len(state.Empty) > 0 {
+
+		waiters, err := ListWaiters(ctx, db, court.Club)
+		if err != nil {
+			message := "Could not list the waiters"
+			f.Errorf(message)
+			f.DumpError(err, message)
+			return nil, err
+		}
 
-		if player, ok = mapOfPlayers[index]; !ok {
-			changes++
+		if len(waiters) < len(state.Empty) {
+			return nil, codeerror.NewBadRequest(fmt.Sprintf("Not enough waiters to fill court [%d]: needed: %d, waiting: %d", courtID, len(state.Empty), len(waiters)))
+		}
 
-			personID, err = GetFirstWaiter(ctx, db)
+		started := make(map[int]time.Time)
+		for _, waiter := range waiters {
+			started[waiter.Person] = waiter.Start
+
+			person := FullPerson{ID: waiter.Person}
+			err = person.LoadPerson(ctx, db)
 			if err != nil {
-				message := "Could not get the first waiter"
+				message := "Could not load waiter"
 				f.Errorf(message)
 				f.DumpError(err, message)
 				return nil, err
 			}
+			state.Waiters = append(state.Waiters, person)
+		}
+
+		waiting := make(map[int]*FullPerson)
+		for i := range state.Waiters {
+			waiting[state.Waiters[i].ID] = &state.Waiters[i]
+		}
+
+		chosen, err := strategy.Choose(ctx, db, &state)
+		if err != nil {
+			message := fmt.Sprintf("Could not choose the waiters: strategy: %s", strategyName)
+			f.Errorf(message)
+			f.DumpError(err, message)
+			return nil, err
+		}
+
+		if len(chosen) != len(state.Empty) {
+			message := fmt.Sprintf("Strategy %s chose %d waiters for %d positions", strategyName, len(chosen), len(state.Empty))
+			err = codeerror.NewInternalServerError(message)
+			f.DumpError(err, message)
+			return nil, err
+		}
+
+		for i, index := range state.Empty {
+			personID := chosen[i]
+
+			person, ok := waiting[personID]
+			if !ok {
+				message := fmt.Sprintf("Strategy %s chose person [%d], who is not waiting", strategyName, personID)
+				err = codeerror.NewInternalServerError(message)
+				f.DumpError(err, message)
+				return nil, err
+			}
+			delete(waiting, personID)
 
 			err = RemoveWaiter(ctx, db, personID)
 			if err != nil {
@@ -191,37 +338,31 @@ func FillCourt(ctx context.Context, db *sql.DB, courtID int) ([]Position, error)
 				return nil, err
 			}
 
-			err = AddPlayer(ctx, db, personID, courtID, index)
+			err = AddPlayer(ctx, db, personID, courtID, index, time.Since(started[personID]))
 			if err != nil {
 				message := "Could not add player"
 				f.Errorf(message)
 				f.DumpError(err, message)
 				return nil, err
 			}
-			p := Player{Person: personID, Court: courtID, Position: index}
-			player = &p
-		}
 
-		person := FullPerson{ID: player.Person}
-		err = person.LoadPerson(ctx, db)
-		if err != nil {
-			message := "Could not load player"
-			f.Errorf(message)
-			f.DumpError(err, message)
-			return nil, err
+			state.Players[index] = person
 		}
+	}
 
-		var position = Position{Index: player.Position, PersonID: player.Person, DisplayName: person.Knownas}
-		positions = append(positions, position)
+	fill := CourtFill{Strategy: strategyName, Positions: make([]Position, 0)}
+	for index := 0; index < court.Capacity; index++ {
+		person := state.Players[index]
+		position := Position{Index: index, PersonID: person.ID, DisplayName: person.Knownas}
+		fill.Positions = append(fill.Positions, position)
 	}
 
-	return positions, nil
+	return &fill, nil
 }
 
 // ClearCourt
-func ClearCourtTx(db *sql.DB, courtID int) error {
+func ClearCourtTx(ctx context.Context, db *sql.DB, clubID int, courtID int) error {
 	f := functionClearCourtTx
-	ctx := context.Background()
 
 	tx, err := db.BeginTx(ctx, nil)
 	if err != nil {
@@ -231,7 +372,20 @@ func ClearCourtTx(db *sql.DB, courtID int) error {
 		return err
 	}
 
-	err = ClearCourt(ctx, db, courtID)
+	version, err := IncrementVersion(ctx, tx)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	court := Court{ID: courtID}
+	err = court.LoadClubCourt(ctx, tx, clubID)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	err = ClearCourt(ctx, tx, courtID)
 	if err != nil {
 		tx.Rollback()
 		message := "Problem clearing court"
@@ -240,7 +394,13 @@ func ClearCourtTx(db *sql.DB, courtID int) error {
 		return err
 	}
 
-	err = tx.Commit()
+	event, err := NewEvent(ctx, tx, version, court.Club, courtID)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	err = Events.CommitAndPublish(tx, event)
 	if err != nil {
 		message := "Could not commit transaction"
 		f.Errorf(message)
@@ -264,9 +424,15 @@ func ClearCourtTx(db *sql.DB, courtID int) error {
 }
 
 // ClearCourt
-func ClearCourt(ctx context.Context, db *sql.DB, courtID int) error {
+func ClearCourt(ctx context.Context, db Queryer, courtID int) error {
 	f := functionClearCourt
 
+	court := Court{ID: courtID}
+	err := court.LoadCourt(ctx, db)
+	if err != nil {
+		return err
+	}
+
 	players, err := ListPlayersForCourt(ctx, db, courtID)
 	if err != nil {
 		message := "Could not list players"
@@ -275,6 +441,29 @@ func ClearCourt(ctx context.Context, db *sql.DB, courtID int) error {
 		return err
 	}
 
+	if len(players) > 0 {
+		session, err := FindOpenSession(ctx, db, court.Club)
+		if err != nil {
+			message := "Could not find the open session"
+			f.Errorf(message)
+			f.DumpError(err, message)
+			return err
+		}
+
+		game := NewGame(&court, players)
+		if session != nil {
+			game.Session = session.ID
+		}
+
+		err = game.SaveGame(ctx, db)
+		if err != nil {
+			message := "Could not save the game"
+			f.Errorf(message)
+			f.DumpError(err, message)
+			return err
+		}
+	}
+
 	for _, player := range players {
 		err = RemovePlayer(ctx, db, player.Person)
 		if err != nil {
@@ -294,7 +483,7 @@ func ClearCourt(ctx context.Context, db *sql.DB, courtID int) error {
 		}
 
 		if person.Status == StatusPlayer {
-			err = AddWaiter(ctx, db, player.Person)
+			err = AddWaiter(ctx, db, court.Club, player.Person)
 			if err != nil {
 				message := "Could not add waiter"
 				f.Errorf(message)
@@ -0,0 +1,257 @@
+package model
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/rsmaxwell/players-api/internal/codeerror"
+
+	_ "github.com/jackc/pgx/stdlib"
+)
+
+var (
+	errInjected = errors.New("injected failure")
+)
+
+// failingQueryer wraps a Queryer and fails the n'th call to ExecContext, so a
+// multi-step model function can be made to fail partway through
+type failingQueryer struct {
+	Queryer
+	failAt int
+	count  int
+}
+
+func (q *failingQueryer) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	q.count++
+	if q.count >= q.failAt {
+		return nil, errInjected
+	}
+	return q.Queryer.ExecContext(ctx, query, args...)
+}
+
+// snapshot records who is waiting and who is playing
+type snapshot struct {
+	waiters []Waiter
+	players []Player
+}
+
+func takeSnapshot(ctx context.Context, t *testing.T, db Queryer) *snapshot {
+
+	waiters, err := ListWaiters(ctx, db, DefaultClubID)
+	if err != nil {
+		t.Log("Could not list the waiters")
+		t.FailNow()
+	}
+
+	players, err := ListPlayers(ctx, db)
+	if err != nil {
+		t.Log("Could not list the players")
+		t.FailNow()
+	}
+
+	return &snapshot{waiters: waiters, players: players}
+}
+
+func (s *snapshot) CheckUnchanged(ctx context.Context, t *testing.T, db Queryer) {
+
+	after := takeSnapshot(ctx, t, db)
+
+	if len(s.waiters) != len(after.waiters) {
+		t.Logf("Unexpected number of waiters. expected: %d, actual: %d", len(s.waiters), len(after.waiters))
+		t.FailNow()
+	}
+	for i, w := range s.waiters {
+		if w.Person != after.waiters[i].Person || !w.Start.Equal(after.waiters[i].Start) {
+			t.Logf("Unexpected waiter at [%d]. expected: %d, actual: %d", i, w.Person, after.waiters[i].Person)
+			t.FailNow()
+		}
+	}
+
+	if len(s.players) != len(after.players) {
+		t.Logf("Unexpected number of players. expected: %d, actual: %d", len(s.players), len(after.players))
+		t.FailNow()
+	}
+	for i, p := range s.players {
+		a := after.players[i]
+		if p.Person != a.Person || p.Court != a.Court || p.Position != a.Position || !p.Start.Equal(a.Start) {
+			t.Logf("Unexpected player at [%d]. expected: %v, actual: %v", i, p, after.players[i])
+			t.FailNow()
+		}
+	}
+}
+
+func TestFillCourtRollback(t *testing.T) {
+	teardown, db, _ := Setup(t)
+	defer teardown(t)
+
+	ctx := context.Background()
+
+	listOfCourts, err := ListCourtsTx(db, DefaultClubID)
+	if err != nil || len(listOfCourts) == 0 {
+		t.Log("Could not find any courts")
+		t.FailNow()
+	}
+	court := listOfCourts[0]
+
+	// Leave fewer waiters than there are positions on the court, so the fill
+	// fails
+	waiters, err := ListWaiters(ctx, db, DefaultClubID)
+	if err != nil {
+		t.Log("Could not list the waiters")
+		t.FailNow()
+	}
+	for _, w := range waiters[2:] {
+		err = MakePersonInactiveTx(ctx, db, DefaultClubID, w.Person)
+		if err != nil {
+			t.Log("Could not make a person inactive")
+			t.FailNow()
+		}
+	}
+
+	before := takeSnapshot(ctx, t, db)
+
+	_, err = FillCourtTx(ctx, db, DefaultClubID, court.ID, "")
+	if err == nil {
+		t.Log("Expected FillCourtTx to fail")
+		t.FailNow()
+	}
+
+	before.CheckUnchanged(ctx, t, db)
+}
+
+func TestFillCourtInjectedFailure(t *testing.T) {
+	teardown, db, _ := Setup(t)
+	defer teardown(t)
+
+	ctx := context.Background()
+
+	listOfCourts, err := ListCourtsTx(db, DefaultClubID)
+	if err != nil || len(listOfCourts) == 0 {
+		t.Log("Could not find any courts")
+		t.FailNow()
+	}
+	court := listOfCourts[0]
+
+	before := takeSnapshot(ctx, t, db)
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		t.Log("Could not begin a new transaction")
+		t.FailNow()
+	}
+
+	// The 4th statement is the second AddPlayer, by which time one player has
+	// been added and two waiters have been removed
+	q := &failingQueryer{Queryer: tx, failAt: 4}
+	_, err = FillCourt(ctx, q, court.ID, "")
+	if !errors.Is(err, errInjected) {
+		tx.Rollback()
+		t.Logf("Expected the injected failure, got: %v", err)
+		t.FailNow()
+	}
+
+	err = tx.Rollback()
+	if err != nil {
+		t.Log("Could not rollback the transaction")
+		t.FailNow()
+	}
+
+	before.CheckUnchanged(ctx, t, db)
+}
+
+func TestClearCourtInjectedFailure(t *testing.T) {
+	teardown, db, _ := Setup(t)
+	defer teardown(t)
+
+	ctx := context.Background()
+
+	listOfCourts, err := ListCourtsTx(db, DefaultClubID)
+	if err != nil || len(listOfCourts) == 0 {
+		t.Log("Could not find any courts")
+		t.FailNow()
+	}
+	court := listOfCourts[0]
+
+	_, err = FillCourtTx(ctx, db, DefaultClubID, court.ID, "")
+	if err != nil {
+		t.Log("Could not fill the court")
+		t.FailNow()
+	}
+
+	before := takeSnapshot(ctx, t, db)
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		t.Log("Could not begin a new transaction")
+		t.FailNow()
+	}
+
+	// Fail after the game has been recorded, and the first player has been
+	// removed and re-queued
+	q := &failingQueryer{Queryer: tx, failAt: 7}
+	err = ClearCourt(ctx, q, court.ID)
+	if !errors.Is(err, errInjected) {
+		tx.Rollback()
+		t.Logf("Expected the injected failure, got: %v", err)
+		t.FailNow()
+	}
+
+	err = tx.Rollback()
+	if err != nil {
+		t.Log("Could not rollback the transaction")
+		t.FailNow()
+	}
+
+	before.CheckUnchanged(ctx, t, db)
+}
+
+func TestFillCourtStaleVersion(t *testing.T) {
+	teardown, db, _ := Setup(t)
+	defer teardown(t)
+
+	ctx := context.Background()
+
+	listOfCourts, err := ListCourtsTx(db, DefaultClubID)
+	if err != nil || len(listOfCourts) < 2 {
+		t.Log("Could not find two courts")
+		t.FailNow()
+	}
+
+	version, err := GetVersion(ctx, db)
+	if err != nil {
+		t.Log("Could not get the version")
+		t.FailNow()
+	}
+
+	// Two organisers fill courts, both believing the state is at the same version
+	stale := WithExpectedVersion(ctx, version)
+
+	_, err = FillCourtTx(stale, db, DefaultClubID, listOfCourts[0].ID, "")
+	if err != nil {
+		t.Log("Could not fill the first court")
+		t.FailNow()
+	}
+
+	before := takeSnapshot(ctx, t, db)
+
+	_, err = FillCourtTx(stale, db, DefaultClubID, listOfCourts[1].ID, "")
+	if codeErr, ok := err.(*codeerror.CodeError); !ok || codeErr.Code() != http.StatusPreconditionFailed {
+		t.Logf("Expected a 'precondition failed' error, got: %v", err)
+		t.FailNow()
+	}
+
+	before.CheckUnchanged(ctx, t, db)
+
+	after, err := GetVersion(ctx, db)
+	if err != nil {
+		t.Log("Could not get the version")
+		t.FailNow()
+	}
+	if after != version+1 {
+		t.Logf("Unexpected version. expected: %d, actual: %d", version+1, after)
+		t.FailNow()
+	}
+}
@@ -5,12 +5,10 @@ import (
 	"database/sql"
 	"encoding/json"
 	"fmt"
-	"strconv"
 	"strings"
 
 	"github.com/rsmaxwell/players-api/internal/codeerror"
 
-	"github.com/rsmaxwell/players-api/internal/basic"
 	"github.com/rsmaxwell/players-api/internal/debug"
 )
 
@@ -24,40 +22,105 @@ type Position struct {
 // Court type
 type Court struct {
 	ID        int        `json:"id" db:"id"`
+	Club      int        `json:"club" db:"club"`
 	Name      string     `json:"name" db:"name" validate:"required,min=3,max=20"`
+	Capacity  int        `json:"capacity" db:"capacity"`
+	Strategy  string     `json:"strategy" db:"strategy"`
 	Positions []Position `json:"positions" db:"positions"`
 }
 
 // NullCourt type
 type NullCourt struct {
-	ID   int
-	Name sql.NullString
+	ID       int
+	Club     int
+	Name     sql.NullString
+	Capacity sql.NullInt32
+	Strategy sql.NullString
 }
 
 const (
-	CourtTable             = "court"
-	NumberOfCourtPositions = 4
+	CourtTable = "court"
+
+	// DefaultCourtCapacity is the number of players on a court, unless the
+	// court says otherwise
+	DefaultCourtCapacity = 4
 )
 
 var (
 	functionUpdateCourt   = debug.NewFunction(pkg, "UpdateCourt")
 	functionSaveCourt     = debug.NewFunction(pkg, "SaveCourt")
+	functionSaveCourtTx   = debug.NewFunction(pkg, "SaveCourtTx")
 	functionListCourts    = debug.NewFunction(pkg, "ListCourts")
 	functionListCourtsTx  = debug.NewFunction(pkg, "ListCourtsTx")
 	functionLoadCourt     = debug.NewFunction(pkg, "LoadCourt")
+	functionLoadClubCourt = debug.NewFunction(pkg, "LoadClubCourt")
+	functionListPositions = debug.NewFunction(pkg, "ListPositions")
 	functionDeleteCourt   = debug.NewFunction(pkg, "DeleteCourt")
 	functionDeleteCourtTx = debug.NewFunction(pkg, "DeleteCourtTx")
 )
 
-// SaveCourt writes a new Court to disk and returns the generated id
-func (c *Court) SaveCourt(ctx context.Context, db *sql.DB) error {
+// SaveCourtTx writes a new Court to disk, within a transaction
+func (c *Court) SaveCourtTx(ctx context.Context, db *sql.DB) error {
+	f := functionSaveCourtTx
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		message := "Could not begin a new transaction"
+		f.DumpError(err, message)
+		return err
+	}
+
+	_, err = IncrementVersion(ctx, tx)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	err = c.SaveCourt(ctx, tx)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		message := "Could not commit the transaction"
+		f.DumpError(err, message)
+		return err
+	}
+
+	return nil
+}
+
+// SaveCourt writes a new Court to disk and returns the generated id. A court
+// which names no club belongs to the default club
+func (c *Court) SaveCourt(ctx context.Context, db Queryer) error {
 	f := functionSaveCourt
 
-	fields := "name"
-	values := basic.Quote(c.Name)
+	if c.Club == 0 {
+		c.Club = DefaultClubID
+	}
+
+	if c.Capacity == 0 {
+		c.Capacity = DefaultCourtCapacity
+	}
+
+	if c.Strategy == "" {
+		c.Strategy = DefaultFillStrategy
+	}
+
+	err := c.checkCapacity()
+	if err != nil {
+		return err
+	}
+
+	_, err = FindFillStrategy(c.Strategy)
+	if err != nil {
+		return err
+	}
 
-	sqlStatement := "INSERT INTO " + CourtTable + " (" + fields + ") VALUES (" + values + ") RETURNING id"
-	err := db.QueryRowContext(ctx, sqlStatement).Scan(&c.ID)
+	sqlStatement := "INSERT INTO " + CourtTable + " (club, name, capacity, strategy) VALUES ($1, $2, $3, $4) RETURNING id"
+	err = db.QueryRowContext(ctx, sqlStatement, c.Club, c.Name, c.Capacity, c.Strategy).Scan(&c.ID)
 	if err != nil {
 		message := "Could not insert into " + CourtTable
 		d := f.DumpSQLError(err, message, sqlStatement)
@@ -69,13 +132,22 @@ func (c *Court) SaveCourt(ctx context.Context, db *sql.DB) error {
 }
 
 // UpdateCourt method
-func (c *Court) UpdateCourt(ctx context.Context, db *sql.DB) error {
+func (c *Court) UpdateCourt(ctx context.Context, db Queryer) error {
 	f := functionUpdateCourt
 
-	items := "name=" + basic.Quote(c.Name)
-	sqlStatement := "UPDATE " + CourtTable + " SET " + items + " WHERE id=" + strconv.Itoa(c.ID)
+	err := c.checkCapacity()
+	if err != nil {
+		return err
+	}
+
+	_, err = FindFillStrategy(c.Strategy)
+	if err != nil {
+		return err
+	}
+
+	sqlStatement := "UPDATE " + CourtTable + " SET name=$1, capacity=$2, strategy=$3 WHERE id=$4"
 
-	_, err := db.ExecContext(ctx, sqlStatement)
+	_, err = db.ExecContext(ctx, sqlStatement, c.Name, c.Capacity, c.Strategy, c.ID)
 	if err != nil {
 		message := "Could not update court"
 		f.DumpSQLError(err, message, sqlStatement)
@@ -86,12 +158,12 @@ func (c *Court) UpdateCourt(ctx context.Context, db *sql.DB) error {
 }
 
 // LoadCourt returns the Court with the given ID
-func (c *Court) LoadCourt(ctx context.Context, db *sql.DB) error {
+func (c *Court) LoadCourt(ctx context.Context, db Queryer) error {
 	f := functionLoadCourt
 
 	// Query the court
-	sqlStatement := "SELECT * FROM " + CourtTable + " WHERE ID=" + strconv.Itoa(c.ID)
-	rows, err := db.QueryContext(ctx, sqlStatement)
+	sqlStatement := "SELECT id, club, name, capacity, strategy FROM " + CourtTable + " WHERE ID=$1"
+	rows, err := db.QueryContext(ctx, sqlStatement, c.ID)
 	if err != nil {
 		message := "Could not select all people"
 		f.DumpSQLError(err, message, sqlStatement)
@@ -104,15 +176,27 @@ func (c *Court) LoadCourt(ctx context.Context, db *sql.DB) error {
 		count++
 
 		var nc NullCourt
-		err := rows.Scan(&nc.ID, &nc.Name)
+		err := rows.Scan(&nc.ID, &nc.Club, &nc.Name, &nc.Capacity, &nc.Strategy)
 		if err != nil {
 			message := "Could not scan the court"
 			f.DumpError(err, message)
 		}
 
+		c.Club = nc.Club
+
 		if nc.Name.Valid {
 			c.Name = nc.Name.String
 		}
+
+		c.Capacity = DefaultCourtCapacity
+		if nc.Capacity.Valid {
+			c.Capacity = int(nc.Capacity.Int32)
+		}
+
+		c.Strategy = DefaultFillStrategy
+		if nc.Strategy.Valid {
+			c.Strategy = nc.Strategy.String
+		}
 	}
 	err = rows.Err()
 	if err != nil {
@@ -133,10 +217,28 @@ func (c *Court) LoadCourt(ctx context.Context, db *sql.DB) error {
 	return nil
 }
 
-// DeleteCourt removes a court and associated playings
-func (c *Court) DeleteCourtTx(db *sql.DB) error {
+// LoadClubCourt returns the Court with the given ID, which must belong to
+// the club
+func (c *Court) LoadClubCourt(ctx context.Context, db Queryer, clubID int) error {
+	f := functionLoadClubCourt
+
+	err := c.LoadCourt(ctx, db)
+	if err != nil {
+		return err
+	}
+
+	if c.Club != clubID {
+		f.DebugVerbose("court [%d] belongs to club [%d], not club [%d]", c.ID, c.Club, clubID)
+		return codeerror.NewNotFound(fmt.Sprintf("Court id %d not found", c.ID))
+	}
+
+	return nil
+}
+
+// DeleteCourt removes a court and associated playings. The court must belong
+// to its Club
+func (c *Court) DeleteCourtTx(ctx context.Context, db *sql.DB) error {
 	f := functionDeleteCourtTx
-	ctx := context.Background()
 
 	tx, err := db.BeginTx(ctx, nil)
 	if err != nil {
@@ -145,24 +247,49 @@ func (c *Court) DeleteCourtTx(db *sql.DB) error {
 		return err
 	}
 
-	err = DeleteCourt(ctx, db, c.ID)
+	version, err := IncrementVersion(ctx, tx)
 	if err != nil {
 		tx.Rollback()
 		return err
 	}
 
-	err = tx.Commit()
+	err = c.LoadClubCourt(ctx, tx, c.Club)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	err = DeleteCourt(ctx, tx, c.ID)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	event, err := NewEvent(ctx, tx, version, c.Club, c.ID)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	err = Events.CommitAndPublish(tx, event)
 	if err != nil {
 		message := "Could not commit the transaction"
 		f.DumpError(err, message)
+		return err
 	}
 
 	return nil
 }
 
-func DeleteCourt(ctx context.Context, db *sql.DB, courtID int) error {
+func DeleteCourt(ctx context.Context, db Queryer, courtID int) error {
 	f := functionDeleteCourt
 
+	court := Court{ID: courtID}
+	err := court.LoadCourt(ctx, db)
+	if err != nil {
+		return err
+	}
+
 	players, err := ListPlayersForCourt(ctx, db, courtID)
 	if err != nil {
 		message := "Could not delete playings"
@@ -171,7 +298,7 @@ func DeleteCourt(ctx context.Context, db *sql.DB, courtID int) error {
 	}
 
 	for _, player := range players {
-		err = MakePlayerWait(ctx, db, player.Person)
+		err = MakePlayerWait(ctx, db, court.Club, player.Person)
 		if err != nil {
 			message := "Could not make player wait"
 			f.DumpError(err, message)
@@ -180,8 +307,8 @@ func DeleteCourt(ctx context.Context, db *sql.DB, courtID int) error {
 	}
 
 	// Remove the associated playing
-	sqlStatement := "DELETE FROM " + PlayingTable + " WHERE court=" + strconv.Itoa(courtID)
-	_, err = db.ExecContext(ctx, sqlStatement)
+	sqlStatement := "DELETE FROM " + PlayingTable + " WHERE court=$1"
+	_, err = db.ExecContext(ctx, sqlStatement, courtID)
 	if err != nil {
 		message := "Could not delete playings"
 		f.DumpSQLError(err, message, sqlStatement)
@@ -189,8 +316,8 @@ func DeleteCourt(ctx context.Context, db *sql.DB, courtID int) error {
 	}
 
 	// Remove the Court
-	sqlStatement = "DELETE FROM " + CourtTable + " WHERE ID=" + strconv.Itoa(courtID)
-	_, err = db.ExecContext(ctx, sqlStatement)
+	sqlStatement = "DELETE FROM " + CourtTable + " WHERE ID=$1"
+	_, err = db.ExecContext(ctx, sqlStatement, courtID)
 	if err != nil {
 		message := "Could not delete court"
 		f.DumpSQLError(err, message, sqlStatement)
@@ -200,8 +327,27 @@ func DeleteCourt(ctx context.Context, db *sql.DB, courtID int) error {
 	return nil
 }
 
-// ListCourts returns a list of the court IDs
-func ListCourtsTx(db *sql.DB) ([]Court, error) {
+// CourtFilter selects the courts of a club
+type CourtFilter struct {
+	Club   int    // the club of the courts
+	Search string // when given, only courts with this text in their name match
+}
+
+// courtSortColumns maps each sort key for courts to its column
+var courtSortColumns = map[string]string{
+	SortName: "name",
+	SortID:   "id",
+}
+
+// ListCourtsTx returns the courts of a club, within a transaction
+func ListCourtsTx(db *sql.DB, clubID int) ([]Court, error) {
+	list, _, err := ListCourtsPageTx(db, CourtFilter{Club: clubID}, Page{})
+	return list, err
+}
+
+// ListCourtsPageTx returns a page of the courts which match the filter, and
+// the number of courts which match, within a transaction
+func ListCourtsPageTx(db *sql.DB, filter CourtFilter, page Page) ([]Court, int, error) {
 	f := functionListCourtsTx
 	ctx := context.Background()
 
@@ -210,38 +356,59 @@ func ListCourtsTx(db *sql.DB) ([]Court, error) {
 	if err != nil {
 		message := "Could not begin a new transaction"
 		f.DumpError(err, message)
-		return nil, err
+		return nil, 0, err
 	}
 
-	list, err := ListCourts(ctx, db)
+	list, total, err := ListCourtsPage(ctx, tx, filter, page)
 	if err != nil {
 		tx.Rollback()
-		message := "Could not begin a new transaction"
+		message := "Could not list the courts"
 		f.DumpError(err, message)
-		return nil, err
+		return nil, 0, err
 	}
 
 	err = tx.Commit()
 	if err != nil {
 		message := "Could not commit the transaction"
 		f.DumpError(err, message)
+		return nil, 0, err
 	}
 
-	return list, nil
+	return list, total, nil
+}
+
+// ListCourts returns the courts of a club
+func ListCourts(ctx context.Context, db Queryer, clubID int) ([]Court, error) {
+	list, _, err := ListCourtsPage(ctx, db, CourtFilter{Club: clubID}, Page{})
+	return list, err
 }
 
-// ListCourts returns a list of the court IDs
-func ListCourts(ctx context.Context, db *sql.DB) ([]Court, error) {
+// ListCourtsPage returns a page of the courts which match the filter, and the
+// number of courts which match
+func ListCourtsPage(ctx context.Context, db Queryer, filter CourtFilter, page Page) ([]Court, int, error) {
 	f := functionListCourts
 
+	var args parameters
+
+	where := ` WHERE club=` + args.add(filter.Club)
+	if filter.Search != "" {
+		where = where + ` AND name ILIKE ` + args.add(containsPattern(filter.Search))
+	}
+	filterArgs := args
+
+	clauses, err := page.clauses(courtSortColumns, &args)
+	if err != nil {
+		return nil, 0, err
+	}
+
 	// Query the courts
-	returnedFields := []string{`id`, `name`}
-	sqlStatement := `SELECT ` + strings.Join(returnedFields, `, `) + ` FROM ` + CourtTable + ` ORDER BY ` + `name`
-	rows, err := db.QueryContext(ctx, sqlStatement)
+	returnedFields := []string{`id`, `club`, `name`, `capacity`, `strategy`}
+	sqlStatement := `SELECT ` + strings.Join(returnedFields, `, `) + ` FROM ` + CourtTable + where + clauses
+	rows, err := db.QueryContext(ctx, sqlStatement, args...)
 	if err != nil {
 		message := "Could not select all from " + CourtTable
 		f.DumpSQLError(err, message, sqlStatement)
-		return nil, err
+		return nil, 0, err
 	}
 	defer rows.Close()
 
@@ -251,50 +418,95 @@ func ListCourts(ctx context.Context, db *sql.DB) ([]Court, error) {
 		court := Court{}
 		court.Positions = make([]Position, 0)
 
-		err := rows.Scan(&court.ID, &court.Name)
+		err := rows.Scan(&court.ID, &court.Club, &court.Name, &court.Capacity, &court.Strategy)
 		if err != nil {
 			message := "Could not scan the court"
 			f.DumpError(err, message)
-			return nil, err
+			return nil, 0, err
 		}
 
-		players, err := ListPlayersForCourt(ctx, db, court.ID)
+		list = append(list, court)
+	}
+	err = rows.Err()
+	if err != nil {
+		message := "Could not list all from " + CourtTable
+		f.DumpError(err, message)
+		return nil, 0, err
+	}
+	rows.Close()
+
+	// The positions are loaded once the courts have been read, because a
+	// transaction cannot run a new query while the rows are still open
+	for i := range list {
+		court := &list[i]
+
+		positions, err := ListPositions(ctx, db, court.ID)
 		if err != nil {
-			message := "Could not list the players on this court"
+			message := "Could not list the positions on this court"
 			f.Errorf(message)
 			d := f.DumpError(err, message)
 
 			data, _ := json.MarshalIndent(court, "", "    ")
 			d.AddByteArray("court.json", data)
 
-			return nil, err
+			return nil, 0, err
 		}
 
-		for _, player := range players {
-
-			person := FullPerson{ID: player.Person}
-			err := person.LoadPerson(ctx, db)
-			if err != nil {
-				message := fmt.Sprintf("Could not load the player [%d]", player.Person)
-				d := f.DumpError(err, message)
-				d.AddObject("court.json", court)
-				d.AddObject("player.json", player)
-				return nil, err
-			}
-			position := Position{Index: player.Position, PersonID: player.Person, DisplayName: person.Knownas}
-			court.Positions = append(court.Positions, position)
-		}
+		court.Positions = positions
+	}
 
-		list = append(list, court)
+	if page.whole() {
+		return list, len(list), nil
 	}
-	err = rows.Err()
+
+	var total int
+	sqlStatement = `SELECT COUNT(*) FROM ` + CourtTable + where
+	err = db.QueryRowContext(ctx, sqlStatement, filterArgs...).Scan(&total)
 	if err != nil {
-		message := "Could not list all from " + CourtTable
+		message := "Could not count the courts"
+		f.DumpSQLError(err, message, sqlStatement)
+		return nil, 0, err
+	}
+
+	return list, total, nil
+}
+
+// ListPositions returns the positions of the players on a court
+func ListPositions(ctx context.Context, db Queryer, courtID int) ([]Position, error) {
+	f := functionListPositions
+
+	players, err := ListPlayersForCourt(ctx, db, courtID)
+	if err != nil {
+		message := "Could not list the players on this court"
+		f.Errorf(message)
 		f.DumpError(err, message)
 		return nil, err
 	}
 
-	return list, nil
+	positions := make([]Position, 0)
+	for _, player := range players {
+
+		person := FullPerson{ID: player.Person}
+		err := person.LoadPerson(ctx, db)
+		if err != nil {
+			message := fmt.Sprintf("Could not load the player [%d]", player.Person)
+			d := f.DumpError(err, message)
+			d.AddObject("player.json", player)
+			return nil, err
+		}
+		position := Position{Index: player.Position, PersonID: player.Person, DisplayName: person.Knownas}
+		positions = append(positions, position)
+	}
+
+	return positions, nil
+}
+
+// checkCapacity makes sure the court has room for at least one player
+func (c *Court) checkCapacity() error {
+	if c.Capacity < 1 {
+		return codeerror.NewBadRequest(fmt.Sprintf("Unexpected capacity: %d", c.Capacity))
+	}
+	return nil
 }
 
 // Dump writes the person to a dump file
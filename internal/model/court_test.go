@@ -50,18 +50,121 @@ func TestCourts(t *testing.T) {
 	}
 	c2.Check(ctx, t, db, name3)
 
-	err = c.DeleteCourtTx(db)
+	err = c.DeleteCourtTx(ctx, db)
 	if err != nil {
 		t.Log("Could not delete court")
 		t.FailNow()
 	}
-	err = c2.DeleteCourtTx(db)
+	err = c2.DeleteCourtTx(ctx, db)
 	if err != nil {
 		t.Log("Could not delete court")
 		t.FailNow()
 	}
 }
 
+func TestCourtCapacity(t *testing.T) {
+	teardown, db, _ := Setup(t)
+	defer teardown(t)
+
+	ctx := context.Background()
+
+	c := Court{Name: "Singles", Capacity: 2}
+	err := c.SaveCourtTx(ctx, db)
+	if err != nil {
+		t.Log("Could not create new court")
+		t.FailNow()
+	}
+
+	fill, err := FillCourtTx(ctx, db, DefaultClubID, c.ID, "")
+	if err != nil {
+		t.Log("Could not fill the court")
+		t.FailNow()
+	}
+	if len(fill.Positions) != c.Capacity {
+		t.Logf("Unexpected number of positions. expected: %d, actual: %d", c.Capacity, len(fill.Positions))
+		t.FailNow()
+	}
+
+	waiters, err := ListWaiters(ctx, db, DefaultClubID)
+	if err != nil || len(waiters) == 0 {
+		t.Log("Could not find any waiters")
+		t.FailNow()
+	}
+
+	err = MakePlayerPlayTx(ctx, db, DefaultClubID, waiters[0].Person, c.ID, c.Capacity)
+	if err == nil {
+		t.Log("Expected a position beyond the capacity to be refused")
+		t.FailNow()
+	}
+
+	err = UpdateCourtFieldsTx(ctx, db, DefaultClubID, c.ID, map[string]interface{}{"capacity": float64(1)})
+	if err == nil {
+		t.Log("Expected the capacity to stay above the positions in use")
+		t.FailNow()
+	}
+
+	err = c.DeleteCourtTx(ctx, db)
+	if err != nil {
+		t.Log("Could not delete court")
+		t.FailNow()
+	}
+}
+
+func TestHostileCourtNames(t *testing.T) {
+	teardown, db, _ := Setup(t)
+	defer teardown(t)
+
+	ctx := context.Background()
+
+	names := []string{
+		"Court'); DROP TABLE court; --",
+		"$@@@$'); DELETE FROM person; --$@@@$",
+		"$$ || (SELECT hash FROM person LIMIT 1) || $$",
+		`back\slash "double" 'single' ` + "`tick`",
+	}
+
+	before, err := ListCourts(ctx, db, DefaultClubID)
+	if err != nil {
+		t.Log("Could not list the courts")
+		t.FailNow()
+	}
+
+	for _, name := range names {
+		c := Court{Name: name}
+		err := c.SaveCourtTx(ctx, db)
+		if err != nil {
+			t.Logf("Could not create a court named [%s]", name)
+			t.FailNow()
+		}
+		c.Check(ctx, t, db, name)
+
+		c.Name = name + name
+		err = c.UpdateCourt(ctx, db)
+		if err != nil {
+			t.Logf("Could not rename the court [%s]", name)
+			t.FailNow()
+		}
+		c.Check(ctx, t, db, name+name)
+
+		err = c.DeleteCourtTx(ctx, db)
+		if err != nil {
+			t.Logf("Could not delete the court [%s]", name)
+			t.FailNow()
+		}
+	}
+
+	after, err := ListCourts(ctx, db, DefaultClubID)
+	if err != nil {
+		t.Log("Could not list the courts")
+		t.FailNow()
+	}
+
+	if len(after) != len(before) {
+		t.Logf("Unexpected number of courts. expected: %d, actual: %d", len(before), len(after))
+		t.FailNow()
+	}
+}
+
 func (c *Court) Check(ctx context.Context, t *testing.T, db *sql.DB, name string) error {
 	err := c.LoadCourt(ctx, db)
 	if err != nil {
@@ -0,0 +1,15 @@
+package model
+
+import (
+	"context"
+	"database/sql"
+)
+
+// Queryer is the set of query methods shared by *sql.DB and *sql.Tx, so the
+// model functions can run either directly against the database or inside the
+// transaction started by one of the *Tx wrappers
+type Queryer interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
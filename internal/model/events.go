@@ -0,0 +1,255 @@
+package model
+
+import (
+	"context"
+	"database/sql"
+	"sync"
+
+	"github.com/rsmaxwell/players-api/internal/debug"
+)
+
+// CourtPositions type
+type CourtPositions struct {
+	ID        int        `json:"id"`
+	Positions []Position `json:"positions"`
+}
+
+// Event describes a change to the courts or the queue of a club. It carries
+// the new positions on each of the affected courts, and the new order of the
+// club's waiters
+type Event struct {
+	Version int64            `json:"version"`
+	Club    int              `json:"club"`
+	Courts  []CourtPositions `json:"courts"`
+	Waiters []Waiter         `json:"waiters"`
+}
+
+// Broadcaster passes events from the model to any number of subscribers
+// within this process
+type Broadcaster struct {
+	mutex       sync.Mutex
+	subscribers map[chan *Event]bool
+	closed      bool
+
+	// publishing is held from before a commit until its event is published
+	publishing sync.Mutex
+}
+
+const (
+	// eventBufferSize is the number of events held for a slow subscriber
+	// before its subscription is ended
+	eventBufferSize = 16
+)
+
+var (
+	functionNewEvent        = debug.NewFunction(pkg, "NewEvent")
+	functionPlayingCourts   = debug.NewFunction(pkg, "playingCourts")
+	functionPublish         = debug.NewFunction(pkg, "Publish")
+	functionListAllCourtIDs = debug.NewFunction(pkg, "listAllCourtIDs")
+)
+
+var (
+	// Events is the broadcaster fed by the model functions which change the
+	// playing or waiting tables
+	Events = NewBroadcaster()
+)
+
+// NewBroadcaster returns a new Broadcaster
+func NewBroadcaster() *Broadcaster {
+	return &Broadcaster{subscribers: make(map[chan *Event]bool)}
+}
+
+// Subscribe returns a channel which receives each published event, and a
+// function to cancel the subscription. The channel is closed at once if the
+// broadcaster is closed
+func (b *Broadcaster) Subscribe() (<-chan *Event, func()) {
+
+	ch := make(chan *Event, eventBufferSize)
+
+	b.mutex.Lock()
+	if b.closed {
+		close(ch)
+	} else {
+		b.subscribers[ch] = true
+	}
+	b.mutex.Unlock()
+
+	cancel := func() {
+		b.mutex.Lock()
+		defer b.mutex.Unlock()
+
+		if b.subscribers[ch] {
+			delete(b.subscribers, ch)
+			close(ch)
+		}
+	}
+
+	return ch, cancel
+}
+
+// Close closes the channel of every subscriber, so the streams reading them
+// end, and of every later subscriber
+func (b *Broadcaster) Close() {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	b.closed = true
+	for ch := range b.subscribers {
+		delete(b.subscribers, ch)
+		close(ch)
+	}
+}
+
+// Publish sends an event to every subscriber. Publish never blocks: a
+// subscriber whose buffer is full would miss the event, and an event only
+// carries the courts it changed, so the subscription is ended instead. The
+// subscriber reads the events already buffered, then finds its channel
+// closed, and must subscribe again and take a fresh snapshot
+func (b *Broadcaster) Publish(event *Event) {
+	f := functionPublish
+
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	for ch := range b.subscribers {
+		select {
+		case ch <- event:
+		default:
+			f.DebugVerbose("ended a slow subscription at event: version: %d", event.Version)
+			delete(b.subscribers, ch)
+			close(ch)
+		}
+	}
+}
+
+// CommitAndPublish commits the transaction which made the event, then
+// publishes the event. The transactions which increment the version commit
+// in version order, as each holds the state row until it ends. Holding the
+// publishing lock from before the commit until the event is published keeps
+// the events in that order too
+func (b *Broadcaster) CommitAndPublish(tx *sql.Tx, event *Event) error {
+	b.publishing.Lock()
+	defer b.publishing.Unlock()
+
+	err := tx.Commit()
+	if err != nil {
+		return err
+	}
+
+	b.Publish(event)
+	return nil
+}
+
+// NewEvent reads the positions on the given courts and the order of the
+// club's waiters. It is called within the transaction making the change, so
+// the event matches the state being committed
+func NewEvent(ctx context.Context, db Queryer, version int64, clubID int, courtIDs ...int) (*Event, error) {
+	f := functionNewEvent
+
+	event := Event{Version: version, Club: clubID}
+	event.Courts = make([]CourtPositions, 0)
+
+	seen := make(map[int]bool)
+	for _, courtID := range courtIDs {
+		if seen[courtID] {
+			continue
+		}
+		seen[courtID] = true
+
+		positions, err := ListPositions(ctx, db, courtID)
+		if err != nil {
+			message := "Could not list the positions"
+			f.Errorf(message)
+			f.DumpError(err, message)
+			return nil, err
+		}
+
+		event.Courts = append(event.Courts, CourtPositions{ID: courtID, Positions: positions})
+	}
+
+	waiters, err := ListWaiters(ctx, db, clubID)
+	if err != nil {
+		message := "Could not list the waiters"
+		f.Errorf(message)
+		f.DumpError(err, message)
+		return nil, err
+	}
+
+	if waiters == nil {
+		waiters = make([]Waiter, 0)
+	}
+	event.Waiters = waiters
+
+	return &event, nil
+}
+
+// NewSnapshotEvent returns an event carrying every court and the whole queue
+// of a club
+func NewSnapshotEvent(ctx context.Context, db Queryer, clubID int) (*Event, error) {
+
+	version, err := GetVersion(ctx, db)
+	if err != nil {
+		return nil, err
+	}
+
+	courtIDs, err := listAllCourtIDs(ctx, db, clubID)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewEvent(ctx, db, version, clubID, courtIDs...)
+}
+
+// playingCourts returns the courts the person is playing on
+func playingCourts(ctx context.Context, db Queryer, personID int) ([]int, error) {
+	f := functionPlayingCourts
+
+	players, err := ListPlayersForPerson(ctx, db, personID)
+	if err != nil {
+		message := "Could not list the players for the person"
+		f.Errorf(message)
+		f.DumpError(err, message)
+		return nil, err
+	}
+
+	courtIDs := make([]int, 0)
+	for _, player := range players {
+		courtIDs = append(courtIDs, player.Court)
+	}
+
+	return courtIDs, nil
+}
+
+// listAllCourtIDs returns the IDs of every court of a club
+func listAllCourtIDs(ctx context.Context, db Queryer, clubID int) ([]int, error) {
+	f := functionListAllCourtIDs
+
+	sqlStatement := "SELECT id FROM " + CourtTable + " WHERE club=$1 ORDER BY name"
+	rows, err := db.QueryContext(ctx, sqlStatement, clubID)
+	if err != nil {
+		message := "Could not select the courts"
+		f.DumpSQLError(err, message, sqlStatement)
+		return nil, err
+	}
+	defer rows.Close()
+
+	courtIDs := make([]int, 0)
+	for rows.Next() {
+		var id int
+		err := rows.Scan(&id)
+		if err != nil {
+			message := "Could not scan the court"
+			f.DumpError(err, message)
+			return nil, err
+		}
+		courtIDs = append(courtIDs, id)
+	}
+	err = rows.Err()
+	if err != nil {
+		message := "Could not list the courts"
+		f.DumpError(err, message)
+		return nil, err
+	}
+
+	return courtIDs, nil
+}
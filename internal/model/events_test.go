@@ -0,0 +1,131 @@
+package model
+
+import (
+	"context"
+	"sync"
+	"testing"
+)
+
+func TestBroadcaster(t *testing.T) {
+
+	b := NewBroadcaster()
+
+	ch1, cancel1 := b.Subscribe()
+	ch2, cancel2 := b.Subscribe()
+	defer cancel2()
+
+	b.Publish(&Event{Version: 1})
+
+	for i, ch := range []<-chan *Event{ch1, ch2} {
+		event := <-ch
+		if event.Version != 1 {
+			t.Logf("Unexpected version for subscriber [%d]. expected: %d, actual: %d", i, 1, event.Version)
+			t.FailNow()
+		}
+	}
+
+	// A cancelled subscriber's channel is closed, and misses later events
+	cancel1()
+	b.Publish(&Event{Version: 2})
+
+	if _, ok := <-ch1; ok {
+		t.Log("Expected the cancelled channel to be closed")
+		t.FailNow()
+	}
+
+	event := <-ch2
+	if event.Version != 2 {
+		t.Logf("Unexpected version. expected: %d, actual: %d", 2, event.Version)
+		t.FailNow()
+	}
+
+	// A slow subscriber does not hold up the publisher. Rather than miss an
+	// event, it gets the events already buffered, then its channel is closed
+	for i := 0; i < eventBufferSize+1; i++ {
+		b.Publish(&Event{Version: int64(3 + i)})
+	}
+
+	for i := 0; i < eventBufferSize; i++ {
+		event, ok := <-ch2
+		if !ok || event.Version != int64(3+i) {
+			t.Logf("Unexpected buffered event [%d]: %v", i, event)
+			t.FailNow()
+		}
+	}
+
+	if _, ok := <-ch2; ok {
+		t.Log("Expected the slow subscriber's channel to be closed")
+		t.FailNow()
+	}
+}
+
+func TestBroadcasterClose(t *testing.T) {
+
+	b := NewBroadcaster()
+
+	ch1, cancel1 := b.Subscribe()
+
+	// Closing ends every subscription, and cancelling afterwards is harmless
+	b.Close()
+	cancel1()
+
+	if _, ok := <-ch1; ok {
+		t.Log("Expected the channel to be closed")
+		t.FailNow()
+	}
+
+	ch2, cancel2 := b.Subscribe()
+	defer cancel2()
+
+	b.Publish(&Event{Version: 1})
+
+	if _, ok := <-ch2; ok {
+		t.Log("Expected a channel subscribed after closing to be closed")
+		t.FailNow()
+	}
+}
+
+func TestEventOrder(t *testing.T) {
+
+	teardown, db, _ := Setup(t)
+	defer teardown(t)
+
+	courts, err := ListCourts(context.Background(), db, DefaultClubID)
+	if err != nil || len(courts) < 2 {
+		t.Logf("Could not list the courts: %v", err)
+		t.FailNow()
+	}
+
+	events, cancel := Events.Subscribe()
+	defer cancel()
+
+	// Concurrent changes publish their events in version order
+	var wait sync.WaitGroup
+	errors := make(chan error, len(courts))
+	for _, court := range courts {
+		wait.Add(1)
+		go func(courtID int) {
+			defer wait.Done()
+			errors <- ClearCourtTx(context.Background(), db, DefaultClubID, courtID)
+		}(court.ID)
+	}
+	wait.Wait()
+	close(errors)
+
+	for err := range errors {
+		if err != nil {
+			t.Logf("Could not clear the court: %v", err)
+			t.FailNow()
+		}
+	}
+
+	var last int64
+	for range courts {
+		event := <-events
+		if event.Version <= last {
+			t.Logf("Unexpected event order. version: %d after: %d", event.Version, last)
+			t.FailNow()
+		}
+		last = event.Version
+	}
+}
@@ -0,0 +1,265 @@
+package model
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rsmaxwell/players-api/internal/debug"
+)
+
+// GamePlayer type
+type GamePlayer struct {
+	Person   int `json:"person"`
+	Position int `json:"position"`
+	Team     int `json:"team"`
+	Wait     int `json:"wait"` // seconds spent waiting before being called
+}
+
+// Game type
+type Game struct {
+	ID      int          `json:"id"`
+	Club    int          `json:"club"`
+	Court   int          `json:"court"`
+	Session int          `json:"session"` // zero if no session was open
+	Start   time.Time    `json:"start"`
+	End     time.Time    `json:"end"`
+	Players []GamePlayer `json:"players"`
+}
+
+// GameFilter selects games. A zero field does not restrict the games
+type GameFilter struct {
+	Club    int       // games played at the club
+	Person  int       // games the person played in
+	Court   int       // games played on the court
+	Session int       // games played during the session
+	From    time.Time // games which ended at or after this time
+	To      time.Time // games which started before this time
+	Limit   int       // the number of games, most recent first
+}
+
+const (
+	// GameTable is the name of the game table
+	GameTable = "game"
+
+	// GamePlayerTable is the name of the table of the players in each game
+	GamePlayerTable = "gameplayer"
+)
+
+var (
+	functionSaveGame        = debug.NewFunction(pkg, "SaveGame")
+	functionListGames       = debug.NewFunction(pkg, "ListGames")
+	functionListGamePlayers = debug.NewFunction(pkg, "listGamePlayers")
+)
+
+// NewGame returns the game being played by the players on a court, which
+// started when the first of them was put on the court and ends now
+func NewGame(court *Court, players []Player) *Game {
+
+	game := Game{Club: court.Club, Court: court.ID, End: time.Now()}
+	game.Players = make([]GamePlayer, 0)
+
+	for i, player := range players {
+		if i == 0 || player.Start.Before(game.Start) {
+			game.Start = player.Start
+		}
+
+		team := teamOf(court, player.Position)
+		game.Players = append(game.Players, GamePlayer{Person: player.Person, Position: player.Position, Team: team, Wait: player.Wait})
+	}
+
+	return &game
+}
+
+// SaveGame writes a new Game to disk, and sets the generated id
+func (g *Game) SaveGame(ctx context.Context, db Queryer) error {
+	f := functionSaveGame
+
+	if g.Club == 0 {
+		g.Club = DefaultClubID
+	}
+
+	sqlStatement := "INSERT INTO " + GameTable + " (club, court, session, start, finish) VALUES ($1, $2, $3, $4, $5) RETURNING id"
+	err := db.QueryRowContext(ctx, sqlStatement, g.Club, g.Court, g.Session, g.Start, g.End).Scan(&g.ID)
+	if err != nil {
+		message := "Could not insert into " + GameTable
+		d := f.DumpSQLError(err, message, sqlStatement)
+		d.AddObject("game.json", g)
+		return err
+	}
+
+	sqlStatement = "INSERT INTO " + GamePlayerTable + " (game, person, position, team, wait) VALUES ($1, $2, $3, $4, $5)"
+	for _, player := range g.Players {
+		_, err = db.ExecContext(ctx, sqlStatement, g.ID, player.Person, player.Position, player.Team, player.Wait)
+		if err != nil {
+			message := "Could not insert into " + GamePlayerTable
+			d := f.DumpSQLError(err, message, sqlStatement)
+			d.AddObject("game.json", g)
+			return err
+		}
+	}
+
+	return nil
+}
+
+// ListGames returns the games matching the filter, most recent first
+func ListGames(ctx context.Context, db Queryer, filter GameFilter) ([]Game, error) {
+	f := functionListGames
+
+	var conditions []string
+	var args []interface{}
+
+	parameter := func(value interface{}) string {
+		args = append(args, value)
+		return "$" + strconv.Itoa(len(args))
+	}
+
+	if filter.Club != 0 {
+		conditions = append(conditions, "club="+parameter(filter.Club))
+	}
+	if filter.Person != 0 {
+		conditions = append(conditions, "id IN (SELECT game FROM "+GamePlayerTable+" WHERE person="+parameter(filter.Person)+")")
+	}
+	if filter.Court != 0 {
+		conditions = append(conditions, "court="+parameter(filter.Court))
+	}
+	if filter.Session != 0 {
+		conditions = append(conditions, "session="+parameter(filter.Session))
+	}
+	if !filter.From.IsZero() {
+		conditions = append(conditions, "finish>="+parameter(filter.From))
+	}
+	if !filter.To.IsZero() {
+		conditions = append(conditions, "start<"+parameter(filter.To))
+	}
+
+	sqlStatement := "SELECT id, club, court, session, start, finish FROM " + GameTable
+	if len(conditions) > 0 {
+		sqlStatement = sqlStatement + " WHERE " + strings.Join(conditions, " AND ")
+	}
+	sqlStatement = sqlStatement + " ORDER BY start DESC, id DESC"
+	if filter.Limit > 0 {
+		sqlStatement = sqlStatement + " LIMIT " + parameter(filter.Limit)
+	}
+
+	rows, err := db.QueryContext(ctx, sqlStatement, args...)
+	if err != nil {
+		message := "Could not select from " + GameTable
+		f.DumpSQLError(err, message, sqlStatement)
+		return nil, err
+	}
+	defer rows.Close()
+
+	list := make([]Game, 0)
+	for rows.Next() {
+
+		var game Game
+		err := rows.Scan(&game.ID, &game.Club, &game.Court, &game.Session, &game.Start, &game.End)
+		if err != nil {
+			message := "Could not scan the game"
+			f.DumpError(err, message)
+			return nil, err
+		}
+
+		list = append(list, game)
+	}
+	err = rows.Err()
+	if err != nil {
+		message := "Could not list the games"
+		f.DumpError(err, message)
+		return nil, err
+	}
+	rows.Close()
+
+	// The players are loaded once the games have been read, because a
+	// transaction cannot run a new query while the rows are still open
+	for i := range list {
+		list[i].Players, err = listGamePlayers(ctx, db, list[i].ID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return list, nil
+}
+
+// listGamePlayers returns the players in a game
+func listGamePlayers(ctx context.Context, db Queryer, gameID int) ([]GamePlayer, error) {
+	f := functionListGamePlayers
+
+	sqlStatement := "SELECT person, position, team, wait FROM " + GamePlayerTable + " WHERE game=$1 ORDER BY position"
+	rows, err := db.QueryContext(ctx, sqlStatement, gameID)
+	if err != nil {
+		message := "Could not select from " + GamePlayerTable
+		f.DumpSQLError(err, message, sqlStatement)
+		return nil, err
+	}
+	defer rows.Close()
+
+	list := make([]GamePlayer, 0)
+	for rows.Next() {
+
+		var player GamePlayer
+		err := rows.Scan(&player.Person, &player.Position, &player.Team, &player.Wait)
+		if err != nil {
+			message := "Could not scan the game player"
+			f.DumpError(err, message)
+			return nil, err
+		}
+
+		list = append(list, player)
+	}
+	err = rows.Err()
+	if err != nil {
+		message := "Could not list the game players"
+		f.DumpError(err, message)
+		return nil, err
+	}
+
+	return list, nil
+}
+
+// Partners returns the other people on the person's team
+func (g *Game) Partners(personID int) []int {
+
+	team := -1
+	for _, player := range g.Players {
+		if player.Person == personID {
+			team = player.Team
+		}
+	}
+
+	partners := make([]int, 0)
+	for _, player := range g.Players {
+		if player.Team == team && player.Person != personID {
+			partners = append(partners, player.Person)
+		}
+	}
+
+	return partners
+}
+
+// Opponents returns the people on the other team to the person
+func (g *Game) Opponents(personID int) []int {
+
+	team := -1
+	for _, player := range g.Players {
+		if player.Person == personID {
+			team = player.Team
+		}
+	}
+
+	opponents := make([]int, 0)
+	if team < 0 {
+		return opponents
+	}
+
+	for _, player := range g.Players {
+		if player.Team != team {
+			opponents = append(opponents, player.Person)
+		}
+	}
+
+	return opponents
+}
@@ -0,0 +1,106 @@
+package model
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	_ "github.com/jackc/pgx/stdlib"
+)
+
+func TestGameHistory(t *testing.T) {
+	teardown, db, _ := Setup(t)
+	defer teardown(t)
+
+	ctx := context.Background()
+
+	listOfCourts, err := ListCourtsTx(db, DefaultClubID)
+	if err != nil || len(listOfCourts) == 0 {
+		t.Log("Could not find any courts")
+		t.FailNow()
+	}
+	court := listOfCourts[0]
+
+	begin := time.Now()
+
+	_, err = FillCourtTx(ctx, db, DefaultClubID, court.ID, "")
+	if err != nil {
+		t.Log("Could not fill the court")
+		t.FailNow()
+	}
+
+	err = ClearCourtTx(ctx, db, DefaultClubID, court.ID)
+	if err != nil {
+		t.Log("Could not clear the court")
+		t.FailNow()
+	}
+
+	games, err := ListGames(ctx, db, GameFilter{Court: court.ID})
+	if err != nil {
+		t.Log("Could not list the games")
+		t.FailNow()
+	}
+	if len(games) != 1 {
+		t.Logf("Unexpected number of games. expected: 1, actual: %d", len(games))
+		t.FailNow()
+	}
+
+	game := games[0]
+	if len(game.Players) != court.Capacity {
+		t.Logf("Unexpected number of players. expected: %d, actual: %d", court.Capacity, len(game.Players))
+		t.FailNow()
+	}
+	if game.End.Before(game.Start) {
+		t.Logf("The game ended before it started: start: %s, end: %s", game.Start, game.End)
+		t.FailNow()
+	}
+
+	// The same game is found through any of its players
+	games, err = ListGames(ctx, db, GameFilter{Person: game.Players[0].Person, From: begin})
+	if err != nil || len(games) != 1 || games[0].ID != game.ID {
+		t.Log("Could not find the game by person")
+		t.FailNow()
+	}
+
+	// ... but not before it started
+	games, err = ListGames(ctx, db, GameFilter{To: begin.Add(-time.Minute)})
+	if err != nil || len(games) != 0 {
+		t.Log("Unexpected games before the game started")
+		t.FailNow()
+	}
+
+	// Clearing an empty court does not record a game
+	err = ClearCourtTx(ctx, db, DefaultClubID, court.ID)
+	if err != nil {
+		t.Log("Could not clear the empty court")
+		t.FailNow()
+	}
+
+	games, err = ListGames(ctx, db, GameFilter{Court: court.ID})
+	if err != nil || len(games) != 1 {
+		t.Log("Unexpected game for an empty court")
+		t.FailNow()
+	}
+}
+
+func TestGamePartners(t *testing.T) {
+
+	game := Game{Players: []GamePlayer{
+		{Person: 1, Position: 0, Team: 0},
+		{Person: 2, Position: 1, Team: 0},
+		{Person: 3, Position: 2, Team: 1},
+		{Person: 4, Position: 3, Team: 1},
+	}}
+
+	partners := game.Partners(3)
+	if !EqualIntArray(partners, []int{4}) {
+		t.Logf("Unexpected partners. expected: [4], actual: %v", partners)
+		t.FailNow()
+	}
+
+	partners = game.Partners(5)
+	if len(partners) != 0 {
+		t.Logf("Unexpected partners for someone not in the game: %v", partners)
+		t.FailNow()
+	}
+}
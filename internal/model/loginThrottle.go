@@ -0,0 +1,280 @@
+package model
+
+import (
+	"strings"
+	"sync"
+	"time"
+)
+
+// LoginPolicy type. After each failed signin the caller must wait for the
+// backoff, which doubles with each failure up to the maximum. After
+// MaxFailures failures in a row, for the same email, the account is locked
+// for the lockout. Zero values disable each measure
+type LoginPolicy struct {
+	MaxFailures int
+	Lockout     time.Duration
+	Backoff     time.Duration
+	MaxBackoff  time.Duration
+}
+
+// LoginStats type
+type LoginStats struct {
+	FailedSignins    int `json:"failedSignins"`
+	ThrottledSignins int `json:"throttledSignins"`
+	Lockouts         int `json:"lockouts"`
+	LockedAccounts   int `json:"lockedAccounts"`
+}
+
+// LoginThrottle type. Failed signins are counted per email and per remote
+// address. Only emails are locked, because many people may share an
+// address
+type LoginThrottle struct {
+	lock      sync.Mutex
+	emails    map[string]*loginFailures
+	addresses map[string]*loginFailures
+	stats     LoginStats
+	now       func() time.Time
+}
+
+type loginFailures struct {
+	count       int
+	last        time.Time
+	lockedUntil time.Time
+	inFlight    int // attempts which passed Check, and have not yet ended
+}
+
+const (
+	// pruneSize is the number of entries above which stale entries are
+	// removed
+	pruneSize = 1024
+
+	// inFlightWait is the wait given while the attempts in flight could
+	// lock the account, and there is no backoff to give instead
+	inFlightWait = time.Second
+)
+
+var (
+	// LoginAttempts tracks the failed signins
+	LoginAttempts = NewLoginThrottle()
+)
+
+// NewLoginThrottle initialises a LoginThrottle object
+func NewLoginThrottle() *LoginThrottle {
+	return &LoginThrottle{
+		emails:    map[string]*loginFailures{},
+		addresses: map[string]*loginFailures{},
+		now:       time.Now,
+	}
+}
+
+// normaliseEmail returns the key under which an email is tracked
+func normaliseEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+// Check returns how long the caller must wait before trying to sign in
+// again, and whether that is because the account is locked. Without a wait,
+// the attempt is in flight until it is ended by Failed, Succeeded or
+// Release. The attempts in flight count as failures for the attempts after
+// them, so parallel attempts cannot all pass before any has failed
+func (t *LoginThrottle) Check(policy LoginPolicy, email string, address string) (time.Duration, bool) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	now := t.now()
+	key := normaliseEmail(email)
+
+	emailWait, locked := t.emails[key].wait(policy, now, true)
+	addressWait, _ := t.addresses[address].wait(policy, now, false)
+
+	wait := emailWait
+	if addressWait > wait {
+		wait = addressWait
+	}
+
+	if wait > 0 {
+		t.stats.ThrottledSignins++
+		return wait, locked
+	}
+
+	t.prune(policy, now)
+	entry(t.emails, key).inFlight++
+	entry(t.addresses, address).inFlight++
+
+	return 0, false
+}
+
+// Failed records a failed signin, and returns whether the account is now
+// locked
+func (t *LoginThrottle) Failed(policy LoginPolicy, email string, address string) bool {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	now := t.now()
+	t.stats.FailedSignins++
+
+	key := normaliseEmail(email)
+	t.emails[key].end()
+	t.addresses[address].end()
+
+	t.prune(policy, now)
+
+	emailFailures := t.record(t.emails, key, policy, now)
+	t.record(t.addresses, address, policy, now)
+
+	if policy.MaxFailures > 0 && emailFailures.count >= policy.MaxFailures {
+		emailFailures.count = 0
+		emailFailures.lockedUntil = now.Add(policy.Lockout)
+		t.stats.Lockouts++
+		return true
+	}
+
+	return false
+}
+
+// Succeeded forgets the failed signins for the email. Those for the address
+// are kept, so one good password does not reset a guessing attack
+func (t *LoginThrottle) Succeeded(email string, address string) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	delete(t.emails, normaliseEmail(email))
+	t.addresses[address].end()
+}
+
+// Release ends an attempt which neither failed nor succeeded, such as one
+// which could not be checked
+func (t *LoginThrottle) Release(email string, address string) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	t.emails[normaliseEmail(email)].end()
+	t.addresses[address].end()
+}
+
+// Unlock forgets the failed signins for the email, and lifts any lock
+func (t *LoginThrottle) Unlock(email string) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	delete(t.emails, normaliseEmail(email))
+}
+
+// Stats returns the signin counters
+func (t *LoginThrottle) Stats() LoginStats {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	now := t.now()
+
+	stats := t.stats
+	for _, failures := range t.emails {
+		if now.Before(failures.lockedUntil) {
+			stats.LockedAccounts++
+		}
+	}
+
+	return stats
+}
+
+// record counts a failure against the key, starting afresh once the
+// earlier failures are stale
+func (t *LoginThrottle) record(failures map[string]*loginFailures, key string, policy LoginPolicy, now time.Time) *loginFailures {
+
+	e, ok := failures[key]
+	if !ok || e.stale(policy, now) {
+		e = &loginFailures{}
+		failures[key] = e
+	}
+
+	e.count++
+	e.last = now
+	return e
+}
+
+// entry returns the entry for the key, adding it if there is none
+func entry(failures map[string]*loginFailures, key string) *loginFailures {
+	e, ok := failures[key]
+	if !ok {
+		e = &loginFailures{}
+		failures[key] = e
+	}
+	return e
+}
+
+// end ends an attempt in flight
+func (f *loginFailures) end() {
+	if f != nil && f.inFlight > 0 {
+		f.inFlight--
+	}
+}
+
+// prune removes the stale entries, once there are many
+func (t *LoginThrottle) prune(policy LoginPolicy, now time.Time) {
+	for _, failures := range []map[string]*loginFailures{t.emails, t.addresses} {
+		if len(failures) < pruneSize {
+			continue
+		}
+		for key, entry := range failures {
+			if entry.stale(policy, now) {
+				delete(failures, key)
+			}
+		}
+	}
+}
+
+// wait returns how long is left of the lock or the backoff. The attempts in
+// flight count as failures made now, and may lock the account if it can be
+// locked
+func (f *loginFailures) wait(policy LoginPolicy, now time.Time, lockable bool) (time.Duration, bool) {
+	if f == nil {
+		return 0, false
+	}
+
+	if now.Before(f.lockedUntil) {
+		return f.lockedUntil.Sub(now), true
+	}
+
+	count := f.count + f.inFlight
+	last := f.last
+	if f.inFlight > 0 {
+		last = now
+	}
+
+	if count == 0 || policy.Backoff <= 0 {
+		if lockable && f.inFlight > 0 && policy.MaxFailures > 0 && count >= policy.MaxFailures {
+			return inFlightWait, false
+		}
+		return 0, false
+	}
+
+	maxBackoff := policy.maxBackoff()
+	backoff := policy.Backoff
+	for i := 1; i < count && backoff < maxBackoff; i++ {
+		backoff *= 2
+	}
+	if backoff > maxBackoff {
+		backoff = maxBackoff
+	}
+
+	wait := last.Add(backoff).Sub(now)
+	if wait < 0 {
+		return 0, false
+	}
+	return wait, false
+}
+
+// stale checks the failures no longer matter: no attempt is in flight, any
+// lock has expired, and so has the longest backoff since the last failure
+func (f *loginFailures) stale(policy LoginPolicy, now time.Time) bool {
+	return f.inFlight == 0 && !now.Before(f.lockedUntil) && now.Sub(f.last) > policy.maxBackoff()+policy.Lockout
+}
+
+// maxBackoff returns the longest backoff. Without a maximum, the backoff
+// does not grow
+func (policy LoginPolicy) maxBackoff() time.Duration {
+	if policy.MaxBackoff < policy.Backoff {
+		return policy.Backoff
+	}
+	return policy.MaxBackoff
+}
@@ -0,0 +1,149 @@
+package model
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestLoginThrottle(t *testing.T) {
+
+	now := time.Date(2021, time.March, 1, 19, 0, 0, 0, time.UTC)
+	throttle := NewLoginThrottle()
+	throttle.now = func() time.Time { return now }
+
+	policy := LoginPolicy{MaxFailures: 3, Lockout: 15 * time.Minute, Backoff: time.Second, MaxBackoff: time.Minute}
+
+	// The backoff doubles after each failure
+	for i, expected := range []time.Duration{time.Second, 2 * time.Second} {
+		locked := throttle.Failed(policy, "Bob@Example.com", "192.0.2.1")
+		if locked {
+			t.Logf("Unexpected lock after %d failures", i+1)
+			t.FailNow()
+		}
+
+		wait, locked := throttle.Check(policy, "bob@example.com", "192.0.2.99")
+		if wait != expected || locked {
+			t.Logf("Unexpected wait after %d failures. expected: %s, actual: %s", i+1, expected, wait)
+			t.FailNow()
+		}
+	}
+
+	// Another email from the same address also has to wait
+	wait, _ := throttle.Check(policy, "alice@example.com", "192.0.2.1")
+	if wait == 0 {
+		t.Log("Unexpected success from a throttled address")
+		t.FailNow()
+	}
+
+	// The account is locked after too many failures ...
+	now = now.Add(10 * time.Second)
+	locked := throttle.Failed(policy, "bob@example.com", "192.0.2.2")
+	if !locked {
+		t.Log("The account was not locked")
+		t.FailNow()
+	}
+
+	wait, locked = throttle.Check(policy, "bob@example.com", "192.0.2.3")
+	if wait != policy.Lockout || !locked {
+		t.Logf("Unexpected lock. expected: %s, actual: %s", policy.Lockout, wait)
+		t.FailNow()
+	}
+
+	stats := throttle.Stats()
+	if stats.FailedSignins != 3 || stats.Lockouts != 1 || stats.LockedAccounts != 1 {
+		t.Logf("Unexpected stats: %+v", stats)
+		t.FailNow()
+	}
+
+	// ... until an admin unlocks it
+	throttle.Unlock("bob@example.com")
+
+	wait, locked = throttle.Check(policy, "bob@example.com", "192.0.2.3")
+	if wait != 0 || locked {
+		t.Log("The account is still locked")
+		t.FailNow()
+	}
+
+	// Failures are forgotten once they are stale
+	throttle.Failed(policy, "carol@example.com", "192.0.2.4")
+	now = now.Add(policy.Lockout + policy.MaxBackoff + time.Second)
+	throttle.Failed(policy, "carol@example.com", "192.0.2.4")
+
+	wait, _ = throttle.Check(policy, "carol@example.com", "192.0.2.5")
+	if wait != time.Second {
+		t.Logf("Unexpected wait after stale failures. expected: %s, actual: %s", time.Second, wait)
+		t.FailNow()
+	}
+}
+
+func TestLoginThrottleConcurrent(t *testing.T) {
+
+	now := time.Date(2021, time.March, 1, 19, 0, 0, 0, time.UTC)
+	throttle := NewLoginThrottle()
+	throttle.now = func() time.Time { return now }
+
+	// checkAll makes many parallel attempts, and returns how many passed
+	checkAll := func(policy LoginPolicy, email string) int {
+		var lock sync.Mutex
+		var wg sync.WaitGroup
+		passed := 0
+		for i := 0; i < 50; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				wait, _ := throttle.Check(policy, email, "192.0.2.1")
+				if wait == 0 {
+					lock.Lock()
+					passed++
+					lock.Unlock()
+				}
+			}()
+		}
+		wg.Wait()
+		return passed
+	}
+
+	// With a backoff, only one attempt at a time is in flight
+	policy := LoginPolicy{MaxFailures: 3, Lockout: 15 * time.Minute, Backoff: time.Second, MaxBackoff: time.Minute}
+
+	passed := checkAll(policy, "bob@example.com")
+	if passed != 1 {
+		t.Logf("Unexpected parallel attempts with a backoff. expected: %d, actual: %d", 1, passed)
+		t.FailNow()
+	}
+
+	throttle.Release("bob@example.com", "192.0.2.1")
+
+	wait, _ := throttle.Check(policy, "bob@example.com", "192.0.2.1")
+	if wait != 0 {
+		t.Logf("Unexpected wait after the attempt was released: %s", wait)
+		t.FailNow()
+	}
+	throttle.Succeeded("bob@example.com", "192.0.2.1")
+
+	// Without a backoff, no more attempts are in flight than could fail
+	// before the account is locked
+	policy.Backoff = 0
+
+	passed = checkAll(policy, "alice@example.com")
+	if passed != policy.MaxFailures {
+		t.Logf("Unexpected parallel attempts without a backoff. expected: %d, actual: %d", policy.MaxFailures, passed)
+		t.FailNow()
+	}
+
+	locked := false
+	for i := 0; i < passed; i++ {
+		locked = throttle.Failed(policy, "alice@example.com", "192.0.2.1")
+	}
+	if !locked {
+		t.Log("The account was not locked")
+		t.FailNow()
+	}
+
+	wait, locked = throttle.Check(policy, "alice@example.com", "192.0.2.1")
+	if wait != policy.Lockout || !locked {
+		t.Logf("Unexpected lock. expected: %s, actual: %s", policy.Lockout, wait)
+		t.FailNow()
+	}
+}
@@ -0,0 +1,85 @@
+package model
+
+import (
+	"context"
+	"database/sql"
+	"strconv"
+	"time"
+
+	"github.com/rsmaxwell/players-api/internal/debug"
+	"github.com/rsmaxwell/players-api/internal/metrics"
+)
+
+var (
+	functionCountByClub = debug.NewFunction(pkg, "countByClub")
+)
+
+// metricsTimeout limits the time spent reading the state of the clubs when
+// the metrics are gathered
+const metricsTimeout = 5 * time.Second
+
+// RegisterMetrics adds the metrics of the state of each club, which are read
+// from the database when the metrics are gathered, and of the signins
+func RegisterMetrics(registry *metrics.Registry, db *sql.DB) {
+
+	registry.NewGaugeFunc("players_waiting", "Number of people in the queue, by club", func() []metrics.Sample {
+		return countByClub(db, "SELECT club, COUNT(*) FROM "+WaitingTable+" GROUP BY club")
+	})
+
+	registry.NewGaugeFunc("players_occupied_courts", "Number of courts with people playing on them, by club", func() []metrics.Sample {
+		return countByClub(db, "SELECT c.club, COUNT(DISTINCT p.court) FROM "+PlayingTable+" p JOIN "+CourtTable+" c ON c.id = p.court GROUP BY c.club")
+	})
+
+	registry.NewGaugeFunc("players_open_sessions", "Number of open sessions, by club", func() []metrics.Sample {
+		return countByClub(db, "SELECT club, COUNT(*) FROM "+SessionTable+" WHERE closed IS NULL GROUP BY club")
+	})
+
+	registry.NewCounterFunc("players_signins_failed_total", "Number of failed signins", func() []metrics.Sample {
+		return []metrics.Sample{{Value: float64(LoginAttempts.Stats().FailedSignins)}}
+	})
+
+	registry.NewCounterFunc("players_signins_throttled_total", "Number of signins refused while waiting after a failure", func() []metrics.Sample {
+		return []metrics.Sample{{Value: float64(LoginAttempts.Stats().ThrottledSignins)}}
+	})
+
+	registry.NewCounterFunc("players_lockouts_total", "Number of accounts locked after repeated failed signins", func() []metrics.Sample {
+		return []metrics.Sample{{Value: float64(LoginAttempts.Stats().Lockouts)}}
+	})
+
+	registry.NewGaugeFunc("players_locked_accounts", "Number of accounts which are locked", func() []metrics.Sample {
+		return []metrics.Sample{{Value: float64(LoginAttempts.Stats().LockedAccounts)}}
+	})
+}
+
+// countByClub runs a query returning a club and a count on each row, and
+// returns a sample for each club. Nothing is returned if the query fails
+func countByClub(db *sql.DB, sqlStatement string) []metrics.Sample {
+	f := functionCountByClub
+
+	ctx, cancel := context.WithTimeout(context.Background(), metricsTimeout)
+	defer cancel()
+
+	rows, err := db.QueryContext(ctx, sqlStatement)
+	if err != nil {
+		message := "Could not read the metric"
+		f.Errorf(message)
+		f.DumpSQLError(err, message, sqlStatement)
+		return nil
+	}
+	defer rows.Close()
+
+	var samples []metrics.Sample
+	for rows.Next() {
+		var club, count int
+		err := rows.Scan(&club, &count)
+		if err != nil {
+			message := "Could not scan the metric"
+			f.Errorf(message)
+			f.DumpError(err, message)
+			return nil
+		}
+		samples = append(samples, metrics.Sample{Labels: []metrics.Label{{Name: "club", Value: strconv.Itoa(club)}}, Value: float64(count)})
+	}
+
+	return samples
+}
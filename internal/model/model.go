@@ -5,6 +5,7 @@ import (
 	"database/sql"
 	"encoding/json"
 	"fmt"
+	"time"
 
 	"github.com/rsmaxwell/players-api/internal/codeerror"
 	"github.com/rsmaxwell/players-api/internal/debug"
@@ -91,6 +92,14 @@ func Populate(db *sql.DB) error {
 		{FirstName: "Caroline", LastName: "Clarke", Knownas: "Carol", Email: "hossemmibe-4189@yopmail.com", Phone: "012345 123019", Password: "ruificent"},
 	}
 
+	session, err := OpenSession(ctx, db, DefaultClubID)
+	if err != nil {
+		message := "Could not open a session"
+		f.Errorf(message)
+		f.DumpError(err, message)
+		return err
+	}
+
 	peopleIDs := make(map[int]int)
 	for i, r := range peopleData {
 
@@ -101,6 +110,9 @@ func Populate(db *sql.DB) error {
 		}
 
 		p.Status = StatusPlayer
+		if p.Email == GoodEmail {
+			p.Roles = []string{RoleAdmin, RoleMember}
+		}
 
 		err = p.SavePersonTx(db)
 		if err != nil {
@@ -108,7 +120,13 @@ func Populate(db *sql.DB) error {
 			return err
 		}
 
-		err = AddWaiter(ctx, db, p.ID)
+		err = AddCheckIn(ctx, db, session.ID, p.ID)
+		if err != nil {
+			f.Errorf("Could not check in")
+			return err
+		}
+
+		err = AddWaiter(ctx, db, DefaultClubID, p.ID)
 		if err != nil {
 			f.Errorf("Could not add waiting")
 			return err
@@ -126,7 +144,7 @@ func Populate(db *sql.DB) error {
 
 	courtIDs := make(map[int]int)
 	for i, x := range courtData {
-		c := Court{Name: x.name}
+		c := Court{Club: DefaultClubID, Name: x.name}
 		err := c.SaveCourt(ctx, db)
 		if err != nil {
 			message := "Could not save court"
@@ -139,10 +157,9 @@ func Populate(db *sql.DB) error {
 	return nil
 }
 
-// MakePlayerWait moves a person from playing to waiting
-func MakePlayerWaitTx(db *sql.DB, personID int) error {
+// MakePlayerWait moves a person from playing to waiting in a club's queue
+func MakePlayerWaitTx(ctx context.Context, db *sql.DB, clubID int, personID int) error {
 	f := functionMakePlayerWaitTx
-	ctx := context.Background()
 
 	tx, err := db.BeginTx(ctx, nil)
 	if err != nil {
@@ -152,13 +169,31 @@ func MakePlayerWaitTx(db *sql.DB, personID int) error {
 		return err
 	}
 
-	err = MakePlayerWait(ctx, db, personID)
+	version, err := IncrementVersion(ctx, tx)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	courtIDs, err := playingCourts(ctx, tx, personID)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	err = MakePlayerWait(ctx, tx, clubID, personID)
 	if err != nil {
 		tx.Rollback()
 		return err
 	}
 
-	err = tx.Commit()
+	event, err := NewEvent(ctx, tx, version, clubID, courtIDs...)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	err = Events.CommitAndPublish(tx, event)
 	if err != nil {
 		message := "Could not commit a new transaction"
 		f.Errorf(message)
@@ -169,7 +204,7 @@ func MakePlayerWaitTx(db *sql.DB, personID int) error {
 	return nil
 }
 
-func MakePlayerWait(ctx context.Context, db *sql.DB, personID int) error {
+func MakePlayerWait(ctx context.Context, db Queryer, clubID int, personID int) error {
 
 	person := FullPerson{ID: personID}
 	err := person.LoadPerson(ctx, db)
@@ -180,6 +215,11 @@ func MakePlayerWait(ctx context.Context, db *sql.DB, personID int) error {
 		return codeerror.NewBadRequest(fmt.Sprintf("Person [%d] is not a player: state: %s", personID, person.Status))
 	}
 
+	err = checkPersonClub(ctx, db, clubID, personID)
+	if err != nil {
+		return err
+	}
+
 	err = RemovePlayer(ctx, db, personID)
 	if err != nil {
 		return err
@@ -190,7 +230,7 @@ func MakePlayerWait(ctx context.Context, db *sql.DB, personID int) error {
 		return err
 	}
 
-	err = AddWaiter(ctx, db, personID)
+	err = AddWaiter(ctx, db, clubID, personID)
 	if err != nil {
 		return err
 	}
@@ -199,9 +239,8 @@ func MakePlayerWait(ctx context.Context, db *sql.DB, personID int) error {
 }
 
 // MakePlayerPlaying moves a person from playing to waiting
-func MakePlayerPlayTx(db *sql.DB, personID int, courtID int, position int) error {
+func MakePlayerPlayTx(ctx context.Context, db *sql.DB, clubID int, personID int, courtID int, position int) error {
 	f := functionMakePlayerPlayTx
-	ctx := context.Background()
 
 	tx, err := db.BeginTx(ctx, nil)
 	if err != nil {
@@ -211,13 +250,38 @@ func MakePlayerPlayTx(db *sql.DB, personID int, courtID int, position int) error
 		return err
 	}
 
-	err = MakePlayerPlay(ctx, db, personID, courtID, position)
+	version, err := IncrementVersion(ctx, tx)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	court := Court{ID: courtID}
+	err = court.LoadClubCourt(ctx, tx, clubID)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	courtIDs, err := playingCourts(ctx, tx, personID)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	err = MakePlayerPlay(ctx, tx, personID, courtID, position)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	event, err := NewEvent(ctx, tx, version, clubID, append(courtIDs, courtID)...)
 	if err != nil {
 		tx.Rollback()
 		return err
 	}
 
-	err = tx.Commit()
+	err = Events.CommitAndPublish(tx, event)
 	if err != nil {
 		message := "Could not commit a new transaction"
 		f.Errorf(message)
@@ -228,7 +292,7 @@ func MakePlayerPlayTx(db *sql.DB, personID int, courtID int, position int) error
 	return nil
 }
 
-func MakePlayerPlay(ctx context.Context, db *sql.DB, personID int, courtID int, position int) error {
+func MakePlayerPlay(ctx context.Context, db Queryer, personID int, courtID int, position int) error {
 
 	person := FullPerson{ID: personID}
 	err := person.LoadPerson(ctx, db)
@@ -247,21 +311,36 @@ func MakePlayerPlay(ctx context.Context, db *sql.DB, personID int, courtID int,
 	if position < 0 {
 		return codeerror.NewBadRequest(fmt.Sprintf("Unexpected position: %d", position))
 	}
-	if position >= NumberOfCourtPositions {
+	if position >= court.Capacity {
 		return codeerror.NewBadRequest(fmt.Sprintf("Unexpected position: %d", position))
 	}
 
+	err = checkPersonClub(ctx, db, court.Club, personID)
+	if err != nil {
+		return err
+	}
+
 	err = RemovePlayer(ctx, db, personID)
 	if err != nil {
 		return err
 	}
 
+	waiters, err := ListWaitersForPerson(ctx, db, personID)
+	if err != nil {
+		return err
+	}
+
+	var wait time.Duration
+	for _, waiter := range waiters {
+		wait = time.Since(waiter.Start)
+	}
+
 	err = RemoveWaiter(ctx, db, personID)
 	if err != nil {
 		return err
 	}
 
-	err = AddPlayer(ctx, db, personID, courtID, position)
+	err = AddPlayer(ctx, db, personID, courtID, position, wait)
 	if err != nil {
 		return err
 	}
@@ -269,10 +348,10 @@ func MakePlayerPlay(ctx context.Context, db *sql.DB, personID int, courtID int,
 	return nil
 }
 
-// MakePersonInactive sets the status of a person to 'inactive'
-func MakePersonInactiveTx(db *sql.DB, personID int) error {
+// MakePersonInactive sets the status of a person to 'inactive', taking them
+// out of a club's queue or off its courts
+func MakePersonInactiveTx(ctx context.Context, db *sql.DB, clubID int, personID int) error {
 	f := functionMakePersonInactiveTx
-	ctx := context.Background()
 
 	tx, err := db.BeginTx(ctx, nil)
 	if err != nil {
@@ -282,13 +361,37 @@ func MakePersonInactiveTx(db *sql.DB, personID int) error {
 		return err
 	}
 
-	err = MakePersonInactive(ctx, db, personID)
+	version, err := IncrementVersion(ctx, tx)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	err = checkPersonClub(ctx, tx, clubID, personID)
 	if err != nil {
 		tx.Rollback()
 		return err
 	}
 
-	err = tx.Commit()
+	courtIDs, err := playingCourts(ctx, tx, personID)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	err = MakePersonInactive(ctx, tx, personID)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	event, err := NewEvent(ctx, tx, version, clubID, courtIDs...)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	err = Events.CommitAndPublish(tx, event)
 	if err != nil {
 		message := "Could not commit a new transaction"
 		f.Errorf(message)
@@ -299,7 +402,7 @@ func MakePersonInactiveTx(db *sql.DB, personID int) error {
 	return nil
 }
 
-func MakePersonInactive(ctx context.Context, db *sql.DB, personID int) error {
+func MakePersonInactive(ctx context.Context, db Queryer, personID int) error {
 
 	person := FullPerson{ID: personID}
 	err := person.LoadPerson(ctx, db)
@@ -307,6 +410,12 @@ func MakePersonInactive(ctx context.Context, db *sql.DB, personID int) error {
 		return codeerror.NewNotFound(fmt.Sprintf("person [%d] not found", personID))
 	}
 
+	// A suspended person is only let in by approving their registration, and
+	// an admin's status is not changed by the queue
+	if person.Status != StatusPlayer && person.Status != StatusInactive {
+		return codeerror.NewBadRequest(fmt.Sprintf("Cannot change person [%d] from %s to %s state", personID, person.Status, StatusInactive))
+	}
+
 	err = RemovePlayer(ctx, db, personID)
 	if err != nil {
 		return err
@@ -327,10 +436,9 @@ func MakePersonInactive(ctx context.Context, db *sql.DB, personID int) error {
 	return nil
 }
 
-// MakePersonPlayer sets the status of a person to 'player'
-func MakePersonPlayerTx(db *sql.DB, personID int) error {
+// MakePersonPlayer sets the status of a person to 'player', in a club's queue
+func MakePersonPlayerTx(ctx context.Context, db *sql.DB, clubID int, personID int) error {
 	f := functionMakePersonPlayerTx
-	ctx := context.Background()
 
 	tx, err := db.BeginTx(ctx, nil)
 	if err != nil {
@@ -340,13 +448,25 @@ func MakePersonPlayerTx(db *sql.DB, personID int) error {
 		return err
 	}
 
-	err = MakePersonPlayer(ctx, db, personID)
+	version, err := IncrementVersion(ctx, tx)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	err = MakePersonPlayer(ctx, tx, clubID, personID)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	event, err := NewEvent(ctx, tx, version, clubID)
 	if err != nil {
 		tx.Rollback()
 		return err
 	}
 
-	err = tx.Commit()
+	err = Events.CommitAndPublish(tx, event)
 	if err != nil {
 		message := "Could not commit a new transaction"
 		f.Errorf(message)
@@ -357,9 +477,14 @@ func MakePersonPlayerTx(db *sql.DB, personID int) error {
 	return nil
 }
 
-func MakePersonPlayer(ctx context.Context, db *sql.DB, personID int) error {
+func MakePersonPlayer(ctx context.Context, db Queryer, clubID int, personID int) error {
 	f := functionMakePersonPlayer
 
+	err := checkPersonClub(ctx, db, clubID, personID)
+	if err != nil {
+		return err
+	}
+
 	players, err := ListPlayersForPerson(ctx, db, personID)
 	if err != nil {
 		return err
@@ -401,6 +526,19 @@ func MakePersonPlayer(ctx context.Context, db *sql.DB, personID int) error {
 		return codeerror.NewBadRequest(fmt.Sprintf("Cannot change person [%d] from %s to %s state", personID, person.Status, person.Status))
 	}
 
+	session, err := FindOpenSession(ctx, db, clubID)
+	if err != nil {
+		return err
+	}
+	if session == nil {
+		return codeerror.NewBadRequest(fmt.Sprintf("No session is open at club [%d]", clubID))
+	}
+
+	err = AddCheckIn(ctx, db, session.ID, personID)
+	if err != nil {
+		return err
+	}
+
 	if person.Status != StatusPlayer {
 		person.Status = StatusPlayer
 		err = person.UpdatePerson(ctx, db)
@@ -417,7 +555,7 @@ func MakePersonPlayer(ctx context.Context, db *sql.DB, personID int) error {
 	}
 
 	if len(waiters) == 0 {
-		err = AddWaiter(ctx, db, personID)
+		err = AddWaiter(ctx, db, clubID, personID)
 		if err != nil {
 			return err
 		}
@@ -2,6 +2,7 @@ package model
 
 import (
 	"context"
+	"errors"
 	"testing"
 
 	_ "github.com/jackc/pgx/stdlib"
@@ -26,7 +27,7 @@ func TestPeople(t *testing.T) {
 		t.FailNow()
 	}
 
-	listOfCourts, err := ListCourtsTx(db)
+	listOfCourts, err := ListCourtsTx(db, DefaultClubID)
 	if err != nil {
 		t.Log("Could not list the courts")
 		t.FailNow()
@@ -43,7 +44,7 @@ func TestPeople(t *testing.T) {
 	// 	t.FailNow()
 	// }
 
-	listOfWaiters, err := ListWaiters(ctx, db)
+	listOfWaiters, err := ListWaiters(ctx, db, DefaultClubID)
 	if err != nil {
 		t.Log("Could not get the first waiter")
 		t.FailNow()
@@ -66,7 +67,7 @@ func TestPeople(t *testing.T) {
 		t.FailNow()
 	}
 
-	err = AddWaiter(ctx, db, p.ID)
+	err = AddWaiter(ctx, db, DefaultClubID, p.ID)
 	if err != nil {
 		t.Log("Could not make a person into a player")
 		t.FailNow()
@@ -114,20 +115,64 @@ func TestPeople(t *testing.T) {
 		t.FailNow()
 	}
 
-	err = c.DeleteCourtTx(db)
+	err = c.DeleteCourtTx(ctx, db)
 	if err != nil {
 		t.Log("Could not delete court")
 		t.FailNow()
 	}
 
-	err = p.DeletePersonTx(db)
+	err = p.DeletePersonTx(ctx, db)
 	if err != nil {
 		t.Log("Could not delete person")
 		t.FailNow()
 	}
-	err = p2.DeletePersonTx(db)
+	err = p2.DeletePersonTx(ctx, db)
 	if err != nil {
 		t.Log("Could not delete person")
 		t.FailNow()
 	}
 }
+
+func TestMakePlayerPlayInjectedFailure(t *testing.T) {
+	teardown, db, _ := Setup(t)
+	defer teardown(t)
+
+	ctx := context.Background()
+
+	listOfCourts, err := ListCourtsTx(db, DefaultClubID)
+	if err != nil || len(listOfCourts) == 0 {
+		t.Log("Could not find any courts")
+		t.FailNow()
+	}
+
+	waiters, err := ListWaiters(ctx, db, DefaultClubID)
+	if err != nil || len(waiters) == 0 {
+		t.Log("Could not find any waiters")
+		t.FailNow()
+	}
+
+	before := takeSnapshot(ctx, t, db)
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		t.Log("Could not begin a new transaction")
+		t.FailNow()
+	}
+
+	// Fail on AddPlayer, after the person has been removed from the waiters
+	q := &failingQueryer{Queryer: tx, failAt: 3}
+	err = MakePlayerPlay(ctx, q, waiters[0].Person, listOfCourts[0].ID, 0)
+	if !errors.Is(err, errInjected) {
+		tx.Rollback()
+		t.Logf("Expected the injected failure, got: %v", err)
+		t.FailNow()
+	}
+
+	err = tx.Rollback()
+	if err != nil {
+		t.Log("Could not rollback the transaction")
+		t.FailNow()
+	}
+
+	before.CheckUnchanged(ctx, t, db)
+}
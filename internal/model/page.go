@@ -0,0 +1,89 @@
+package model
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/rsmaxwell/players-api/internal/codeerror"
+)
+
+// Page selects part of a sorted list. The zero value is the whole list, in
+// the default order
+type Page struct {
+	Sort       string // the sort key, which defaults to the name
+	Descending bool   // reverses the order
+	Offset     int    // the number of items skipped
+	Limit      int    // the most items returned. Zero means no limit
+}
+
+const (
+	// SortName sorts by name
+	SortName = "name"
+
+	// SortID sorts by id, which is the order the items were created
+	SortID = "id"
+
+	// SortStatus sorts by status
+	SortStatus = "status"
+)
+
+// parameters collects the parameters of a query, and returns the placeholder
+// for each one as it is added
+type parameters []interface{}
+
+func (p *parameters) add(value interface{}) string {
+	*p = append(*p, value)
+	return "$" + strconv.Itoa(len(*p))
+}
+
+// clauses returns the ORDER BY, LIMIT and OFFSET clauses for the page, given
+// the column of each sort key. The id breaks ties, so the order is the same
+// from one page to the next
+func (page Page) clauses(columns map[string]string, args *parameters) (string, error) {
+
+	key := page.Sort
+	if key == "" {
+		key = SortName
+	}
+
+	column, ok := columns[key]
+	if !ok {
+		return "", codeerror.NewBadRequest(fmt.Sprintf("unexpected sort key: '%s'", key))
+	}
+
+	direction := " ASC"
+	if page.Descending {
+		direction = " DESC"
+	}
+
+	if page.Offset < 0 || page.Limit < 0 {
+		return "", codeerror.NewBadRequest(fmt.Sprintf("unexpected page: offset %d, limit %d", page.Offset, page.Limit))
+	}
+
+	clauses := " ORDER BY " + column + direction
+	if column != "id" {
+		clauses = clauses + ", id" + direction
+	}
+	if page.Limit > 0 {
+		clauses = clauses + " LIMIT " + args.add(page.Limit)
+	}
+	if page.Offset > 0 {
+		clauses = clauses + " OFFSET " + args.add(page.Offset)
+	}
+
+	return clauses, nil
+}
+
+// whole reports if the page is the whole list, so the count is the number
+// of items returned
+func (page Page) whole() bool {
+	return page.Offset == 0 && page.Limit == 0
+}
+
+// containsPattern returns a LIKE pattern which matches text anywhere in a
+// value, treating the wildcards in the text as ordinary characters
+func containsPattern(text string) string {
+	escaper := strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+	return "%" + escaper.Replace(text) + "%"
+}
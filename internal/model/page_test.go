@@ -0,0 +1,62 @@
+package model
+
+import (
+	"testing"
+)
+
+func TestPageClauses(t *testing.T) {
+
+	columns := map[string]string{SortName: "knownas", SortID: "id"}
+
+	tests := []struct {
+		page     Page
+		expected string
+		args     int
+	}{
+		{Page{}, " ORDER BY knownas ASC, id ASC", 0},
+		{Page{Sort: SortID, Descending: true}, " ORDER BY id DESC", 0},
+		{Page{Limit: 10}, " ORDER BY knownas ASC, id ASC LIMIT $2", 1},
+		{Page{Offset: 20, Limit: 10}, " ORDER BY knownas ASC, id ASC LIMIT $2 OFFSET $3", 2},
+	}
+
+	for _, test := range tests {
+		args := parameters{"filter"}
+		clauses, err := test.page.clauses(columns, &args)
+		if err != nil {
+			t.Logf("Unexpected error for page %+v: %v", test.page, err)
+			t.FailNow()
+		}
+		if clauses != test.expected || len(args) != test.args+1 {
+			t.Logf("Unexpected clauses for page %+v. expected: %q, actual: %q, args: %v", test.page, test.expected, clauses, args)
+			t.FailNow()
+		}
+	}
+
+	// The sort key is chosen from the columns, never put in the SQL
+	for _, page := range []Page{{Sort: "knownas; DROP TABLE person"}, {Sort: SortStatus}, {Limit: -1}} {
+		args := parameters{}
+		_, err := page.clauses(columns, &args)
+		if err == nil {
+			t.Logf("Unexpected success for page %+v", page)
+			t.FailNow()
+		}
+	}
+}
+
+func TestContainsPattern(t *testing.T) {
+
+	tests := map[string]string{
+		"smith":  "%smith%",
+		"50%":    `%50\%%`,
+		"a_b":    `%a\_b%`,
+		`back\s`: `%back\\s%`,
+	}
+
+	for text, expected := range tests {
+		actual := containsPattern(text)
+		if actual != expected {
+			t.Logf("Unexpected pattern for %q. expected: %q, actual: %q", text, expected, actual)
+			t.FailNow()
+		}
+	}
+}
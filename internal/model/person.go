@@ -6,36 +6,42 @@ import (
 	"encoding/hex"
 	"encoding/json"
 	"fmt"
-	"strconv"
+	"strings"
 
 	"github.com/jackc/pgconn"
-	"golang.org/x/crypto/bcrypt"
 
+	"github.com/rsmaxwell/players-api/internal/basic"
 	"github.com/rsmaxwell/players-api/internal/codeerror"
 	"github.com/rsmaxwell/players-api/internal/debug"
 )
 
 // LimitedPerson type
 type Person struct {
-	ID        int    `json:"id"`
-	FirstName string `json:"firstname"`
-	LastName  string `json:"lastname"`
-	Knownas   string `json:"knownas"`
-	Email     string `json:"email"`
-	Phone     string `json:"phone"`
-	Status    string `json:"status"`
+	ID        int      `json:"id"`
+	FirstName string   `json:"firstname"`
+	LastName  string   `json:"lastname"`
+	Knownas   string   `json:"knownas"`
+	Email     string   `json:"email"`
+	Phone     string   `json:"phone"`
+	Status    string   `json:"status"`
+	Skill     int      `json:"skill"`
+	Gender    string   `json:"gender"`
+	Roles     []string `json:"roles"`
 }
 
 // Person type
 type FullPerson struct {
-	ID        int    `json:"id"`
-	FirstName string `json:"firstname" validate:"required,min=3,max=20"`
-	LastName  string `json:"lastname" validate:"required,min=3,max=20"`
-	Knownas   string `json:"knownas" validate:"required,min=3,max=20"`
-	Email     string `json:"email" validate:"required,email"`
-	Phone     string `json:"phone" validate:"required,min=3,max=20"`
-	Hash      []byte `json:"hash"`
-	Status    string `json:"status"`
+	ID        int      `json:"id"`
+	FirstName string   `json:"firstname" validate:"required,min=3,max=20"`
+	LastName  string   `json:"lastname" validate:"required,min=3,max=20"`
+	Knownas   string   `json:"knownas" validate:"required,min=3,max=20"`
+	Email     string   `json:"email" validate:"required,email"`
+	Phone     string   `json:"phone" validate:"required,min=3,max=20"`
+	Hash      []byte   `json:"hash"`
+	Status    string   `json:"status"`
+	Skill     int      `json:"skill" validate:"min=0"`
+	Gender    string   `json:"gender" validate:"omitempty,oneof=male female"`
+	Roles     []string `json:"roles"`
 }
 
 // NullPerson type
@@ -48,6 +54,8 @@ type NullPerson struct {
 	Phone     sql.NullString `db:"phone"`
 	Hash      sql.NullString `db:"hash"`
 	Status    sql.NullString `db:"status"`
+	Skill     sql.NullInt32  `db:"skill"`
+	Gender    sql.NullString `db:"gender"`
 }
 
 const (
@@ -66,6 +74,7 @@ var (
 	functionDeletePerson      = debug.NewFunction(pkg, "DeletePerson")
 	functionAuthenticate      = debug.NewFunction(pkg, "Authenticate")
 	functionCheckPassword     = debug.NewFunction(pkg, "CheckPassword")
+	functionRehashPassword    = debug.NewFunction(pkg, "rehashPassword")
 )
 
 const (
@@ -82,6 +91,14 @@ const (
 	StatusSuspended = "suspended"
 )
 
+const (
+	// GenderMale constant
+	GenderMale = "male"
+
+	// GenderFemale constant
+	GenderFemale = "female"
+)
+
 var (
 	// AllStates lists all the states
 	AllStates []string
@@ -92,6 +109,21 @@ func init() {
 	AllStates = []string{StatusAdmin, StatusPlayer, StatusInactive, StatusSuspended}
 }
 
+// validStatus checks the status is one we know about
+func validStatus(status string) bool {
+	for _, s := range AllStates {
+		if s == status {
+			return true
+		}
+	}
+	return false
+}
+
+// validGender checks the gender is one we know about. The gender is optional
+func validGender(gender string) bool {
+	return gender == "" || gender == GenderMale || gender == GenderFemale
+}
+
 // NewPerson initialises a Person object
 func NewPerson(firstname string, lastname string, knownas string, email string, phone string, hash []byte) *FullPerson {
 	p := new(FullPerson)
@@ -102,6 +134,7 @@ func NewPerson(firstname string, lastname string, knownas string, email string,
 	p.Phone = phone
 	p.Hash = hash
 	p.Status = StatusSuspended
+	p.Roles = []string{RoleMember}
 	return p
 }
 
@@ -118,7 +151,19 @@ func (p *FullPerson) SavePersonTx(db *sql.DB) error {
 		return err
 	}
 
-	err = p.xxxSavePerson(ctx, db)
+	_, err = IncrementVersion(ctx, tx)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	err = p.xxxSavePerson(ctx, tx)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	err = SetRoles(ctx, tx, p.ID, p.Roles)
 	if err != nil {
 		tx.Rollback()
 		return err
@@ -128,20 +173,21 @@ func (p *FullPerson) SavePersonTx(db *sql.DB) error {
 	if err != nil {
 		message := "Could not commit the transaction"
 		f.DumpError(err, message)
+		return err
 	}
 
 	return nil
 }
 
 // SavePerson writes a new Person to disk and returns the generated id
-func (p *FullPerson) xxxSavePerson(ctx context.Context, db *sql.DB) error {
+func (p *FullPerson) xxxSavePerson(ctx context.Context, db Queryer) error {
 	f := functionSavePerson
 
-	fields := "firstname, lastname, knownas, email, phone, hash, status"
-	values := "$1, $2, $3, $4, $5, $6, $7"
+	fields := "firstname, lastname, knownas, email, phone, hash, status, skill, gender"
+	values := "$1, $2, $3, $4, $5, $6, $7, $8, $9"
 	sqlStatement := "INSERT INTO " + PersonTable + " (" + fields + ") VALUES (" + values + ") RETURNING id"
 
-	err := db.QueryRowContext(ctx, sqlStatement, p.FirstName, p.LastName, p.Knownas, p.Email, p.Phone, hex.EncodeToString(p.Hash), p.Status).Scan(&p.ID)
+	err := db.QueryRowContext(ctx, sqlStatement, p.FirstName, p.LastName, p.Knownas, p.Email, p.Phone, encodeHash(p.Hash), p.Status, p.Skill, p.Gender).Scan(&p.ID)
 	if err != nil {
 		pgerr, ok := err.(*pgconn.PgError)
 		if ok {
@@ -159,12 +205,12 @@ func (p *FullPerson) xxxSavePerson(ctx context.Context, db *sql.DB) error {
 	return nil
 }
 
-func (p *FullPerson) UpdatePerson(ctx context.Context, db *sql.DB) error {
+func (p *FullPerson) UpdatePerson(ctx context.Context, db Queryer) error {
 	f := functionUpdatePerson
 
-	fields := "firstname=$1, lastname=$2, knownas=$3, email=$4, phone=$5, hash=$6, status=$7"
-	sqlStatement := "UPDATE " + PersonTable + " SET " + fields + " WHERE id=" + strconv.Itoa(p.ID)
-	_, err := db.ExecContext(ctx, sqlStatement, p.FirstName, p.LastName, p.Knownas, p.Email, p.Phone, hex.EncodeToString(p.Hash), p.Status)
+	fields := "firstname=$1, lastname=$2, knownas=$3, email=$4, phone=$5, hash=$6, status=$7, skill=$8, gender=$9"
+	sqlStatement := "UPDATE " + PersonTable + " SET " + fields + " WHERE id=$10"
+	_, err := db.ExecContext(ctx, sqlStatement, p.FirstName, p.LastName, p.Knownas, p.Email, p.Phone, encodeHash(p.Hash), p.Status, p.Skill, p.Gender, p.ID)
 	if err != nil {
 		message := "Could not update person"
 		f.DumpSQLError(err, message, sqlStatement)
@@ -175,11 +221,11 @@ func (p *FullPerson) UpdatePerson(ctx context.Context, db *sql.DB) error {
 }
 
 // LoadPerson returns the Person with the given ID
-func (p *FullPerson) LoadPerson(ctx context.Context, db *sql.DB) error {
+func (p *FullPerson) LoadPerson(ctx context.Context, db Queryer) error {
 	f := functionLoadPerson
 
 	// Query the person
-	fields := "firstname, lastname, knownas, email, phone, hash, status"
+	fields := "firstname, lastname, knownas, email, phone, hash, status, skill, gender"
 	sqlStatement := "SELECT " + fields + " FROM " + PersonTable + " WHERE id=$1"
 	rows, err := db.QueryContext(ctx, sqlStatement, p.ID)
 	if err != nil {
@@ -194,7 +240,7 @@ func (p *FullPerson) LoadPerson(ctx context.Context, db *sql.DB) error {
 		count++
 
 		var np NullPerson
-		err := rows.Scan(&np.FirstName, &np.LastName, &np.Knownas, &np.Email, &np.Phone, &np.Hash, &np.Status)
+		err := rows.Scan(&np.FirstName, &np.LastName, &np.Knownas, &np.Email, &np.Phone, &np.Hash, &np.Status, &np.Skill, &np.Gender)
 		if err != nil {
 			message := "Could not scan the person"
 			f.DumpError(err, message)
@@ -222,7 +268,7 @@ func (p *FullPerson) LoadPerson(ctx context.Context, db *sql.DB) error {
 		}
 
 		if np.Hash.Valid {
-			p.Hash, err = hex.DecodeString(np.Hash.String)
+			p.Hash, err = decodeHash(np.Hash.String)
 			if err != nil {
 				message := "Could not scan the Hash HexString"
 				f.DumpError(err, message)
@@ -233,6 +279,14 @@ func (p *FullPerson) LoadPerson(ctx context.Context, db *sql.DB) error {
 		if np.Status.Valid {
 			p.Status = np.Status.String
 		}
+
+		if np.Skill.Valid {
+			p.Skill = int(np.Skill.Int32)
+		}
+
+		if np.Gender.Valid {
+			p.Gender = np.Gender.String
+		}
 	}
 	err = rows.Err()
 	if err != nil {
@@ -251,13 +305,17 @@ func (p *FullPerson) LoadPerson(ctx context.Context, db *sql.DB) error {
 		return err
 	}
 
+	p.Roles, err = ListRoles(ctx, db, p.ID)
+	if err != nil {
+		return err
+	}
+
 	return nil
 }
 
 // DeletePerson removes a person and associated waiters and playings
-func (p *FullPerson) DeletePersonTx(db *sql.DB) error {
+func (p *FullPerson) DeletePersonTx(ctx context.Context, db *sql.DB) error {
 	f := functionDeletePersonTx
-	ctx := context.Background()
 
 	// Create a new context, and begin a transaction
 	tx, err := db.BeginTx(ctx, nil)
@@ -267,27 +325,55 @@ func (p *FullPerson) DeletePersonTx(db *sql.DB) error {
 		return err
 	}
 
-	err = DeletePerson(ctx, db, p.ID)
+	version, err := IncrementVersion(ctx, tx)
 	if err != nil {
 		tx.Rollback()
 		return err
 	}
 
-	err = tx.Commit()
+	courtIDs, err := playingCourts(ctx, tx, p.ID)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	clubID, found, err := personClub(ctx, tx, p.ID)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	if !found {
+		clubID = DefaultClubID
+	}
+
+	err = DeletePerson(ctx, tx, p.ID)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	event, err := NewEvent(ctx, tx, version, clubID, courtIDs...)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	err = Events.CommitAndPublish(tx, event)
 	if err != nil {
 		message := "Could not commit the transaction"
 		f.DumpError(err, message)
+		return err
 	}
 
 	return nil
 }
 
-func DeletePerson(ctx context.Context, db *sql.DB, personID int) error {
+func DeletePerson(ctx context.Context, db Queryer, personID int) error {
 	f := functionDeletePerson
 
 	// Remove the associated waiters
-	sqlStatement := "DELETE FROM " + WaitingTable + " WHERE person=" + strconv.Itoa(personID)
-	_, err := db.ExecContext(ctx, sqlStatement)
+	sqlStatement := "DELETE FROM " + WaitingTable + " WHERE person=$1"
+	_, err := db.ExecContext(ctx, sqlStatement, personID)
 	if err != nil {
 		message := "Could not delete waiters"
 		f.DumpSQLError(err, message, sqlStatement)
@@ -295,8 +381,8 @@ func DeletePerson(ctx context.Context, db *sql.DB, personID int) error {
 	}
 
 	// Remove the associated playing
-	sqlStatement = "DELETE FROM " + PlayingTable + " WHERE person=" + strconv.Itoa(personID)
-	_, err = db.ExecContext(ctx, sqlStatement)
+	sqlStatement = "DELETE FROM " + PlayingTable + " WHERE person=$1"
+	_, err = db.ExecContext(ctx, sqlStatement, personID)
 	if err != nil {
 		message := "Could not delete playings"
 		f.DumpSQLError(err, message, sqlStatement)
@@ -304,8 +390,8 @@ func DeletePerson(ctx context.Context, db *sql.DB, personID int) error {
 	}
 
 	// Remove the Person
-	sqlStatement = "DELETE FROM " + PersonTable + " WHERE ID=" + strconv.Itoa(personID) + " AND status != '" + StatusAdmin + "'"
-	_, err = db.ExecContext(ctx, sqlStatement)
+	sqlStatement = "DELETE FROM " + PersonTable + " WHERE ID=$1 AND status != $2"
+	_, err = db.ExecContext(ctx, sqlStatement, personID, StatusAdmin)
 	if err != nil {
 		message := "Could not delete person"
 		f.DumpSQLError(err, message, sqlStatement)
@@ -316,11 +402,11 @@ func DeletePerson(ctx context.Context, db *sql.DB, personID int) error {
 }
 
 // FindPersonByEmail function
-func FindPersonByEmail(ctx context.Context, db *sql.DB, email string) (*FullPerson, error) {
+func FindPersonByEmail(ctx context.Context, db Queryer, email string) (*FullPerson, error) {
 	f := functionFindPersonByEmail
 
 	// Query the people
-	fields := "id, firstname, lastname, knownas, email, phone, hash, status"
+	fields := "id, firstname, lastname, knownas, email, phone, hash, status, skill, gender"
 	where := `email=$1`
 	sqlStatement := `SELECT ` + fields + ` FROM ` + PersonTable + ` WHERE ` + where
 
@@ -337,14 +423,14 @@ func FindPersonByEmail(ctx context.Context, db *sql.DB, email string) (*FullPers
 
 		var p FullPerson
 		var hexstring string
-		err := rows.Scan(&p.ID, &p.FirstName, &p.LastName, &p.Knownas, &p.Email, &p.Phone, &hexstring, &p.Status)
+		err := rows.Scan(&p.ID, &p.FirstName, &p.LastName, &p.Knownas, &p.Email, &p.Phone, &hexstring, &p.Status, &p.Skill, &p.Gender)
 		if err != nil {
 			message := "Could not scan the person"
 			f.DumpError(err, message)
 			return nil, err
 		}
 
-		p.Hash, err = hex.DecodeString(hexstring)
+		p.Hash, err = decodeHash(hexstring)
 		if err != nil {
 			message := "Could not decode hextring: " + hexstring
 			f.DumpError(err, message)
@@ -382,18 +468,60 @@ func FindPersonByEmail(ctx context.Context, db *sql.DB, email string) (*FullPers
 	return &arrayOfPeople[0], nil
 }
 
-// ListPeople returns a list of the people IDs
-func ListPeople(ctx context.Context, db *sql.DB, whereClause string) ([]FullPerson, error) {
+// PeopleFilter type. The zero value matches everyone
+type PeopleFilter struct {
+	Status string // when given, only people with this status match
+	Search string // when given, only people with this text in their firstname, lastname, knownas or email match
+}
+
+// peopleSortColumns maps each sort key for people to its column
+var peopleSortColumns = map[string]string{
+	SortName:   "knownas",
+	SortID:     "id",
+	SortStatus: "status",
+}
+
+// ListPeople returns the people who match the filter
+func ListPeople(ctx context.Context, db Queryer, filter PeopleFilter) ([]FullPerson, error) {
+	list, _, err := ListPeoplePage(ctx, db, filter, Page{})
+	return list, err
+}
+
+// ListPeoplePage returns a page of the people who match the filter, and the
+// number of people who match
+func ListPeoplePage(ctx context.Context, db Queryer, filter PeopleFilter, page Page) ([]FullPerson, int, error) {
 	f := functionListPeople
 
+	var conditions []string
+	var args parameters
+
+	if filter.Status != "" {
+		conditions = append(conditions, "status="+args.add(filter.Status))
+	}
+	if filter.Search != "" {
+		pattern := args.add(containsPattern(filter.Search))
+		conditions = append(conditions, "(firstname ILIKE "+pattern+" OR lastname ILIKE "+pattern+" OR knownas ILIKE "+pattern+" OR email ILIKE "+pattern+")")
+	}
+
+	where := ""
+	if len(conditions) > 0 {
+		where = " WHERE " + strings.Join(conditions, " AND ")
+	}
+	filterArgs := args
+
+	clauses, err := page.clauses(peopleSortColumns, &args)
+	if err != nil {
+		return nil, 0, err
+	}
+
 	// Query the people
-	fields := "id, firstname, lastname, knownas, email, phone, hash, status"
-	sqlStatement := `SELECT ` + fields + ` FROM ` + PersonTable + ` ` + whereClause + ` ORDER BY ` + `knownas`
-	rows, err := db.QueryContext(ctx, sqlStatement)
+	fields := "id, firstname, lastname, knownas, email, phone, hash, status, skill, gender"
+	sqlStatement := `SELECT ` + fields + ` FROM ` + PersonTable + where + clauses
+	rows, err := db.QueryContext(ctx, sqlStatement, args...)
 	if err != nil {
 		message := "Could not select all from " + PersonTable
 		f.DumpSQLError(err, message, sqlStatement)
-		return nil, err
+		return nil, 0, err
 	}
 	defer rows.Close()
 
@@ -402,18 +530,18 @@ func ListPeople(ctx context.Context, db *sql.DB, whereClause string) ([]FullPers
 
 		var p FullPerson
 		var hexstring string
-		err := rows.Scan(&p.ID, &p.FirstName, &p.LastName, &p.Knownas, &p.Email, &p.Phone, &hexstring, &p.Status)
+		err := rows.Scan(&p.ID, &p.FirstName, &p.LastName, &p.Knownas, &p.Email, &p.Phone, &hexstring, &p.Status, &p.Skill, &p.Gender)
 		if err != nil {
 			message := "Could not scan the person"
 			f.DumpError(err, message)
-			return nil, err
+			return nil, 0, err
 		}
 
-		p.Hash, err = hex.DecodeString(hexstring)
+		p.Hash, err = decodeHash(hexstring)
 		if err != nil {
 			message := "Could not decode hextring: " + hexstring
 			f.DumpError(err, message)
-			return nil, err
+			return nil, 0, err
 		}
 
 		// fmt.Printf("    FirstName: %s\n", p.FirstName)
@@ -429,17 +557,31 @@ func ListPeople(ctx context.Context, db *sql.DB, whereClause string) ([]FullPers
 	if err != nil {
 		message := "Could not list all from " + PersonTable
 		f.DumpSQLError(err, message, sqlStatement)
-		return nil, err
+		return nil, 0, err
+	}
+	rows.Close()
+
+	if page.whole() {
+		return list, len(list), nil
+	}
+
+	var total int
+	sqlStatement = `SELECT COUNT(*) FROM ` + PersonTable + where
+	err = db.QueryRowContext(ctx, sqlStatement, filterArgs...).Scan(&total)
+	if err != nil {
+		message := "Could not count the people"
+		f.DumpSQLError(err, message, sqlStatement)
+		return nil, 0, err
 	}
 
-	return list, nil
+	return list, total, nil
 }
 
 // Authenticate method
 func (p *FullPerson) Authenticate(db *sql.DB, password string) error {
 	f := functionAuthenticate
 
-	err := p.checkPassword(password)
+	needsRehash, err := p.checkPassword(password)
 	if err != nil {
 		f.DebugVerbose("password check failed for person [%d]", p.ID)
 		return codeerror.NewUnauthorized("Not Authorized")
@@ -451,11 +593,43 @@ func (p *FullPerson) Authenticate(db *sql.DB, password string) error {
 		return codeerror.NewForbidden("Forbidden")
 	}
 
+	// The password is known to be good, so it can be hashed again if the
+	// stored hash is weaker than the current policy. The signin does not
+	// depend on this succeeding
+	if needsRehash {
+		p.rehashPassword(db, password)
+	}
+
 	return nil
 }
 
-// CheckPassword checks the validity of the password
-func (p *FullPerson) checkPassword(password string) error {
+// rehashPassword replaces the person's hash with one which meets the current
+// policy
+func (p *FullPerson) rehashPassword(db *sql.DB, password string) {
+	f := functionRehashPassword
+
+	hash, err := basic.HashPassword(password)
+	if err != nil {
+		message := "Could not generate password hash"
+		f.DumpError(err, message)
+		return
+	}
+
+	sqlStatement := "UPDATE " + PersonTable + " SET hash=$1 WHERE id=$2"
+	_, err = db.ExecContext(context.Background(), sqlStatement, encodeHash(hash), p.ID)
+	if err != nil {
+		message := fmt.Sprintf("Could not update the hash for person [%d]", p.ID)
+		f.DumpSQLError(err, message, sqlStatement)
+		return
+	}
+
+	f.DebugVerbose("rehashed the password for person [%d]", p.ID)
+	p.Hash = hash
+}
+
+// checkPassword checks the validity of the password, and returns whether
+// the hash should be replaced
+func (p *FullPerson) checkPassword(password string) (bool, error) {
 	f := functionCheckPassword
 
 	// fmt.Printf("    FirstName: %s\n", p.FirstName)
@@ -464,63 +638,34 @@ func (p *FullPerson) checkPassword(password string) error {
 	// fmt.Printf("    hash:      %v\n", p.Hash)
 	// fmt.Printf("    hash:      %s\n", hex.EncodeToString(p.Hash))
 
-	err := bcrypt.CompareHashAndPassword(p.Hash, []byte(password))
+	needsRehash, err := basic.CheckPassword(p.Hash, password)
 	if err != nil {
-		message := fmt.Sprintf("The password %s was invalid for the user with email: %s", password, p.Email)
+		message := fmt.Sprintf("The password was invalid for the user with email: %s", p.Email)
 		d := f.DumpError(err, message)
-		d.AddString("hash.txt", hex.EncodeToString(p.Hash))
-		return err
+		d.AddString("hash.txt", encodeHash(p.Hash))
+		return false, err
 	}
-	return nil
+	return needsRehash, nil
 }
 
-// CanLogin checks the user is allowed to login
-func (p *FullPerson) CanLogin() error {
-
-	if p.Status == StatusAdmin {
-		return nil
-	}
-	if p.Status == StatusPlayer {
-		return nil
-	}
-	if p.Status == StatusInactive {
-		return nil
-	}
-
-	return fmt.Errorf("not Authorized")
+// encodeHash returns the text stored in the hash column. Hashes record their
+// algorithm and parameters, in the form $<algorithm>$<parameters>$..., so
+// are stored as they are
+func encodeHash(hash []byte) string {
+	return string(hash)
 }
 
-// CanEditCourt checks the user is allowed update a court
-func (p *FullPerson) CanEditCourt() error {
-
-	if p.Status == StatusAdmin {
-		return nil
-	}
-	if p.Status == StatusPlayer {
-		return nil
-	}
-
-	return fmt.Errorf("not Authorized")
-}
-
-// CanGetMetrics checks the user is allowed get the metrics
-func (p *FullPerson) CanGetMetrics() error {
-
-	if p.Status == StatusAdmin {
-		return nil
-	}
-	if p.Status == StatusPlayer {
-		return nil
-	}
-	if p.Status == StatusInactive {
-		return nil
+// decodeHash returns the hash held in the hash column. Older hashes were
+// stored hex encoded, and are still accepted
+func decodeHash(text string) ([]byte, error) {
+	if strings.HasPrefix(text, "$") {
+		return []byte(text), nil
 	}
-
-	return fmt.Errorf("not Authorized")
+	return hex.DecodeString(text)
 }
 
-// CanEditOtherPeople checks the user is allowed update a court
-func (p *FullPerson) CanEditOtherPeople() error {
+// CanLogin checks the user is allowed to login
+func (p *FullPerson) CanLogin() error {
 
 	if p.Status == StatusAdmin {
 		return nil
@@ -535,20 +680,9 @@ func (p *FullPerson) CanEditOtherPeople() error {
 	return fmt.Errorf("not Authorized")
 }
 
-// CanEditSelf checks the user is allowed update a court
-func (p *FullPerson) CanEditSelf() error {
-
-	if p.Status == StatusAdmin {
-		return nil
-	}
-	if p.Status == StatusPlayer {
-		return nil
-	}
-	if p.Status == StatusInactive {
-		return nil
-	}
-
-	return fmt.Errorf("not Authorized")
+// Can checks the person's roles grant the permission
+func (p *FullPerson) Can(permission Permission) bool {
+	return RolesAllow(p.Roles, permission)
 }
 
 // ToLimited converts a person to a Limited person
@@ -561,6 +695,9 @@ func (p *FullPerson) ToLimited() *Person {
 		Email:     p.Email,
 		Phone:     p.Phone,
 		Status:    p.Status,
+		Skill:     p.Skill,
+		Gender:    p.Gender,
+		Roles:     p.Roles,
 	}
 	return lp
 }
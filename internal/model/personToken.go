@@ -0,0 +1,199 @@
+package model
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/rsmaxwell/players-api/internal/codeerror"
+	"github.com/rsmaxwell/players-api/internal/debug"
+)
+
+const (
+	// PersonTokenTable is the name of the person token table
+	PersonTokenTable = "persontoken"
+
+	// PurposeVerifyEmail tokens prove a person can read mail sent to their
+	// email address
+	PurposeVerifyEmail = "verify"
+
+	// PurposeResetPassword tokens let a person who has forgotten their
+	// password choose a new one
+	PurposeResetPassword = "reset"
+
+	// personTokenBytes is the number of random bytes in a person token
+	personTokenBytes = 32
+
+	// passwordRules are the rules for a new password, as for a Registration
+	passwordRules = "required,min=8,max=30"
+)
+
+var (
+	functionCreatePersonToken = debug.NewFunction(pkg, "CreatePersonToken")
+	functionUsePersonToken    = debug.NewFunction(pkg, "UsePersonToken")
+	functionVerifyEmailTx     = debug.NewFunction(pkg, "VerifyEmailTx")
+	functionResetPasswordTx   = debug.NewFunction(pkg, "ResetPasswordTx")
+)
+
+// CreatePersonToken returns a new single use token, which is sent to the
+// person by mail. Only the hash of the token is kept. The person's earlier
+// unused tokens for the same purpose are cancelled
+func CreatePersonToken(ctx context.Context, db Queryer, personID int, purpose string, expiry time.Duration) (string, error) {
+	f := functionCreatePersonToken
+
+	sqlStatement := "DELETE FROM " + PersonTokenTable + " WHERE person=$1 AND purpose=$2 AND used IS NULL"
+	_, err := db.ExecContext(ctx, sqlStatement, personID, purpose)
+	if err != nil {
+		message := "Could not delete from " + PersonTokenTable
+		f.DumpSQLError(err, message, sqlStatement)
+		return "", err
+	}
+
+	token, err := newRandomString(personTokenBytes)
+	if err != nil {
+		message := "Could not create a person token"
+		f.DumpError(err, message)
+		return "", err
+	}
+
+	now := time.Now()
+
+	fields := "person, purpose, hash, created, expires"
+	sqlStatement = "INSERT INTO " + PersonTokenTable + " (" + fields + ") VALUES ($1, $2, $3, $4, $5)"
+	_, err = db.ExecContext(ctx, sqlStatement, personID, purpose, hashToken(token), now, now.Add(expiry))
+	if err != nil {
+		message := fmt.Sprintf("Could not insert into %s for person [%d]", PersonTokenTable, personID)
+		f.DumpSQLError(err, message, sqlStatement)
+		return "", err
+	}
+
+	return token, nil
+}
+
+// UsePersonToken marks a token as used, and returns the person it was sent
+// to. Tokens which are unknown, for another purpose, expired or already
+// used are refused
+func UsePersonToken(ctx context.Context, db Queryer, token string, purpose string) (int, error) {
+	f := functionUsePersonToken
+
+	var personID int
+	sqlStatement := "UPDATE " + PersonTokenTable + " SET used=$1 WHERE hash=$2 AND purpose=$3 AND used IS NULL AND expires > $1 RETURNING person"
+	err := db.QueryRowContext(ctx, sqlStatement, time.Now(), hashToken(token), purpose).Scan(&personID)
+	if err == sql.ErrNoRows {
+		f.DebugVerbose("%s token refused", purpose)
+		return 0, codeerror.NewBadRequest("Invalid or expired token")
+	} else if err != nil {
+		message := "Could not update " + PersonTokenTable
+		f.DumpSQLError(err, message, sqlStatement)
+		return 0, err
+	}
+
+	return personID, nil
+}
+
+// VerifyEmailTx uses an email verification token, within a transaction.
+// A person whose registration has been approved is given their status, and
+// can sign in
+func VerifyEmailTx(ctx context.Context, db *sql.DB, token string) error {
+	f := functionVerifyEmailTx
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		message := "Could not begin a new transaction"
+		f.DumpError(err, message)
+		return err
+	}
+
+	_, err = IncrementVersion(ctx, tx)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	personID, err := UsePersonToken(ctx, tx, token, PurposeVerifyEmail)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	approval, err := markApprovalVerified(ctx, tx, personID)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if approval != nil {
+		_, err = activateApprovedPerson(ctx, tx, approval)
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		message := "Could not commit the transaction"
+		f.DumpError(err, message)
+		return err
+	}
+
+	return nil
+}
+
+// ResetPasswordTx uses a password reset token, within a transaction, to
+// set a new password. The person is signed out everywhere, in case the
+// old password was known to someone else
+func ResetPasswordTx(ctx context.Context, db *sql.DB, token string, password string) error {
+	f := functionResetPasswordTx
+
+	// Check the password before the token is used up
+	err := validate.Var(password, passwordRules)
+	if err != nil {
+		errs := translateError(err, trans)
+		message := "Password" + errs[0].Error()
+		f.DebugVerbose(message)
+		return codeerror.NewBadRequest(message)
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		message := "Could not begin a new transaction"
+		f.DumpError(err, message)
+		return err
+	}
+
+	personID, err := UsePersonToken(ctx, tx, token, PurposeResetPassword)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	person := FullPerson{ID: personID}
+	err = person.LoadPerson(ctx, tx)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	err = person.UpdatePersonFields(ctx, tx, map[string]interface{}{"password": password})
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	err = RevokeRefreshTokens(ctx, tx, personID)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		message := "Could not commit the transaction"
+		f.DumpError(err, message)
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,80 @@
+package model
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	_ "github.com/jackc/pgx/stdlib"
+)
+
+func TestPersonTokens(t *testing.T) {
+	teardown, db, _ := Setup(t)
+	defer teardown(t)
+
+	ctx := context.Background()
+
+	person, err := FindPersonByEmail(ctx, db, GoodEmail)
+	if err != nil {
+		t.Log("Could not find the person")
+		t.FailNow()
+	}
+
+	token, err := CreatePersonToken(ctx, db, person.ID, PurposeResetPassword, time.Hour)
+	if err != nil {
+		t.Log("Could not create a person token")
+		t.FailNow()
+	}
+
+	// A token is only good for its purpose ...
+	_, err = UsePersonToken(ctx, db, token, PurposeVerifyEmail)
+	if err == nil {
+		t.Log("Unexpected success using a token for another purpose")
+		t.FailNow()
+	}
+
+	personID, err := UsePersonToken(ctx, db, token, PurposeResetPassword)
+	if err != nil || personID != person.ID {
+		t.Log("Could not use the person token")
+		t.FailNow()
+	}
+
+	// ... and can only be used once
+	_, err = UsePersonToken(ctx, db, token, PurposeResetPassword)
+	if err == nil {
+		t.Log("Unexpected success using a person token twice")
+		t.FailNow()
+	}
+
+	// A new token cancels the earlier one
+	first, err := CreatePersonToken(ctx, db, person.ID, PurposeResetPassword, time.Hour)
+	if err != nil {
+		t.Log("Could not create a person token")
+		t.FailNow()
+	}
+
+	_, err = CreatePersonToken(ctx, db, person.ID, PurposeResetPassword, time.Hour)
+	if err != nil {
+		t.Log("Could not create a person token")
+		t.FailNow()
+	}
+
+	_, err = UsePersonToken(ctx, db, first, PurposeResetPassword)
+	if err == nil {
+		t.Log("Unexpected success using a cancelled person token")
+		t.FailNow()
+	}
+
+	// An expired token is refused
+	expired, err := CreatePersonToken(ctx, db, person.ID, PurposeVerifyEmail, -time.Minute)
+	if err != nil {
+		t.Log("Could not create a person token")
+		t.FailNow()
+	}
+
+	_, err = UsePersonToken(ctx, db, expired, PurposeVerifyEmail)
+	if err == nil {
+		t.Log("Unexpected success using an expired person token")
+		t.FailNow()
+	}
+}
@@ -3,10 +3,13 @@ package model
 import (
 	"context"
 	"database/sql"
+	"net/http"
 	"testing"
 
 	"golang.org/x/crypto/bcrypt"
 
+	"github.com/rsmaxwell/players-api/internal/codeerror"
+
 	_ "github.com/jackc/pgx/stdlib"
 )
 
@@ -101,18 +104,88 @@ func TestPeopleBasic(t *testing.T) {
 
 	p.CheckPerson(ctx, t, db, FirstName3, LastName3, Knownas3, Email3, Phone3, Password3, StatusPlayer)
 
-	err = p.DeletePersonTx(db)
+	err = p.DeletePersonTx(ctx, db)
 	if err != nil {
 		t.Log("Could not delete person")
 		t.FailNow()
 	}
-	err = p2.DeletePersonTx(db)
+	err = p2.DeletePersonTx(ctx, db)
 	if err != nil {
 		t.Log("Could not delete person")
 		t.FailNow()
 	}
 }
 
+func TestHostilePersonNames(t *testing.T) {
+	teardown, db, _ := Setup(t)
+	defer teardown(t)
+
+	ctx := context.Background()
+
+	everyone, err := ListPeople(ctx, db, PeopleFilter{})
+	if err != nil {
+		t.Log("Could not list the people")
+		t.FailNow()
+	}
+
+	r := Registration{
+		FirstName: "Robert'); --", LastName: "O'Brien", Knownas: "$@@@$';--$@@@$", Email: "hostile@example.com", Phone: "+44 1234 999999", Password: "TopSecret",
+	}
+
+	p, err := r.ToPerson()
+	if err != nil {
+		t.Log(err.Error())
+		t.FailNow()
+	}
+
+	err = p.SavePersonTx(db)
+	if err != nil {
+		t.Log("Could not create a person with hostile names")
+		t.FailNow()
+	}
+
+	p.CheckPerson(ctx, t, db, r.FirstName, r.LastName, r.Knownas, r.Email, r.Phone, r.Password, StatusSuspended)
+
+	p.FirstName = `back\slash`
+	p.LastName = `"double" 'single'`
+	p.Knownas = "$$ OR 1=1 $$"
+
+	err = p.UpdatePerson(ctx, db)
+	if err != nil {
+		t.Log("Could not update a person with hostile names")
+		t.FailNow()
+	}
+
+	p.CheckPerson(ctx, t, db, `back\slash`, `"double" 'single'`, "$$ OR 1=1 $$", r.Email, r.Phone, r.Password, StatusSuspended)
+
+	// The filter's status is a value, not SQL
+	list, err := ListPeople(ctx, db, PeopleFilter{Status: "player' OR '1'='1"})
+	if err != nil {
+		t.Log("Could not list the people with a hostile status")
+		t.FailNow()
+	}
+	if len(list) != 0 {
+		t.Logf("Unexpected number of people. expected: 0, actual: %d", len(list))
+		t.FailNow()
+	}
+
+	err = DeletePerson(ctx, db, p.ID)
+	if err != nil {
+		t.Log("Could not delete the person")
+		t.FailNow()
+	}
+
+	list, err = ListPeople(ctx, db, PeopleFilter{})
+	if err != nil {
+		t.Log("Could not list the people")
+		t.FailNow()
+	}
+	if len(list) != len(everyone) {
+		t.Logf("Unexpected number of people. expected: %d, actual: %d", len(everyone), len(list))
+		t.FailNow()
+	}
+}
+
 func (p *FullPerson) CheckPerson(ctx context.Context, t *testing.T, db *sql.DB, firstname string, lastname string, displayname string, email string, phone string, password string, status string) {
 
 	err := p.LoadPerson(ctx, db)
@@ -157,3 +230,70 @@ func (p *FullPerson) CheckPerson(ctx context.Context, t *testing.T, db *sql.DB,
 		t.FailNow()
 	}
 }
+
+func TestListPeoplePage(t *testing.T) {
+	teardown, db, _ := Setup(t)
+	defer teardown(t)
+
+	ctx := context.Background()
+
+	everyone, err := ListPeople(ctx, db, PeopleFilter{})
+	if err != nil || len(everyone) < 3 {
+		t.Log("Could not list the people")
+		t.FailNow()
+	}
+
+	// The pages cover everyone, once each, in order of id
+	page := Page{Sort: SortID, Limit: 2}
+	seen := make(map[int]bool)
+	previous := 0
+	for {
+		list, total, err := ListPeoplePage(ctx, db, PeopleFilter{}, page)
+		if err != nil {
+			t.Log("Could not list a page of people")
+			t.FailNow()
+		}
+		if total != len(everyone) {
+			t.Logf("Unexpected total. expected: %d, actual: %d", len(everyone), total)
+			t.FailNow()
+		}
+		if len(list) == 0 {
+			break
+		}
+		for _, p := range list {
+			if seen[p.ID] || p.ID <= previous {
+				t.Logf("Unexpected person [%d] after [%d]", p.ID, previous)
+				t.FailNow()
+			}
+			seen[p.ID] = true
+			previous = p.ID
+		}
+		page.Offset = page.Offset + len(list)
+	}
+	if len(seen) != len(everyone) {
+		t.Logf("Unexpected number of people. expected: %d, actual: %d", len(everyone), len(seen))
+		t.FailNow()
+	}
+
+	// The search matches part of any of the names, or the email, ignoring case
+	for _, search := range []string{"BROWN", "bob@ntl", "ob"} {
+		list, total, err := ListPeoplePage(ctx, db, PeopleFilter{Search: search}, Page{Limit: 10})
+		if err != nil || total == 0 || len(list) != total {
+			t.Logf("Unexpected people for search %q: total: %d, list: %d", search, total, len(list))
+			t.FailNow()
+		}
+	}
+
+	// The wildcards in the search are ordinary characters
+	list, total, err := ListPeoplePage(ctx, db, PeopleFilter{Search: "%"}, Page{Limit: 10})
+	if err != nil || total != 0 || len(list) != 0 {
+		t.Logf("Unexpected people for a wildcard search: total: %d, list: %d", total, len(list))
+		t.FailNow()
+	}
+
+	_, _, err = ListPeoplePage(ctx, db, PeopleFilter{}, Page{Sort: "junk"})
+	if codeErr, ok := err.(*codeerror.CodeError); !ok || codeErr.Code() != http.StatusBadRequest {
+		t.Logf("Expected a 'bad request' error for an unknown sort key, got: %v", err)
+		t.FailNow()
+	}
+}
@@ -2,17 +2,19 @@ package model
 
 import (
 	"context"
-	"database/sql"
 	"encoding/json"
+	"time"
 
 	"github.com/rsmaxwell/players-api/internal/debug"
 )
 
 // Player type
 type Player struct {
-	Person   int `json:"person"`
-	Court    int `json:"court"`
-	Position int `json:"position"`
+	Person   int       `json:"person"`
+	Court    int       `json:"court"`
+	Position int       `json:"position"`
+	Start    time.Time `json:"start"`
+	Wait     int       `json:"wait"` // seconds spent waiting before being called
 }
 
 const (
@@ -28,15 +30,17 @@ var (
 	functionListPlayersForCourt  = debug.NewFunction(pkg, "ListPlayersForCourt")
 )
 
-// AddPlayer
-func AddPlayer(ctx context.Context, db *sql.DB, personID int, courtID int, position int) error {
+// AddPlayer puts a person on a court, recording how long they waited to be called
+func AddPlayer(ctx context.Context, db Queryer, personID int, courtID int, position int, wait time.Duration) error {
 	f := functionAddPlayer
 
-	fields := "person, court, position"
-	values := "$1, $2, $3"
+	start := time.Now()
+
+	fields := "person, court, position, start, wait"
+	values := "$1, $2, $3, $4, $5"
 	sqlStatement := "INSERT INTO " + PlayingTable + " (" + fields + ") VALUES (" + values + ")"
 
-	_, err := db.ExecContext(ctx, sqlStatement, personID, courtID, position)
+	_, err := db.ExecContext(ctx, sqlStatement, personID, courtID, position, start, int(wait.Seconds()))
 	if err != nil {
 		message := "Could not insert into " + PlayingTable
 		f.Errorf(message)
@@ -57,7 +61,7 @@ func AddPlayer(ctx context.Context, db *sql.DB, personID int, courtID int, posit
 }
 
 // RemovePlayer
-func RemovePlayer(ctx context.Context, db *sql.DB, personID int) error {
+func RemovePlayer(ctx context.Context, db Queryer, personID int) error {
 	f := functionRemovePlayer
 
 	sqlStatement := "DELETE FROM " + PlayingTable + " WHERE person=$1"
@@ -74,13 +78,13 @@ func RemovePlayer(ctx context.Context, db *sql.DB, personID int) error {
 }
 
 // ListPlayers
-func ListPlayers(ctx context.Context, db *sql.DB) ([]Player, error) {
+func ListPlayers(ctx context.Context, db Queryer) ([]Player, error) {
 	f := functionListPlayers
 
-	fields := "court, person, position"
-	sqlStatement := "SELECT " + fields + " FROM " + PlayingTable
+	fields := "person, court, position, start, wait"
+	sqlStatement := "SELECT " + fields + " FROM " + PlayingTable + " ORDER BY court, position"
 
-	rows, err := db.Query(sqlStatement)
+	rows, err := db.QueryContext(ctx, sqlStatement)
 	if err != nil {
 		message := "Could not list the players"
 		f.Errorf(message)
@@ -93,7 +97,7 @@ func ListPlayers(ctx context.Context, db *sql.DB) ([]Player, error) {
 	for rows.Next() {
 
 		var player Player
-		err := rows.Scan(&player.Person, &player.Court, &player.Position)
+		err := rows.Scan(&player.Person, &player.Court, &player.Position, &player.Start, &player.Wait)
 		if err != nil {
 			message := "Could not scan the player"
 			f.Errorf(message)
@@ -115,10 +119,10 @@ func ListPlayers(ctx context.Context, db *sql.DB) ([]Player, error) {
 }
 
 // ListPlayersForPerson
-func ListPlayersForPerson(ctx context.Context, db *sql.DB, personID int) ([]Player, error) {
+func ListPlayersForPerson(ctx context.Context, db Queryer, personID int) ([]Player, error) {
 	f := functionListPlayersForPerson
 
-	fields := "court, person, position"
+	fields := "person, court, position, start, wait"
 	sqlStatement := "SELECT " + fields + " FROM " + PlayingTable + " WHERE person=$1"
 
 	rows, err := db.QueryContext(ctx, sqlStatement, personID)
@@ -134,7 +138,7 @@ func ListPlayersForPerson(ctx context.Context, db *sql.DB, personID int) ([]Play
 	for rows.Next() {
 
 		var player Player
-		err := rows.Scan(&player.Person, &player.Court, &player.Position)
+		err := rows.Scan(&player.Person, &player.Court, &player.Position, &player.Start, &player.Wait)
 		if err != nil {
 			message := "Could not scan the player"
 			f.Errorf(message)
@@ -156,10 +160,10 @@ func ListPlayersForPerson(ctx context.Context, db *sql.DB, personID int) ([]Play
 }
 
 // ListPlayersForCourt
-func ListPlayersForCourt(ctx context.Context, db *sql.DB, courtID int) ([]Player, error) {
+func ListPlayersForCourt(ctx context.Context, db Queryer, courtID int) ([]Player, error) {
 	f := functionListPlayersForCourt
 
-	fields := "person, court, position"
+	fields := "person, court, position, start, wait"
 	sqlStatement := "SELECT " + fields + " FROM " + PlayingTable + " WHERE court=$1"
 
 	rows, err := db.QueryContext(ctx, sqlStatement, courtID)
@@ -175,7 +179,7 @@ func ListPlayersForCourt(ctx context.Context, db *sql.DB, courtID int) ([]Player
 	for rows.Next() {
 
 		var player Player
-		err := rows.Scan(&player.Person, &player.Court, &player.Position)
+		err := rows.Scan(&player.Person, &player.Court, &player.Position, &player.Start, &player.Wait)
 		if err != nil {
 			message := "Could not scan the player"
 			f.Errorf(message)
@@ -0,0 +1,284 @@
+package model
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"github.com/rsmaxwell/players-api/internal/codeerror"
+	"github.com/rsmaxwell/players-api/internal/debug"
+)
+
+// RefreshToken type. Only the hash of the token is kept. Each refresh
+// replaces the token with a new one in the same family, so a token which
+// is presented after it has been replaced has been stolen, and the whole
+// family is revoked
+type RefreshToken struct {
+	ID      int
+	Person  int
+	Device  string
+	Family  string
+	Hash    string
+	Created time.Time
+	Expires time.Time
+	Used    time.Time // zero until the token has been replaced
+	Revoked time.Time // zero until the token has been revoked
+}
+
+const (
+	// RefreshTokenTable is the name of the refresh token table
+	RefreshTokenTable = "refreshtoken"
+
+	// refreshTokenBytes is the number of random bytes in a refresh token
+	refreshTokenBytes = 32
+)
+
+var (
+	functionCreateRefreshToken       = debug.NewFunction(pkg, "CreateRefreshToken")
+	functionRotateRefreshTokenTx     = debug.NewFunction(pkg, "RotateRefreshTokenTx")
+	functionFindRefreshToken         = debug.NewFunction(pkg, "findRefreshToken")
+	functionInsertRefreshToken       = debug.NewFunction(pkg, "insertRefreshToken")
+	functionRevokeRefreshToken       = debug.NewFunction(pkg, "RevokeRefreshToken")
+	functionRevokeRefreshTokens      = debug.NewFunction(pkg, "RevokeRefreshTokens")
+	functionRevokeRefreshTokenFamily = debug.NewFunction(pkg, "revokeRefreshTokenFamily")
+)
+
+// hashToken returns the hash under which a token is kept
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// newRandomString returns a random, url safe, string
+func newRandomString(size int) (string, error) {
+	b := make([]byte, size)
+	_, err := rand.Read(b)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// CreateRefreshToken starts a new family of refresh tokens for a person
+// signing in on a device, and returns the first token. The person's earlier
+// tokens for the same device are revoked
+func CreateRefreshToken(ctx context.Context, db Queryer, personID int, device string, expiry time.Duration) (string, error) {
+	f := functionCreateRefreshToken
+
+	now := time.Now()
+
+	sqlStatement := "UPDATE " + RefreshTokenTable + " SET revoked=$1 WHERE person=$2 AND device=$3 AND revoked IS NULL"
+	_, err := db.ExecContext(ctx, sqlStatement, now, personID, device)
+	if err != nil {
+		message := "Could not update " + RefreshTokenTable
+		f.DumpSQLError(err, message, sqlStatement)
+		return "", err
+	}
+
+	family, err := newRandomString(16)
+	if err != nil {
+		message := "Could not create a refresh token family"
+		f.DumpError(err, message)
+		return "", err
+	}
+
+	return insertRefreshToken(ctx, db, personID, device, family, expiry)
+}
+
+// RotateRefreshTokenTx replaces a refresh token with a new one, within a
+// transaction, and returns the person and the new token
+func RotateRefreshTokenTx(ctx context.Context, db *sql.DB, token string, expiry time.Duration) (int, string, error) {
+	f := functionRotateRefreshTokenTx
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		message := "Could not begin a new transaction"
+		f.DumpError(err, message)
+		return 0, "", err
+	}
+
+	old, err := findRefreshToken(ctx, tx, token)
+	if err != nil {
+		tx.Rollback()
+		return 0, "", err
+	}
+	if old == nil {
+		tx.Rollback()
+		return 0, "", codeerror.NewUnauthorized("Unauthorized")
+	}
+
+	now := time.Now()
+	reason := ""
+	if !old.Revoked.IsZero() {
+		reason = "revoked"
+	} else if !old.Used.IsZero() {
+		reason = "reused"
+	} else if now.After(old.Expires) {
+		reason = "expired"
+	} else {
+		person := FullPerson{ID: old.Person}
+		err = person.LoadPerson(ctx, tx)
+		if err != nil {
+			tx.Rollback()
+			return 0, "", err
+		}
+		if person.CanLogin() != nil {
+			reason = "person cannot login"
+		}
+	}
+
+	if reason != "" {
+		f.DebugVerbose("refresh token for person [%d] refused: %s", old.Person, reason)
+
+		// Whoever holds the rest of the family can no longer use it
+		if reason == "reused" || reason == "person cannot login" {
+			err = revokeRefreshTokenFamily(ctx, tx, old.Family)
+			if err != nil {
+				tx.Rollback()
+				return 0, "", err
+			}
+		}
+
+		err = tx.Commit()
+		if err != nil {
+			message := "Could not commit the transaction"
+			f.DumpError(err, message)
+			return 0, "", err
+		}
+
+		return 0, "", codeerror.NewUnauthorized("Unauthorized")
+	}
+
+	sqlStatement := "UPDATE " + RefreshTokenTable + " SET used=$1 WHERE id=$2"
+	_, err = tx.ExecContext(ctx, sqlStatement, now, old.ID)
+	if err != nil {
+		tx.Rollback()
+		message := "Could not update " + RefreshTokenTable
+		f.DumpSQLError(err, message, sqlStatement)
+		return 0, "", err
+	}
+
+	newToken, err := insertRefreshToken(ctx, tx, old.Person, old.Device, old.Family, expiry)
+	if err != nil {
+		tx.Rollback()
+		return 0, "", err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		message := "Could not commit the transaction"
+		f.DumpError(err, message)
+		return 0, "", err
+	}
+
+	return old.Person, newToken, nil
+}
+
+// RevokeRefreshToken revokes a refresh token, and the rest of its family.
+// Unknown tokens are ignored
+func RevokeRefreshToken(ctx context.Context, db Queryer, token string) error {
+	f := functionRevokeRefreshToken
+
+	old, err := findRefreshToken(ctx, db, token)
+	if err != nil {
+		message := "Could not find the refresh token"
+		f.Errorf(message)
+		f.DumpError(err, message)
+		return err
+	}
+	if old == nil {
+		return nil
+	}
+
+	return revokeRefreshTokenFamily(ctx, db, old.Family)
+}
+
+// RevokeRefreshTokens revokes every refresh token held by a person, which
+// signs them out on all their devices once their access tokens expire
+func RevokeRefreshTokens(ctx context.Context, db Queryer, personID int) error {
+	f := functionRevokeRefreshTokens
+
+	sqlStatement := "UPDATE " + RefreshTokenTable + " SET revoked=$1 WHERE person=$2 AND revoked IS NULL"
+	_, err := db.ExecContext(ctx, sqlStatement, time.Now(), personID)
+	if err != nil {
+		message := "Could not update " + RefreshTokenTable
+		f.DumpSQLError(err, message, sqlStatement)
+		return err
+	}
+
+	return nil
+}
+
+// revokeRefreshTokenFamily revokes every token in a family
+func revokeRefreshTokenFamily(ctx context.Context, db Queryer, family string) error {
+	f := functionRevokeRefreshTokenFamily
+
+	sqlStatement := "UPDATE " + RefreshTokenTable + " SET revoked=$1 WHERE family=$2 AND revoked IS NULL"
+	_, err := db.ExecContext(ctx, sqlStatement, time.Now(), family)
+	if err != nil {
+		message := "Could not update " + RefreshTokenTable
+		f.DumpSQLError(err, message, sqlStatement)
+		return err
+	}
+
+	return nil
+}
+
+// findRefreshToken returns the refresh token, or nil if it is not known
+func findRefreshToken(ctx context.Context, db Queryer, token string) (*RefreshToken, error) {
+	f := functionFindRefreshToken
+
+	var t RefreshToken
+	var used sql.NullTime
+	var revoked sql.NullTime
+
+	fields := "id, person, device, family, hash, created, expires, used, revoked"
+	sqlStatement := "SELECT " + fields + " FROM " + RefreshTokenTable + " WHERE hash=$1 FOR UPDATE"
+	err := db.QueryRowContext(ctx, sqlStatement, hashToken(token)).Scan(&t.ID, &t.Person, &t.Device, &t.Family, &t.Hash, &t.Created, &t.Expires, &used, &revoked)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	} else if err != nil {
+		message := "Could not select the refresh token"
+		f.DumpSQLError(err, message, sqlStatement)
+		return nil, err
+	}
+
+	if used.Valid {
+		t.Used = used.Time
+	}
+	if revoked.Valid {
+		t.Revoked = revoked.Time
+	}
+
+	return &t, nil
+}
+
+// insertRefreshToken adds a new token to a family, and returns it
+func insertRefreshToken(ctx context.Context, db Queryer, personID int, device string, family string, expiry time.Duration) (string, error) {
+	f := functionInsertRefreshToken
+
+	token, err := newRandomString(refreshTokenBytes)
+	if err != nil {
+		message := "Could not create a refresh token"
+		f.DumpError(err, message)
+		return "", err
+	}
+
+	now := time.Now()
+
+	fields := "person, device, family, hash, created, expires"
+	sqlStatement := "INSERT INTO " + RefreshTokenTable + " (" + fields + ") VALUES ($1, $2, $3, $4, $5, $6)"
+	_, err = db.ExecContext(ctx, sqlStatement, personID, device, family, hashToken(token), now, now.Add(expiry))
+	if err != nil {
+		message := fmt.Sprintf("Could not insert into %s for person [%d]", RefreshTokenTable, personID)
+		f.DumpSQLError(err, message, sqlStatement)
+		return "", err
+	}
+
+	return token, nil
+}
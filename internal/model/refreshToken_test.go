@@ -0,0 +1,105 @@
+package model
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	_ "github.com/jackc/pgx/stdlib"
+)
+
+func TestRefreshTokenRotation(t *testing.T) {
+	teardown, db, _ := Setup(t)
+	defer teardown(t)
+
+	ctx := context.Background()
+
+	person, err := FindPersonByEmail(ctx, db, GoodEmail)
+	if err != nil {
+		t.Log("Could not find the person")
+		t.FailNow()
+	}
+
+	first, err := CreateRefreshToken(ctx, db, person.ID, "phone", time.Hour)
+	if err != nil {
+		t.Log("Could not create a refresh token")
+		t.FailNow()
+	}
+
+	personID, second, err := RotateRefreshTokenTx(ctx, db, first, time.Hour)
+	if err != nil || personID != person.ID || second == first {
+		t.Log("Could not rotate the refresh token")
+		t.FailNow()
+	}
+
+	// Using the first token again revokes the whole family ...
+	_, _, err = RotateRefreshTokenTx(ctx, db, first, time.Hour)
+	if err == nil {
+		t.Log("Unexpected success reusing a refresh token")
+		t.FailNow()
+	}
+
+	// ... so the second token no longer works either
+	_, _, err = RotateRefreshTokenTx(ctx, db, second, time.Hour)
+	if err == nil {
+		t.Log("Unexpected success using a token from a revoked family")
+		t.FailNow()
+	}
+}
+
+func TestRevokeRefreshTokens(t *testing.T) {
+	teardown, db, _ := Setup(t)
+	defer teardown(t)
+
+	ctx := context.Background()
+
+	person, err := FindPersonByEmail(ctx, db, GoodEmail)
+	if err != nil {
+		t.Log("Could not find the person")
+		t.FailNow()
+	}
+
+	phone, err := CreateRefreshToken(ctx, db, person.ID, "phone", time.Hour)
+	if err != nil {
+		t.Log("Could not create a refresh token")
+		t.FailNow()
+	}
+
+	laptop, err := CreateRefreshToken(ctx, db, person.ID, "laptop", time.Hour)
+	if err != nil {
+		t.Log("Could not create a refresh token")
+		t.FailNow()
+	}
+
+	// Signing out on one device leaves the other signed in
+	err = RevokeRefreshToken(ctx, db, phone)
+	if err != nil {
+		t.Log("Could not revoke the refresh token")
+		t.FailNow()
+	}
+
+	_, _, err = RotateRefreshTokenTx(ctx, db, phone, time.Hour)
+	if err == nil {
+		t.Log("Unexpected success using a revoked refresh token")
+		t.FailNow()
+	}
+
+	_, laptop, err = RotateRefreshTokenTx(ctx, db, laptop, time.Hour)
+	if err != nil {
+		t.Log("Could not rotate the refresh token for the other device")
+		t.FailNow()
+	}
+
+	// Revoking all the person's tokens signs them out everywhere
+	err = RevokeRefreshTokens(ctx, db, person.ID)
+	if err != nil {
+		t.Log("Could not revoke the refresh tokens")
+		t.FailNow()
+	}
+
+	_, _, err = RotateRefreshTokenTx(ctx, db, laptop, time.Hour)
+	if err == nil {
+		t.Log("Unexpected success using a refresh token after they were all revoked")
+		t.FailNow()
+	}
+}
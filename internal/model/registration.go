@@ -3,10 +3,10 @@ package model
 import (
 	"fmt"
 
+	"github.com/rsmaxwell/players-api/internal/basic"
 	"github.com/rsmaxwell/players-api/internal/codeerror"
 	"github.com/rsmaxwell/players-api/internal/debug"
 
-	"golang.org/x/crypto/bcrypt"
 	validator "gopkg.in/go-playground/validator.v9"
 
 	"github.com/go-playground/locales/en"
@@ -68,7 +68,7 @@ func (r *Registration) ToPerson() (*FullPerson, error) {
 		return nil, codeerror.NewBadRequest(message)
 	}
 
-	hash, err := bcrypt.GenerateFromPassword([]byte(r.Password), bcrypt.MinCost)
+	hash, err := basic.HashPassword(r.Password)
 	if err != nil {
 		message := "Could not generate password hash"
 		f.Errorf(message)
@@ -0,0 +1,273 @@
+package model
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/rsmaxwell/players-api/internal/codeerror"
+	"github.com/rsmaxwell/players-api/internal/debug"
+)
+
+// Permission type. A permission allows a set of actions, and is granted to
+// a person through their roles
+type Permission string
+
+const (
+	// PersonRoleTable is the name of the table of the roles held by each person
+	PersonRoleTable = "personrole"
+
+	// RoleAdmin runs the club: they manage people and their roles
+	RoleAdmin = "admin"
+
+	// RoleOrganiser runs a club night: they manage the sessions, the courts and the queue
+	RoleOrganiser = "organiser"
+
+	// RoleMember plays
+	RoleMember = "member"
+)
+
+const (
+	// PermissionNone is needed by the routes open to everyone
+	PermissionNone Permission = ""
+
+	// PermissionView allows seeing the courts, the queue, people, games and statistics
+	PermissionView Permission = "view"
+
+	// PermissionEditSelf allows people to update their own details
+	PermissionEditSelf Permission = "editSelf"
+
+	// PermissionCheckIn allows people to check themselves in and out of a session
+	PermissionCheckIn Permission = "checkIn"
+
+	// PermissionManageQueue allows moving people between the queue and the
+	// courts, and filling and clearing courts
+	PermissionManageQueue Permission = "manageQueue"
+
+	// PermissionEditCourts allows creating, updating and deleting courts
+	PermissionEditCourts Permission = "editCourts"
+
+	// PermissionManageSessions allows opening and closing sessions
+	PermissionManageSessions Permission = "manageSessions"
+
+	// PermissionEditPeople allows updating and deleting other people
+	PermissionEditPeople Permission = "editPeople"
+
+	// PermissionManageRoles allows changing the roles people hold
+	PermissionManageRoles Permission = "manageRoles"
+
+	// PermissionApproveRegistrations allows approving and rejecting the
+	// registrations of new people
+	PermissionApproveRegistrations Permission = "approveRegistrations"
+
+	// PermissionViewMetrics allows seeing the server metrics
+	PermissionViewMetrics Permission = "viewMetrics"
+
+	// PermissionManageLogging allows changing the log format and levels
+	PermissionManageLogging Permission = "manageLogging"
+
+	// PermissionManageDumps allows listing, downloading and deleting the dumps
+	PermissionManageDumps Permission = "manageDumps"
+
+	// PermissionManageClubs allows creating clubs. It is only granted by the
+	// roles a person holds across every club
+	PermissionManageClubs Permission = "manageClubs"
+)
+
+var (
+	// AllRoles lists all the roles
+	AllRoles = []string{RoleAdmin, RoleOrganiser, RoleMember}
+
+	rolePermissions = map[string][]Permission{
+		RoleMember: {
+			PermissionView,
+			PermissionEditSelf,
+			PermissionCheckIn,
+		},
+		RoleOrganiser: {
+			PermissionView,
+			PermissionEditSelf,
+			PermissionCheckIn,
+			PermissionManageQueue,
+			PermissionEditCourts,
+			PermissionManageSessions,
+		},
+		RoleAdmin: {
+			PermissionView,
+			PermissionEditSelf,
+			PermissionCheckIn,
+			PermissionManageQueue,
+			PermissionEditCourts,
+			PermissionManageSessions,
+			PermissionEditPeople,
+			PermissionManageRoles,
+			PermissionApproveRegistrations,
+			PermissionViewMetrics,
+			PermissionManageLogging,
+			PermissionManageDumps,
+			PermissionManageClubs,
+		},
+	}
+)
+
+var (
+	functionListRoles     = debug.NewFunction(pkg, "ListRoles")
+	functionSetRolesTx    = debug.NewFunction(pkg, "SetRolesTx")
+	functionSetRoles      = debug.NewFunction(pkg, "SetRoles")
+	functionHasPermission = debug.NewFunction(pkg, "HasPermission")
+)
+
+// RolesAllow tells whether any of the roles grant the permission
+func RolesAllow(roles []string, permission Permission) bool {
+
+	if permission == PermissionNone {
+		return true
+	}
+
+	for _, role := range roles {
+		for _, p := range rolePermissions[role] {
+			if p == permission {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// HasPermission tells whether the person's roles grant the permission
+func HasPermission(ctx context.Context, db Queryer, personID int, permission Permission) (bool, error) {
+	f := functionHasPermission
+
+	roles, err := ListRoles(ctx, db, personID)
+	if err != nil {
+		message := fmt.Sprintf("Could not list the roles of person [%d]", personID)
+		f.Errorf(message)
+		f.DumpError(err, message)
+		return false, err
+	}
+
+	return RolesAllow(roles, permission), nil
+}
+
+// ListRoles returns the roles held by a person
+func ListRoles(ctx context.Context, db Queryer, personID int) ([]string, error) {
+	f := functionListRoles
+
+	sqlStatement := "SELECT role FROM " + PersonRoleTable + " WHERE person=$1 ORDER BY role"
+	rows, err := db.QueryContext(ctx, sqlStatement, personID)
+	if err != nil {
+		message := "Could not select from " + PersonRoleTable
+		f.DumpSQLError(err, message, sqlStatement)
+		return nil, err
+	}
+	defer rows.Close()
+
+	list := make([]string, 0)
+	for rows.Next() {
+
+		var role string
+		err := rows.Scan(&role)
+		if err != nil {
+			message := "Could not scan the role"
+			f.DumpError(err, message)
+			return nil, err
+		}
+
+		list = append(list, role)
+	}
+	err = rows.Err()
+	if err != nil {
+		message := "Could not list the roles"
+		f.DumpError(err, message)
+		return nil, err
+	}
+
+	return list, nil
+}
+
+// SetRolesTx replaces the roles held by a person, within a transaction
+func SetRolesTx(ctx context.Context, db *sql.DB, personID int, roles []string) error {
+	f := functionSetRolesTx
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		message := "Could not begin a new transaction"
+		f.DumpError(err, message)
+		return err
+	}
+
+	_, err = IncrementVersion(ctx, tx)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	person := FullPerson{ID: personID}
+	err = person.LoadPerson(ctx, tx)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	err = SetRoles(ctx, tx, personID, roles)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	// Someone must always be able to manage the roles
+	sqlStatement := "SELECT COUNT(*) FROM " + PersonRoleTable + " WHERE role=$1"
+	var count int
+	err = tx.QueryRowContext(ctx, sqlStatement, RoleAdmin).Scan(&count)
+	if err != nil {
+		tx.Rollback()
+		message := "Could not count the admins"
+		f.DumpSQLError(err, message, sqlStatement)
+		return err
+	}
+	if count == 0 {
+		tx.Rollback()
+		return codeerror.NewBadRequest("Cannot remove the last admin")
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		message := "Could not commit the transaction"
+		f.DumpError(err, message)
+		return err
+	}
+
+	return nil
+}
+
+// SetRoles replaces the roles held by a person
+func SetRoles(ctx context.Context, db Queryer, personID int, roles []string) error {
+	f := functionSetRoles
+
+	for _, role := range roles {
+		if _, ok := rolePermissions[role]; !ok {
+			return codeerror.NewBadRequest(fmt.Sprintf("Unknown role: %s", role))
+		}
+	}
+
+	sqlStatement := "DELETE FROM " + PersonRoleTable + " WHERE person=$1"
+	_, err := db.ExecContext(ctx, sqlStatement, personID)
+	if err != nil {
+		message := "Could not delete from " + PersonRoleTable
+		f.DumpSQLError(err, message, sqlStatement)
+		return err
+	}
+
+	sqlStatement = "INSERT INTO " + PersonRoleTable + " (person, role) VALUES ($1, $2) ON CONFLICT DO NOTHING"
+	for _, role := range roles {
+		_, err = db.ExecContext(ctx, sqlStatement, personID, role)
+		if err != nil {
+			message := "Could not insert into " + PersonRoleTable
+			f.DumpSQLError(err, message, sqlStatement)
+			return err
+		}
+	}
+
+	return nil
+}
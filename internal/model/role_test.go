@@ -0,0 +1,93 @@
+package model
+
+import (
+	"context"
+	"testing"
+
+	"github.com/rsmaxwell/players-api/internal/codeerror"
+
+	_ "github.com/jackc/pgx/stdlib"
+)
+
+func TestRolePermissions(t *testing.T) {
+
+	tests := []struct {
+		roles      []string
+		permission Permission
+		expected   bool
+	}{
+		{roles: []string{RoleMember}, permission: PermissionView, expected: true},
+		{roles: []string{RoleMember}, permission: PermissionManageQueue, expected: false},
+		{roles: []string{RoleMember}, permission: PermissionEditPeople, expected: false},
+		{roles: []string{RoleOrganiser}, permission: PermissionManageSessions, expected: true},
+		{roles: []string{RoleOrganiser}, permission: PermissionManageRoles, expected: false},
+		{roles: []string{RoleMember, RoleOrganiser}, permission: PermissionEditCourts, expected: true},
+		{roles: []string{RoleAdmin}, permission: PermissionViewMetrics, expected: true},
+		{roles: []string{RoleAdmin}, permission: PermissionApproveRegistrations, expected: true},
+		{roles: []string{RoleOrganiser}, permission: PermissionApproveRegistrations, expected: false},
+		{roles: []string{}, permission: PermissionView, expected: false},
+		{roles: []string{}, permission: PermissionNone, expected: true},
+		{roles: []string{"junk"}, permission: PermissionView, expected: false},
+	}
+
+	for _, test := range tests {
+		actual := RolesAllow(test.roles, test.permission)
+		if actual != test.expected {
+			t.Logf("Unexpected result for roles %v and permission [%s]. expected: %t, actual: %t", test.roles, test.permission, test.expected, actual)
+			t.FailNow()
+		}
+	}
+}
+
+func TestSetRoles(t *testing.T) {
+	teardown, db, _ := Setup(t)
+	defer teardown(t)
+
+	ctx := context.Background()
+
+	admin, err := FindPersonByEmail(ctx, db, GoodEmail)
+	if err != nil {
+		t.Log("Could not find the admin")
+		t.FailNow()
+	}
+
+	member, err := FindPersonByEmail(ctx, db, AnotherEmail)
+	if err != nil {
+		t.Log("Could not find the member")
+		t.FailNow()
+	}
+
+	allowed, err := HasPermission(ctx, db, member.ID, PermissionManageSessions)
+	if err != nil || allowed {
+		t.Log("A member should not be able to manage sessions")
+		t.FailNow()
+	}
+
+	err = SetRolesTx(ctx, db, member.ID, []string{RoleMember, RoleOrganiser})
+	if err != nil {
+		t.Log("Could not set the roles")
+		t.FailNow()
+	}
+
+	allowed, err = HasPermission(ctx, db, member.ID, PermissionManageSessions)
+	if err != nil || !allowed {
+		t.Log("An organiser should be able to manage sessions")
+		t.FailNow()
+	}
+
+	err = SetRolesTx(ctx, db, member.ID, []string{"junk"})
+	if err == nil {
+		t.Log("Unexpected success setting an unknown role")
+		t.FailNow()
+	}
+	if cerr, ok := err.(*codeerror.CodeError); !ok || cerr.Code() != 400 {
+		t.Logf("Unexpected error setting an unknown role: %v", err)
+		t.FailNow()
+	}
+
+	roles, err := ListRoles(ctx, db, admin.ID)
+	if err != nil || !RolesAllow(roles, PermissionManageRoles) {
+		t.Log("The admin should be able to manage roles")
+		t.FailNow()
+	}
+}
@@ -0,0 +1,426 @@
+package model
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/rsmaxwell/players-api/internal/codeerror"
+	"github.com/rsmaxwell/players-api/internal/debug"
+)
+
+// CheckIn type
+type CheckIn struct {
+	Person int       `json:"person"`
+	Time   time.Time `json:"time"`
+}
+
+// Session type. A session is a club night: people check in to the club's
+// open session to join its queue, and closing it clears the club's courts
+// and queue
+type Session struct {
+	ID       int       `json:"id"`
+	Club     int       `json:"club"`
+	Opened   time.Time `json:"opened"`
+	Closed   time.Time `json:"closed"` // zero while the session is open
+	CheckIns []CheckIn `json:"checkins"`
+}
+
+const (
+	// SessionTable is the name of the session table
+	SessionTable = "session"
+
+	// CheckInTable is the name of the table of the people checked in to each session
+	CheckInTable = "checkin"
+)
+
+var (
+	functionOpenSessionTx   = debug.NewFunction(pkg, "OpenSessionTx")
+	functionOpenSession     = debug.NewFunction(pkg, "OpenSession")
+	functionFindOpenSession = debug.NewFunction(pkg, "FindOpenSession")
+	functionLoadSession     = debug.NewFunction(pkg, "LoadSession")
+	functionCloseSessionTx  = debug.NewFunction(pkg, "CloseSessionTx")
+	functionCloseSession    = debug.NewFunction(pkg, "CloseSession")
+	functionAddCheckIn      = debug.NewFunction(pkg, "AddCheckIn")
+	functionListCheckIns    = debug.NewFunction(pkg, "ListCheckIns")
+	functionListSessions    = debug.NewFunction(pkg, "ListSessions")
+	functionCheckInTx       = debug.NewFunction(pkg, "CheckInTx")
+)
+
+// OpenSessionTx opens a new session at a club, within a transaction
+func OpenSessionTx(ctx context.Context, db *sql.DB, clubID int) (*Session, error) {
+	f := functionOpenSessionTx
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		message := "Could not begin a new transaction"
+		f.DumpError(err, message)
+		return nil, err
+	}
+
+	_, err = IncrementVersion(ctx, tx)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	session, err := OpenSession(ctx, tx, clubID)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		message := "Could not commit the transaction"
+		f.DumpError(err, message)
+		return nil, err
+	}
+
+	return session, nil
+}
+
+// OpenSession opens a new session at a club. Only one session may be open
+// at a time in each club
+func OpenSession(ctx context.Context, db Queryer, clubID int) (*Session, error) {
+	f := functionOpenSession
+
+	club := Club{ID: clubID}
+	err := club.LoadClub(ctx, db)
+	if err != nil {
+		return nil, err
+	}
+
+	open, err := FindOpenSession(ctx, db, clubID)
+	if err != nil {
+		return nil, err
+	}
+	if open != nil {
+		return nil, codeerror.NewBadRequest(fmt.Sprintf("Session [%d] is already open", open.ID))
+	}
+
+	session := Session{Club: clubID, Opened: time.Now(), CheckIns: make([]CheckIn, 0)}
+
+	sqlStatement := "INSERT INTO " + SessionTable + " (club, opened) VALUES ($1, $2) RETURNING id"
+	err = db.QueryRowContext(ctx, sqlStatement, session.Club, session.Opened).Scan(&session.ID)
+	if err != nil {
+		message := "Could not insert into " + SessionTable
+		f.DumpSQLError(err, message, sqlStatement)
+		return nil, err
+	}
+
+	return &session, nil
+}
+
+// FindOpenSession returns the open session at a club, or nil if there is none
+func FindOpenSession(ctx context.Context, db Queryer, clubID int) (*Session, error) {
+	f := functionFindOpenSession
+
+	session := Session{Club: clubID}
+
+	sqlStatement := "SELECT id, opened FROM " + SessionTable + " WHERE club=$1 AND closed IS NULL"
+	err := db.QueryRowContext(ctx, sqlStatement, clubID).Scan(&session.ID, &session.Opened)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	} else if err != nil {
+		message := "Could not select the open session"
+		f.DumpSQLError(err, message, sqlStatement)
+		return nil, err
+	}
+
+	session.CheckIns, err = ListCheckIns(ctx, db, session.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &session, nil
+}
+
+// LoadSession reads a session and the people checked in to it
+func (s *Session) LoadSession(ctx context.Context, db Queryer) error {
+	f := functionLoadSession
+
+	var closed sql.NullTime
+
+	sqlStatement := "SELECT club, opened, closed FROM " + SessionTable + " WHERE id=$1"
+	err := db.QueryRowContext(ctx, sqlStatement, s.ID).Scan(&s.Club, &s.Opened, &closed)
+	if err == sql.ErrNoRows {
+		return codeerror.NewNotFound(fmt.Sprintf("Session ID %d not found", s.ID))
+	} else if err != nil {
+		message := "Could not select the session"
+		f.DumpSQLError(err, message, sqlStatement)
+		return err
+	}
+
+	s.Closed = time.Time{}
+	if closed.Valid {
+		s.Closed = closed.Time
+	}
+
+	s.CheckIns, err = ListCheckIns(ctx, db, s.ID)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// ListSessions returns the sessions of a club, oldest first. A zero club
+// lists the sessions of every club
+func ListSessions(ctx context.Context, db Queryer, clubID int) ([]Session, error) {
+	f := functionListSessions
+
+	sqlStatement := "SELECT id, club, opened, closed FROM " + SessionTable + " WHERE $1=0 OR club=$1 ORDER BY opened"
+	rows, err := db.QueryContext(ctx, sqlStatement, clubID)
+	if err != nil {
+		message := "Could not select from " + SessionTable
+		f.DumpSQLError(err, message, sqlStatement)
+		return nil, err
+	}
+	defer rows.Close()
+
+	list := make([]Session, 0)
+	for rows.Next() {
+
+		var session Session
+		var closed sql.NullTime
+		err := rows.Scan(&session.ID, &session.Club, &session.Opened, &closed)
+		if err != nil {
+			message := "Could not scan the session"
+			f.DumpError(err, message)
+			return nil, err
+		}
+
+		if closed.Valid {
+			session.Closed = closed.Time
+		}
+
+		list = append(list, session)
+	}
+	err = rows.Err()
+	if err != nil {
+		message := "Could not list the sessions"
+		f.DumpError(err, message)
+		return nil, err
+	}
+	rows.Close()
+
+	for i := range list {
+		list[i].CheckIns, err = ListCheckIns(ctx, db, list[i].ID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return list, nil
+}
+
+// CloseSessionTx closes a club's session, within a transaction
+func CloseSessionTx(ctx context.Context, db *sql.DB, clubID int, sessionID int) error {
+	f := functionCloseSessionTx
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		message := "Could not begin a new transaction"
+		f.DumpError(err, message)
+		return err
+	}
+
+	version, err := IncrementVersion(ctx, tx)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	err = CloseSession(ctx, tx, clubID, sessionID)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	courtIDs, err := listAllCourtIDs(ctx, tx, clubID)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	event, err := NewEvent(ctx, tx, version, clubID, courtIDs...)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	err = Events.CommitAndPublish(tx, event)
+	if err != nil {
+		message := "Could not commit the transaction"
+		f.DumpError(err, message)
+		return err
+	}
+
+	return nil
+}
+
+// CloseSession clears every court of the club, recording the games, and then
+// empties the club's queue by making each waiter inactive. They check in
+// again at the next session
+func CloseSession(ctx context.Context, db Queryer, clubID int, sessionID int) error {
+	f := functionCloseSession
+
+	session := Session{ID: sessionID}
+	err := session.LoadSession(ctx, db)
+	if err != nil {
+		return err
+	}
+	if session.Club != clubID {
+		return codeerror.NewNotFound(fmt.Sprintf("Session ID %d not found", sessionID))
+	}
+	if !session.Closed.IsZero() {
+		return codeerror.NewBadRequest(fmt.Sprintf("Session [%d] is already closed", sessionID))
+	}
+
+	courtIDs, err := listAllCourtIDs(ctx, db, clubID)
+	if err != nil {
+		return err
+	}
+
+	for _, courtID := range courtIDs {
+		err = ClearCourt(ctx, db, courtID)
+		if err != nil {
+			message := fmt.Sprintf("Could not clear court [%d]", courtID)
+			f.Errorf(message)
+			f.DumpError(err, message)
+			return err
+		}
+	}
+
+	waiters, err := ListWaiters(ctx, db, clubID)
+	if err != nil {
+		message := "Could not list the waiters"
+		f.Errorf(message)
+		f.DumpError(err, message)
+		return err
+	}
+
+	for _, waiter := range waiters {
+		err = MakePersonInactive(ctx, db, waiter.Person)
+		if err != nil {
+			message := fmt.Sprintf("Could not make person [%d] inactive", waiter.Person)
+			f.Errorf(message)
+			f.DumpError(err, message)
+			return err
+		}
+	}
+
+	sqlStatement := "UPDATE " + SessionTable + " SET closed=$1 WHERE id=$2"
+	_, err = db.ExecContext(ctx, sqlStatement, time.Now(), sessionID)
+	if err != nil {
+		message := "Could not update " + SessionTable
+		f.DumpSQLError(err, message, sqlStatement)
+		return err
+	}
+
+	return nil
+}
+
+// CheckInTx checks a person in to a session and puts them in the club's
+// queue, within a transaction. The session must be the club's open one
+func CheckInTx(ctx context.Context, db *sql.DB, clubID int, sessionID int, personID int) error {
+	f := functionCheckInTx
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		message := "Could not begin a new transaction"
+		f.DumpError(err, message)
+		return err
+	}
+
+	version, err := IncrementVersion(ctx, tx)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	session, err := FindOpenSession(ctx, tx, clubID)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	if session == nil || session.ID != sessionID {
+		tx.Rollback()
+		return codeerror.NewBadRequest(fmt.Sprintf("Session [%d] is not open", sessionID))
+	}
+
+	err = MakePersonPlayer(ctx, tx, clubID, personID)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	event, err := NewEvent(ctx, tx, version, clubID)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	err = Events.CommitAndPublish(tx, event)
+	if err != nil {
+		message := "Could not commit the transaction"
+		f.DumpError(err, message)
+		return err
+	}
+
+	return nil
+}
+
+// AddCheckIn records that a person has arrived at a session. Checking in twice
+// keeps the first time
+func AddCheckIn(ctx context.Context, db Queryer, sessionID int, personID int) error {
+	f := functionAddCheckIn
+
+	sqlStatement := "INSERT INTO " + CheckInTable + " (session, person, time) VALUES ($1, $2, $3) ON CONFLICT DO NOTHING"
+	_, err := db.ExecContext(ctx, sqlStatement, sessionID, personID, time.Now())
+	if err != nil {
+		message := "Could not insert into " + CheckInTable
+		f.DumpSQLError(err, message, sqlStatement)
+		return err
+	}
+
+	return nil
+}
+
+// ListCheckIns returns the people checked in to a session, in the order they arrived
+func ListCheckIns(ctx context.Context, db Queryer, sessionID int) ([]CheckIn, error) {
+	f := functionListCheckIns
+
+	sqlStatement := "SELECT person, time FROM " + CheckInTable + " WHERE session=$1 ORDER BY time"
+	rows, err := db.QueryContext(ctx, sqlStatement, sessionID)
+	if err != nil {
+		message := "Could not select from " + CheckInTable
+		f.DumpSQLError(err, message, sqlStatement)
+		return nil, err
+	}
+	defer rows.Close()
+
+	list := make([]CheckIn, 0)
+	for rows.Next() {
+
+		var checkIn CheckIn
+		err := rows.Scan(&checkIn.Person, &checkIn.Time)
+		if err != nil {
+			message := "Could not scan the check-in"
+			f.DumpError(err, message)
+			return nil, err
+		}
+
+		list = append(list, checkIn)
+	}
+	err = rows.Err()
+	if err != nil {
+		message := "Could not list the check-ins"
+		f.DumpError(err, message)
+		return nil, err
+	}
+
+	return list, nil
+}
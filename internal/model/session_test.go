@@ -0,0 +1,100 @@
+package model
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/rsmaxwell/players-api/internal/codeerror"
+
+	_ "github.com/jackc/pgx/stdlib"
+)
+
+func TestCloseSession(t *testing.T) {
+	teardown, db, _ := Setup(t)
+	defer teardown(t)
+
+	ctx := context.Background()
+
+	// Populate opens a session and checks everyone in
+	session, err := FindOpenSession(ctx, db, DefaultClubID)
+	if err != nil || session == nil {
+		t.Log("Could not find the open session")
+		t.FailNow()
+	}
+	if len(session.CheckIns) == 0 {
+		t.Log("Expected people to be checked in")
+		t.FailNow()
+	}
+
+	_, err = OpenSessionTx(ctx, db, DefaultClubID)
+	if codeErr, ok := err.(*codeerror.CodeError); !ok || codeErr.Code() != http.StatusBadRequest {
+		t.Logf("Expected a 'bad request' error opening a second session, got: %v", err)
+		t.FailNow()
+	}
+
+	listOfCourts, err := ListCourtsTx(db, DefaultClubID)
+	if err != nil || len(listOfCourts) == 0 {
+		t.Log("Could not find any courts")
+		t.FailNow()
+	}
+	court := listOfCourts[0]
+
+	_, err = FillCourtTx(ctx, db, DefaultClubID, court.ID, "")
+	if err != nil {
+		t.Log("Could not fill the court")
+		t.FailNow()
+	}
+
+	err = CloseSessionTx(ctx, db, DefaultClubID, session.ID)
+	if err != nil {
+		t.Log("Could not close the session")
+		t.FailNow()
+	}
+
+	// The courts and the queue are empty, and the game belongs to the session
+	players, err := ListPlayers(ctx, db)
+	if err != nil || len(players) != 0 {
+		t.Logf("Unexpected players after closing the session: %v", players)
+		t.FailNow()
+	}
+
+	waiters, err := ListWaiters(ctx, db, DefaultClubID)
+	if err != nil || len(waiters) != 0 {
+		t.Logf("Unexpected waiters after closing the session: %v", waiters)
+		t.FailNow()
+	}
+
+	games, err := ListGames(ctx, db, GameFilter{Session: session.ID})
+	if err != nil || len(games) != 1 || games[0].Court != court.ID {
+		t.Logf("Unexpected games for the session: %v", games)
+		t.FailNow()
+	}
+
+	// Nobody can join the queue until the next session opens
+	personID := session.CheckIns[0].Person
+
+	err = MakePersonPlayerTx(ctx, db, DefaultClubID, personID)
+	if codeErr, ok := err.(*codeerror.CodeError); !ok || codeErr.Code() != http.StatusBadRequest {
+		t.Logf("Expected a 'bad request' error checking in without a session, got: %v", err)
+		t.FailNow()
+	}
+
+	next, err := OpenSessionTx(ctx, db, DefaultClubID)
+	if err != nil {
+		t.Log("Could not open the next session")
+		t.FailNow()
+	}
+
+	err = MakePersonPlayerTx(ctx, db, DefaultClubID, personID)
+	if err != nil {
+		t.Log("Could not check in to the next session")
+		t.FailNow()
+	}
+
+	err = next.LoadSession(ctx, db)
+	if err != nil || len(next.CheckIns) != 1 || next.CheckIns[0].Person != personID {
+		t.Logf("Unexpected check-ins for the next session: %v", next.CheckIns)
+		t.FailNow()
+	}
+}
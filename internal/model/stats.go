@@ -0,0 +1,278 @@
+package model
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/rsmaxwell/players-api/internal/codeerror"
+	"github.com/rsmaxwell/players-api/internal/debug"
+)
+
+// PersonCount type
+type PersonCount struct {
+	Person int `json:"person"`
+	Games  int `json:"games"`
+}
+
+// PersonStats type. Times are in seconds
+type PersonStats struct {
+	Person        int           `json:"person"`
+	GamesToday    int           `json:"gamestoday"`
+	GamesThisWeek int           `json:"gamesthisweek"`
+	GamesAllTime  int           `json:"gamesalltime"`
+	AverageWait   int           `json:"averagewait"`
+	MaxWait       int           `json:"maxwait"`
+	Partners      []PersonCount `json:"partners"`
+	Opponents     []PersonCount `json:"opponents"`
+}
+
+// LeaderboardEntry type. Times are in seconds
+type LeaderboardEntry struct {
+	Person      int    `json:"person"`
+	DisplayName string `json:"displayname"`
+	Games       int    `json:"games"`
+	PlayTime    int    `json:"playtime"`
+	AverageWait int    `json:"averagewait"`
+	MaxWait     int    `json:"maxwait"`
+	Waiting     int    `json:"waiting"` // how long they have been in the queue, if they are waiting now
+}
+
+const (
+	// PeriodToday covers the games since midnight
+	PeriodToday = "today"
+
+	// PeriodWeek covers the games since midnight on Monday
+	PeriodWeek = "week"
+
+	// PeriodAll covers every game
+	PeriodAll = "all"
+
+	// StatsTopCount is the number of partners and opponents listed
+	StatsTopCount = 5
+)
+
+var (
+	functionGetPersonStats = debug.NewFunction(pkg, "GetPersonStats")
+	functionLeaderboard    = debug.NewFunction(pkg, "Leaderboard")
+)
+
+// PeriodStart returns the time the named period began
+func PeriodStart(period string, now time.Time) (time.Time, error) {
+
+	midnight := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+
+	switch period {
+	case PeriodToday:
+		return midnight, nil
+	case PeriodWeek:
+		days := (int(midnight.Weekday()) + 6) % 7
+		return midnight.AddDate(0, 0, -days), nil
+	case PeriodAll:
+		return time.Time{}, nil
+	}
+
+	return time.Time{}, codeerror.NewBadRequest(fmt.Sprintf("Unknown period: %s", period))
+}
+
+// GetPersonStats returns the statistics for a person, from their games and
+// the times they waited to be called
+func GetPersonStats(ctx context.Context, db Queryer, personID int) (*PersonStats, error) {
+	f := functionGetPersonStats
+
+	person := FullPerson{ID: personID}
+	err := person.LoadPerson(ctx, db)
+	if err != nil {
+		return nil, err
+	}
+
+	games, err := ListGames(ctx, db, GameFilter{Person: personID})
+	if err != nil {
+		message := "Could not list the games"
+		f.Errorf(message)
+		f.DumpError(err, message)
+		return nil, err
+	}
+
+	// A person on a court now has been called, so their wait counts too
+	players, err := ListPlayersForPerson(ctx, db, personID)
+	if err != nil {
+		message := "Could not list the players for the person"
+		f.Errorf(message)
+		f.DumpError(err, message)
+		return nil, err
+	}
+
+	now := time.Now()
+	today, _ := PeriodStart(PeriodToday, now)
+	week, _ := PeriodStart(PeriodWeek, now)
+
+	stats := PersonStats{Person: personID}
+	partners := make(map[int]int)
+	opponents := make(map[int]int)
+
+	var waits []int
+	for _, game := range games {
+
+		stats.GamesAllTime++
+		if !game.End.Before(week) {
+			stats.GamesThisWeek++
+		}
+		if !game.End.Before(today) {
+			stats.GamesToday++
+		}
+
+		for _, player := range game.Players {
+			if player.Person == personID {
+				waits = append(waits, player.Wait)
+			}
+		}
+		for _, partner := range game.Partners(personID) {
+			partners[partner]++
+		}
+		for _, opponent := range game.Opponents(personID) {
+			opponents[opponent]++
+		}
+	}
+	for _, player := range players {
+		waits = append(waits, player.Wait)
+	}
+
+	stats.AverageWait, stats.MaxWait = summariseWaits(waits)
+	stats.Partners = topCounts(partners, StatsTopCount)
+	stats.Opponents = topCounts(opponents, StatsTopCount)
+
+	return &stats, nil
+}
+
+// Leaderboard returns an entry for each player in the club's queue or on its
+// courts, and anyone else who played a game there in the period, with the
+// most games first
+func Leaderboard(ctx context.Context, db Queryer, clubID int, period string) ([]LeaderboardEntry, error) {
+	f := functionLeaderboard
+
+	now := time.Now()
+	from, err := PeriodStart(period, now)
+	if err != nil {
+		return nil, err
+	}
+
+	people, err := ListPeople(ctx, db, PeopleFilter{})
+	if err != nil {
+		message := "Could not list the people"
+		f.Errorf(message)
+		f.DumpError(err, message)
+		return nil, err
+	}
+
+	games, err := ListGames(ctx, db, GameFilter{Club: clubID, From: from})
+	if err != nil {
+		message := "Could not list the games"
+		f.Errorf(message)
+		f.DumpError(err, message)
+		return nil, err
+	}
+
+	waiters, err := ListWaiters(ctx, db, clubID)
+	if err != nil {
+		message := "Could not list the waiters"
+		f.Errorf(message)
+		f.DumpError(err, message)
+		return nil, err
+	}
+
+	present, err := listClubPlayerIDs(ctx, db, clubID)
+	if err != nil {
+		message := "Could not list the players"
+		f.Errorf(message)
+		f.DumpError(err, message)
+		return nil, err
+	}
+
+	entries := make(map[int]*LeaderboardEntry)
+	waits := make(map[int][]int)
+	for _, game := range games {
+		for _, player := range game.Players {
+			entry, ok := entries[player.Person]
+			if !ok {
+				entry = &LeaderboardEntry{Person: player.Person}
+				entries[player.Person] = entry
+			}
+			entry.Games++
+			entry.PlayTime += int(game.End.Sub(game.Start).Seconds())
+			waits[player.Person] = append(waits[player.Person], player.Wait)
+		}
+	}
+
+	list := make([]LeaderboardEntry, 0)
+	for _, person := range people {
+		entry, ok := entries[person.ID]
+		if !ok {
+			if person.Status != StatusPlayer || !present[person.ID] {
+				continue
+			}
+			entry = &LeaderboardEntry{Person: person.ID}
+		}
+		entry.DisplayName = person.Knownas
+		entry.AverageWait, entry.MaxWait = summariseWaits(waits[person.ID])
+
+		for _, waiter := range waiters {
+			if waiter.Person == person.ID {
+				entry.Waiting = int(now.Sub(waiter.Start).Seconds())
+			}
+		}
+
+		list = append(list, *entry)
+	}
+
+	sort.SliceStable(list, func(i, j int) bool {
+		if list[i].Games != list[j].Games {
+			return list[i].Games > list[j].Games
+		}
+		return list[i].PlayTime > list[j].PlayTime
+	})
+
+	return list, nil
+}
+
+// summariseWaits returns the average and maximum of the waits
+func summariseWaits(waits []int) (int, int) {
+
+	if len(waits) == 0 {
+		return 0, 0
+	}
+
+	total := 0
+	max := 0
+	for _, wait := range waits {
+		total += wait
+		if wait > max {
+			max = wait
+		}
+	}
+
+	return total / len(waits), max
+}
+
+// topCounts returns the people with the highest counts, highest first
+func topCounts(counts map[int]int, n int) []PersonCount {
+
+	list := make([]PersonCount, 0, len(counts))
+	for person, games := range counts {
+		list = append(list, PersonCount{Person: person, Games: games})
+	}
+
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].Games != list[j].Games {
+			return list[i].Games > list[j].Games
+		}
+		return list[i].Person < list[j].Person
+	})
+
+	if len(list) > n {
+		list = list[:n]
+	}
+
+	return list
+}
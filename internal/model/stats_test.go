@@ -0,0 +1,129 @@
+package model
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	_ "github.com/jackc/pgx/stdlib"
+)
+
+func TestPeriodStart(t *testing.T) {
+
+	// A Wednesday afternoon
+	now := time.Date(2020, time.June, 10, 15, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		period   string
+		expected time.Time
+	}{
+		{period: PeriodToday, expected: time.Date(2020, time.June, 10, 0, 0, 0, 0, time.UTC)},
+		{period: PeriodWeek, expected: time.Date(2020, time.June, 8, 0, 0, 0, 0, time.UTC)},
+		{period: PeriodAll, expected: time.Time{}},
+	}
+
+	for _, test := range tests {
+		start, err := PeriodStart(test.period, now)
+		if err != nil {
+			t.Logf("Unexpected error for period %s: %s", test.period, err)
+			t.FailNow()
+		}
+		if !start.Equal(test.expected) {
+			t.Logf("Unexpected start for period %s. expected: %s, actual: %s", test.period, test.expected, start)
+			t.FailNow()
+		}
+	}
+
+	_, err := PeriodStart("fortnight", now)
+	if err == nil {
+		t.Log("Expected an error for an unknown period")
+		t.FailNow()
+	}
+}
+
+func TestTopCounts(t *testing.T) {
+
+	counts := map[int]int{1: 2, 2: 5, 3: 2, 4: 1}
+
+	list := topCounts(counts, 3)
+	expected := []PersonCount{{Person: 2, Games: 5}, {Person: 1, Games: 2}, {Person: 3, Games: 2}}
+
+	if len(list) != len(expected) {
+		t.Logf("Unexpected number of counts. expected: %d, actual: %d", len(expected), len(list))
+		t.FailNow()
+	}
+	for i := range expected {
+		if list[i] != expected[i] {
+			t.Logf("Unexpected count at [%d]. expected: %v, actual: %v", i, expected[i], list[i])
+			t.FailNow()
+		}
+	}
+}
+
+func TestPersonStats(t *testing.T) {
+	teardown, db, _ := Setup(t)
+	defer teardown(t)
+
+	ctx := context.Background()
+
+	listOfCourts, err := ListCourtsTx(db, DefaultClubID)
+	if err != nil || len(listOfCourts) == 0 {
+		t.Log("Could not find any courts")
+		t.FailNow()
+	}
+	court := listOfCourts[0]
+
+	fill, err := FillCourtTx(ctx, db, DefaultClubID, court.ID, "")
+	if err != nil {
+		t.Log("Could not fill the court")
+		t.FailNow()
+	}
+
+	err = ClearCourtTx(ctx, db, DefaultClubID, court.ID)
+	if err != nil {
+		t.Log("Could not clear the court")
+		t.FailNow()
+	}
+
+	personID := fill.Positions[0].PersonID
+
+	stats, err := GetPersonStats(ctx, db, personID)
+	if err != nil {
+		t.Log("Could not get the stats")
+		t.FailNow()
+	}
+
+	if stats.GamesToday != 1 || stats.GamesThisWeek != 1 || stats.GamesAllTime != 1 {
+		t.Logf("Unexpected number of games: %+v", stats)
+		t.FailNow()
+	}
+	if len(stats.Partners) != court.Capacity/2-1 {
+		t.Logf("Unexpected number of partners. expected: %d, actual: %d", court.Capacity/2-1, len(stats.Partners))
+		t.FailNow()
+	}
+	if len(stats.Opponents) != court.Capacity/2 {
+		t.Logf("Unexpected number of opponents. expected: %d, actual: %d", court.Capacity/2, len(stats.Opponents))
+		t.FailNow()
+	}
+
+	leaderboard, err := Leaderboard(ctx, db, DefaultClubID, PeriodToday)
+	if err != nil {
+		t.Log("Could not get the leaderboard")
+		t.FailNow()
+	}
+
+	found := false
+	for _, entry := range leaderboard {
+		if entry.Person == personID {
+			found = true
+			if entry.Games != 1 {
+				t.Logf("Unexpected number of games on the leaderboard. expected: 1, actual: %d", entry.Games)
+				t.FailNow()
+			}
+		}
+	}
+	if !found {
+		t.Log("The player is missing from the leaderboard")
+		t.FailNow()
+	}
+}
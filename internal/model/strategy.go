@@ -0,0 +1,307 @@
+package model
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/rsmaxwell/players-api/internal/codeerror"
+)
+
+// FillState holds what a strategy needs to know to fill a court
+type FillState struct {
+	Court   *Court
+	Players map[int]*FullPerson // the people already on the court, by position
+	Waiters []FullPerson        // the people waiting, in the order they joined the queue
+	Empty   []int               // the empty positions, in order
+}
+
+// FillStrategy chooses the waiters to put on a court
+type FillStrategy interface {
+
+	// Choose returns the ID of the waiter to put into each of the empty
+	// positions, in the same order as the empty positions. There are always
+	// at least as many waiters as empty positions
+	Choose(ctx context.Context, db Queryer, state *FillState) ([]int, error)
+}
+
+const (
+	// StrategyFIFO takes the waiters in the order they joined the queue
+	StrategyFIFO = "fifo"
+
+	// StrategyBalanced takes the waiters in order, and splits them between
+	// the teams so the total skill of each team is as close as possible
+	StrategyBalanced = "balanced"
+
+	// StrategyMixed works down the queue for waiters which give each team
+	// one man and one woman
+	StrategyMixed = "mixed"
+
+	// StrategyNoRepeat works down the queue for waiters who have not
+	// partnered anyone on their team in their last few games
+	StrategyNoRepeat = "norepeat"
+
+	// NoRepeatGames is the number of recent games checked for partners
+	NoRepeatGames = 3
+
+	// DefaultFillStrategy is used when neither the court nor the request
+	// name a strategy
+	DefaultFillStrategy = StrategyFIFO
+)
+
+var (
+	fillStrategies = make(map[string]FillStrategy)
+)
+
+func init() {
+	RegisterFillStrategy(StrategyFIFO, fifoStrategy{})
+	RegisterFillStrategy(StrategyBalanced, balancedStrategy{})
+	RegisterFillStrategy(StrategyMixed, mixedStrategy{})
+	RegisterFillStrategy(StrategyNoRepeat, noRepeatStrategy{games: NoRepeatGames})
+}
+
+// RegisterFillStrategy makes a strategy available under the given name
+func RegisterFillStrategy(name string, strategy FillStrategy) {
+	fillStrategies[name] = strategy
+}
+
+// FindFillStrategy returns the strategy with the given name
+func FindFillStrategy(name string) (FillStrategy, error) {
+	strategy, ok := fillStrategies[name]
+	if !ok {
+		return nil, codeerror.NewBadRequest(fmt.Sprintf("Unknown fill strategy: %s", name))
+	}
+	return strategy, nil
+}
+
+// FillStrategyNames returns the names of the available strategies
+func FillStrategyNames() []string {
+	names := make([]string, 0, len(fillStrategies))
+	for name := range fillStrategies {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// teamOf returns the team a position on the court belongs to. The first
+// half of the positions make up one team, and the second half the other
+func teamOf(court *Court, position int) int {
+	return position * 2 / court.Capacity
+}
+
+// fifoStrategy type
+type fifoStrategy struct{}
+
+// Choose takes the waiters at the front of the queue
+func (s fifoStrategy) Choose(ctx context.Context, db Queryer, state *FillState) ([]int, error) {
+
+	chosen := make([]int, len(state.Empty))
+	for i := range state.Empty {
+		chosen[i] = state.Waiters[i].ID
+	}
+
+	return chosen, nil
+}
+
+// balancedStrategy type
+type balancedStrategy struct{}
+
+// Choose takes the waiters at the front of the queue, and places the
+// strongest first, each into the team with the lowest total skill so far
+func (s balancedStrategy) Choose(ctx context.Context, db Queryer, state *FillState) ([]int, error) {
+
+	waiters := make([]FullPerson, len(state.Empty))
+	copy(waiters, state.Waiters[:len(state.Empty)])
+	sort.SliceStable(waiters, func(i, j int) bool {
+		return waiters[i].Skill > waiters[j].Skill
+	})
+
+	totals := make(map[int]int)
+	for position, person := range state.Players {
+		totals[teamOf(state.Court, position)] += person.Skill
+	}
+
+	free := make(map[int][]int)
+	for _, position := range state.Empty {
+		team := teamOf(state.Court, position)
+		free[team] = append(free[team], position)
+	}
+
+	assigned := make(map[int]int)
+	for _, waiter := range waiters {
+
+		best := -1
+		for team, positions := range free {
+			if len(positions) == 0 {
+				continue
+			}
+			if best < 0 || totals[team] < totals[best] || (totals[team] == totals[best] && team < best) {
+				best = team
+			}
+		}
+
+		position := free[best][0]
+		free[best] = free[best][1:]
+
+		assigned[position] = waiter.ID
+		totals[best] += waiter.Skill
+	}
+
+	chosen := make([]int, len(state.Empty))
+	for i, position := range state.Empty {
+		chosen[i] = assigned[position]
+	}
+
+	return chosen, nil
+}
+
+// mixedStrategy type
+type mixedStrategy struct{}
+
+// Choose fills each position with the first waiter of the gender the team
+// is short of. When the team is even, or nobody suitable is waiting, the
+// first waiter is taken
+func (s mixedStrategy) Choose(ctx context.Context, db Queryer, state *FillState) ([]int, error) {
+
+	genders := make(map[int][]string)
+	for position, person := range state.Players {
+		team := teamOf(state.Court, position)
+		genders[team] = append(genders[team], person.Gender)
+	}
+
+	used := make([]bool, len(state.Waiters))
+	chosen := make([]int, len(state.Empty))
+	for i, position := range state.Empty {
+		team := teamOf(state.Court, position)
+		wanted := wantedGender(genders[team])
+
+		pick := -1
+		for j, waiter := range state.Waiters {
+			if !used[j] && (wanted == "" || waiter.Gender == wanted) {
+				pick = j
+				break
+			}
+		}
+		if pick < 0 {
+			for j := range state.Waiters {
+				if !used[j] {
+					pick = j
+					break
+				}
+			}
+		}
+
+		used[pick] = true
+		chosen[i] = state.Waiters[pick].ID
+		genders[team] = append(genders[team], state.Waiters[pick].Gender)
+	}
+
+	return chosen, nil
+}
+
+// wantedGender returns the gender a team is short of, if any
+func wantedGender(genders []string) string {
+
+	men := 0
+	women := 0
+	for _, gender := range genders {
+		switch gender {
+		case GenderMale:
+			men++
+		case GenderFemale:
+			women++
+		}
+	}
+
+	if men > women {
+		return GenderFemale
+	} else if women > men {
+		return GenderMale
+	}
+	return ""
+}
+
+// noRepeatStrategy type
+type noRepeatStrategy struct {
+	games int
+}
+
+// Choose fills each position with the first waiter who has not partnered
+// anyone already on the team in their recent games. When everyone has, the
+// first waiter is taken
+func (s noRepeatStrategy) Choose(ctx context.Context, db Queryer, state *FillState) ([]int, error) {
+
+	teams := make(map[int][]int)
+	for position, person := range state.Players {
+		team := teamOf(state.Court, position)
+		teams[team] = append(teams[team], person.ID)
+	}
+
+	partners := make(map[int]map[int]bool)
+	recentPartners := func(personID int) (map[int]bool, error) {
+		if found, ok := partners[personID]; ok {
+			return found, nil
+		}
+
+		games, err := ListGames(ctx, db, GameFilter{Person: personID, Limit: s.games})
+		if err != nil {
+			return nil, err
+		}
+
+		found := make(map[int]bool)
+		for _, game := range games {
+			for _, partner := range game.Partners(personID) {
+				found[partner] = true
+			}
+		}
+
+		partners[personID] = found
+		return found, nil
+	}
+
+	used := make([]bool, len(state.Waiters))
+	chosen := make([]int, len(state.Empty))
+	for i, position := range state.Empty {
+		team := teamOf(state.Court, position)
+
+		pick := -1
+		for j, waiter := range state.Waiters {
+			if used[j] {
+				continue
+			}
+
+			found, err := recentPartners(waiter.ID)
+			if err != nil {
+				return nil, err
+			}
+
+			repeat := false
+			for _, teammate := range teams[team] {
+				if found[teammate] {
+					repeat = true
+					break
+				}
+			}
+
+			if !repeat {
+				pick = j
+				break
+			}
+		}
+		if pick < 0 {
+			for j := range state.Waiters {
+				if !used[j] {
+					pick = j
+					break
+				}
+			}
+		}
+
+		used[pick] = true
+		chosen[i] = state.Waiters[pick].ID
+		teams[team] = append(teams[team], state.Waiters[pick].ID)
+	}
+
+	return chosen, nil
+}
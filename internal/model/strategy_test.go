@@ -0,0 +1,107 @@
+package model
+
+import (
+	"context"
+	"testing"
+)
+
+func newFillState(capacity int, players map[int]*FullPerson, waiters []FullPerson) *FillState {
+
+	state := FillState{Court: &Court{Capacity: capacity}, Players: players, Waiters: waiters}
+	for index := 0; index < capacity; index++ {
+		if _, ok := players[index]; !ok {
+			state.Empty = append(state.Empty, index)
+		}
+	}
+	return &state
+}
+
+func chooseWith(t *testing.T, name string, state *FillState) []int {
+
+	strategy, err := FindFillStrategy(name)
+	if err != nil {
+		t.Logf("Could not find the strategy: %s", name)
+		t.FailNow()
+	}
+
+	chosen, err := strategy.Choose(context.Background(), nil, state)
+	if err != nil {
+		t.Logf("Could not choose the waiters: %s", err)
+		t.FailNow()
+	}
+
+	if len(chosen) != len(state.Empty) {
+		t.Logf("Unexpected number of waiters chosen. expected: %d, actual: %d", len(state.Empty), len(chosen))
+		t.FailNow()
+	}
+
+	return chosen
+}
+
+func TestFillStrategyFIFO(t *testing.T) {
+
+	waiters := []FullPerson{{ID: 1}, {ID: 2}, {ID: 3}, {ID: 4}, {ID: 5}}
+	players := map[int]*FullPerson{1: {ID: 9}}
+
+	chosen := chooseWith(t, StrategyFIFO, newFillState(4, players, waiters))
+
+	for i, expected := range []int{1, 2, 3} {
+		if chosen[i] != expected {
+			t.Logf("Unexpected waiter at [%d]. expected: %d, actual: %d", i, expected, chosen[i])
+			t.FailNow()
+		}
+	}
+}
+
+func TestFillStrategyBalanced(t *testing.T) {
+
+	waiters := []FullPerson{{ID: 1, Skill: 9}, {ID: 2, Skill: 8}, {ID: 3, Skill: 2}, {ID: 4, Skill: 1}, {ID: 5, Skill: 5}}
+
+	chosen := chooseWith(t, StrategyBalanced, newFillState(4, map[int]*FullPerson{}, waiters))
+
+	skills := map[int]int{1: 9, 2: 8, 3: 2, 4: 1}
+	team0 := skills[chosen[0]] + skills[chosen[1]]
+	team1 := skills[chosen[2]] + skills[chosen[3]]
+
+	if !EqualIntArray(chosen, []int{1, 2, 3, 4}) {
+		t.Logf("Expected the waiters at the front of the queue: %v", chosen)
+		t.FailNow()
+	}
+
+	if team0 != 10 || team1 != 10 {
+		t.Logf("Unbalanced teams: %d vs %d: %v", team0, team1, chosen)
+		t.FailNow()
+	}
+}
+
+func TestFillStrategyMixed(t *testing.T) {
+
+	waiters := []FullPerson{
+		{ID: 1, Gender: GenderMale},
+		{ID: 2, Gender: GenderMale},
+		{ID: 3, Gender: GenderMale},
+		{ID: 4, Gender: GenderFemale},
+		{ID: 5, Gender: GenderFemale},
+	}
+
+	chosen := chooseWith(t, StrategyMixed, newFillState(4, map[int]*FullPerson{}, waiters))
+
+	genders := map[int]string{1: GenderMale, 2: GenderMale, 3: GenderMale, 4: GenderFemale, 5: GenderFemale}
+	for team := 0; team < 2; team++ {
+		a := genders[chosen[2*team]]
+		b := genders[chosen[2*team+1]]
+		if a == b {
+			t.Logf("Team %d is not mixed: %v", team, chosen)
+			t.FailNow()
+		}
+	}
+}
+
+func TestFillStrategyUnknown(t *testing.T) {
+
+	_, err := FindFillStrategy("junk")
+	if err == nil {
+		t.Log("Expected an unknown strategy to be refused")
+		t.FailNow()
+	}
+}
@@ -15,9 +15,8 @@ var (
 )
 
 // UpdateCourt method
-func UpdateCourtFieldsTx(db *sql.DB, courtID int, fields map[string]interface{}) error {
+func UpdateCourtFieldsTx(ctx context.Context, db *sql.DB, clubID int, courtID int, fields map[string]interface{}) error {
 	f := functionUpdateCourtFieldsTx
-	ctx := context.Background()
 
 	// Begin a transaction
 	tx, err := db.BeginTx(ctx, nil)
@@ -27,7 +26,20 @@ func UpdateCourtFieldsTx(db *sql.DB, courtID int, fields map[string]interface{})
 		return err
 	}
 
-	err = UpdateCourtFields(ctx, db, courtID, fields)
+	_, err = IncrementVersion(ctx, tx)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	court := Court{ID: courtID}
+	err = court.LoadClubCourt(ctx, tx, clubID)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	err = UpdateCourtFields(ctx, tx, courtID, fields)
 	if err != nil {
 		tx.Rollback()
 		return err
@@ -37,12 +49,13 @@ func UpdateCourtFieldsTx(db *sql.DB, courtID int, fields map[string]interface{})
 	if err != nil {
 		message := "Could not commit the transaction"
 		f.DumpError(err, message)
+		return err
 	}
 
 	return nil
 }
 
-func UpdateCourtFields(ctx context.Context, db *sql.DB, courtID int, fields map[string]interface{}) error {
+func UpdateCourtFields(ctx context.Context, db Queryer, courtID int, fields map[string]interface{}) error {
 	f := functionUpdateCourtFields
 
 	c := Court{ID: courtID}
@@ -65,6 +78,51 @@ func UpdateCourtFields(ctx context.Context, db *sql.DB, courtID int, fields map[
 		}
 	}
 
+	if val, ok := fields["capacity"]; ok {
+		number, ok := val.(float64)
+		if !ok || number != float64(int(number)) {
+			message := fmt.Sprintf("unexpected type for [%s]: %v", "capacity", val)
+			f.DebugVerbose(message)
+			return codeerror.NewBadRequest(message)
+		}
+		c.Capacity = int(number)
+
+		err = c.checkCapacity()
+		if err != nil {
+			return err
+		}
+
+		players, err := ListPlayersForCourt(ctx, db, courtID)
+		if err != nil {
+			message := fmt.Sprintf("could not list the players on court: %d", courtID)
+			f.DebugVerbose(message)
+			f.DumpError(err, message)
+			return codeerror.NewInternalServerError(message)
+		}
+
+		for _, player := range players {
+			if player.Position >= c.Capacity {
+				message := fmt.Sprintf("position %d on court %d is in use", player.Position, courtID)
+				f.DebugVerbose(message)
+				return codeerror.NewBadRequest(message)
+			}
+		}
+	}
+
+	if val, ok := fields["strategy"]; ok {
+		c.Strategy, ok = val.(string)
+		if !ok {
+			message := fmt.Sprintf("unexpected type for [%s]: %v", "strategy", val)
+			f.DebugVerbose(message)
+			return codeerror.NewBadRequest(message)
+		}
+
+		_, err = FindFillStrategy(c.Strategy)
+		if err != nil {
+			return err
+		}
+	}
+
 	err = c.UpdateCourt(ctx, db)
 	if err != nil {
 		message := fmt.Sprintf("problem updating court: %d", courtID)
@@ -5,20 +5,23 @@ import (
 	"database/sql"
 	"fmt"
 
+	"github.com/rsmaxwell/players-api/internal/basic"
 	"github.com/rsmaxwell/players-api/internal/codeerror"
 	"github.com/rsmaxwell/players-api/internal/debug"
-	"golang.org/x/crypto/bcrypt"
 )
 
+// RestrictedPersonFields are the fields which only people with permission to
+// edit other people may change, even their own
+var RestrictedPersonFields = []string{"status", "skill"}
+
 var (
 	functionUpdatePersonFieldsTx = debug.NewFunction(pkg, "UpdatePersonFieldsTx")
 	functionUpdatePersonFields   = debug.NewFunction(pkg, "UpdatePersonFields")
 )
 
 // UpdatePerson method
-func UpdatePersonFieldsTx(db *sql.DB, personID int, fields map[string]interface{}) error {
+func UpdatePersonFieldsTx(ctx context.Context, db *sql.DB, personID int, fields map[string]interface{}) error {
 	f := functionUpdatePersonFieldsTx
-	ctx := context.Background()
 
 	// Begin a transaction
 	tx, err := db.BeginTx(ctx, nil)
@@ -28,10 +31,17 @@ func UpdatePersonFieldsTx(db *sql.DB, personID int, fields map[string]interface{
 		return err
 	}
 
+	_, err = IncrementVersion(ctx, tx)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
 	var person FullPerson
 	person.ID = personID
-	err = person.LoadPerson(ctx, db)
+	err = person.LoadPerson(ctx, tx)
 	if err != nil {
+		tx.Rollback()
 		message := fmt.Sprintf("could not load person: %d", personID)
 		f.DebugVerbose(message)
 		d := f.DumpError(err, message)
@@ -39,13 +49,40 @@ func UpdatePersonFieldsTx(db *sql.DB, personID int, fields map[string]interface{
 		return codeerror.NewInternalServerError(message)
 	}
 
-	err = person.UpdatePersonFields(ctx, db, fields)
+	previousStatus := person.Status
+
+	err = person.UpdatePersonFields(ctx, tx, fields)
 	if err != nil {
 		tx.Rollback()
 		return err
 	}
 
-	_, err = person.CheckConistencyPerson(ctx, db, true)
+	// A registration which is pending or rejected keeps the person
+	// suspended: only approving it lets them in
+	if previousStatus == StatusSuspended && person.Status != StatusSuspended {
+		approval, err := loadApproval(ctx, tx, personID)
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+		if approval != nil && approval.Decision != DecisionApproved {
+			tx.Rollback()
+			message := fmt.Sprintf("the registration of person [%d] is pending or rejected", personID)
+			f.DebugVerbose(message)
+			return codeerror.NewBadRequest(message)
+		}
+	}
+
+	// A suspended person is signed out everywhere
+	if person.Status == StatusSuspended && previousStatus != StatusSuspended {
+		err = RevokeRefreshTokens(ctx, tx, personID)
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+
+	_, err = person.CheckConistencyPerson(ctx, tx, true)
 	if err != nil {
 		tx.Rollback()
 		return err
@@ -55,12 +92,13 @@ func UpdatePersonFieldsTx(db *sql.DB, personID int, fields map[string]interface{
 	if err != nil {
 		message := "Could not commit the transaction"
 		f.DumpError(err, message)
+		return err
 	}
 
 	return nil
 }
 
-func (person *FullPerson) UpdatePersonFields(ctx context.Context, db *sql.DB, fields map[string]interface{}) error {
+func (person *FullPerson) UpdatePersonFields(ctx context.Context, db Queryer, fields map[string]interface{}) error {
 	f := functionUpdatePersonFields
 
 	if val, ok := fields["firstname"]; ok {
@@ -117,7 +155,7 @@ func (person *FullPerson) UpdatePersonFields(ctx context.Context, db *sql.DB, fi
 		}
 
 		var err error
-		person.Hash, err = bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+		person.Hash, err = basic.HashPassword(password)
 		if err != nil {
 			message := "problem hashing password"
 			f.DebugVerbose(message)
@@ -128,10 +166,29 @@ func (person *FullPerson) UpdatePersonFields(ctx context.Context, db *sql.DB, fi
 
 	if val, ok := fields["status"]; ok {
 		person.Status, ok = val.(string)
-		if !ok {
-			message := fmt.Sprintf("unexpected type for [%s]: %v", "status", val)
+		if !ok || !validStatus(person.Status) {
+			message := fmt.Sprintf("unexpected value for [%s]: %v", "status", val)
 			f.DebugVerbose(message)
-			return codeerror.NewInternalServerError(message)
+			return codeerror.NewBadRequest(message)
+		}
+	}
+
+	if val, ok := fields["skill"]; ok {
+		number, ok := val.(float64)
+		if !ok || number != float64(int(number)) || number < 0 {
+			message := fmt.Sprintf("unexpected value for [%s]: %v", "skill", val)
+			f.DebugVerbose(message)
+			return codeerror.NewBadRequest(message)
+		}
+		person.Skill = int(number)
+	}
+
+	if val, ok := fields["gender"]; ok {
+		person.Gender, ok = val.(string)
+		if !ok || !validGender(person.Gender) {
+			message := fmt.Sprintf("unexpected value for [%s]: %v", "gender", val)
+			f.DebugVerbose(message)
+			return codeerror.NewBadRequest(message)
 		}
 	}
 
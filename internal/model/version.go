@@ -0,0 +1,103 @@
+package model
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/rsmaxwell/players-api/internal/codeerror"
+	"github.com/rsmaxwell/players-api/internal/debug"
+)
+
+// ContextKey type
+type ContextKey string
+
+const (
+	// StateTable is the name of the table holding the state version
+	StateTable = "state"
+
+	// stateID is the key of the single row in the state table
+	stateID = 1
+
+	// ContextExpectedVersionKey holds the version the client believes is current
+	ContextExpectedVersionKey ContextKey = "expectedVersion"
+)
+
+var (
+	functionGetVersion       = debug.NewFunction(pkg, "GetVersion")
+	functionIncrementVersion = debug.NewFunction(pkg, "IncrementVersion")
+)
+
+// WithExpectedVersion returns a copy of the context carrying the version the
+// client believes is current. Updates made with the returned context fail
+// if the state has moved on since
+func WithExpectedVersion(ctx context.Context, version int64) context.Context {
+	return context.WithValue(ctx, ContextExpectedVersionKey, version)
+}
+
+// GetVersion returns the current state version
+func GetVersion(ctx context.Context, db Queryer) (int64, error) {
+	f := functionGetVersion
+
+	sqlStatement := "SELECT version FROM " + StateTable + " WHERE id=$1"
+
+	var version int64
+	err := db.QueryRowContext(ctx, sqlStatement, stateID).Scan(&version)
+	if err == sql.ErrNoRows {
+		return 0, nil
+	} else if err != nil {
+		message := "Could not get the version"
+		f.Errorf(message)
+		f.DumpSQLError(err, message, sqlStatement)
+		return 0, err
+	}
+
+	return version, nil
+}
+
+// IncrementVersion bumps the state version, and must be called by every
+// transaction which changes the state. The state row stays locked until the
+// transaction ends, so concurrent updates are applied one at a time. If the
+// context carries an expected version which is not current, the state is
+// left alone and a 'precondition failed' error is returned
+func IncrementVersion(ctx context.Context, db Queryer) (int64, error) {
+	f := functionIncrementVersion
+
+	sqlStatement := "INSERT INTO " + StateTable + " (id, version) VALUES ($1, 0) ON CONFLICT (id) DO NOTHING"
+	_, err := db.ExecContext(ctx, sqlStatement, stateID)
+	if err != nil {
+		message := "Could not initialise the version"
+		f.Errorf(message)
+		f.DumpSQLError(err, message, sqlStatement)
+		return 0, err
+	}
+
+	sqlStatement = "SELECT version FROM " + StateTable + " WHERE id=$1 FOR UPDATE"
+
+	var version int64
+	err = db.QueryRowContext(ctx, sqlStatement, stateID).Scan(&version)
+	if err != nil {
+		message := "Could not lock the version"
+		f.Errorf(message)
+		f.DumpSQLError(err, message, sqlStatement)
+		return 0, err
+	}
+
+	if expected, ok := ctx.Value(ContextExpectedVersionKey).(int64); ok {
+		if expected != version {
+			f.DebugVerbose("stale update: expected version: %d, actual: %d", expected, version)
+			return 0, codeerror.NewPreconditionFailed(fmt.Sprintf("State has changed: version: %d", version))
+		}
+	}
+
+	sqlStatement = "UPDATE " + StateTable + " SET version=version+1 WHERE id=$1 RETURNING version"
+	err = db.QueryRowContext(ctx, sqlStatement, stateID).Scan(&version)
+	if err != nil {
+		message := "Could not increment the version"
+		f.Errorf(message)
+		f.DumpSQLError(err, message, sqlStatement)
+		return 0, err
+	}
+
+	return version, nil
+}
@@ -6,6 +6,7 @@ import (
 	"encoding/json"
 	"time"
 
+	"github.com/rsmaxwell/players-api/internal/codeerror"
 	"github.com/rsmaxwell/players-api/internal/debug"
 )
 
@@ -31,16 +32,17 @@ var (
 	functionListWaiters          = debug.NewFunction(pkg, "ListWaiters")
 	functionListWaitersForPerson = debug.NewFunction(pkg, "ListWaitersForPerson")
 	functionGetFirstWaiter       = debug.NewFunction(pkg, "GetFirstWaiter")
+	functionAddWaiter            = debug.NewFunction(pkg, "AddWaiter")
 	functionRemoveWaiter         = debug.NewFunction(pkg, "RemoveWaiter")
 )
 
-// ListWaiters returns the list of waiters
-func ListWaiters(ctx context.Context, db *sql.DB) ([]Waiter, error) {
+// ListWaiters returns the list of waiters in a club's queue
+func ListWaiters(ctx context.Context, db Queryer, clubID int) ([]Waiter, error) {
 	f := functionListWaiters
 
-	sqlStatement := "SELECT * FROM " + WaitingTable + " ORDER BY start ASC"
+	sqlStatement := "SELECT person, start FROM " + WaitingTable + " WHERE club=$1 ORDER BY start ASC"
 
-	rows, err := db.QueryContext(ctx, sqlStatement)
+	rows, err := db.QueryContext(ctx, sqlStatement, clubID)
 	if err != nil {
 		message := "Could not get list the waiters"
 		f.DumpSQLError(err, message, sqlStatement)
@@ -79,13 +81,13 @@ func ListWaiters(ctx context.Context, db *sql.DB) ([]Waiter, error) {
 }
 
 // ListWaitersForPerson returns the list of waiters for a person
-func ListWaitersForPerson(ctx context.Context, db *sql.DB, id int) ([]Waiter, error) {
+func ListWaitersForPerson(ctx context.Context, db Queryer, id int) ([]Waiter, error) {
 	f := functionListWaitersForPerson
 
 	fields := "person, start"
 	sqlStatement := "SELECT " + fields + " FROM " + WaitingTable + " WHERE person=$1"
 
-	rows, err := db.Query(sqlStatement, id)
+	rows, err := db.QueryContext(ctx, sqlStatement, id)
 	if err != nil {
 		message := "Could not get list the waiters"
 		f.DumpSQLError(err, message, sqlStatement)
@@ -123,13 +125,13 @@ func ListWaitersForPerson(ctx context.Context, db *sql.DB, id int) ([]Waiter, er
 	return list, nil
 }
 
-// Get first GetFirstWaiter
-func GetFirstWaiter(ctx context.Context, db *sql.DB) (int, error) {
+// GetFirstWaiter returns the person at the front of a club's queue
+func GetFirstWaiter(ctx context.Context, db Queryer, clubID int) (int, error) {
 	f := functionGetFirstWaiter
 
 	fields := "person"
-	sqlStatement := "SELECT " + fields + " FROM " + WaitingTable + " ORDER BY start LIMIT 1"
-	rows, err := db.QueryContext(ctx, sqlStatement)
+	sqlStatement := "SELECT " + fields + " FROM " + WaitingTable + " WHERE club=$1 ORDER BY start LIMIT 1"
+	rows, err := db.QueryContext(ctx, sqlStatement, clubID)
 	if err != nil {
 		message := "Could not get the first waiter"
 		f.DumpSQLError(err, message, sqlStatement)
@@ -155,31 +157,32 @@ func GetFirstWaiter(ctx context.Context, db *sql.DB) (int, error) {
 		return 0, err
 	}
 	if count < 1 {
-		message := "There were no waiters"
-		f.DumpError(err, message)
-		return 0, err
+		return 0, codeerror.NewBadRequest("There were no waiters")
 	}
 
 	return id, nil
 }
 
-func AddWaiter(ctx context.Context, db *sql.DB, personID int) error {
-	f := functionRemoveWaiter
+// AddWaiter puts a person at the back of a club's queue
+func AddWaiter(ctx context.Context, db Queryer, clubID int, personID int) error {
+	f := functionAddWaiter
 
 	start := time.Now()
 
-	fields := "person, start"
-	values := "$1, $2"
+	fields := "club, person, start"
+	values := "$1, $2, $3"
 	sqlStatement := "INSERT INTO " + WaitingTable + " (" + fields + ") VALUES (" + values + ")"
 
-	_, err := db.ExecContext(ctx, sqlStatement, personID, start)
+	_, err := db.ExecContext(ctx, sqlStatement, clubID, personID, start)
 	if err != nil {
 		message := "Could not insert into " + WaitingTable
 		d := f.DumpSQLError(err, message, sqlStatement)
 		data := struct {
+			ClubID   int
 			PersonID int
 			Start    time.Time
 		}{
+			ClubID:   clubID,
 			PersonID: personID,
 			Start:    start,
 		}
@@ -191,17 +194,16 @@ func AddWaiter(ctx context.Context, db *sql.DB, personID int) error {
 	return nil
 }
 
-func RemoveWaiter(ctx context.Context, db *sql.DB, personID int) error {
+func RemoveWaiter(ctx context.Context, db Queryer, personID int) error {
 	f := functionRemoveWaiter
 
 	sqlStatement := "DELETE FROM " + WaitingTable + " WHERE person=$1"
-	rows, err := db.QueryContext(ctx, sqlStatement, personID)
+	_, err := db.ExecContext(ctx, sqlStatement, personID)
 	if err != nil {
 		message := "Could not delete the waiter"
 		f.DumpSQLError(err, message, sqlStatement)
 		return err
 	}
-	defer rows.Close()
 
 	return nil
 }